@@ -4,12 +4,15 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"flag"
 	"fmt"
 	"net/http"
 	"path/filepath"
 
 	"github.com/pitabwire/frame"
+	"github.com/pitabwire/frame/client"
+	frameconfig "github.com/pitabwire/frame/config"
 	"github.com/pitabwire/frame/security/interceptors/httptor"
 	frameversion "github.com/pitabwire/frame/version"
 	"github.com/pitabwire/util"
@@ -23,6 +26,7 @@ import (
 	"github.com/pitabwire/thesa/internal/openapi"
 	"github.com/pitabwire/thesa/internal/search"
 	"github.com/pitabwire/thesa/internal/transport"
+	"github.com/pitabwire/thesa/model"
 )
 
 func main() {
@@ -52,7 +56,11 @@ func main() {
 	}
 
 	validator := definition.NewValidator()
-	verrs := validator.Validate(defs, oaIndex)
+	validator.Strict = cfg.Definitions.StrictMappings
+	verrs, warnings := definition.SplitWarnings(validator.Validate(defs, oaIndex))
+	for _, w := range warnings {
+		log.Warn("definition validation warning", "warning", w.Error())
+	}
 	if len(verrs) > 0 {
 		for _, ve := range verrs {
 			log.Error("definition validation error", "error", ve.Error())
@@ -61,6 +69,13 @@ func main() {
 	}
 
 	registry := definition.NewRegistry(defs)
+	registry.SetWarnings(warnings)
+	for _, id := range cfg.Commands.Disabled {
+		if _, ok := registry.GetCommand(id); !ok {
+			log.Warn("disabled command is not defined", "command", id)
+		}
+		registry.SetCommandDisabled(id, true)
+	}
 
 	// Create Frame service (provides HTTP client, telemetry, lifecycle,
 	// and SecurityManager with authorization service access).
@@ -82,40 +97,96 @@ func main() {
 	// authorization service (Keto) using BatchCheck, which evaluates
 	// OPL rules, role hierarchies, and computed permissions.
 	authorizer := svc.SecurityManager().GetAuthorizer(ctx)
-	capChecks := capability.CollectCapabilityChecks(defs, cfg.Services)
+	capChecks := capability.BuildCapabilityChecks(defs, cfg)
 	evaluator := capability.NewKetoPolicyEvaluator(authorizer, capChecks)
 	capResolver := capability.NewResolver(evaluator, cfg.Capability.Cache.TTL)
+	capResolver.SetAliases(cfg.Capability.Aliases)
 
 	// Build invoker registry.
 	sdkHandlers := invoker.NewSDKHandlerRegistry()
 	invokerReg := invoker.NewRegistry()
-	invokerReg.Register(invoker.NewOpenAPIOperationInvoker(oaIndex, cfg.Services, httpClient))
+	// Services with their own TLS transport get a frame client built around
+	// it, so they keep the tracing, retry and credential layers of the
+	// shared client.
+	clientCtx := frameconfig.ToContext(ctx, svc.Config())
+	newClient := func(transport *http.Transport) *http.Client {
+		return client.NewHTTPClient(clientCtx, client.WithHTTPTransport(transport), client.WithHTTPTimeout(httpClient.Timeout))
+	}
+	openapiInvoker, err := invoker.NewOpenAPIOperationInvoker(oaIndex, cfg.Services, httpClient, newClient)
+	if err != nil {
+		log.WithError(err).Fatal("invoker setup failed")
+	}
+	sizeRecorder, err := invoker.NewMetricsSizeRecorder(nil)
+	if err != nil {
+		log.WithError(err).Fatal("invoker metrics setup failed")
+	}
+	openapiInvoker.SetSizeRecorder(sizeRecorder)
+	go func() {
+		for _, res := range openapiInvoker.Prewarm(ctx) {
+			if res.Err != nil {
+				log.Warn("backend connection pre-warm incomplete", "service", res.ServiceID, "opened", res.Opened, "error", res.Err)
+			}
+		}
+	}()
+	invokerReg.Register(openapiInvoker)
 	invokerReg.Register(invoker.NewSDKOperationInvoker(sdkHandlers))
 
 	// Build providers.
 	cmdExecutor := command.NewCommandExecutor(registry, invokerReg, oaIndex)
+	confirmationSecret := []byte(cfg.Commands.ConfirmationSecret)
+	if len(confirmationSecret) == 0 {
+		confirmationSecret = make([]byte, 32)
+		if _, err := rand.Read(confirmationSecret); err != nil {
+			log.WithError(err).Fatal("confirmation secret generation failed")
+		}
+		log.Warn("commands.confirmation_secret is not set; confirmation tokens are valid on this instance only")
+	}
+	cmdExecutor.SetConfirmationSigner(command.NewConfirmationSigner(confirmationSecret, cfg.Commands.ConfirmationTTL))
+	cmdExecutor.AddObserver(command.NewAuditObserver(log.SLog()))
+	cmdExecutor.SetJobStore(command.NewMemoryJobStore(cfg.Commands.JobRetention), cfg.Commands.JobWorkers, cfg.Commands.JobQueueSize)
+	cmdExecutor.SetMaxUploadSize(cfg.Commands.MaxUploadSize)
 	actionProvider := metadata.NewActionProvider()
+	actionProvider.SetCommandStatus(registry)
 	menuProvider := metadata.NewMenuProvider(registry, invokerReg)
 	pageProvider := metadata.NewPageProvider(registry, invokerReg, actionProvider)
 	formProvider := metadata.NewFormProvider(registry, invokerReg, actionProvider)
+	cmdExecutor.SetMaskedValueSource(formProvider)
 	schemaProvider := metadata.NewSchemaProvider(registry)
-	resourceProvider := metadata.NewResourceProvider(registry, invokerReg, oaIndex)
+	resourceProvider := metadata.NewResourceProvider(registry, invokerReg, oaIndex, actionProvider)
+	cmdExecutor.SetRouteResolver(resourceProvider)
 	searchProvider := search.NewSearchProvider(
 		registry, invokerReg,
 		cfg.Search.TimeoutPerProvider,
 		cfg.Search.MaxResultsPerProvider,
 	)
+	searchProvider.SetIdentity(search.Identity(cfg.Search.DedupIdentity))
 	lookupProvider := search.NewLookupProvider(
 		registry, invokerReg,
 		cfg.Lookup.Cache.TTL,
 		cfg.Lookup.Cache.MaxEntries,
+		cfg.Lookup.MaxResults,
 	)
+	cmdExecutor.SetOptionSource(lookupProvider)
+	pageProvider.SetOptionSource(lookupProvider)
+	if len(cfg.Lookup.Warm) > 0 {
+		go func() {
+			for _, res := range lookupProvider.Warm(ctx, cfg.Lookup.Warm) {
+				if res.Status != model.WarmStatusWarmed {
+					log.Warn("lookup cache warm-up incomplete", "lookup", res.LookupID, "status", res.Status, "reason", res.Reason)
+				}
+			}
+		}()
+	}
 
 	// Build HTTP router.
 	authenticate := func(next http.Handler) http.Handler {
 		return httptor.AuthenticationMiddleware(next, authenticator)
 	}
 
+	denialRecorder, err := transport.NewMetricsDenialRecorder(nil)
+	if err != nil {
+		log.WithError(err).Fatal("denial metrics setup failed")
+	}
 	router := transport.NewRouter(transport.Dependencies{
 		Config:             cfg,
 		Authenticate:       authenticate,
@@ -129,6 +200,7 @@ func main() {
 		CommandExecutor:    cmdExecutor,
 		SearchProvider:     searchProvider,
 		LookupProvider:     lookupProvider,
+		DenialRecorder:     denialRecorder,
 		AppVersion:         frameversion.Version,
 	})
 
@@ -177,6 +249,7 @@ func buildSpecSources(specsCfg config.SpecsConfig) []openapi.SpecSource {
 		sources[i] = openapi.SpecSource{
 			ServiceID: s.ServiceID,
 			SpecPath:  specPath,
+			Aliases:   s.OperationAliases,
 		}
 	}
 	return sources
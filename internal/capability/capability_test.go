@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"slices"
 	"sort"
 	"testing"
 	"time"
@@ -443,3 +444,57 @@ func (s *CapabilityTestSuite) TestPermissionToCapability() {
 	s.Equal("access:create", PermissionToCapability("access_create"))
 	s.Equal("simple", PermissionToCapability("simple"))
 }
+
+// --- Capability aliases ---
+
+type fixedEvaluator model.CapabilitySet
+
+func (e fixedEvaluator) ResolveCapabilities(context.Context, *model.RequestContext) (model.CapabilitySet, error) {
+	return model.CapabilitySet(e), nil
+}
+
+func TestResolver_aliases(t *testing.T) {
+	aliases := map[string]string{"orders:view": "orders:read"}
+	rctx := &model.RequestContext{SubjectID: "user-1", TenantID: "tenant-1"}
+
+	for granted, required := range map[string]string{"orders:view": "orders:read", "orders:read": "orders:view"} {
+		resolver := NewResolver(fixedEvaluator{granted: true}, time.Minute)
+		resolver.SetAliases(aliases)
+		caps, err := resolver.Resolve(t.Context(), rctx)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !caps.Has(required) {
+			t.Errorf("granted %q: capabilities %v do not satisfy %q", granted, caps, required)
+		}
+	}
+}
+
+func TestWithAliasChecks(t *testing.T) {
+	checks := []CapabilityCheck{
+		{Capability: "orders:read", Namespace: "service_orders"},
+		{Capability: "orders:edit", Namespace: "service_orders"},
+	}
+	got := WithAliasChecks(checks, map[string]string{"orders:view": "orders:read"})
+	want := append(checks, CapabilityCheck{Capability: "orders:view", Namespace: "service_orders"})
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("WithAliasChecks() = %v, want %v", got, want)
+	}
+}
+
+// --- Own capabilities ---
+
+func TestBuildCapabilityChecks_ownCapabilities(t *testing.T) {
+	cfg := thesaconfig.Defaults()
+	cfg.Capability.ExplainCapability = "thesa:explain:view"
+	cfg.Capability.Aliases = map[string]string{"thesa:diagnostics:read": "thesa:diagnostics:view"}
+
+	checks := BuildCapabilityChecks(nil, cfg)
+	want := append(cfg.Capability.OwnCapabilities(), "thesa:diagnostics:read")
+	for _, cap := range want {
+		check := CapabilityCheck{Capability: cap, Namespace: cfg.Capability.Namespace}
+		if !slices.Contains(checks, check) {
+			t.Errorf("checks %v are missing %v", checks, check)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package capability
 
 import (
 	"context"
+	"slices"
 	"strings"
 
 	"github.com/pitabwire/frame/security"
@@ -126,6 +127,38 @@ func (e *KetoPolicyEvaluator) fallbackIndividualChecks(ctx context.Context, requ
 	return caps, nil
 }
 
+// WithAliasChecks extends checks so that each capability's aliases (see
+// config.CapabilityConfig.Aliases) are checked in the same namespace too. A
+// grant under any name then reaches the Resolver, which treats the names as
+// equivalent.
+func WithAliasChecks(checks []CapabilityCheck, aliases map[string]string) []CapabilityCheck {
+	if len(aliases) == 0 {
+		return checks
+	}
+	classOf := make(map[string][]string)
+	for _, class := range model.AliasClasses(aliases) {
+		for _, c := range class {
+			classOf[c] = class
+		}
+	}
+
+	seen := make(map[CapabilityCheck]bool, len(checks))
+	for _, c := range checks {
+		seen[c] = true
+	}
+	out := slices.Clip(checks)
+	for _, c := range checks {
+		for _, equivalent := range classOf[c.Capability] {
+			check := CapabilityCheck{Capability: equivalent, Namespace: c.Namespace}
+			if !seen[check] {
+				seen[check] = true
+				out = append(out, check)
+			}
+		}
+	}
+	return out
+}
+
 // CapabilityToPermission converts a colon-separated capability string
 // to a Keto-compatible permission name (underscores).
 // Example: "tenants:view" → "tenants_view"
@@ -215,6 +248,27 @@ func CollectCapabilityChecks(domains []model.DomainDefinition, services map[stri
 	return checks
 }
 
+// OwnCapabilityChecks returns a check for each capability guarding the
+// BFF's own endpoints and features (diagnostics, cache and command admin,
+// explain), in the configured capability namespace. They appear in no
+// definition, so without these checks Keto could never grant them.
+func OwnCapabilityChecks(cfg config.CapabilityConfig) []CapabilityCheck {
+	var checks []CapabilityCheck
+	for _, cap := range cfg.OwnCapabilities() {
+		checks = append(checks, CapabilityCheck{Capability: cap, Namespace: cfg.Namespace})
+	}
+	return checks
+}
+
+// BuildCapabilityChecks returns every check the evaluator needs: the
+// capabilities in the definitions, the BFF's own capabilities and the
+// aliases of both.
+func BuildCapabilityChecks(domains []model.DomainDefinition, cfg *config.Config) []CapabilityCheck {
+	checks := CollectCapabilityChecks(domains, cfg.Services)
+	checks = append(checks, OwnCapabilityChecks(cfg.Capability)...)
+	return WithAliasChecks(checks, cfg.Capability.Aliases)
+}
+
 // findDomainService determines the primary service_id for a domain by
 // scanning its pages, commands, and searches for the first explicit
 // service_id reference.
@@ -5,6 +5,7 @@ package capability
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pitabwire/thesa/model"
@@ -19,8 +20,11 @@ type cacheEntry struct {
 type Resolver struct {
 	evaluator model.PolicyEvaluator
 	ttl       time.Duration
+	aliases   map[string]string
 	mu        sync.RWMutex
 	cache     map[string]cacheEntry
+	hits      atomic.Int64
+	misses    atomic.Int64
 }
 
 // NewResolver creates a new Resolver with the given evaluator and cache TTL.
@@ -32,6 +36,14 @@ func NewResolver(evaluator model.PolicyEvaluator, ttl time.Duration) *Resolver {
 	}
 }
 
+// SetAliases installs capability aliases, mapping alias names to canonical
+// capabilities. Resolved sets then match a canonical capability and all of
+// its aliases whenever any one of them is granted. It must be called before
+// the resolver serves requests.
+func (r *Resolver) SetAliases(aliases map[string]string) {
+	r.aliases = aliases
+}
+
 func cacheKey(rctx *model.RequestContext) string {
 	return rctx.SubjectID + ":" + rctx.TenantID + ":" + rctx.PartitionID
 }
@@ -44,14 +56,17 @@ func (r *Resolver) Resolve(ctx context.Context, rctx *model.RequestContext) (mod
 	r.mu.RLock()
 	if entry, ok := r.cache[key]; ok && time.Now().Before(entry.expires) {
 		r.mu.RUnlock()
+		r.hits.Add(1)
 		return entry.caps, nil
 	}
 	r.mu.RUnlock()
+	r.misses.Add(1)
 
 	caps, err := r.evaluator.ResolveCapabilities(ctx, rctx)
 	if err != nil {
 		return nil, err
 	}
+	caps = caps.WithAliases(r.aliases)
 
 	r.mu.Lock()
 	r.cache[key] = cacheEntry{caps: caps, expires: time.Now().Add(r.ttl)}
@@ -71,3 +86,11 @@ func (r *Resolver) Invalidate(subjectID, tenantID string) {
 	}
 	r.mu.Unlock()
 }
+
+// CacheStats reports the capability cache size and hit rate.
+func (r *Resolver) CacheStats() model.CacheStats {
+	r.mu.RLock()
+	entries := len(r.cache)
+	r.mu.RUnlock()
+	return model.NewCacheStats(entries, r.hits.Load(), r.misses.Load())
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+)
+
+// AuditEventCommandExecuted is the audit event logged for each executed command.
+const AuditEventCommandExecuted = "command.executed"
+
+// AuditObserver writes a structured audit entry for every command event,
+// tagged "type": "audit" so it can be separated from application logs.
+type AuditObserver struct {
+	logger *slog.Logger
+}
+
+// NewAuditObserver creates an AuditObserver that writes to logger.
+func NewAuditObserver(logger *slog.Logger) *AuditObserver {
+	return &AuditObserver{logger: logger}
+}
+
+// OnCommand logs the event with the caller's identity, the command outcome
+// and, for audited updates, the changed fields.
+func (a *AuditObserver) OnCommand(ctx context.Context, event CommandEvent) {
+	attrs := []any{
+		slog.String("type", "audit"),
+		slog.String("event", AuditEventCommandExecuted),
+	}
+	if rctx := event.Context; rctx != nil {
+		attrs = append(attrs,
+			slog.String("subject_id", rctx.SubjectID),
+			slog.String("tenant_id", rctx.TenantID),
+			slog.String("partition_id", rctx.PartitionID),
+			slog.String("correlation_id", rctx.CorrelationID),
+			slog.String("trace_id", rctx.TraceID),
+		)
+	}
+	data := []any{
+		slog.String("command_id", event.CommandID),
+		slog.Bool("success", event.Response.Success),
+	}
+	if len(event.Changes) > 0 {
+		data = append(data, slog.Any("changes", event.Changes))
+	}
+	attrs = append(attrs, slog.Group("data", data...))
+	a.logger.InfoContext(ctx, "audit", attrs...)
+}
@@ -0,0 +1,206 @@
+package command
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// DefaultConfirmationTTL is how long a confirmation token stays valid when
+// no TTL is configured.
+const DefaultConfirmationTTL = 5 * time.Minute
+
+// ConfirmationSigner mints and verifies confirmation tokens for commands that
+// require them. A token is an HMAC over the command ID, the caller's subject
+// and tenant, the command input and route params, and an expiry, so it cannot
+// be replayed for a different resource or a modified input. A token is
+// single-use: Verify checks it and Consume spends it, once the command has
+// passed validation. Consumed tokens are remembered by this signer until they
+// expire, so they are single-use per instance; replicas sharing a secret
+// accept each other's tokens but do not share consumption.
+type ConfirmationSigner struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	used map[string]int64 // token → expiry (Unix seconds)
+}
+
+// NewConfirmationSigner returns a signer using secret as the HMAC key. A
+// non-positive ttl uses DefaultConfirmationTTL.
+func NewConfirmationSigner(secret []byte, ttl time.Duration) *ConfirmationSigner {
+	if ttl <= 0 {
+		ttl = DefaultConfirmationTTL
+	}
+	return &ConfirmationSigner{secret: secret, ttl: ttl, now: time.Now, used: make(map[string]int64)}
+}
+
+// Mint returns a token for commandID bound to the caller and input.
+func (s *ConfirmationSigner) Mint(rctx *model.RequestContext, commandID string, input model.CommandInput) (model.ConfirmationResponse, error) {
+	expires := s.now().Add(s.ttl).Truncate(time.Second)
+	sig, err := s.sign(rctx, commandID, input, expires.Unix())
+	if err != nil {
+		return model.ConfirmationResponse{}, err
+	}
+	return model.ConfirmationResponse{
+		Token:     strconv.FormatInt(expires.Unix(), 10) + "." + sig,
+		ExpiresAt: expires,
+	}, nil
+}
+
+// Verify reports whether input.ConfirmationToken is an unexpired, unused
+// token minted for commandID, the caller and the input. It does not consume
+// the token; see Consume.
+func (s *ConfirmationSigner) Verify(rctx *model.RequestContext, commandID string, input model.CommandInput) bool {
+	expiry, sig, ok := strings.Cut(input.ConfirmationToken, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || s.now().Unix() > unix {
+		return false
+	}
+	want, err := s.sign(rctx, commandID, input, unix)
+	if err != nil || !hmac.Equal([]byte(sig), []byte(want)) {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, used := s.used[input.ConfirmationToken]
+	return !used
+}
+
+// Consume marks a verified token used, returning false if it already was,
+// e.g. by a concurrent request. Expired entries are dropped, since their
+// tokens no longer verify.
+func (s *ConfirmationSigner) Consume(token string) bool {
+	expiry, _, _ := strings.Cut(token, ".")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, used := s.used[token]; used {
+		return false
+	}
+	now := s.now().Unix()
+	for t, exp := range s.used {
+		if now > exp {
+			delete(s.used, t)
+		}
+	}
+	s.used[token] = unix
+	return true
+}
+
+func (s *ConfirmationSigner) sign(rctx *model.RequestContext, commandID string, input model.CommandInput, expiry int64) (string, error) {
+	// json.Marshal sorts map keys, so equal inputs encode identically.
+	payload, err := json.Marshal(struct {
+		Input       map[string]any    `json:"i"`
+		RouteParams map[string]string `json:"r"`
+	}{input.Input, input.RouteParams})
+	if err != nil {
+		return "", fmt.Errorf("command: encoding confirmation payload: %w", err)
+	}
+
+	var subject, tenant string
+	if rctx != nil {
+		subject, tenant = rctx.SubjectID, rctx.TenantID
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	for _, part := range []string{commandID, subject, tenant, strconv.FormatInt(expiry, 10)} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// SetConfirmationSigner installs the signer used for commands that require
+// confirmation. Without one, such commands are always rejected.
+func (e *CommandExecutor) SetConfirmationSigner(s *ConfirmationSigner) {
+	e.confirmations = s
+}
+
+// Confirm mints a confirmation token for a command that requires one.
+func (e *CommandExecutor) Confirm(
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	commandID string,
+	input model.CommandInput,
+) (model.ConfirmationResponse, error) {
+	cmdDef, ok := e.registry.GetCommand(commandID)
+	if !ok || !rctx.EntitledTo(e.registry.CommandDomain(commandID)) {
+		return model.ConfirmationResponse{}, model.NewNotFoundError(
+			fmt.Sprintf("command %q not found", commandID),
+		)
+	}
+	if e.registry.CommandDisabled(commandID) {
+		return model.ConfirmationResponse{}, model.NewCommandUnavailableError(commandID)
+	}
+	if missing := caps.Missing(cmdDef.Capabilities...); len(missing) > 0 {
+		return model.ConfirmationResponse{}, model.NewInsufficientCapabilitiesError(
+			fmt.Sprintf("insufficient capabilities for command %q", commandID),
+			missing,
+		)
+	}
+	if !cmdDef.RequireConfirmation {
+		return model.ConfirmationResponse{}, model.NewBadRequestError(
+			fmt.Sprintf("command %q does not require confirmation", commandID),
+		)
+	}
+	if e.confirmations == nil {
+		return model.ConfirmationResponse{}, model.NewInternalError()
+	}
+	return e.confirmations.Mint(rctx, commandID, input)
+}
+
+// checkConfirmation rejects a command that requires confirmation unless the
+// input carries a valid, unused token. The token is not consumed, so a
+// request that then fails validation can be corrected and resubmitted with
+// it; see consumeConfirmation.
+func (e *CommandExecutor) checkConfirmation(rctx *model.RequestContext, cmdDef model.CommandDefinition, input model.CommandInput) error {
+	if !cmdDef.RequireConfirmation {
+		return nil
+	}
+	if input.ConfirmationToken == "" {
+		return model.NewConfirmationRequiredError(
+			fmt.Sprintf("command %q requires a confirmation token", cmdDef.ID),
+		)
+	}
+	if e.confirmations == nil || !e.confirmations.Verify(rctx, cmdDef.ID, input) {
+		return invalidConfirmationError(cmdDef.ID)
+	}
+	return nil
+}
+
+// consumeConfirmation uses up the verified confirmation token of a command
+// that requires one. It is called once the input has passed validation,
+// right before the backend is invoked, and fails if a concurrent request
+// used the token first.
+func (e *CommandExecutor) consumeConfirmation(cmdDef model.CommandDefinition, input model.CommandInput) error {
+	if !cmdDef.RequireConfirmation {
+		return nil
+	}
+	if e.confirmations == nil || !e.confirmations.Consume(input.ConfirmationToken) {
+		return invalidConfirmationError(cmdDef.ID)
+	}
+	return nil
+}
+
+func invalidConfirmationError(commandID string) *model.ErrorEnvelope {
+	return model.NewConfirmationRequiredError(
+		fmt.Sprintf("confirmation token for command %q is invalid, expired or already used", commandID),
+	)
+}
@@ -0,0 +1,208 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+func bulkCancelInput() model.CommandInput {
+	return model.CommandInput{
+		Input:       map[string]any{"ids": []any{"ord-1", "ord-2"}},
+		RouteParams: map[string]string{"tenant": "acme"},
+	}
+}
+
+// newConfirmingExecutor returns an executor whose orders.simple command
+// requires confirmation, counting backend invocations in invoked.
+func newConfirmingExecutor(invoked *int, edits ...func(*model.DomainDefinition)) *CommandExecutor {
+	edits = append(edits, withCommand("orders.simple", func(c *model.CommandDefinition) {
+		c.RequireConfirmation = true
+	}))
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		*invoked++
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+	}, edits...)
+	e.SetConfirmationSigner(NewConfirmationSigner([]byte("test-secret"), time.Minute))
+	return e
+}
+
+func TestExecutor_confirmation_missingToken(t *testing.T) {
+	var invoked int
+	e := newConfirmingExecutor(&invoked)
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", bulkCancelInput())
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrConfirmationRequired {
+		t.Fatalf("error = %v, want CONFIRMATION_REQUIRED", err)
+	}
+	if invoked != 0 {
+		t.Error("backend invoked without a confirmation token")
+	}
+}
+
+func TestExecutor_confirmation_validToken(t *testing.T) {
+	var invoked int
+	e := newConfirmingExecutor(&invoked)
+	rctx := testRctxForExecutor()
+
+	conf, err := e.Confirm(rctx, model.CapabilitySet{}, "orders.simple", bulkCancelInput())
+	if err != nil {
+		t.Fatalf("Confirm error: %v", err)
+	}
+	if conf.Token == "" || !conf.ExpiresAt.After(time.Now()) {
+		t.Fatalf("Confirm() = %+v, want a token expiring in the future", conf)
+	}
+
+	input := bulkCancelInput()
+	input.ConfirmationToken = conf.Token
+	resp, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", input)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !resp.Success || invoked != 1 {
+		t.Errorf("Success = %v, invoked = %d, want true and 1", resp.Success, invoked)
+	}
+
+	// The token is consumed by the first execution.
+	_, err = e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", input)
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrConfirmationRequired {
+		t.Errorf("replayed token error = %v, want CONFIRMATION_REQUIRED", err)
+	}
+	if invoked != 1 {
+		t.Errorf("backend invoked %d times, want 1 after a replayed token", invoked)
+	}
+}
+
+func TestExecutor_confirmation_failedValidationKeepsToken(t *testing.T) {
+	var invoked int
+	e := newConfirmingExecutor(&invoked, func(d *model.DomainDefinition) {
+		d.Forms = []model.FormDefinition{{
+			ID:            "orders.simple-form",
+			SubmitCommand: "orders.simple",
+			Sections: []model.SectionDefinition{{
+				ID:     "main",
+				Fields: []model.FieldDefinition{{Field: "tags", Type: "multiselect", Lookup: &model.LookupRefDefinition{LookupID: "orders.tags"}}},
+			}},
+		}}
+	})
+	// The lookup is down for the first submit, so its tags cannot be
+	// verified.
+	src := tagOptions()
+	src.err = errors.New("lookup unavailable")
+	e.SetOptionSource(src)
+	rctx := testRctxForExecutor()
+
+	input := model.CommandInput{Input: map[string]any{"tags": []any{"rush"}}}
+	conf, err := e.Confirm(rctx, model.CapabilitySet{}, "orders.simple", input)
+	if err != nil {
+		t.Fatalf("Confirm error: %v", err)
+	}
+	input.ConfirmationToken = conf.Token
+
+	if _, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", input); err == nil {
+		t.Fatal("Execute error = nil, want the lookup error")
+	}
+	if invoked != 0 {
+		t.Fatalf("backend invoked %d times, want 0 before validation passes", invoked)
+	}
+
+	src.err = nil
+	resp, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", input)
+	if err != nil || !resp.Success || invoked != 1 {
+		t.Errorf("resubmit = %+v, %v, invoked %d, want success with the same token", resp, err, invoked)
+	}
+}
+
+func TestExecutor_confirmation_tokenBoundToInputAndCaller(t *testing.T) {
+	var invoked int
+	e := newConfirmingExecutor(&invoked)
+	rctx := testRctxForExecutor()
+
+	conf, err := e.Confirm(rctx, model.CapabilitySet{}, "orders.simple", bulkCancelInput())
+	if err != nil {
+		t.Fatalf("Confirm error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		rctx   *model.RequestContext
+		modify func(*model.CommandInput)
+	}{
+		{name: "different input", rctx: rctx, modify: func(in *model.CommandInput) {
+			in.Input["ids"] = []any{"ord-3"}
+		}},
+		{name: "different route params", rctx: rctx, modify: func(in *model.CommandInput) {
+			in.RouteParams["tenant"] = "globex"
+		}},
+		{name: "different caller", rctx: &model.RequestContext{SubjectID: "user-bob", TenantID: "acme-corp"}},
+		{name: "tampered token", rctx: rctx, modify: func(in *model.CommandInput) {
+			in.ConfirmationToken += "x"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := bulkCancelInput()
+			input.ConfirmationToken = conf.Token
+			if tt.modify != nil {
+				tt.modify(&input)
+			}
+			_, err := e.Execute(context.Background(), tt.rctx, model.CapabilitySet{}, "orders.simple", input)
+			envErr, ok := err.(*model.ErrorEnvelope)
+			if !ok || envErr.Code != model.ErrConfirmationRequired {
+				t.Errorf("error = %v, want CONFIRMATION_REQUIRED", err)
+			}
+		})
+	}
+	if invoked != 0 {
+		t.Errorf("backend invoked %d times with mismatched tokens", invoked)
+	}
+}
+
+func TestExecutor_confirmation_expiredToken(t *testing.T) {
+	var invoked int
+	e := newConfirmingExecutor(&invoked)
+	issued := time.Now()
+	e.confirmations.now = func() time.Time { return issued }
+
+	conf, err := e.Confirm(testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", bulkCancelInput())
+	if err != nil {
+		t.Fatalf("Confirm error: %v", err)
+	}
+	e.confirmations.now = func() time.Time { return issued.Add(2 * time.Minute) }
+
+	input := bulkCancelInput()
+	input.ConfirmationToken = conf.Token
+	_, err = e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", input)
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrConfirmationRequired {
+		t.Fatalf("error = %v, want CONFIRMATION_REQUIRED", err)
+	}
+}
+
+func TestExecutor_Confirm_notRequired(t *testing.T) {
+	e := newTestExecutor(nil)
+	e.SetConfirmationSigner(NewConfirmationSigner([]byte("test-secret"), 0))
+
+	_, err := e.Confirm(testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", bulkCancelInput())
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrBadRequest {
+		t.Fatalf("error = %v, want BAD_REQUEST", err)
+	}
+}
+
+func TestExecutor_Confirm_disabledCommand(t *testing.T) {
+	var invoked int
+	e := newConfirmingExecutor(&invoked)
+	e.registry.SetCommandDisabled("orders.simple", true)
+
+	_, err := e.Confirm(testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", bulkCancelInput())
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrCommandUnavailable {
+		t.Fatalf("error = %v, want COMMAND_UNAVAILABLE", err)
+	}
+}
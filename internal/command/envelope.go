@@ -0,0 +1,91 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// applyEnvelope wraps a mapped request body in a binding's request envelope.
+// A nil envelope returns the body unchanged.
+func applyEnvelope(envelope map[string]any, body any) (any, error) {
+	if envelope == nil {
+		return body, nil
+	}
+	return fillEnvelope(envelope, body)
+}
+
+// fillEnvelope copies an envelope value, resolving placeholders in strings.
+func fillEnvelope(v, body any) (any, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, child := range val {
+			filled, err := fillEnvelope(child, body)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = filled
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, child := range val {
+			filled, err := fillEnvelope(child, body)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = filled
+		}
+		return out, nil
+	case string:
+		return fillPlaceholders(val, body)
+	default:
+		return v, nil
+	}
+}
+
+// fillPlaceholders resolves the placeholders in s. A string that is exactly
+// one placeholder is replaced by the referenced value itself; otherwise
+// values are interpolated as text, with missing values as "".
+func fillPlaceholders(s string, body any) (any, error) {
+	if m := model.EnvelopePlaceholder.FindStringSubmatchIndex(s); m != nil && m[0] == 0 && m[1] == len(s) {
+		return envelopeValue(s[m[2]:m[3]], body)
+	}
+
+	var resolveErr error
+	out := model.EnvelopePlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+		ref := model.EnvelopePlaceholder.FindStringSubmatch(match)[1]
+		v, err := envelopeValue(ref, body)
+		if err != nil {
+			resolveErr = err
+			return ""
+		}
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	return out, nil
+}
+
+// envelopeValue resolves a placeholder reference against the mapped body:
+// "body" is the whole body, "body.<path>" a field within it.
+func envelopeValue(ref string, body any) (any, error) {
+	if ref == "body" {
+		return body, nil
+	}
+	path, ok := strings.CutPrefix(ref, "body.")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("unknown envelope placeholder %q", ref)
+	}
+	m, ok := body.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	return navigatePath(m, path), nil
+}
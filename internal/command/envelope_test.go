@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pitabwire/thesa/internal/definition"
+	"github.com/pitabwire/thesa/internal/invoker"
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestExecutor_requestEnvelope(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Commands[0].Operation.RequestEnvelope = map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "orders.cancel",
+		"params":  "{{body}}",
+		"meta": map[string]any{
+			"reason": "{{ body.cancellationReason }}",
+			"note":   "refund={{body.refundType}} ref={{body.missing}}",
+			"tags":   []any{"{{body.refundType}}", 1},
+		},
+	}
+	var got any
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		got = input.Body
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(),
+		model.CapabilitySet{"orders:cancel:execute": true}, "orders.cancel",
+		model.CommandInput{
+			Input:       map[string]any{"reason": "duplicate", "refund_type": "full"},
+			RouteParams: map[string]string{"id": "ord-1"},
+		})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	want := map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "orders.cancel",
+		"params":  map[string]any{"cancellationReason": "duplicate", "refundType": "full"},
+		"meta": map[string]any{
+			"reason": "duplicate",
+			"note":   "refund=full ref=",
+			"tags":   []any{"full", 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %#v\nwant %#v", got, want)
+	}
+}
+
+func TestApplyEnvelope(t *testing.T) {
+	body := map[string]any{"amount": 42.5, "customer": map[string]any{"id": "c-1"}}
+
+	tests := []struct {
+		name     string
+		envelope map[string]any
+		want     any
+		wantErr  bool
+	}{
+		{name: "no envelope", envelope: nil, want: body},
+		{name: "typed field", envelope: map[string]any{"total": "{{body.amount}}"}, want: map[string]any{"total": 42.5}},
+		{name: "nested field", envelope: map[string]any{"who": "{{body.customer.id}}"}, want: map[string]any{"who": "c-1"}},
+		{name: "missing field", envelope: map[string]any{"x": "{{body.nope}}"}, want: map[string]any{"x": nil}},
+		{name: "unknown reference", envelope: map[string]any{"x": "{{input.amount}}"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyEnvelope(tt.envelope, body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyEnvelope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyEnvelope() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
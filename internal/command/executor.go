@@ -2,7 +2,21 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pitabwire/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/pitabwire/thesa/internal/definition"
 	"github.com/pitabwire/thesa/internal/invoker"
@@ -12,10 +26,29 @@ import (
 
 // CommandExecutor implements the command execution pipeline.
 type CommandExecutor struct {
-	registry *definition.Registry
-	invokers *invoker.Registry
-	index    *openapiIndex.Index
-	mapper   *InputMapper
+	registry  *definition.Registry
+	invokers  *invoker.Registry
+	index     *openapiIndex.Index
+	mapper    *InputMapper
+	observers []CommandObserver
+	// confirmations signs and verifies confirmation tokens.
+	confirmations *ConfirmationSigner
+	// options resolves lookup options for select field validation.
+	options OptionSource
+	// masked loads stored values to compare submitted masked values with.
+	masked MaskedValueSource
+	// routes resolves the detail route of resources created by commands.
+	routes RouteResolver
+	// tracer creates the spans of command executions.
+	tracer trace.Tracer
+	// jobs stores async command jobs; nil runs async commands synchronously.
+	jobs JobStore
+	// jobSlots bounds the number of concurrently running jobs.
+	jobSlots chan struct{}
+	// jobBacklog bounds the number of jobs waiting for a slot.
+	jobBacklog chan struct{}
+	// maxUploadSize bounds the body of a streamed upload, in bytes.
+	maxUploadSize int64
 }
 
 // NewCommandExecutor creates a CommandExecutor with its required dependencies.
@@ -29,6 +62,9 @@ func NewCommandExecutor(
 		invokers: invokers,
 		index:    index,
 		mapper:   NewInputMapper(),
+		tracer:   otel.Tracer(tracerName),
+
+		maxUploadSize: DefaultMaxUploadSize,
 	}
 }
 
@@ -39,127 +75,463 @@ func (e *CommandExecutor) Execute(
 	caps model.CapabilitySet,
 	commandID string,
 	input model.CommandInput,
+) (model.CommandResponse, error) {
+	ctx, span := e.startSpan(ctx, "command.execute", commandID)
+	resp, err := e.execute(ctx, span, rctx, caps, commandID, input)
+	endSpan(span, err)
+	return resp, err
+}
+
+func (e *CommandExecutor) execute(
+	ctx context.Context,
+	span trace.Span,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	commandID string,
+	input model.CommandInput,
 ) (model.CommandResponse, error) {
 	// Step 1: Lookup command definition.
 	cmdDef, ok := e.registry.GetCommand(commandID)
-	if !ok {
+	if !ok || !rctx.EntitledTo(e.registry.CommandDomain(commandID)) {
 		return model.CommandResponse{}, model.NewNotFoundError(
 			fmt.Sprintf("command %q not found", commandID),
 		)
 	}
+	if e.registry.CommandDisabled(commandID) {
+		return model.CommandResponse{}, model.NewCommandUnavailableError(commandID)
+	}
 
 	// Step 2: Check capabilities.
-	if len(cmdDef.Capabilities) > 0 && !caps.HasAll(cmdDef.Capabilities...) {
-		return model.CommandResponse{}, model.NewForbiddenError(
+	if missing := caps.Missing(cmdDef.Capabilities...); len(missing) > 0 {
+		return model.CommandResponse{}, model.NewInsufficientCapabilitiesError(
 			fmt.Sprintf("insufficient capabilities for command %q", commandID),
+			missing,
 		)
 	}
 
-	// Step 5: Apply input mapping.
-	invInput, err := e.mapper.MapInput(cmdDef.Input, input, rctx, nil)
-	if err != nil {
+	if len(input.Files) > 0 && !cmdDef.Upload {
 		return model.CommandResponse{}, model.NewBadRequestError(
-			fmt.Sprintf("input mapping error: %v", err),
+			fmt.Sprintf("command %q does not accept uploads", commandID),
 		)
 	}
 
-	// Step 6: Validate constructed body against OpenAPI schema.
-	if e.index != nil && cmdDef.Operation.Type == "openapi" && cmdDef.Operation.ServiceID != "" {
-		if bodyMap, ok := invInput.Body.(map[string]any); ok {
-			valErrs := e.index.ValidateRequest(cmdDef.Operation.ServiceID, cmdDef.Operation.OperationID, bodyMap)
-			if len(valErrs) > 0 {
-				reverseMap := ReverseFieldMap(cmdDef.Input.FieldProjection)
-				fieldErrors := translateValidationErrors(valErrs, reverseMap)
-				return model.CommandResponse{
-					Success: false,
-					Errors:  fieldErrors,
-				}, model.NewValidationError(fieldErrors)
-			}
+	if err := e.checkConfirmation(rctx, cmdDef, input); err != nil {
+		return model.CommandResponse{}, err
+	}
+
+	// Masked values submitted back unchanged keep the stored value.
+	unmasked, err := e.dropMaskedValues(ctx, rctx, commandID, input)
+	if err != nil {
+		return model.CommandResponse{}, err
+	}
+	input.Input = unmasked
+	cmdDef = selectOperation(cmdDef, input.Input)
+
+	span.SetAttributes(attribute.String("command.operation_id", cmdDef.Operation.OperationID))
+
+	// Select values outside the field's options are collected with the
+	// schema errors below, so a submit reports every field error at once.
+	fieldErrors, err := e.checkInput(ctx, rctx, cmdDef, input.Input)
+	if err != nil {
+		return model.CommandResponse{Success: false, Errors: fieldErrors}, err
+	}
+
+	// Step 5: Apply input mapping. If it fails, option errors are still
+	// reported as field errors.
+	_, mapSpan := e.startSpan(ctx, "command.map_input", commandID)
+	invInput, err := e.mapper.MapInput(cmdDef.Input, input, rctx, nil)
+	if err == nil {
+		invInput.Body, err = applyEnvelope(cmdDef.Operation.RequestEnvelope, invInput.Body)
+	}
+	if err != nil {
+		err = model.NewBadRequestError(fmt.Sprintf("input mapping error: %v", err))
+		endSpan(mapSpan, err)
+		if len(fieldErrors) > 0 {
+			return model.CommandResponse{Success: false, Errors: fieldErrors}, model.NewValidationError(fieldErrors)
 		}
+		return model.CommandResponse{}, err
+	}
+	endSpan(mapSpan, nil)
+
+	// Step 6: Validate constructed body against OpenAPI schema. A multipart
+	// body is not, since its schema also describes the file parts.
+	if len(input.Files) > 0 {
+		invInput.Files = input.Files
+	} else {
+		_, schemaSpan := e.startSpan(ctx, "command.validate_schema", commandID)
+		schemaErrors := e.validateBody(cmdDef, invInput.Body)
+		if len(schemaErrors) > 0 {
+			endSpan(schemaSpan, model.NewValidationError(schemaErrors))
+		} else {
+			endSpan(schemaSpan, nil)
+		}
+		fieldErrors = append(fieldErrors, schemaErrors...)
+	}
+	if len(fieldErrors) > 0 {
+		return model.CommandResponse{Success: false, Errors: fieldErrors}, model.NewValidationError(fieldErrors)
+	}
+
+	resolver := &ExpressionResolver{Input: input.Input, RouteParams: input.RouteParams, Context: rctx}
+
+	if err := e.consumeConfirmation(cmdDef, input); err != nil {
+		return model.CommandResponse{}, err
+	}
+
+	// Async commands are validated and mapped now, and invoked by a worker.
+	if cmdDef.Async && e.jobs != nil && cmdDef.Operation.Type != OperationTypeNoop {
+		return e.enqueue(ctx, rctx, cmdDef, input, invInput, resolver)
+	}
+
+	// Noop commands record intent only; there is no backend to invoke.
+	if cmdDef.Operation.Type == OperationTypeNoop {
+		result := model.InvocationResult{StatusCode: http.StatusOK}
+		resp := e.mapOutput(ctx, result, cmdDef, resolver)
+		e.notify(ctx, cmdDef, rctx, input, result, resp)
+		if !resp.Success {
+			return resp, model.NewBadRequestError(resp.Message)
+		}
+		return resp, nil
 	}
 
 	// Step 7: Invoke backend.
-	result, err := e.invokers.Invoke(ctx, rctx, cmdDef.Operation, invInput)
+	result, err := e.invoke(ctx, rctx, cmdDef, invInput)
 	if err != nil {
 		return model.CommandResponse{}, err
 	}
 
 	// Step 8: Handle response.
-	resp := e.handleResponse(result, cmdDef)
+	resp := e.mapOutput(ctx, result, cmdDef, resolver)
+	resp = attachStream(resp, result)
+	e.notify(ctx, cmdDef, rctx, input, result, resp)
 
 	if !resp.Success {
-		return resp, model.NewBadRequestError(resp.Message)
+		return resp, commandError(result, resp)
+	}
+
+	return resp, nil
+}
+
+// checkInput enforces a command's input limits and checks select values
+// against their options, in a command.validate span. Limit violations are
+// returned alone as a validation error, before any other check runs; option
+// errors are returned with a nil error, to be reported with later ones.
+func (e *CommandExecutor) checkInput(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	cmdDef model.CommandDefinition,
+	input map[string]any,
+) ([]model.FieldError, error) {
+	ctx, span := e.startSpan(ctx, "command.validate", cmdDef.ID)
+	if limitErrors := checkInputLimits(cmdDef.Limits, input); len(limitErrors) > 0 {
+		err := model.NewValidationError(limitErrors)
+		endSpan(span, err)
+		return limitErrors, err
+	}
+	fieldErrors, err := e.checkOptions(ctx, rctx, cmdDef.ID, input)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	if len(fieldErrors) > 0 {
+		endSpan(span, model.NewValidationError(fieldErrors))
+	} else {
+		endSpan(span, nil)
+	}
+	return fieldErrors, nil
+}
+
+// ExecuteUpload runs the command pipeline for a command flagged as an upload,
+// streaming body to the backend instead of decoding it into the input. Path,
+// query and header mappings are still resolved from input, which is checked
+// against the command's limits and select options like Execute's; body
+// mappings and schema validation are skipped. A body over the upload size
+// limit fails with PAYLOAD_TOO_LARGE.
+func (e *CommandExecutor) ExecuteUpload(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	commandID string,
+	input model.CommandInput,
+	body io.Reader,
+	contentType string,
+) (model.CommandResponse, error) {
+	ctx, span := e.startSpan(ctx, "command.execute_upload", commandID)
+	resp, err := e.executeUpload(ctx, rctx, caps, commandID, input, body, contentType)
+	endSpan(span, err)
+	return resp, err
+}
+
+func (e *CommandExecutor) executeUpload(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	commandID string,
+	input model.CommandInput,
+	body io.Reader,
+	contentType string,
+) (model.CommandResponse, error) {
+	cmdDef, ok := e.registry.GetCommand(commandID)
+	if !ok || !rctx.EntitledTo(e.registry.CommandDomain(commandID)) {
+		return model.CommandResponse{}, model.NewNotFoundError(
+			fmt.Sprintf("command %q not found", commandID),
+		)
+	}
+	if e.registry.CommandDisabled(commandID) {
+		return model.CommandResponse{}, model.NewCommandUnavailableError(commandID)
+	}
+	if !cmdDef.Upload {
+		return model.CommandResponse{}, model.NewBadRequestError(
+			fmt.Sprintf("command %q does not accept uploads", commandID),
+		)
+	}
+
+	if missing := caps.Missing(cmdDef.Capabilities...); len(missing) > 0 {
+		return model.CommandResponse{}, model.NewInsufficientCapabilitiesError(
+			fmt.Sprintf("insufficient capabilities for command %q", commandID),
+			missing,
+		)
+	}
+
+	if err := e.checkConfirmation(rctx, cmdDef, input); err != nil {
+		return model.CommandResponse{}, err
+	}
+
+	unmasked, err := e.dropMaskedValues(ctx, rctx, commandID, input)
+	if err != nil {
+		return model.CommandResponse{}, err
+	}
+	input.Input = unmasked
+	cmdDef = selectOperation(cmdDef, input.Input)
+
+	fieldErrors, err := e.checkInput(ctx, rctx, cmdDef, input.Input)
+	if err == nil && len(fieldErrors) > 0 {
+		err = model.NewValidationError(fieldErrors)
+	}
+	if err != nil {
+		return model.CommandResponse{Success: false, Errors: fieldErrors}, err
+	}
+
+	mapping := cmdDef.Input
+	mapping.BodyMapping = "passthrough"
+	mapping.BodyTemplate = nil
+	mapping.FieldProjection = nil
+	invInput, err := e.mapper.MapInput(mapping, input, rctx, nil)
+	if err != nil {
+		return model.CommandResponse{}, model.NewBadRequestError(
+			fmt.Sprintf("input mapping error: %v", err),
+		)
+	}
+	limited := newUploadBody(body, e.maxUploadSize)
+	invInput.Body = nil
+	invInput.BodyStream = limited
+	if contentType != "" {
+		if invInput.Headers == nil {
+			invInput.Headers = make(map[string]string, 1)
+		}
+		invInput.Headers["Content-Type"] = contentType
+	}
+
+	resolver := &ExpressionResolver{Input: input.Input, RouteParams: input.RouteParams, Context: rctx}
+
+	if err := e.consumeConfirmation(cmdDef, input); err != nil {
+		return model.CommandResponse{}, err
+	}
+
+	result, err := e.invoke(ctx, rctx, cmdDef, invInput)
+	if limited.exceeded {
+		if err == nil && result.BodyReader != nil {
+			_ = result.BodyReader.Close()
+		}
+		return model.CommandResponse{}, model.NewPayloadTooLargeError(e.maxUploadSize)
+	}
+	if err != nil {
+		return model.CommandResponse{}, err
 	}
 
+	resp := e.mapOutput(ctx, result, cmdDef, resolver)
+	resp = attachStream(resp, result)
+	e.notify(ctx, cmdDef, rctx, input, result, resp)
+	if !resp.Success {
+		return resp, commandError(result, resp)
+	}
 	return resp, nil
 }
 
-// Validate performs dry-run validation of a command's input against the
-// OpenAPI schema without invoking the backend.
+// attachStream hands a streamed backend body to a successful response. The
+// body of a failed one is discarded.
+func attachStream(resp model.CommandResponse, result model.InvocationResult) model.CommandResponse {
+	if result.BodyReader == nil {
+		return resp
+	}
+	if !resp.Success {
+		_ = result.BodyReader.Close()
+		return resp
+	}
+	resp.Stream = result.BodyReader
+	resp.StreamHeaders = result.Headers
+	return resp
+}
+
+// commandError returns the error for a failed command. It carries the
+// response's own error code, if set, then the error code the backend response
+// was mapped to, if any, and BAD_REQUEST otherwise.
+func commandError(result model.InvocationResult, resp model.CommandResponse) *model.ErrorEnvelope {
+	code := resp.ErrorCode
+	if code == "" {
+		code = result.ErrorCode
+	}
+	if code == "" {
+		return model.NewBadRequestError(resp.Message)
+	}
+	return &model.ErrorEnvelope{Code: code, Message: resp.Message, Details: resp.Errors}
+}
+
+// Validate performs dry-run validation of a command's input against its
+// limits, select field options and the OpenAPI schema without invoking the
+// backend.
 func (e *CommandExecutor) Validate(
+	ctx context.Context,
 	rctx *model.RequestContext,
 	caps model.CapabilitySet,
 	commandID string,
 	input model.CommandInput,
 ) []model.FieldError {
 	cmdDef, ok := e.registry.GetCommand(commandID)
-	if !ok {
+	if !ok || !rctx.EntitledTo(e.registry.CommandDomain(commandID)) {
 		return []model.FieldError{{Field: "", Code: "NOT_FOUND", Message: fmt.Sprintf("command %q not found", commandID)}}
 	}
+	if e.registry.CommandDisabled(commandID) {
+		return []model.FieldError{{Field: "", Code: model.ErrCommandUnavailable, Message: "command is temporarily unavailable"}}
+	}
 
 	if len(cmdDef.Capabilities) > 0 && !caps.HasAll(cmdDef.Capabilities...) {
 		return []model.FieldError{{Field: "", Code: "FORBIDDEN", Message: "insufficient capabilities"}}
 	}
+	unmasked, err := e.dropMaskedValues(ctx, rctx, commandID, input)
+	if err != nil {
+		var env *model.ErrorEnvelope
+		if errors.As(err, &env) && len(env.Details) > 0 {
+			return env.Details
+		}
+		return []model.FieldError{{Field: "", Code: model.ErrBackendUnavailable, Message: "masked values cannot be verified"}}
+	}
+	input.Input = unmasked
+	cmdDef = selectOperation(cmdDef, input.Input)
+
+	if limitErrs := checkInputLimits(cmdDef.Limits, input.Input); len(limitErrs) > 0 {
+		return limitErrs
+	}
+
+	// Collect option and schema errors together, so a form learns of every
+	// invalid field in one round-trip.
+	fieldErrors, err := e.checkOptions(ctx, rctx, commandID, input.Input)
+	if err != nil {
+		return []model.FieldError{{Field: "", Code: model.ErrBackendUnavailable, Message: "options cannot be verified"}}
+	}
 
 	// Apply input mapping to get the backend body.
 	invInput, err := e.mapper.MapInput(cmdDef.Input, input, rctx, nil)
+	if err == nil {
+		invInput.Body, err = applyEnvelope(cmdDef.Operation.RequestEnvelope, invInput.Body)
+	}
 	if err != nil {
-		return []model.FieldError{{Field: "", Code: "MAPPING_ERROR", Message: err.Error()}}
+		return append(fieldErrors, model.FieldError{Field: "", Code: "MAPPING_ERROR", Message: err.Error()})
 	}
 
+	return append(fieldErrors, e.validateBody(cmdDef, invInput.Body)...)
+}
+
+// validateBody checks a mapped request body against the operation's OpenAPI
+// request schema: required fields, and for strict commands, fields the
+// schema does not declare. Field names are translated back to input names.
+func (e *CommandExecutor) validateBody(cmdDef model.CommandDefinition, body any) []model.FieldError {
 	if e.index == nil || cmdDef.Operation.Type != "openapi" || cmdDef.Operation.ServiceID == "" {
 		return nil
 	}
 
-	bodyMap, ok := invInput.Body.(map[string]any)
+	bodyMap, ok := body.(map[string]any)
 	if !ok {
 		return nil
 	}
 
-	valErrs := e.index.ValidateRequest(cmdDef.Operation.ServiceID, cmdDef.Operation.OperationID, bodyMap)
-	if len(valErrs) == 0 {
-		return nil
-	}
-
 	reverseMap := ReverseFieldMap(cmdDef.Input.FieldProjection)
-	return translateValidationErrors(valErrs, reverseMap)
+	var fieldErrors []model.FieldError
+	if valErrs := e.index.ValidateRequest(cmdDef.Operation.ServiceID, cmdDef.Operation.OperationID, bodyMap); len(valErrs) > 0 {
+		fieldErrors = translateValidationErrors(valErrs, reverseMap)
+	}
+	if cmdDef.StrictInput {
+		for _, field := range e.index.UnknownFields(cmdDef.Operation.ServiceID, cmdDef.Operation.OperationID, bodyMap) {
+			uiField := field
+			if mapped, ok := reverseMap[field]; ok {
+				uiField = mapped
+			}
+			fieldErrors = append(fieldErrors, model.FieldError{
+				Field:   uiField,
+				Code:    "UNKNOWN_FIELD",
+				Message: fmt.Sprintf("%s is not an accepted field", uiField),
+			})
+		}
+	}
+	return fieldErrors
 }
 
 // handleResponse processes the backend response and builds a CommandResponse.
 func (e *CommandExecutor) handleResponse(
+	ctx context.Context,
 	result model.InvocationResult,
 	cmdDef model.CommandDefinition,
+	resolver *ExpressionResolver,
 ) model.CommandResponse {
 	statusCode := result.StatusCode
 
-	// 2xx: Success.
-	if statusCode >= 200 && statusCode < 300 {
-		resp := model.CommandResponse{
-			Success: true,
-			Message: cmdDef.Output.SuccessMessage,
+	// 2xx: Success, unless it differs from the configured expected status
+	// or the service's error mapping detected an error body.
+	if statusCode >= 200 && statusCode < 300 && result.ErrorCode == "" {
+		if expected := cmdDef.Output.ExpectedStatus; expected != 0 && statusCode != expected {
+			if cmdDef.Output.OnUnexpectedStatus == "fail" {
+				return model.CommandResponse{
+					Success:   false,
+					Message:   fmt.Sprintf("Backend returned unexpected status %d (expected %d)", statusCode, expected),
+					ErrorCode: model.ErrBackendError,
+				}
+			}
+			util.Log(ctx).Warn("command: backend returned unexpected success status",
+				"command", cmdDef.ID,
+				"status", statusCode,
+				"expected", expected,
+			)
 		}
 
+		resp := model.CommandResponse{Success: true}
+
 		// Apply output mapping.
 		if body, ok := result.Body.(map[string]any); ok {
 			resp.Result = applyOutputMapping(body, cmdDef.Output)
+			resp.Resource = mapUpdatedResource(body, cmdDef.Output.Resource)
 		}
 
+		// The backend has already applied the command, so a conflict is
+		// reported as a backend error that says so, with the backend's
+		// result, rather than as a rejected request.
+		merged, conflict := mergeEcho(resp.Result, cmdDef.Output, resolver)
+		if conflict != "" {
+			return model.CommandResponse{
+				Success:   false,
+				Message:   fmt.Sprintf("The command was applied, but the backend result conflicts with the submitted value of field %q", conflict),
+				Result:    resp.Result,
+				ErrorCode: model.ErrBackendError,
+			}
+		}
+		resp.Result = merged
+		resp.Message = successMessage(cmdDef.Output, resp.Result)
+		resp.Location = e.createdLocation(ctx, resolver.Context, cmdDef, resp.Result)
+
 		return resp
 	}
 
-	// 4xx: Client error — translate via error_map.
-	if statusCode >= 400 && statusCode < 500 {
-		return e.handleClientError(result, cmdDef)
+	// 4xx or mapped error: Client error — translate via error_map.
+	if (statusCode >= 400 && statusCode < 500) || result.ErrorCode != "" {
+		return e.handleClientError(result, cmdDef, resolver)
 	}
 
 	// 5xx: Server error — generic error.
@@ -169,10 +541,35 @@ func (e *CommandExecutor) handleResponse(
 	}
 }
 
+// selectOperation returns cmdDef bound to the operation of the first
+// conditional operation whose conditions hold on input, or unchanged when
+// none match.
+func selectOperation(cmdDef model.CommandDefinition, input map[string]any) model.CommandDefinition {
+	for _, co := range cmdDef.Operations {
+		if model.ConditionsHold(co.When, input) {
+			cmdDef.Operation = co.Operation
+			break
+		}
+	}
+	return cmdDef
+}
+
+// successMessage returns the message of the first success message rule that
+// matches the mapped result, or the default success message.
+func successMessage(output model.OutputMapping, result map[string]any) string {
+	for _, rule := range output.SuccessMessages {
+		if model.ConditionsHold(rule.When, result) {
+			return rule.Message
+		}
+	}
+	return output.SuccessMessage
+}
+
 // handleClientError translates backend 4xx errors using the error_map.
 func (e *CommandExecutor) handleClientError(
 	result model.InvocationResult,
 	cmdDef model.CommandDefinition,
+	resolver *ExpressionResolver,
 ) model.CommandResponse {
 	body, ok := result.Body.(map[string]any)
 	if !ok {
@@ -192,9 +589,9 @@ func (e *CommandExecutor) handleClientError(
 		errorMsg = extractString(body, "message")
 	}
 
-	// Translate error code via error_map.
+	// Translate error code via error_map, interpolating any placeholders.
 	if translated, ok := cmdDef.Output.ErrorMap[errorCode]; ok {
-		errorMsg = translated
+		errorMsg = interpolateMessage(translated, resolver, body)
 	}
 
 	resp := model.CommandResponse{
@@ -230,6 +627,129 @@ func applyOutputMapping(body map[string]any, output model.OutputMapping) map[str
 	return result
 }
 
+// mapUpdatedResource extracts the updated resource from a backend body,
+// keeping only the configured fields. It returns nil when the command does
+// not opt in or the body holds no resource object at the configured path.
+func mapUpdatedResource(body map[string]any, out *model.ResourceOutput) map[string]any {
+	if out == nil || len(out.Fields) == 0 {
+		return nil
+	}
+	resource := body
+	if out.Path != "" {
+		m, ok := navigatePath(body, out.Path).(map[string]any)
+		if !ok {
+			return nil
+		}
+		resource = m
+	}
+	mapped := make(map[string]any, len(out.Fields))
+	for uiField, path := range out.Fields {
+		if v := navigatePath(resource, path); v != nil {
+			mapped[uiField] = v
+		}
+	}
+	return mapped
+}
+
+// mergeEcho merges the output mapping's echoed input values into the
+// backend result, resolving colliding keys by the configured strategy.
+// Keys holding equal values on both sides are not treated as conflicts.
+// With error-on-conflict, the first conflicting field is returned.
+func mergeEcho(result map[string]any, output model.OutputMapping, resolver *ExpressionResolver) (map[string]any, string) {
+	if len(output.Echo) == 0 || resolver == nil {
+		return result, ""
+	}
+
+	merged := make(map[string]any, len(result)+len(output.Echo))
+	maps.Copy(merged, result)
+
+	fields := make([]string, 0, len(output.Echo))
+	for field := range output.Echo {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		val, err := resolver.Resolve(output.Echo[field])
+		if err != nil {
+			continue
+		}
+		existing, collides := result[field]
+		if !collides {
+			merged[field] = val
+			continue
+		}
+		switch output.Merge {
+		case model.MergeInputWins:
+			merged[field] = val
+		case model.MergeErrorOnConflict:
+			if !reflect.DeepEqual(existing, val) {
+				return nil, field
+			}
+		}
+	}
+	return merged, ""
+}
+
+// messagePlaceholder matches {expression} placeholders in message templates.
+var messagePlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// interpolateMessage replaces {expression} placeholders in an error_map
+// message. Expressions use the input mapping syntax (input.*, route.*,
+// context.*); error.* reads from the backend error object (the "error" field
+// of the response body, or the body itself). Unresolvable placeholders are
+// replaced with an empty string. Messages are plain text, so interpolated
+// values are not escaped; since they may originate from user input or the
+// backend, control characters are dropped and each value is capped at
+// maxMessageValueLen runes.
+func interpolateMessage(tmpl string, resolver *ExpressionResolver, body map[string]any) string {
+	if !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+	return messagePlaceholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		expr := strings.TrimSpace(match[1 : len(match)-1])
+
+		var val any
+		if path, ok := strings.CutPrefix(expr, "error."); ok {
+			errObj, isMap := body["error"].(map[string]any)
+			if !isMap {
+				errObj = body
+			}
+			val = navigatePath(errObj, path)
+		} else if resolver != nil {
+			val, _ = resolver.Resolve(expr)
+		}
+
+		if val == nil {
+			return ""
+		}
+		return sanitizeMessageValue(fmt.Sprint(val))
+	})
+}
+
+// maxMessageValueLen caps the runes a single interpolated value contributes
+// to a message.
+const maxMessageValueLen = 200
+
+// sanitizeMessageValue drops control characters from an interpolated message
+// value and truncates it to maxMessageValueLen runes.
+func sanitizeMessageValue(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			continue
+		}
+		if n == maxMessageValueLen {
+			b.WriteString("…")
+			break
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return b.String()
+}
+
 // translateValidationErrors converts OpenAPI validation errors to model.FieldError,
 // reversing field names using the reverse map.
 func translateValidationErrors(valErrs []openapiIndex.ValidationError, reverseMap map[string]string) []model.FieldError {
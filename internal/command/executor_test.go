@@ -3,8 +3,11 @@ package command
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/pitabwire/thesa/internal/definition"
@@ -108,22 +111,48 @@ func testRctxForExecutor() *model.RequestContext {
 	}
 }
 
-func newTestExecutor(invokeFn func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error)) *CommandExecutor {
-	reg := definition.NewRegistry(testCommandDefinitions())
+// newTestExecutor builds an executor over testCommandDefinitions, after
+// applying edits to the orders domain, whose operations call invokeFn.
+func newTestExecutor(invokeFn func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error), edits ...func(*model.DomainDefinition)) *CommandExecutor {
+	defs := testCommandDefinitions()
+	for _, edit := range edits {
+		edit(&defs[0])
+	}
 	invReg := invoker.NewRegistry()
 	invReg.Register(&mockOperationInvoker{invokeFn: invokeFn})
 
-	return NewCommandExecutor(reg, invReg, nil)
+	return NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
 }
 
-func newTestExecutorWithIndex(invokeFn func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error)) *CommandExecutor {
-	reg := definition.NewRegistry(testCommandDefinitions())
+func newTestExecutorWithIndex(invokeFn func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error), edits ...func(*model.DomainDefinition)) *CommandExecutor {
+	defs := testCommandDefinitions()
+	for _, edit := range edits {
+		edit(&defs[0])
+	}
 	invReg := invoker.NewRegistry()
 	invReg.Register(&mockOperationInvoker{invokeFn: invokeFn})
 
 	idx := loadTestOAIndex()
 
-	return NewCommandExecutor(reg, invReg, idx)
+	return NewCommandExecutor(definition.NewRegistry(defs), invReg, idx)
+}
+
+// withCommand applies edit to the test command with the given ID.
+func withCommand(id string, edit func(*model.CommandDefinition)) func(*model.DomainDefinition) {
+	return func(d *model.DomainDefinition) {
+		for i := range d.Commands {
+			if d.Commands[i].ID == id {
+				edit(&d.Commands[i])
+			}
+		}
+	}
+}
+
+// respondWith answers every invocation with the given status and body.
+func respondWith(status int, body map[string]any) func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+	return func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{StatusCode: status, Body: body}, nil
+	}
 }
 
 // loadTestOAIndex creates a minimal OpenAPI index for testing schema validation.
@@ -146,10 +175,16 @@ paths:
               properties:
                 customer_id:
                   type: string
+                priority:
+                  type: string
+                  enum: [low, high]
                 items:
                   type: array
                   items:
                     type: object
+                    properties:
+                      quantity:
+                        type: integer
       responses:
         "200":
           description: OK
@@ -226,6 +261,23 @@ func TestExecutor_forbidden(t *testing.T) {
 	if envErr.Code != model.ErrForbidden {
 		t.Errorf("code = %s, want %s", envErr.Code, model.ErrForbidden)
 	}
+	if len(envErr.RequiredCapabilities) == 0 {
+		t.Error("RequiredCapabilities is empty, want the missing capabilities")
+	}
+}
+
+func TestExecutor_unentitledDomain(t *testing.T) {
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		t.Error("backend invoked for an unentitled domain")
+		return model.InvocationResult{StatusCode: 200}, nil
+	})
+	rctx := testRctxForExecutor()
+	rctx.UnentitledDomains = []string{"orders"}
+
+	_, err := e.Execute(context.Background(), rctx, model.CapabilitySet{"*": true}, "orders.simple", model.CommandInput{Input: map[string]any{}})
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrNotFound {
+		t.Errorf("Execute error = %v, want NOT_FOUND", err)
+	}
 }
 
 func TestExecutor_noCapabilitiesRequired(t *testing.T) {
@@ -243,6 +295,41 @@ func TestExecutor_noCapabilitiesRequired(t *testing.T) {
 	}
 }
 
+func TestExecutor_disabledCommand(t *testing.T) {
+	invoked := false
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		invoked = true
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+	})
+	e.registry.SetCommandDisabled("orders.create", true)
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok {
+		t.Fatalf("error = %v, want COMMAND_UNAVAILABLE", err)
+	}
+	if envErr.Code != model.ErrCommandUnavailable {
+		t.Errorf("code = %s, want %s", envErr.Code, model.ErrCommandUnavailable)
+	}
+	if invoked {
+		t.Error("backend invoked for a disabled command")
+	}
+	if errs := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{}); len(errs) != 1 || errs[0].Code != model.ErrCommandUnavailable {
+		t.Errorf("Validate = %+v, want COMMAND_UNAVAILABLE", errs)
+	}
+
+	caps := model.CapabilitySet{"orders:cancel:execute": true}
+	input := model.CommandInput{Input: map[string]any{"reason": "x", "refund_type": "full"}, RouteParams: map[string]string{"id": "ord-1"}}
+	if _, err := e.Execute(context.Background(), testRctxForExecutor(), caps, "orders.cancel", input); err != nil {
+		t.Errorf("other command: Execute error: %v", err)
+	}
+
+	e.registry.SetCommandDisabled("orders.create", false)
+	if _, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}}); err != nil {
+		t.Errorf("re-enabled command: Execute error: %v", err)
+	}
+}
+
 // --- Step 5: Input mapping ---
 
 func TestExecutor_inputMappingApplied(t *testing.T) {
@@ -284,6 +371,72 @@ func TestExecutor_inputMappingApplied(t *testing.T) {
 	}
 }
 
+func TestExecutor_inputMappingFallback(t *testing.T) {
+	var captured model.InvocationInput
+	invokeFn := func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		captured = input
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+	}
+	newExecutor := func(projection map[string]string) *CommandExecutor {
+		defs := testCommandDefinitions()
+		defs[0].Commands[0].Input.FieldProjection = projection
+		invReg := invoker.NewRegistry()
+		invReg.Register(&mockOperationInvoker{invokeFn: invokeFn})
+		return NewCommandExecutor(definition.NewRegistry(defs), invReg, loadTestOAIndex())
+	}
+	caps := model.CapabilitySet{"orders:cancel:execute": true}
+	cancel := func(e *CommandExecutor, input map[string]any) (model.CommandResponse, error) {
+		captured = model.InvocationInput{}
+		return e.Execute(context.Background(), testRctxForExecutor(), caps, "orders.cancel",
+			model.CommandInput{Input: input, RouteParams: map[string]string{"id": "ord-1"}})
+	}
+
+	e := newExecutor(map[string]string{
+		"cancellationReason": "input.reason",
+		"refundType":         `input.refund_type ?? "none"`,
+	})
+	if _, err := cancel(e, map[string]any{"reason": "x", "refund_type": "full"}); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := captured.Body.(map[string]any)["refundType"]; got != "full" {
+		t.Errorf("refundType = %v, want the present value full", got)
+	}
+	if _, err := cancel(e, map[string]any{"reason": "x"}); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := captured.Body.(map[string]any)["refundType"]; got != "none" {
+		t.Errorf("refundType = %v, want the fallback none", got)
+	}
+
+	// The fallback is part of the body validated against the schema.
+	e = newExecutor(map[string]string{"cancellationReason": "input.reason ?? 42"})
+	_, err := cancel(e, map[string]any{})
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrValidationError || len(envErr.Details) != 1 || envErr.Details[0].Field != "reason" {
+		t.Errorf("error = %v, want a validation error on the defaulted reason", err)
+	}
+	if captured.Body != nil {
+		t.Error("backend invoked with an invalid defaulted value")
+	}
+}
+
+func TestExecutor_inputMappingUnknownTransform(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Commands[2].Input = model.InputMapping{
+		BodyMapping:     "projection",
+		FieldProjection: map[string]string{"reason": "input.reason | shout"},
+	}
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", model.CommandInput{Input: map[string]any{"reason": "x"}})
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrBadRequest || !strings.Contains(envErr.Message, `unknown transform "shout"`) {
+		t.Errorf("error = %v, want BAD_REQUEST naming the unknown transform", err)
+	}
+}
+
 func TestExecutor_inputMappingError(t *testing.T) {
 	e := newTestExecutor(nil)
 
@@ -512,6 +665,214 @@ func TestExecutor_clientError_withFieldErrors(t *testing.T) {
 	}
 }
 
+func TestExecutor_mappedErrorCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      model.InvocationResult
+		wantMessage string
+		wantDetails int
+	}{
+		{
+			name: "409 mapped to validation error",
+			result: model.InvocationResult{
+				StatusCode: 409,
+				ErrorCode:  model.ErrValidationError,
+				Body: map[string]any{"error": map[string]any{
+					"message": "Reason already used",
+					"details": []any{map[string]any{"field": "cancellationReason", "message": "Already used"}},
+				}},
+			},
+			wantMessage: "Reason already used",
+			wantDetails: 1,
+		},
+		{
+			name: "200 with error body",
+			result: model.InvocationResult{
+				StatusCode: 200,
+				ErrorCode:  model.ErrBadRequest,
+				Body:       map[string]any{"error": map[string]any{"code": "INVALID", "message": "Order is locked"}},
+			},
+			wantMessage: "Order is locked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+				return tt.result, nil
+			})
+			caps := model.CapabilitySet{"orders:cancel:execute": true}
+			input := model.CommandInput{
+				Input:       map[string]any{"reason": "test", "refund_type": "full"},
+				RouteParams: map[string]string{"id": "ord-123"},
+			}
+
+			resp, err := e.Execute(context.Background(), testRctxForExecutor(), caps, "orders.cancel", input)
+			if resp.Success {
+				t.Error("Success = true, want false")
+			}
+			envErr, ok := err.(*model.ErrorEnvelope)
+			if !ok {
+				t.Fatalf("error = %v, want *model.ErrorEnvelope", err)
+			}
+			if envErr.Code != tt.result.ErrorCode {
+				t.Errorf("Code = %q, want %q", envErr.Code, tt.result.ErrorCode)
+			}
+			if envErr.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", envErr.Message, tt.wantMessage)
+			}
+			if len(envErr.Details) != tt.wantDetails {
+				t.Fatalf("len(Details) = %d, want %d", len(envErr.Details), tt.wantDetails)
+			}
+			if tt.wantDetails > 0 && envErr.Details[0].Field != "reason" {
+				t.Errorf("Details[0].Field = %q, want reason", envErr.Details[0].Field)
+			}
+		})
+	}
+}
+
+func TestExecutor_maskedFieldUnchanged(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Forms = []model.FormDefinition{{
+		ID: "orders.payment", SubmitCommand: "orders.create",
+		Sections: []model.SectionDefinition{{ID: "card", Fields: []model.FieldDefinition{
+			{Field: "card_number", Mask: &model.MaskDefinition{ShowLast: 4}},
+		}}},
+	}}
+	var body map[string]any
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		body, _ = input.Body.(map[string]any)
+		return model.InvocationResult{StatusCode: 200}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	tests := []struct {
+		name     string
+		value    string
+		wantSent bool
+	}{
+		{name: "unchanged mask", value: "************4242"},
+		{name: "changed value", value: "5555555555554444", wantSent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := model.CommandInput{Input: map[string]any{"card_number": tt.value, "holder": "Alice"}}
+			if _, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input); err != nil {
+				t.Fatalf("Execute error: %v", err)
+			}
+			got, sent := body["card_number"]
+			if sent != tt.wantSent || (sent && got != tt.value) {
+				t.Errorf("body[card_number] = %v (sent %v), want sent %v", got, sent, tt.wantSent)
+			}
+			if body["holder"] != "Alice" {
+				t.Errorf("body[holder] = %v, want Alice", body["holder"])
+			}
+			if input.Input["card_number"] != tt.value {
+				t.Error("caller's input was modified")
+			}
+		})
+	}
+}
+
+type stubMaskedSource map[string]any
+
+func (s stubMaskedSource) MaskedOriginals(ctx context.Context, rctx *model.RequestContext, commandID string, routeParams map[string]string) (map[string]any, error) {
+	return s, nil
+}
+
+func TestExecutor_maskedFieldComparedWithOriginal(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Forms = []model.FormDefinition{{
+		ID: "orders.payment", SubmitCommand: "orders.create",
+		Sections: []model.SectionDefinition{{ID: "card", Fields: []model.FieldDefinition{
+			{Field: "card_number", Mask: &model.MaskDefinition{ShowLast: 4}},
+		}}},
+	}}
+	var body map[string]any
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		body, _ = input.Body.(map[string]any)
+		return model.InvocationResult{StatusCode: 200}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+	e.SetMaskedValueSource(stubMaskedSource{"card_number": "4242424242424242"})
+
+	tests := []struct {
+		name     string
+		value    string
+		wantSent bool
+		wantErr  bool
+	}{
+		{name: "exact rendering", value: "************4242"},
+		{name: "edited visible characters", value: "************9999", wantErr: true},
+		{name: "shorter mask", value: "****4242", wantErr: true},
+		{name: "new value", value: "5555555555554444", wantSent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body = nil
+			input := model.CommandInput{Input: map[string]any{"card_number": tt.value, "holder": "Alice"}}
+			_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input)
+			if tt.wantErr {
+				if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrValidationError {
+					t.Errorf("Execute error = %v, want VALIDATION_ERROR", err)
+				}
+				if errs := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input); len(errs) == 0 || errs[0].Field != "card_number" {
+					t.Errorf("Validate() = %v, want an error on card_number", errs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute error: %v", err)
+			}
+			if _, sent := body["card_number"]; sent != tt.wantSent {
+				t.Errorf("card_number sent = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestExecutor_conditionalOperation(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Commands[1].Operations = []model.ConditionalOperation{
+		{
+			When:      []model.ConditionDefinition{{Field: "order_type", Operator: "eq", Value: "wholesale"}},
+			Operation: model.OperationBinding{Type: "openapi", ServiceID: "orders-svc", OperationID: "createWholesaleOrder"},
+		},
+		{
+			When:      []model.ConditionDefinition{{Field: "order_type", Operator: "in", Value: []any{"gift", "sample"}}},
+			Operation: model.OperationBinding{Type: "openapi", ServiceID: "orders-svc", OperationID: "createFreeOrder"},
+		},
+	}
+	var called string
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		called = binding.OperationID
+		return model.InvocationResult{StatusCode: 200}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	tests := []struct {
+		orderType string
+		want      string
+	}{
+		{orderType: "wholesale", want: "createWholesaleOrder"},
+		{orderType: "sample", want: "createFreeOrder"},
+		{orderType: "retail", want: "createOrder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.orderType, func(t *testing.T) {
+			input := model.CommandInput{Input: map[string]any{"order_type": tt.orderType}}
+			if _, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input); err != nil {
+				t.Fatalf("Execute error: %v", err)
+			}
+			if called != tt.want {
+				t.Errorf("invoked operation = %q, want %q", called, tt.want)
+			}
+		})
+	}
+}
+
 func TestExecutor_serverError(t *testing.T) {
 	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
 		return model.InvocationResult{
@@ -538,6 +899,298 @@ func TestExecutor_serverError(t *testing.T) {
 	}
 }
 
+func itemsInput(n int) model.CommandInput {
+	items := make([]any, n)
+	for i := range items {
+		items[i] = map[string]any{"sku": fmt.Sprintf("sku-%d", i)}
+	}
+	return model.CommandInput{Input: map[string]any{"order": map[string]any{"items": items}}}
+}
+
+func TestExecutor_inputLimits_oversizedArray(t *testing.T) {
+	var invoked bool
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		invoked = true
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+	}, withCommand("orders.simple", func(c *model.CommandDefinition) {
+		c.Limits = &model.InputLimits{MaxArrayLength: 100}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", itemsInput(101))
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrValidationError {
+		t.Fatalf("error = %v, want VALIDATION_ERROR", err)
+	}
+	if invoked {
+		t.Error("backend invoked for oversized input")
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Field != "order.items" || resp.Errors[0].Code != "MAX_ITEMS" {
+		t.Errorf("Errors = %+v, want MAX_ITEMS on order.items", resp.Errors)
+	}
+
+	if errs := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", itemsInput(101)); len(errs) != 1 || errs[0].Code != "MAX_ITEMS" {
+		t.Errorf("Validate() = %+v, want MAX_ITEMS", errs)
+	}
+}
+
+func TestExecutor_inputLimits_withinLimit(t *testing.T) {
+	var invoked bool
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		invoked = true
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+	}, withCommand("orders.simple", func(c *model.CommandDefinition) {
+		c.Limits = &model.InputLimits{MaxArrayLength: 100, MaxFields: 250}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", itemsInput(100))
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !resp.Success || !invoked {
+		t.Errorf("Success = %v, invoked = %v, want both true", resp.Success, invoked)
+	}
+}
+
+func TestExecutor_inputLimits_maxFields(t *testing.T) {
+	var invoked bool
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		invoked = true
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+	}, withCommand("orders.simple", func(c *model.CommandDefinition) {
+		c.Limits = &model.InputLimits{MaxFields: 10}
+	}))
+
+	// 1 "order" + 1 "items" + 10 "sku" fields.
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", itemsInput(10))
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrValidationError || envErr.Details[0].Code != "MAX_FIELDS" {
+		t.Fatalf("error = %v, want VALIDATION_ERROR with MAX_FIELDS", err)
+	}
+	if invoked {
+		t.Error("backend invoked for input with too many fields")
+	}
+}
+
+// updatedOrderBody is a backend response carrying an updated order.
+func updatedOrderBody() map[string]any {
+	return map[string]any{
+		"data": map[string]any{
+			"id":            "ord-1",
+			"status":        "confirmed",
+			"total":         42.5,
+			"internal_note": "fraud score 0.2",
+		},
+	}
+}
+
+func TestExecutor_updatedResource_enabled(t *testing.T) {
+	e := newTestExecutor(respondWith(200, updatedOrderBody()), withCommand("orders.create", func(c *model.CommandDefinition) {
+		c.Output = model.OutputMapping{
+			Resource: &model.ResourceOutput{
+				Path:   "data",
+				Fields: map[string]string{"id": "id", "status": "status", "amount": "total"},
+			},
+		}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	want := map[string]any{"id": "ord-1", "status": "confirmed", "amount": 42.5}
+	if len(resp.Resource) != len(want) {
+		t.Fatalf("Resource = %v, want %v", resp.Resource, want)
+	}
+	for k, v := range want {
+		if resp.Resource[k] != v {
+			t.Errorf("Resource[%s] = %v, want %v", k, resp.Resource[k], v)
+		}
+	}
+	if _, leaked := resp.Resource["internal_note"]; leaked {
+		t.Error("unmapped field internal_note leaked into Resource")
+	}
+}
+
+func TestExecutor_updatedResource_omittedByDefault(t *testing.T) {
+	e := newTestExecutor(respondWith(200, updatedOrderBody()), withCommand("orders.create", func(c *model.CommandDefinition) {
+		c.Output = model.OutputMapping{}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if resp.Resource != nil {
+		t.Errorf("Resource = %v, want nil when not enabled", resp.Resource)
+	}
+}
+
+func TestExecutor_strictInput(t *testing.T) {
+	input := model.CommandInput{Input: map[string]any{
+		"customer_id": "cust-1",
+		"items":       []any{},
+		"nickname":    "typo",
+	}}
+
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			defs := testCommandDefinitions()
+			defs[0].Commands[1].StrictInput = strict
+			var sent map[string]any
+			invReg := invoker.NewRegistry()
+			invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+				sent, _ = input.Body.(map[string]any)
+				return model.InvocationResult{StatusCode: 201, Body: map[string]any{}}, nil
+			}})
+			e := NewCommandExecutor(definition.NewRegistry(defs), invReg, loadTestOAIndex())
+
+			resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input)
+			if !strict {
+				if err != nil {
+					t.Fatalf("Execute error: %v", err)
+				}
+				if sent["nickname"] != "typo" {
+					t.Errorf("backend body = %v, want the extra field forwarded", sent)
+				}
+				return
+			}
+
+			envErr, ok := err.(*model.ErrorEnvelope)
+			if !ok || envErr.Code != model.ErrValidationError {
+				t.Fatalf("error = %v, want VALIDATION_ERROR", err)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Field != "nickname" || resp.Errors[0].Code != "UNKNOWN_FIELD" {
+				t.Errorf("Errors = %+v, want one UNKNOWN_FIELD error for nickname", resp.Errors)
+			}
+			if sent != nil {
+				t.Error("backend invoked despite an unknown field")
+			}
+		})
+	}
+}
+
+func TestExecutor_conditionalSuccessMessage(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Commands[1].Output = model.OutputMapping{
+		Type:           "full",
+		SuccessMessage: "Order created",
+		SuccessMessages: []model.SuccessMessageRule{
+			{When: []model.ConditionDefinition{{Field: "status", Operator: "eq", Value: "placed"}}, Message: "Order placed"},
+			{When: []model.ConditionDefinition{{Field: "status", Operator: "in", Value: []any{"pending_review", "flagged"}}}, Message: "Order queued for review"},
+		},
+	}
+	var status string
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{StatusCode: 201, Body: map[string]any{"id": "ord-1", "status": status}}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: "placed", want: "Order placed"},
+		{status: "pending_review", want: "Order queued for review"},
+		{status: "flagged", want: "Order queued for review"},
+		{status: "draft", want: "Order created"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			status = tt.status
+			resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+			if err != nil {
+				t.Fatalf("Execute error: %v", err)
+			}
+			if resp.Message != tt.want {
+				t.Errorf("Message = %q, want %q", resp.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutor_expectedStatus_match(t *testing.T) {
+	e := newTestExecutor(respondWith(201, map[string]any{"id": "ord-1"}), withCommand("orders.create", func(c *model.CommandDefinition) {
+		c.Output = model.OutputMapping{ExpectedStatus: 201, OnUnexpectedStatus: "fail"}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !resp.Success {
+		t.Error("Success = false, want true")
+	}
+}
+
+func TestExecutor_expectedStatus_mismatchFail(t *testing.T) {
+	e := newTestExecutor(respondWith(200, map[string]any{"id": "ord-1"}), withCommand("orders.create", func(c *model.CommandDefinition) {
+		c.Output = model.OutputMapping{ExpectedStatus: 201, OnUnexpectedStatus: "fail"}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+	if err == nil {
+		t.Fatal("expected error for unexpected status")
+	}
+	if resp.Success {
+		t.Error("Success = true, want false")
+	}
+	if resp.Message != "Backend returned unexpected status 200 (expected 201)" {
+		t.Errorf("Message = %q", resp.Message)
+	}
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrBackendError {
+		t.Errorf("error = %v, want BACKEND_ERROR", err)
+	}
+}
+
+func TestExecutor_expectedStatus_mismatchWarn(t *testing.T) {
+	e := newTestExecutor(respondWith(200, map[string]any{"id": "ord-1"}), withCommand("orders.create", func(c *model.CommandDefinition) {
+		c.Output = model.OutputMapping{ExpectedStatus: 201, SuccessMessage: "Order created"}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !resp.Success {
+		t.Error("Success = false, want true")
+	}
+	if resp.Message != "Order created" {
+		t.Errorf("Message = %q", resp.Message)
+	}
+}
+
+func TestExecutor_clientError_interpolatedErrorMap(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Commands[0].Output.ErrorMap = map[string]string{
+		"ORDER_SHIPPED": "Cannot cancel order {input.order_number} in {error.details.state} state",
+	}
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{
+			StatusCode: 409,
+			Body: map[string]any{
+				"error": map[string]any{
+					"code":    "ORDER_SHIPPED",
+					"details": map[string]any{"state": "shipped"},
+				},
+			},
+		}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	caps := model.CapabilitySet{"orders:cancel:execute": true}
+	input := model.CommandInput{
+		Input:       map[string]any{"reason": "test", "refund_type": "full", "order_number": "ORD-001"},
+		RouteParams: map[string]string{"id": "ord-123"},
+	}
+
+	resp, _ := e.Execute(context.Background(), testRctxForExecutor(), caps, "orders.cancel", input)
+	if resp.Message != "Cannot cancel order ORD-001 in shipped state" {
+		t.Errorf("Message = %q", resp.Message)
+	}
+}
+
 // --- Validate (dry-run) ---
 
 func TestExecutor_Validate_valid(t *testing.T) {
@@ -549,7 +1202,7 @@ func TestExecutor_Validate_valid(t *testing.T) {
 		RouteParams: map[string]string{"id": "ord-123"},
 	}
 
-	errors := e.Validate(testRctxForExecutor(), caps, "orders.cancel", input)
+	errors := e.Validate(context.Background(), testRctxForExecutor(), caps, "orders.cancel", input)
 	if len(errors) != 0 {
 		t.Errorf("Validate returned %d errors, want 0: %+v", len(errors), errors)
 	}
@@ -562,16 +1215,79 @@ func TestExecutor_Validate_missingFields(t *testing.T) {
 		Input: map[string]any{"notes": "just notes"}, // Missing customer_id and items.
 	}
 
-	errors := e.Validate(testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input)
+	errors := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input)
 	if len(errors) < 2 {
 		t.Errorf("Validate returned %d errors, want at least 2: %+v", len(errors), errors)
 	}
 }
 
+func TestExecutor_Validate_reportsAllFieldErrors(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Forms = []model.FormDefinition{{
+		ID:            "orders.create-form",
+		SubmitCommand: "orders.create",
+		Sections: []model.SectionDefinition{{
+			ID: "main",
+			Fields: []model.FieldDefinition{{Field: "channel", Type: "select", Lookup: &model.LookupRefDefinition{
+				Static: []model.StaticOption{{Label: "Web", Value: "web"}},
+			}}},
+		}},
+	}}
+	invoked := false
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		invoked = true
+		return model.InvocationResult{StatusCode: 201}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, loadTestOAIndex())
+
+	input := model.CommandInput{Input: map[string]any{
+		"channel":     "fax",
+		"customer_id": float64(42),
+		"priority":    "urgent",
+		"items":       []any{map[string]any{"quantity": 1.5}},
+	}}
+	want := []model.FieldError{
+		{Field: "channel", Code: "INVALID_OPTION"},
+		{Field: "customer_id", Code: "INVALID_VALUE"},
+		{Field: "items[0].quantity", Code: "INVALID_VALUE"},
+		{Field: "priority", Code: "INVALID_VALUE"},
+	}
+	check := func(name string, got []model.FieldError) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s returned %+v, want %d errors", name, got, len(want))
+		}
+		for i, w := range want {
+			if got[i].Field != w.Field || got[i].Code != w.Code {
+				t.Errorf("%s error[%d] = %s/%s, want %s/%s", name, i, got[i].Field, got[i].Code, w.Field, w.Code)
+			}
+		}
+	}
+
+	check("Validate", e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input)
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrValidationError {
+		t.Fatalf("Execute error = %v, want VALIDATION_ERROR", err)
+	}
+	check("Execute", resp.Errors)
+	if invoked {
+		t.Error("backend invoked despite field errors")
+	}
+
+	delete(input.Input, "items")
+	errs := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", input)
+	if len(errs) != 4 || errs[2].Field != "items" || errs[2].Code != "REQUIRED" {
+		t.Errorf("Validate() = %+v, want the missing items reported with the other errors", errs)
+	}
+}
+
 func TestExecutor_Validate_notFound(t *testing.T) {
 	e := newTestExecutor(nil)
 
-	errors := e.Validate(testRctxForExecutor(), model.CapabilitySet{}, "nonexistent", model.CommandInput{})
+	errors := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "nonexistent", model.CommandInput{})
 	if len(errors) != 1 {
 		t.Fatalf("len(errors) = %d, want 1", len(errors))
 	}
@@ -583,7 +1299,7 @@ func TestExecutor_Validate_notFound(t *testing.T) {
 func TestExecutor_Validate_forbidden(t *testing.T) {
 	e := newTestExecutor(nil)
 
-	errors := e.Validate(testRctxForExecutor(), model.CapabilitySet{}, "orders.cancel", model.CommandInput{})
+	errors := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.cancel", model.CommandInput{})
 	if len(errors) != 1 {
 		t.Fatalf("len(errors) = %d, want 1", len(errors))
 	}
@@ -685,6 +1401,35 @@ func TestExtractFieldErrors_noDetails(t *testing.T) {
 	}
 }
 
+func TestInterpolateMessage(t *testing.T) {
+	resolver := &ExpressionResolver{
+		Input: map[string]any{
+			"name":  "O'Brien <b>Bob</b>",
+			"notes": "line\none\ttab\x1b[31m",
+			"long":  strings.Repeat("x", maxMessageValueLen+10),
+		},
+		RouteParams: map[string]string{"id": "ord-9"},
+	}
+	body := map[string]any{"code": "CONFLICT", "state": "locked"}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"static message", "static message"},
+		{"Order {route.id} is {error.state}", "Order ord-9 is locked"},
+		{"Hello {input.name}", "Hello O'Brien <b>Bob</b>"},
+		{"Notes: {input.notes}", "Notes: lineonetab[31m"},
+		{"{input.long}", strings.Repeat("x", maxMessageValueLen) + "…"},
+		{"Missing {input.unknown}.", "Missing ."},
+	}
+	for _, tt := range tests {
+		if got := interpolateMessage(tt.tmpl, resolver, body); got != tt.want {
+			t.Errorf("interpolateMessage(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
 func TestContainsWord(t *testing.T) {
 	tests := []struct {
 		s, word string
@@ -702,3 +1447,177 @@ func TestContainsWord(t *testing.T) {
 		}
 	}
 }
+
+func TestExecutor_mergeEcho_strategies(t *testing.T) {
+	tests := []struct {
+		name        string
+		merge       string
+		wantSuccess bool
+		wantID      any
+	}{
+		{"default backend wins", "", true, "ord-1"},
+		{"backend-wins", model.MergeBackendWins, true, "ord-1"},
+		{"input-wins", model.MergeInputWins, true, "draft-7"},
+		{"error-on-conflict", model.MergeErrorOnConflict, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExecutor(respondWith(201, map[string]any{"id": "ord-1"}), withCommand("orders.create", func(c *model.CommandDefinition) {
+				c.Output = model.OutputMapping{
+					Echo:  map[string]string{"id": "input.id", "note": "input.note"},
+					Merge: tt.merge,
+				}
+			}))
+
+			resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create",
+				model.CommandInput{Input: map[string]any{"id": "draft-7", "note": "rush"}})
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("Success = %v, want %v (err = %v)", resp.Success, tt.wantSuccess, err)
+			}
+			if !tt.wantSuccess {
+				if err == nil {
+					t.Fatal("expected error on conflict")
+				}
+				if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrBackendError {
+					t.Errorf("error = %v, want BACKEND_ERROR", err)
+				}
+				if !strings.Contains(resp.Message, "was applied") || !strings.Contains(resp.Message, `"id"`) {
+					t.Errorf("Message = %q, want it to say the command was applied", resp.Message)
+				}
+				if resp.Result["id"] != "ord-1" {
+					t.Errorf("Result[id] = %v, want the backend's ord-1", resp.Result["id"])
+				}
+				return
+			}
+			if resp.Result["id"] != tt.wantID {
+				t.Errorf("Result[id] = %v, want %v", resp.Result["id"], tt.wantID)
+			}
+			if resp.Result["note"] != "rush" {
+				t.Errorf("Result[note] = %v, want rush", resp.Result["note"])
+			}
+		})
+	}
+}
+
+func TestExecutor_mergeEcho_equalValuesDoNotConflict(t *testing.T) {
+	e := newTestExecutor(respondWith(201, map[string]any{"id": "ord-1"}), withCommand("orders.create", func(c *model.CommandDefinition) {
+		c.Output = model.OutputMapping{
+			Echo:  map[string]string{"id": "input.id"},
+			Merge: model.MergeErrorOnConflict,
+		}
+	}))
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create",
+		model.CommandInput{Input: map[string]any{"id": "ord-1"}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if resp.Result["id"] != "ord-1" {
+		t.Errorf("Result[id] = %v, want ord-1", resp.Result["id"])
+	}
+}
+
+func TestExecutor_noopCommand(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Commands = append(defs[0].Commands, model.CommandDefinition{
+		ID:           "orders.flag",
+		Capabilities: []string{"orders:flag"},
+		Operation:    model.OperationBinding{Type: OperationTypeNoop},
+		Input:        model.InputMapping{BodyMapping: "passthrough"},
+		Output: model.OutputMapping{
+			SuccessMessage: "Flag recorded",
+			Echo:           map[string]string{"reason": "input.reason"},
+		},
+	})
+
+	invoked := false
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		invoked = true
+		return model.InvocationResult{StatusCode: http.StatusOK}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	var events []CommandEvent
+	e.AddObserver(CommandObserverFunc(func(ctx context.Context, event CommandEvent) {
+		events = append(events, event)
+	}))
+
+	caps := model.CapabilitySet{"orders:flag": true}
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), caps, "orders.flag",
+		model.CommandInput{Input: map[string]any{"reason": "fraud check"}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !resp.Success || resp.Message != "Flag recorded" {
+		t.Errorf("resp = %+v, want success with message", resp)
+	}
+	if resp.Result["reason"] != "fraud check" {
+		t.Errorf("Result[reason] = %v, want echoed input", resp.Result["reason"])
+	}
+	if invoked {
+		t.Error("noop command invoked a backend")
+	}
+	if len(events) != 1 {
+		t.Fatalf("observer notified %d times, want 1", len(events))
+	}
+	if events[0].CommandID != "orders.flag" || events[0].Context.SubjectID != "user-alice" || !events[0].Response.Success {
+		t.Errorf("event = %+v", events[0])
+	}
+
+	// Capabilities are still enforced; observers are not notified on rejection.
+	if _, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.flag", model.CommandInput{}); err == nil {
+		t.Error("expected forbidden error without capability")
+	}
+	if len(events) != 1 {
+		t.Errorf("observer notified %d times after rejection, want 1", len(events))
+	}
+}
+
+func TestExecutor_observerReceivesAuditDiff(t *testing.T) {
+	defs := testCommandDefinitions()
+	defs[0].Commands[1].Output = model.OutputMapping{Audit: &model.AuditDiff{
+		Before: "previous",
+		After:  "order",
+		Fields: map[string]string{"status": "status", "total": "amount.total", "notes": "notes", "customer": "customer_id"},
+	}}
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockOperationInvoker{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{StatusCode: http.StatusOK, Body: map[string]any{
+			"previous": map[string]any{"status": "pending", "amount": map[string]any{"total": 10.0}, "customer_id": "c-1"},
+			"order":    map[string]any{"status": "shipped", "amount": map[string]any{"total": 10.0}, "customer_id": "c-1", "notes": "fragile"},
+		}}, nil
+	}})
+	e := NewCommandExecutor(definition.NewRegistry(defs), invReg, nil)
+
+	var event CommandEvent
+	e.AddObserver(CommandObserverFunc(func(ctx context.Context, ev CommandEvent) { event = ev }))
+	if _, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}}); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	want := map[string]FieldChange{
+		"status": {Before: "pending", After: "shipped"},
+		"notes":  {Before: nil, After: "fragile"},
+	}
+	if !reflect.DeepEqual(event.Changes, want) {
+		t.Errorf("Changes = %v, want %v", event.Changes, want)
+	}
+}
+
+func TestExecutor_observerNotifiedForBackendCommand(t *testing.T) {
+	e := newTestExecutor(respondWith(http.StatusCreated, map[string]any{"id": "ord-1"}), withCommand("orders.create", func(c *model.CommandDefinition) {
+		c.Output = model.OutputMapping{}
+	}))
+	var got []string
+	e.AddObserver(CommandObserverFunc(func(ctx context.Context, event CommandEvent) {
+		got = append(got, event.CommandID)
+	}))
+
+	if _, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}}); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "orders.create" {
+		t.Errorf("observed = %v, want [orders.create]", got)
+	}
+}
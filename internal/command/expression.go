@@ -1,13 +1,18 @@
 package command
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 
+	"github.com/pitabwire/thesa/internal/transform"
 	"github.com/pitabwire/thesa/model"
 )
 
+// errValueMissing marks a source expression whose value is absent, which a
+// "??" fallback or a default transform may replace.
+var errValueMissing = errors.New("not found")
+
 // ExpressionResolver resolves source expressions against the available
 // sources: user input, route params, request context, and optional
 // workflow state.
@@ -28,22 +33,77 @@ type ExpressionResolver struct {
 //   - context.partition_id     — from RequestContext
 //   - context.email            — from RequestContext
 //   - workflow.field_name      — from workflow state
-//   - 'literal'                — single-quoted literal string
+//   - 'literal' / "literal"    — quoted literal string
 //   - 123 / 99.99              — numeric literal
+//
+// A value is missing when it is absent or the empty string (see
+// transform.Blank); both fallbacks below use that rule.
+//
+// Expressions joined by "??" fall back left to right: the first whose value
+// is not missing is used, e.g. input.refund_type ?? 'none'.
+//
+// An expression may be followed by transforms separated by "|", applied
+// left to right: trim, lower, upper, toInt, toFloat, and default(value),
+// which substitutes a quoted string or number when the value is missing.
+// For example: input.country | trim | upper.
 func (r *ExpressionResolver) Resolve(expr string) (any, error) {
+	if !strings.ContainsAny(expr, "|?") {
+		return r.resolveSource(expr)
+	}
+	source, steps, err := transform.Split(expr)
+	if err != nil {
+		return nil, err
+	}
+	val, err := r.resolveFallbacks(source)
+	if err != nil && !errors.Is(err, errValueMissing) {
+		return nil, err
+	}
+	missing := err
+	for _, step := range steps {
+		if val, err = step.Apply(val); err != nil {
+			return nil, err
+		}
+	}
+	if val == nil && missing != nil {
+		return nil, missing
+	}
+	return val, nil
+}
+
+// resolveFallbacks evaluates expressions joined by "??", returning the
+// first value that is not missing. If every value is missing, the last
+// expression's result is returned.
+func (r *ExpressionResolver) resolveFallbacks(expr string) (any, error) {
+	var val any
+	var err error
+	for _, alt := range transform.SplitUnquoted(expr, "??") {
+		val, err = r.resolveSource(alt)
+		if errors.Is(err, errValueMissing) {
+			continue
+		}
+		if err != nil || !transform.Blank(val) {
+			return val, err
+		}
+	}
+	return val, err
+}
+
+// resolveSource evaluates a single expression without fallbacks or
+// transforms.
+func (r *ExpressionResolver) resolveSource(expr string) (any, error) {
 	expr = strings.TrimSpace(expr)
 	if expr == "" {
 		return nil, fmt.Errorf("empty expression")
 	}
 
-	// Literal string: single-quoted.
-	if len(expr) >= 2 && expr[0] == '\'' && expr[len(expr)-1] == '\'' {
-		return expr[1 : len(expr)-1], nil
+	// Literal string: single- or double-quoted.
+	if s, ok := transform.Unquote(expr); ok {
+		return s, nil
 	}
 
 	// Numeric literal.
-	if isNumericLiteral(expr) {
-		return parseNumeric(expr)
+	if transform.IsNumericLiteral(expr) {
+		return transform.ParseNumeric(expr)
 	}
 
 	// Source expressions: prefix.path
@@ -79,7 +139,7 @@ func (r *ExpressionResolver) resolveInput(path string) (any, error) {
 	}
 	val := navigatePath(r.Input, path)
 	if val == nil {
-		return nil, fmt.Errorf("input field %q not found", path)
+		return nil, fmt.Errorf("input field %q %w", path, errValueMissing)
 	}
 	return val, nil
 }
@@ -91,7 +151,7 @@ func (r *ExpressionResolver) resolveRoute(param string) (any, error) {
 	}
 	val, ok := r.RouteParams[param]
 	if !ok {
-		return nil, fmt.Errorf("route param %q not found", param)
+		return nil, fmt.Errorf("route param %q %w", param, errValueMissing)
 	}
 	return val, nil
 }
@@ -122,7 +182,7 @@ func (r *ExpressionResolver) resolveWorkflow(path string) (any, error) {
 	}
 	val := navigatePath(r.WorkflowState, path)
 	if val == nil {
-		return nil, fmt.Errorf("workflow field %q not found", path)
+		return nil, fmt.Errorf("workflow field %q %w", path, errValueMissing)
 	}
 	return val, nil
 }
@@ -140,45 +200,3 @@ func navigatePath(data map[string]any, path string) any {
 	}
 	return current
 }
-
-// isNumericLiteral returns true if the string looks like a number.
-func isNumericLiteral(s string) bool {
-	if len(s) == 0 {
-		return false
-	}
-	start := 0
-	if s[0] == '-' || s[0] == '+' {
-		start = 1
-		if start >= len(s) {
-			return false
-		}
-	}
-	hasDot := false
-	for i := start; i < len(s); i++ {
-		if s[i] == '.' {
-			if hasDot {
-				return false
-			}
-			hasDot = true
-		} else if s[i] < '0' || s[i] > '9' {
-			return false
-		}
-	}
-	return true
-}
-
-// parseNumeric parses a numeric string literal.
-func parseNumeric(s string) (any, error) {
-	if strings.ContainsRune(s, '.') {
-		v, err := strconv.ParseFloat(s, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid numeric literal %q: %w", s, err)
-		}
-		return v, nil
-	}
-	v, err := strconv.ParseInt(s, 10, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid numeric literal %q: %w", s, err)
-	}
-	return v, nil
-}
@@ -335,30 +335,97 @@ func TestExpressionResolver_whitespace(t *testing.T) {
 
 // --- helper function tests ---
 
-func TestIsNumericLiteral(t *testing.T) {
+// --- transforms ---
+
+func TestExpressionResolver_transforms(t *testing.T) {
+	r := testResolver()
+	r.Input["reason"] = "  Changed my mind  "
+	r.Input["qty"] = " 12 "
+	r.Input["price"] = "9.5"
+	r.Input["blank"] = ""
+
 	tests := []struct {
-		input string
-		want  bool
+		expr string
+		want any
 	}{
-		{"42", true},
-		{"-5", true},
-		{"+5", true},
-		{"99.99", true},
-		{"-0.5", true},
-		{"0", true},
-		{"", false},
-		{"abc", false},
-		{"12abc", false},
-		{"1.2.3", false},
-		{"-", false},
-		{"+", false},
-		{"'42'", false},
-		{"input.field", false},
+		{expr: "input.reason | trim", want: "Changed my mind"},
+		{expr: "input.reason | trim | upper", want: "CHANGED MY MIND"},
+		{expr: "input.address.country|lower", want: "us"},
+		{expr: "input.qty | toInt", want: int64(12)},
+		{expr: "input.age | toInt", want: int64(30)},
+		{expr: "input.price | toFloat", want: 9.5},
+		{expr: "input.qty | trim | toFloat", want: float64(12)},
+		{expr: "input.missing | default('n/a')", want: "n/a"},
+		{expr: "input.blank | default('n/a') | upper", want: "N/A"},
+		{expr: "input.missing | trim | default(5)", want: int64(5)},
+		{expr: "input.name | default('n/a')", want: "Alice"},
+		{expr: "'a|b' | upper", want: "A|B"},
 	}
 	for _, tt := range tests {
-		got := isNumericLiteral(tt.input)
-		if got != tt.want {
-			t.Errorf("isNumericLiteral(%q) = %v, want %v", tt.input, got, tt.want)
+		t.Run(tt.expr, func(t *testing.T) {
+			val, err := r.Resolve(tt.expr)
+			if err != nil {
+				t.Fatalf("Resolve error: %v", err)
+			}
+			if val != tt.want {
+				t.Errorf("val = %#v, want %#v", val, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionResolver_fallback(t *testing.T) {
+	r := testResolver()
+	r.Input["blank"] = ""
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{expr: "input.name ?? 'nobody'", want: "Alice"},
+		{expr: "input.missing ?? 'none'", want: "none"},
+		{expr: "input.blank ?? 'none'", want: "none"},
+		{expr: `input.missing ?? "none"`, want: "none"},
+		{expr: "input.missing ?? route.id", want: "user-123"},
+		{expr: "input.missing ?? workflow.missing ?? 0", want: int64(0)},
+		{expr: "input.missing ?? ' a??b ' | trim", want: "a??b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			val, err := r.Resolve(tt.expr)
+			if err != nil {
+				t.Fatalf("Resolve error: %v", err)
+			}
+			if val != tt.want {
+				t.Errorf("val = %#v, want %#v", val, tt.want)
+			}
+		})
+	}
+
+	if _, err := r.Resolve("input.missing ?? route.missing"); err == nil {
+		t.Error("expected an error when every alternative is missing")
+	}
+	if _, err := r.Resolve("context.unknown ?? 'x'"); err == nil {
+		t.Error("expected an invalid expression to fail rather than fall back")
+	}
+}
+
+func TestExpressionResolver_transformErrors(t *testing.T) {
+	r := testResolver()
+	r.Input["price"] = "9.5"
+
+	for _, expr := range []string{
+		"input.name | reverse",
+		"input.name | trim(1)",
+		"input.name | default()",
+		"input.name |",
+		"input.price | toInt",
+		"input.age | upper",
+		"input.missing | trim",
+		"context.unknown | default('x')",
+	} {
+		if _, err := r.Resolve(expr); err == nil {
+			t.Errorf("Resolve(%q) succeeded, want an error", expr)
 		}
 	}
 }
@@ -0,0 +1,301 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// DefaultJobRetention is how long a MemoryJobStore keeps jobs when no
+// retention is configured.
+const DefaultJobRetention = time.Hour
+
+// defaultJobWorkers bounds concurrently running jobs when SetJobStore is
+// given no worker count.
+const defaultJobWorkers = 4
+
+// defaultJobQueueSize bounds jobs waiting for a worker when SetJobStore is
+// given no queue size.
+const defaultJobQueueSize = 100
+
+// JobStatus is the lifecycle state of an async command job.
+type JobStatus string
+
+// Job statuses. A job is queued until a worker picks it up, then running,
+// then succeeded or failed.
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is the state of one async command execution. Response is set once
+// the backend has answered; Error is set when the job failed.
+type Job struct {
+	ID        string    `json:"id"`
+	CommandID string    `json:"command_id"`
+	Status    JobStatus `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Response is the command response of a finished job.
+	Response *model.CommandResponse `json:"response,omitempty"`
+	// Error describes why a failed job failed.
+	Error *model.ErrorEnvelope `json:"error,omitempty"`
+
+	// The owner and idempotency key scope access and deduplication; they
+	// are not returned to clients.
+	TenantID       string `json:"-"`
+	SubjectID      string `json:"-"`
+	IdempotencyKey string `json:"-"`
+}
+
+// Done reports whether the job has finished.
+func (j Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// JobStore persists async command jobs. Implementations must be safe for
+// concurrent use.
+type JobStore interface {
+	// Create stores a new job unless its owner (tenant and subject) already
+	// created one for the same command with the same idempotency key. That
+	// job is then returned with created false. The check and the insert
+	// must be atomic, so concurrent requests sharing a key create one job.
+	Create(ctx context.Context, job Job) (existing Job, created bool, err error)
+	// Put replaces a job, e.g. to record its progress.
+	Put(ctx context.Context, job Job) error
+	// Get returns the job with the given ID.
+	Get(ctx context.Context, id string) (Job, bool, error)
+}
+
+// MemoryJobStore is a JobStore held in process memory. Finished jobs are
+// dropped retention after they last changed; queued and running jobs are
+// kept. Jobs do not survive a restart and are not visible to other
+// replicas.
+type MemoryJobStore struct {
+	retention time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	jobs  map[string]Job
+	byKey map[string]string
+}
+
+// NewMemoryJobStore returns an empty store keeping finished jobs for
+// retention. A
+// non-positive retention uses DefaultJobRetention.
+func NewMemoryJobStore(retention time.Duration) *MemoryJobStore {
+	if retention <= 0 {
+		retention = DefaultJobRetention
+	}
+	return &MemoryJobStore{
+		retention: retention,
+		now:       time.Now,
+		jobs:      make(map[string]Job),
+		byKey:     make(map[string]string),
+	}
+}
+
+// jobKey scopes an idempotency key to the job's owner and command.
+func jobKey(job Job) string {
+	return job.TenantID + "|" + job.SubjectID + "|" + job.CommandID + "|" + job.IdempotencyKey
+}
+
+// Create implements JobStore.
+func (s *MemoryJobStore) Create(_ context.Context, job Job) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+	if job.IdempotencyKey != "" {
+		if existing, ok := s.jobs[s.byKey[jobKey(job)]]; ok {
+			return existing, false, nil
+		}
+		s.byKey[jobKey(job)] = job.ID
+	}
+	s.jobs[job.ID] = job
+	return job, true, nil
+}
+
+// Put implements JobStore.
+func (s *MemoryJobStore) Put(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Get implements JobStore.
+func (s *MemoryJobStore) Get(_ context.Context, id string) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+	job, ok := s.jobs[id]
+	return job, ok, nil
+}
+
+// evict drops finished jobs past their retention. Must be called with mu
+// held.
+func (s *MemoryJobStore) evict() {
+	cutoff := s.now().Add(-s.retention)
+	for id, job := range s.jobs {
+		if job.Done() && job.UpdatedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			if job.IdempotencyKey != "" {
+				delete(s.byKey, jobKey(job))
+			}
+		}
+	}
+}
+
+// SetJobStore enables async execution: commands that set Async are queued
+// in store and run by at most workers concurrent workers. At most
+// queueSize jobs wait for a worker; further ones are rejected with
+// RATE_LIMITED. Non-positive values use defaults. Without a store, async
+// commands run synchronously. It must be called before the executor serves
+// requests.
+func (e *CommandExecutor) SetJobStore(store JobStore, workers, queueSize int) {
+	if workers <= 0 {
+		workers = defaultJobWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultJobQueueSize
+	}
+	e.jobs = store
+	e.jobSlots = make(chan struct{}, workers)
+	e.jobBacklog = make(chan struct{}, queueSize)
+}
+
+// Job returns the job with the given ID if the caller created it. Returns
+// an error with code NOT_FOUND otherwise, or if async execution is not
+// enabled.
+func (e *CommandExecutor) Job(ctx context.Context, rctx *model.RequestContext, jobID string) (Job, error) {
+	notFound := model.NewNotFoundError("job not found")
+	if e.jobs == nil || rctx == nil {
+		return Job{}, notFound
+	}
+	job, ok, err := e.jobs.Get(ctx, jobID)
+	if err != nil {
+		return Job{}, err
+	}
+	if !ok || job.TenantID != rctx.TenantID || job.SubjectID != rctx.SubjectID {
+		return Job{}, notFound
+	}
+	return job, nil
+}
+
+// enqueue queues a validated async command and returns the response that
+// acknowledges it. A request repeating the idempotency key of an earlier
+// job of the same caller and command gets that job back instead of
+// queueing another. Returns an error with code RATE_LIMITED if the queue is
+// full.
+func (e *CommandExecutor) enqueue(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	cmdDef model.CommandDefinition,
+	input model.CommandInput,
+	invInput model.InvocationInput,
+	resolver *ExpressionResolver,
+) (model.CommandResponse, error) {
+	// Reserve a place in the queue before creating the job, so a rejected
+	// request leaves nothing behind.
+	select {
+	case e.jobBacklog <- struct{}{}:
+	default:
+		return model.CommandResponse{}, model.NewRateLimitedError()
+	}
+
+	now := time.Now()
+	job := Job{
+		ID:             util.IDString(),
+		CommandID:      cmdDef.ID,
+		Status:         JobQueued,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		TenantID:       rctx.TenantID,
+		SubjectID:      rctx.SubjectID,
+		IdempotencyKey: input.IdempotencyKey,
+	}
+	existing, created, err := e.jobs.Create(ctx, job)
+	if err != nil || !created {
+		<-e.jobBacklog
+		if err != nil {
+			return model.CommandResponse{}, err
+		}
+		return acceptedResponse(existing), nil
+	}
+
+	// The job outlives the request: keep its values (logger, trace) but
+	// not its cancellation or deadline.
+	jobCtx := context.WithoutCancel(ctx)
+	go func() {
+		e.jobSlots <- struct{}{}
+		<-e.jobBacklog
+		defer func() { <-e.jobSlots }()
+		e.runJob(jobCtx, rctx, cmdDef, input, invInput, resolver, job)
+	}()
+	return acceptedResponse(job), nil
+}
+
+// runJob invokes the backend for a queued job and records the outcome.
+func (e *CommandExecutor) runJob(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	cmdDef model.CommandDefinition,
+	input model.CommandInput,
+	invInput model.InvocationInput,
+	resolver *ExpressionResolver,
+	job Job,
+) {
+	ctx, span := e.startSpan(ctx, "command.run_job", cmdDef.ID)
+	defer span.End()
+
+	job.Status, job.UpdatedAt = JobRunning, time.Now()
+	e.saveJob(ctx, job)
+
+	result, err := e.invoke(ctx, rctx, cmdDef, invInput)
+	if err == nil {
+		resp := e.mapOutput(ctx, result, cmdDef, resolver)
+		e.notify(ctx, cmdDef, rctx, input, result, resp)
+		job.Response = &resp
+		if !resp.Success {
+			err = commandError(result, resp)
+		}
+	}
+	job.Status = JobSucceeded
+	if err != nil {
+		job.Status = JobFailed
+		var env *model.ErrorEnvelope
+		if !errors.As(err, &env) {
+			env = model.NewInternalError()
+		}
+		job.Error = env
+	}
+	job.UpdatedAt = time.Now()
+	e.saveJob(ctx, job)
+}
+
+func (e *CommandExecutor) saveJob(ctx context.Context, job Job) {
+	if err := e.jobs.Put(ctx, job); err != nil {
+		util.Log(ctx).WithError(err).Error("command: job state not saved",
+			"job", job.ID,
+			"command", job.CommandID,
+			"status", job.Status,
+		)
+	}
+}
+
+// acceptedResponse acknowledges a queued job.
+func acceptedResponse(job Job) model.CommandResponse {
+	return model.CommandResponse{
+		Success: true,
+		Message: "Command accepted",
+		JobID:   job.ID,
+	}
+}
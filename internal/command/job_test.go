@@ -0,0 +1,242 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// asyncCommand marks orders.simple as async.
+var asyncCommand = withCommand("orders.simple", func(c *model.CommandDefinition) {
+	c.Async = true
+})
+
+// waitForJob polls until the job has finished.
+func waitForJob(t *testing.T, e *CommandExecutor, rctx *model.RequestContext, jobID string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := e.Job(context.Background(), rctx, jobID)
+		if err != nil {
+			t.Fatalf("Job error: %v", err)
+		}
+		if job.Done() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still %s", jobID, job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExecutor_asyncCommand(t *testing.T) {
+	release := make(chan struct{})
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		<-release
+		return model.InvocationResult{StatusCode: http.StatusOK, Body: map[string]any{"id": "ord-1"}}, nil
+	}, asyncCommand)
+	e.SetJobStore(NewMemoryJobStore(time.Minute), 1, 0)
+	rctx := testRctxForExecutor()
+
+	resp, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !resp.Success || resp.JobID == "" {
+		t.Fatalf("response = %+v, want an accepted job", resp)
+	}
+	if job, err := e.Job(context.Background(), rctx, resp.JobID); err != nil || job.Done() {
+		t.Errorf("Job() = %+v, %v, want an unfinished job", job, err)
+	}
+
+	close(release)
+	job := waitForJob(t, e, rctx, resp.JobID)
+	if job.Status != JobSucceeded || job.Response == nil || !job.Response.Success {
+		t.Errorf("job = %+v, want succeeded with a response", job)
+	}
+
+	other := testRctxForExecutor()
+	other.SubjectID = "user-bob"
+	_, err = e.Job(context.Background(), other, resp.JobID)
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrNotFound {
+		t.Errorf("Job() by another subject error = %v, want NOT_FOUND", err)
+	}
+}
+
+func TestExecutor_asyncCommand_failed(t *testing.T) {
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{}, model.NewBackendUnavailableError()
+	}, asyncCommand)
+	e.SetJobStore(NewMemoryJobStore(time.Minute), 1, 0)
+	rctx := testRctxForExecutor()
+
+	resp, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	job := waitForJob(t, e, rctx, resp.JobID)
+	if job.Status != JobFailed || job.Error == nil || job.Error.Code != model.ErrBackendUnavailable {
+		t.Errorf("job = %+v, want failed with BACKEND_UNAVAILABLE", job)
+	}
+}
+
+func TestExecutor_asyncCommand_idempotencyKey(t *testing.T) {
+	var calls int
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		calls++
+		return model.InvocationResult{StatusCode: http.StatusOK, Body: map[string]any{}}, nil
+	}, asyncCommand)
+	e.SetJobStore(NewMemoryJobStore(time.Minute), 1, 0)
+	rctx := testRctxForExecutor()
+	input := model.CommandInput{Input: map[string]any{}, IdempotencyKey: "key-1"}
+
+	first, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", input)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	waitForJob(t, e, rctx, first.JobID)
+	second, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", input)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if second.JobID != first.JobID {
+		t.Errorf("repeated key JobID = %q, want %q", second.JobID, first.JobID)
+	}
+	if calls != 1 {
+		t.Errorf("backend calls = %d, want 1", calls)
+	}
+}
+
+func TestExecutor_asyncCommand_noStoreRunsSynchronously(t *testing.T) {
+	e := newTestExecutor(nil, asyncCommand)
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !resp.Success || resp.JobID != "" {
+		t.Errorf("response = %+v, want a synchronous result", resp)
+	}
+}
+
+func TestExecutor_asyncCommand_queueFull(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		<-release
+		return model.InvocationResult{StatusCode: http.StatusOK, Body: map[string]any{}}, nil
+	}, asyncCommand)
+	e.SetJobStore(NewMemoryJobStore(time.Minute), 1, 1)
+	rctx := testRctxForExecutor()
+	execute := func() error {
+		_, err := e.Execute(context.Background(), rctx, model.CapabilitySet{}, "orders.simple", model.CommandInput{Input: map[string]any{}})
+		return err
+	}
+
+	// The first job occupies the worker, the second waits in the queue.
+	if err := execute(); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(e.jobBacklog) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := execute(); err != nil {
+		t.Fatalf("queued Execute error: %v", err)
+	}
+	err := execute()
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrRateLimited {
+		t.Errorf("Execute with a full queue error = %v, want RATE_LIMITED", err)
+	}
+}
+
+func TestMemoryJobStore_create(t *testing.T) {
+	s := NewMemoryJobStore(time.Minute)
+	ctx := context.Background()
+	job := Job{CommandID: "c", TenantID: "t", SubjectID: "u", IdempotencyKey: "k", CreatedAt: time.Now()}
+
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			j := job
+			j.ID = fmt.Sprintf("j%d", i)
+			if _, ok, _ := s.Create(ctx, j); ok {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if created.Load() != 1 {
+		t.Errorf("created %d jobs for one idempotency key, want 1", created.Load())
+	}
+
+	other := job
+	other.ID, other.SubjectID = "other", "u2"
+	if _, ok, _ := s.Create(ctx, other); !ok {
+		t.Error("idempotency key matched another subject's job")
+	}
+}
+
+func TestMemoryJobStore_retention(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryJobStore(time.Minute)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	job := Job{ID: "j1", CommandID: "c", TenantID: "t", SubjectID: "u", IdempotencyKey: "k", Status: JobSucceeded, CreatedAt: now, UpdatedAt: now}
+	_, _, _ = s.Create(ctx, job)
+	if existing, ok, _ := s.Create(ctx, Job{ID: "j2", CommandID: "c", TenantID: "t", SubjectID: "u", IdempotencyKey: "k", CreatedAt: now}); ok || existing.ID != "j1" {
+		t.Fatalf("Create() = %q, %v; want the existing job j1", existing.ID, ok)
+	}
+	if _, ok, _ := s.Create(ctx, Job{ID: "j3", CommandID: "c", TenantID: "other", SubjectID: "u", IdempotencyKey: "k", CreatedAt: now}); !ok {
+		t.Error("idempotency key matched another tenant's job")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := s.Get(ctx, "j1"); ok {
+		t.Error("job kept past its retention")
+	}
+	if _, ok, _ := s.Create(ctx, Job{ID: "j4", CommandID: "c", TenantID: "t", SubjectID: "u", IdempotencyKey: "k", CreatedAt: now}); !ok {
+		t.Error("idempotency key kept past the job's retention")
+	}
+}
+
+func TestMemoryJobStore_retentionKeepsUnfinishedJobs(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryJobStore(time.Minute)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, _, _ = s.Create(ctx, Job{ID: "queued", Status: JobQueued, CreatedAt: now, UpdatedAt: now})
+	_, _, _ = s.Create(ctx, Job{ID: "running", Status: JobQueued, CreatedAt: now, UpdatedAt: now})
+	now = now.Add(2 * time.Minute)
+	_ = s.Put(ctx, Job{ID: "running", Status: JobRunning, CreatedAt: now.Add(-2 * time.Minute), UpdatedAt: now})
+
+	now = now.Add(2 * time.Minute)
+	for _, id := range []string{"queued", "running"} {
+		if _, ok, _ := s.Get(ctx, id); !ok {
+			t.Errorf("%s job dropped before it finished", id)
+		}
+	}
+
+	// A job finishing late is kept for the retention from when it finished.
+	_ = s.Put(ctx, Job{ID: "running", Status: JobSucceeded, CreatedAt: now.Add(-4 * time.Minute), UpdatedAt: now})
+	now = now.Add(30 * time.Second)
+	if _, ok, _ := s.Get(ctx, "running"); !ok {
+		t.Error("finished job dropped within its retention")
+	}
+	now = now.Add(time.Minute)
+	if _, ok, _ := s.Get(ctx, "running"); ok {
+		t.Error("finished job kept past its retention")
+	}
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// checkInputLimits enforces a command's input limits on the raw user input.
+// Oversized arrays are reported by field path, in path order, and not
+// descended into; an excess of fields is reported once for the whole input.
+func checkInputLimits(limits *model.InputLimits, input map[string]any) []model.FieldError {
+	if limits == nil || (limits.MaxArrayLength <= 0 && limits.MaxFields <= 0) {
+		return nil
+	}
+
+	c := &limitChecker{limits: limits}
+	c.walk("", input)
+	if limits.MaxFields > 0 && c.fields > limits.MaxFields {
+		c.errs = append(c.errs, model.FieldError{
+			Field:   "",
+			Code:    "MAX_FIELDS",
+			Message: fmt.Sprintf("input has %d fields, maximum is %d", c.fields, limits.MaxFields),
+		})
+	}
+	return c.errs
+}
+
+type limitChecker struct {
+	limits *model.InputLimits
+	fields int
+	errs   []model.FieldError
+}
+
+func (c *limitChecker) walk(path string, v any) {
+	switch val := v.(type) {
+	case map[string]any:
+		c.fields += len(val)
+		for _, k := range slices.Sorted(maps.Keys(val)) {
+			c.walk(joinPath(path, k), val[k])
+		}
+	case []any:
+		if c.limits.MaxArrayLength > 0 && len(val) > c.limits.MaxArrayLength {
+			c.errs = append(c.errs, model.FieldError{
+				Field:   path,
+				Code:    "MAX_ITEMS",
+				Message: fmt.Sprintf("array has %d items, maximum is %d", len(val), c.limits.MaxArrayLength),
+			})
+			return
+		}
+		for i, child := range val {
+			c.walk(fmt.Sprintf("%s[%d]", path, i), child)
+		}
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pitabwire/util"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// RouteResolver resolves a resource reference to the route of its detail
+// page. It is implemented by metadata.ResourceProvider.
+type RouteResolver interface {
+	ResolveRoute(rctx *model.RequestContext, resourceType, id string) (model.RouteResolution, error)
+}
+
+// SetRouteResolver installs the resolver used to locate resources created by
+// commands whose output declares Created. Without one, created resources are
+// returned without a location.
+func (e *CommandExecutor) SetRouteResolver(r RouteResolver) {
+	e.routes = r
+}
+
+// createdLocation returns the detail route of the resource a creating
+// command created, taking its ID from the mapped result. It returns "" if the
+// command does not create a resource or the route cannot be resolved; the
+// command still succeeds.
+func (e *CommandExecutor) createdLocation(ctx context.Context, rctx *model.RequestContext, cmdDef model.CommandDefinition, result map[string]any) string {
+	created := cmdDef.Output.Created
+	if created == nil || e.routes == nil {
+		return ""
+	}
+	idField := created.IDField
+	if idField == "" {
+		idField = "id"
+	}
+	id, ok := result[idField]
+	if !ok || id == nil || fmt.Sprint(id) == "" {
+		util.Log(ctx).Warn("command: created resource has no id",
+			"command", cmdDef.ID,
+			"id_field", idField,
+		)
+		return ""
+	}
+	resolution, err := e.routes.ResolveRoute(rctx, created.ResourceType, fmt.Sprint(id))
+	if err != nil {
+		util.Log(ctx).Warn("command: cannot resolve created resource route",
+			"command", cmdDef.ID,
+			"resource_type", created.ResourceType,
+			"error", err,
+		)
+		return ""
+	}
+	return resolution.Route
+}
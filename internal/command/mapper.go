@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pitabwire/thesa/internal/transform"
 	"github.com/pitabwire/thesa/model"
 )
 
@@ -154,10 +155,11 @@ func ReverseFieldMap(projection map[string]string) map[string]string {
 
 	reverse := make(map[string]string, len(projection))
 	for uiField, expr := range projection {
-		// Only reverse mappings that reference input fields.
-		if strings.HasPrefix(expr, "input.") {
+		// Only reverse mappings whose primary source is an input field;
+		// fallbacks and transforms do not change which field it is.
+		source := transform.SplitUnquoted(transform.SplitUnquoted(expr, "|")[0], "??")[0]
+		if origField, ok := strings.CutPrefix(strings.TrimSpace(source), "input."); ok {
 			backendField := uiField // The output key IS the backend field name.
-			origField := expr[len("input."):]
 			reverse[backendField] = origField
 		}
 	}
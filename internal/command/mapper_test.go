@@ -467,7 +467,7 @@ func TestInputMapper_fullPipeline(t *testing.T) {
 func TestReverseFieldMap(t *testing.T) {
 	projection := map[string]string{
 		"cancellationReason": "input.reason",
-		"refundType":         "input.refund_type",
+		"refundType":         "input.refund_type ?? 'none' | upper",
 		"updatedBy":          "context.subject_id", // Not an input field.
 		"source":             "'bff'",              // Literal.
 	}
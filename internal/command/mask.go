@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// MaskedValueSource loads the stored values of the masked fields on the
+// forms submitting to a command. It is implemented by
+// metadata.FormProvider.
+type MaskedValueSource interface {
+	MaskedOriginals(ctx context.Context, rctx *model.RequestContext, commandID string, routeParams map[string]string) (map[string]any, error)
+}
+
+// SetMaskedValueSource installs the source used to tell an unchanged masked
+// value from an edited one. Without one, any submitted value shaped like a
+// mask is taken as unchanged.
+func (e *CommandExecutor) SetMaskedValueSource(src MaskedValueSource) {
+	e.masked = src
+}
+
+// dropMaskedValues returns the input without the masked fields submitted
+// back unchanged, so the backend keeps the stored value. A value is
+// unchanged only if it equals the masked rendering of the stored value. A
+// value shaped like a mask that differs from it, e.g. one whose visible
+// characters were edited, fails with VALIDATION_ERROR: the hidden
+// characters are unknown to the client, so the full value must be entered.
+// The caller's input is not modified.
+func (e *CommandExecutor) dropMaskedValues(ctx context.Context, rctx *model.RequestContext, commandID string, input model.CommandInput) (map[string]any, error) {
+	var masked []model.FieldDefinition
+	for _, f := range e.registry.MaskedFields(commandID) {
+		if s, ok := input.Input[f.Field].(string); ok && f.Mask.IsMasked(s) {
+			masked = append(masked, f)
+		}
+	}
+	if len(masked) == 0 {
+		return input.Input, nil
+	}
+
+	var originals map[string]any
+	if e.masked != nil {
+		var err error
+		if originals, err = e.masked.MaskedOriginals(ctx, rctx, commandID, input.RouteParams); err != nil {
+			return nil, err
+		}
+	}
+
+	result := maps.Clone(input.Input)
+	var errs []model.FieldError
+	for _, f := range masked {
+		if e.masked != nil {
+			original, ok := originals[f.Field]
+			if !ok || original == nil || f.Mask.Apply(fmt.Sprint(original)) != result[f.Field] {
+				errs = append(errs, model.FieldError{
+					Field:   f.Field,
+					Code:    "MASKED_VALUE_EDITED",
+					Message: "Enter the full value to change a masked field",
+				})
+				continue
+			}
+		}
+		delete(result, f.Field)
+	}
+	if len(errs) > 0 {
+		return nil, model.NewValidationError(errs)
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// OperationTypeNoop is the operation type of commands that record intent
+// without calling a backend. They succeed immediately once capabilities and
+// input mapping pass, and are reported to observers like any other command.
+const OperationTypeNoop = "noop"
+
+// CommandEvent describes a completed command execution.
+type CommandEvent struct {
+	CommandID string
+	Operation model.OperationBinding
+	Context   *model.RequestContext
+	Input     model.CommandInput
+	Response  model.CommandResponse
+	// Changes holds the audited fields whose values differ between the
+	// before and after snapshots of a successful command with an output
+	// audit. Unchanged fields are omitted.
+	Changes map[string]FieldChange
+}
+
+// FieldChange is the before and after value of a changed field. A nil value
+// means the field was absent from that snapshot.
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// CommandObserver is notified after each command execution, e.g. for audit
+// logging. Observers run synchronously and must not block.
+type CommandObserver interface {
+	OnCommand(ctx context.Context, event CommandEvent)
+}
+
+// CommandObserverFunc adapts a function to the CommandObserver interface.
+type CommandObserverFunc func(ctx context.Context, event CommandEvent)
+
+// OnCommand calls f(ctx, event).
+func (f CommandObserverFunc) OnCommand(ctx context.Context, event CommandEvent) {
+	f(ctx, event)
+}
+
+// AddObserver registers an observer notified after every command that
+// reaches its backend (or completes without one, for noop commands).
+// It must be called before the executor serves requests.
+func (e *CommandExecutor) AddObserver(o CommandObserver) {
+	e.observers = append(e.observers, o)
+}
+
+func (e *CommandExecutor) notify(ctx context.Context, cmdDef model.CommandDefinition, rctx *model.RequestContext, input model.CommandInput, result model.InvocationResult, resp model.CommandResponse) {
+	if len(e.observers) == 0 {
+		return
+	}
+	event := CommandEvent{
+		CommandID: cmdDef.ID,
+		Operation: cmdDef.Operation,
+		Context:   rctx,
+		Input:     input,
+		Response:  resp,
+	}
+	if resp.Success {
+		event.Changes = auditChanges(result.Body, cmdDef.Output.Audit)
+	}
+	for _, o := range e.observers {
+		o.OnCommand(ctx, event)
+	}
+}
+
+// auditChanges compares the audited fields of the before and after snapshots
+// in a backend response body.
+func auditChanges(body any, audit *model.AuditDiff) map[string]FieldChange {
+	m, ok := body.(map[string]any)
+	if audit == nil || !ok {
+		return nil
+	}
+	before, _ := navigatePath(m, audit.Before).(map[string]any)
+	after, _ := navigatePath(m, audit.After).(map[string]any)
+	if before == nil && after == nil {
+		return nil
+	}
+	changes := make(map[string]FieldChange)
+	for field, path := range audit.Fields {
+		b, a := navigatePath(before, path), navigatePath(after, path)
+		if !reflect.DeepEqual(b, a) {
+			changes[field] = FieldChange{Before: b, After: a}
+		}
+	}
+	return changes
+}
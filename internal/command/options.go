@@ -0,0 +1,101 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// OptionSource resolves the full option list of a lookup for the given
+// parameter values. It is implemented by search.LookupProvider.
+type OptionSource interface {
+	OptionsWithParams(ctx context.Context, rctx *model.RequestContext, lookupID string, params map[string]string) ([]model.OptionDescriptor, error)
+}
+
+// SetOptionSource installs the source used to validate values of
+// lookup-backed select fields. Without one, only fields with static options
+// are checked.
+func (e *CommandExecutor) SetOptionSource(src OptionSource) {
+	e.options = src
+}
+
+// checkOptions verifies that each submitted value of an option-backed field
+// on the forms submitting to the command is one of the field's options.
+// Absent and empty values are left to required-field validation. The
+// parameters of a parameterized lookup are taken from the input. A lookup
+// that cannot be resolved returns its error, since the value cannot be
+// verified.
+func (e *CommandExecutor) checkOptions(ctx context.Context, rctx *model.RequestContext, commandID string, input map[string]any) ([]model.FieldError, error) {
+	var errs []model.FieldError
+	for _, field := range e.registry.OptionFields(commandID) {
+		value := navigatePath(input, field.Field)
+		if value == nil || value == "" {
+			continue
+		}
+
+		allowed, ok, err := e.allowedOptions(ctx, rctx, field.Lookup, input)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		values, isList := value.([]any)
+		if !isList {
+			values = []any{value}
+		}
+		for _, v := range values {
+			if !allowed[fmt.Sprint(v)] {
+				errs = append(errs, model.FieldError{
+					Field:   field.Field,
+					Code:    "INVALID_OPTION",
+					Message: fmt.Sprintf("%v is not an allowed value", v),
+				})
+				break
+			}
+		}
+	}
+	return errs, nil
+}
+
+// allowedOptions returns the set of allowed values for a field's options,
+// and false if there is no source to determine them.
+func (e *CommandExecutor) allowedOptions(ctx context.Context, rctx *model.RequestContext, ref *model.LookupRefDefinition, input map[string]any) (map[string]bool, bool, error) {
+	if len(ref.Static) > 0 {
+		allowed := make(map[string]bool, len(ref.Static))
+		for _, opt := range ref.Static {
+			allowed[opt.Value] = true
+		}
+		return allowed, true, nil
+	}
+	if e.options == nil {
+		return nil, false, nil
+	}
+
+	options, err := e.options.OptionsWithParams(ctx, rctx, ref.LookupID, lookupParams(ref, input))
+	if err != nil {
+		return nil, false, err
+	}
+	allowed := make(map[string]bool, len(options))
+	for _, opt := range options {
+		allowed[opt.Value] = true
+	}
+	return allowed, true, nil
+}
+
+// lookupParams maps the submitted input values to the lookup parameters
+// they supply.
+func lookupParams(ref *model.LookupRefDefinition, input map[string]any) map[string]string {
+	if len(ref.Params) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(ref.Params))
+	for param, field := range ref.Params {
+		if v := navigatePath(input, field); v != nil {
+			params[param] = fmt.Sprint(v)
+		}
+	}
+	return params
+}
@@ -0,0 +1,161 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// fakeOptionSource serves fixed lookup options, keyed by lookup ID and, for
+// parameterized lookups, "?region=<value>". It counts calls.
+type fakeOptionSource struct {
+	options map[string][]model.OptionDescriptor
+	err     error
+	calls   int
+}
+
+func (f *fakeOptionSource) OptionsWithParams(_ context.Context, _ *model.RequestContext, lookupID string, params map[string]string) ([]model.OptionDescriptor, error) {
+	f.calls++
+	if region, ok := params["region"]; ok {
+		lookupID += "?region=" + region
+	}
+	return f.options[lookupID], f.err
+}
+
+// withOptionsForm adds a form submitting to orders.simple with a static
+// "priority" select and a lookup-backed "tags" multi-select.
+func withOptionsForm(d *model.DomainDefinition) {
+	d.Forms = []model.FormDefinition{{
+		ID:            "orders.simple-form",
+		SubmitCommand: "orders.simple",
+		Sections: []model.SectionDefinition{{
+			ID: "main",
+			Fields: []model.FieldDefinition{
+				{Field: "notes", Type: "text"},
+				{Field: "priority", Type: "select", Lookup: &model.LookupRefDefinition{
+					Static: []model.StaticOption{{Label: "Low", Value: "low"}, {Label: "High", Value: "high"}},
+				}},
+				{Field: "tags", Type: "multiselect", Lookup: &model.LookupRefDefinition{LookupID: "orders.tags"}},
+			},
+		}},
+	}}
+}
+
+func tagOptions() *fakeOptionSource {
+	return &fakeOptionSource{options: map[string][]model.OptionDescriptor{
+		"orders.tags": {{Label: "Rush", Value: "rush"}, {Label: "Gift", Value: "gift"}},
+	}}
+}
+
+func TestExecutor_Validate_selectOptions(t *testing.T) {
+	e := newTestExecutor(nil, withOptionsForm)
+	e.SetOptionSource(tagOptions())
+
+	tests := []struct {
+		name      string
+		input     map[string]any
+		wantField string
+	}{
+		{name: "valid static and lookup values", input: map[string]any{"priority": "high", "tags": []any{"rush", "gift"}}},
+		{name: "absent values", input: map[string]any{"notes": "n/a"}},
+		{name: "invalid static value", input: map[string]any{"priority": "urgent"}, wantField: "priority"},
+		{name: "invalid lookup value", input: map[string]any{"tags": []any{"rush", "vip"}}, wantField: "tags"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", model.CommandInput{Input: tt.input})
+			if tt.wantField == "" {
+				if len(errs) != 0 {
+					t.Errorf("Validate() = %+v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField || errs[0].Code != "INVALID_OPTION" {
+				t.Errorf("Validate() = %+v, want INVALID_OPTION on %s", errs, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestExecutor_selectOptions_rejectedOnExecute(t *testing.T) {
+	e := newTestExecutor(nil, withOptionsForm)
+	e.SetOptionSource(tagOptions())
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple",
+		model.CommandInput{Input: map[string]any{"priority": "urgent"}})
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrValidationError || envErr.Details[0].Code != "INVALID_OPTION" {
+		t.Fatalf("error = %v, want VALIDATION_ERROR with INVALID_OPTION", err)
+	}
+
+	resp, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple",
+		model.CommandInput{Input: map[string]any{"priority": "low", "tags": []any{"gift"}}})
+	if err != nil || !resp.Success {
+		t.Fatalf("Execute() = %+v, %v; want success for valid options", resp, err)
+	}
+}
+
+func TestExecutor_selectOptions_lookupUnavailable(t *testing.T) {
+	src := &fakeOptionSource{err: errors.New("lookup backend down")}
+	e := newTestExecutor(nil, withOptionsForm)
+	e.SetOptionSource(src)
+
+	errs := e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple",
+		model.CommandInput{Input: map[string]any{"tags": []any{"anything"}}})
+	if len(errs) != 1 || errs[0].Code != model.ErrBackendUnavailable {
+		t.Errorf("Validate() = %+v, want BACKEND_UNAVAILABLE when options are unavailable", errs)
+	}
+	if src.calls != 1 {
+		t.Errorf("option source calls = %d, want 1", src.calls)
+	}
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple",
+		model.CommandInput{Input: map[string]any{"tags": []any{"anything"}}})
+	if err == nil || err.Error() != "lookup backend down" {
+		t.Errorf("Execute() error = %v, want the lookup error", err)
+	}
+
+	// Static options are still enforced without an option source.
+	e = newTestExecutor(nil, withOptionsForm)
+	errs = e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple",
+		model.CommandInput{Input: map[string]any{"priority": "urgent", "tags": []any{"anything"}}})
+	if len(errs) != 1 || errs[0].Field != "priority" {
+		t.Errorf("Validate() = %+v, want only the static field checked", errs)
+	}
+}
+
+func TestExecutor_selectOptions_parameterizedLookup(t *testing.T) {
+	src := &fakeOptionSource{options: map[string][]model.OptionDescriptor{
+		"orders.warehouses?region=eu": {{Label: "Dublin", Value: "dub"}},
+		"orders.warehouses?region=us": {{Label: "Reno", Value: "rno"}},
+	}}
+	e := newTestExecutor(nil, func(d *model.DomainDefinition) {
+		d.Forms = []model.FormDefinition{{
+			ID:            "orders.simple-form",
+			SubmitCommand: "orders.simple",
+			Sections: []model.SectionDefinition{{
+				ID: "main",
+				Fields: []model.FieldDefinition{
+					{Field: "region", Type: "text"},
+					{Field: "warehouse", Type: "select", Lookup: &model.LookupRefDefinition{
+						LookupID: "orders.warehouses",
+						Params:   map[string]string{"region": "region"},
+					}},
+				},
+			}},
+		}}
+	})
+	e.SetOptionSource(src)
+
+	validate := func(input map[string]any) []model.FieldError {
+		return e.Validate(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.simple", model.CommandInput{Input: input})
+	}
+	if errs := validate(map[string]any{"region": "eu", "warehouse": "dub"}); len(errs) != 0 {
+		t.Errorf("Validate(eu, dub) = %+v, want no errors", errs)
+	}
+	if errs := validate(map[string]any{"region": "us", "warehouse": "dub"}); len(errs) != 1 || errs[0].Code != "INVALID_OPTION" {
+		t.Errorf("Validate(us, dub) = %+v, want INVALID_OPTION for another region's option", errs)
+	}
+}
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+const tracerName = "github.com/pitabwire/thesa/internal/command"
+
+// SetTracerProvider installs the provider command spans are created with.
+// By default the global tracer provider is used.
+func (e *CommandExecutor) SetTracerProvider(tp trace.TracerProvider) {
+	e.tracer = tp.Tracer(tracerName)
+}
+
+// startSpan starts a span for a command or one of its pipeline steps,
+// attributed with the command ID.
+func (e *CommandExecutor) startSpan(ctx context.Context, name, commandID string) (context.Context, trace.Span) {
+	return e.tracer.Start(ctx, name, trace.WithAttributes(attribute.String("command.id", commandID)))
+}
+
+// endSpan ends span, marking it failed if err is set.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// invoke calls the command's backend operation in a command.invoke span.
+func (e *CommandExecutor) invoke(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	cmdDef model.CommandDefinition,
+	input model.InvocationInput,
+) (model.InvocationResult, error) {
+	ctx, span := e.startSpan(ctx, "command.invoke", cmdDef.ID)
+	span.SetAttributes(
+		attribute.String("command.service_id", cmdDef.Operation.ServiceID),
+		attribute.String("command.operation_id", cmdDef.Operation.OperationID),
+	)
+	result, err := e.invokers.Invoke(ctx, rctx, cmdDef.Operation, input)
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+	}
+	endSpan(span, err)
+	return result, err
+}
+
+// mapOutput builds the command response in a command.map_output span.
+func (e *CommandExecutor) mapOutput(
+	ctx context.Context,
+	result model.InvocationResult,
+	cmdDef model.CommandDefinition,
+	resolver *ExpressionResolver,
+) model.CommandResponse {
+	ctx, span := e.startSpan(ctx, "command.map_output", cmdDef.ID)
+	resp := e.handleResponse(ctx, result, cmdDef, resolver)
+	span.SetAttributes(attribute.Bool("command.success", resp.Success))
+	span.End()
+	return resp
+}
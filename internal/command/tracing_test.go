@@ -0,0 +1,94 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+func newTracedExecutor(t *testing.T) (*CommandExecutor, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	e := newTestExecutor(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{StatusCode: 201, Body: map[string]any{"id": "ord-1"}}, nil
+	})
+	e.SetTracerProvider(tp)
+	return e, exporter
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestExecutor_spans(t *testing.T) {
+	e, exporter := newTracedExecutor(t)
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.create", model.CommandInput{Input: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	root, ok := byName["command.execute"]
+	if !ok {
+		t.Fatalf("spans = %v, want command.execute", spans)
+	}
+	if got := spanAttr(root, "command.operation_id").AsString(); got != "createOrder" {
+		t.Errorf("command.execute operation_id = %q, want createOrder", got)
+	}
+
+	for _, name := range []string{"command.validate", "command.map_input", "command.validate_schema", "command.invoke", "command.map_output"} {
+		s, ok := byName[name]
+		if !ok {
+			t.Errorf("missing span %s", name)
+			continue
+		}
+		if s.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("%s is not a child of command.execute", name)
+		}
+		if got := spanAttr(s, "command.id").AsString(); got != "orders.create" {
+			t.Errorf("%s command.id = %q, want orders.create", name, got)
+		}
+	}
+	if got := spanAttr(byName["command.invoke"], "http.response.status_code").AsInt64(); got != 201 {
+		t.Errorf("command.invoke status = %d, want 201", got)
+	}
+	if !spanAttr(byName["command.map_output"], "command.success").AsBool() {
+		t.Error("command.map_output command.success = false, want true")
+	}
+}
+
+func TestExecutor_spans_failure(t *testing.T) {
+	e, exporter := newTracedExecutor(t)
+
+	_, err := e.Execute(context.Background(), testRctxForExecutor(), model.CapabilitySet{}, "orders.cancel", model.CommandInput{})
+	if err == nil {
+		t.Fatal("expected forbidden error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "command.execute" {
+		t.Fatalf("spans = %v, want only command.execute", spans)
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", spans[0].Status.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"errors"
+	"io"
+)
+
+// DefaultMaxUploadSize is the largest streamed upload body, in bytes, when
+// no limit is configured.
+const DefaultMaxUploadSize = 32 << 20
+
+// errUploadTooLarge is returned by an uploadBody read past its limit.
+var errUploadTooLarge = errors.New("command: upload body too large")
+
+// SetMaxUploadSize sets the largest body, in bytes, that ExecuteUpload
+// forwards; larger uploads fail with PAYLOAD_TOO_LARGE. A non-positive size
+// uses DefaultMaxUploadSize.
+func (e *CommandExecutor) SetMaxUploadSize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxUploadSize
+	}
+	e.maxUploadSize = size
+}
+
+// AcceptsUpload reports whether the command is flagged as an upload, so its
+// request body is streamed with ExecuteUpload rather than decoded as JSON.
+func (e *CommandExecutor) AcceptsUpload(commandID string) bool {
+	cmdDef, ok := e.registry.GetCommand(commandID)
+	return ok && cmdDef.Upload
+}
+
+// uploadBody limits a streamed upload to a number of bytes. A read past the
+// limit fails and records it, so the caller can tell an oversized upload
+// from a backend failure however the invoker reports the aborted request.
+type uploadBody struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func newUploadBody(r io.Reader, limit int64) *uploadBody {
+	return &uploadBody{r: r, remaining: limit}
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errUploadTooLarge
+	}
+	if b.remaining <= 0 {
+		// At the limit: the upload fits only if the body ends here.
+		var probe [1]byte
+		n, err := b.r.Read(probe[:])
+		if n > 0 {
+			b.exceeded = true
+			return 0, errUploadTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -6,11 +6,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	frameconfig "github.com/pitabwire/frame/config"
 	"gopkg.in/yaml.v3"
+
+	"github.com/pitabwire/thesa/model"
 )
 
 // Config is the root application configuration.
@@ -24,17 +29,55 @@ type Config struct {
 	Capability    CapabilityConfig         `yaml:"capability"`
 	Search        SearchConfig             `yaml:"search"`
 	Lookup        LookupCacheConfig        `yaml:"lookup"`
+	Commands      CommandsConfig           `yaml:"commands"`
+	Locale        LocaleConfig             `yaml:"locale"`
+	Entitlements  EntitlementsConfig       `yaml:"entitlements"`
 	Observability ObservabilityConfig      `yaml:"observability"`
 }
 
 // ServerConfig describes HTTP server settings.
 type ServerConfig struct {
-	Port            int           `yaml:"port"`
-	ReadTimeout     time.Duration `yaml:"read_timeout"`
-	WriteTimeout    time.Duration `yaml:"write_timeout"`
-	HandlerTimeout  time.Duration `yaml:"handler_timeout"`
-	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
-	CORS            CORSConfig    `yaml:"cors"`
+	Port            int               `yaml:"port"`
+	ReadTimeout     time.Duration     `yaml:"read_timeout"`
+	WriteTimeout    time.Duration     `yaml:"write_timeout"`
+	HandlerTimeout  time.Duration     `yaml:"handler_timeout"`
+	ShutdownTimeout time.Duration     `yaml:"shutdown_timeout"`
+	CORS            CORSConfig        `yaml:"cors"`
+	Compression     CompressionConfig `yaml:"compression"`
+	// ETags enables entity tags and conditional GETs on metadata routes.
+	ETags bool `yaml:"etags"`
+	// PanicDetails includes the panic message and stack in the 500 response
+	// of a panicking handler. Intended for development; never enable it in
+	// production.
+	PanicDetails bool `yaml:"panic_details"`
+	// ResponseHeaders maps a route group (see RouteGroups) to headers set
+	// on every response in that group, error responses included. A header
+	// replaces the global default of the same name (e.g. Cache-Control:
+	// no-store); Vary values are added to the existing ones instead.
+	ResponseHeaders map[string]map[string]string `yaml:"response_headers"`
+}
+
+// Route groups that response headers can be configured for.
+const (
+	RouteGroupMetadata = "metadata" // navigation, page, form, schema and capability descriptors
+	RouteGroupData     = "data"     // page and form data, resources, lookups and search
+	RouteGroupCommands = "commands" // commands and actions
+	RouteGroupFiles    = "files"    // uploads and downloads
+	RouteGroupAdmin    = "admin"    // operator endpoints
+)
+
+// RouteGroups lists the route groups known to the router.
+var RouteGroups = []string{
+	RouteGroupMetadata, RouteGroupData, RouteGroupCommands, RouteGroupFiles, RouteGroupAdmin,
+}
+
+// CompressionConfig describes response compression settings. Brotli is
+// negotiated when enabled and preferred by the client, otherwise gzip.
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Brotli  bool `yaml:"brotli"`
+	// MinSize is the smallest response body, in bytes, that is compressed.
+	MinSize int `yaml:"min_size"`
 }
 
 // CORSConfig describes Cross-Origin Resource Sharing settings.
@@ -50,6 +93,9 @@ type DefinitionsConfig struct {
 	Directories     []string `yaml:"directories"`
 	HotReload       bool     `yaml:"hot_reload"`
 	StrictChecksums bool     `yaml:"strict_checksums"`
+	// StrictMappings fails startup when response mappings reference fields
+	// missing from the OpenAPI response schemas, instead of warning.
+	StrictMappings bool `yaml:"strict_mappings"`
 }
 
 // SpecsConfig describes where to find OpenAPI specification files.
@@ -62,14 +108,174 @@ type SpecsConfig struct {
 type SpecSource struct {
 	ServiceID string `yaml:"service_id"`
 	SpecFile  string `yaml:"spec_file"`
+	// OperationAliases maps stable alias names used by definitions to the
+	// spec's current operation IDs, easing operation renames. A target must
+	// exist in this spec and an alias must not equal a real operation ID;
+	// otherwise the spec fails to load.
+	OperationAliases map[string]string `yaml:"operation_aliases"`
 }
 
 // ServiceConfig describes a backend service.
 type ServiceConfig struct {
-	BaseURL                string        `yaml:"base_url"`
-	Timeout                time.Duration `yaml:"timeout"`
-	Retry                  RetryConfig   `yaml:"retry"`
-	AuthorizationNamespace string        `yaml:"authorization_namespace"`
+	BaseURL                string               `yaml:"base_url"`
+	Timeout                time.Duration        `yaml:"timeout"`
+	Retry                  RetryConfig          `yaml:"retry"`
+	AuthorizationNamespace string               `yaml:"authorization_namespace"`
+	Auth                   ServiceAuthConfig    `yaml:"auth"`
+	ResponseHeaders        ResponseHeaderConfig `yaml:"response_headers"`
+	// RequestContentType is the default request body encoding:
+	// "application/json" (default) or "application/x-www-form-urlencoded".
+	RequestContentType string `yaml:"request_content_type"`
+	// RedirectPolicy controls backend redirects: "no-follow" (default)
+	// returns the 3xx response as is, "same-host" follows redirects to the
+	// same scheme and host only.
+	RedirectPolicy string `yaml:"redirect_policy"`
+	// ForwardAuthOnRedirect keeps credential headers (Authorization, Cookie,
+	// the static API key header) on followed redirects.
+	ForwardAuthOnRedirect bool `yaml:"forward_auth_on_redirect"`
+	// ContextHeaders are extra request headers derived from the caller's
+	// request context, such as a region header chosen by tenant.
+	ContextHeaders []ContextHeaderConfig `yaml:"context_headers"`
+	// PrewarmConnections is the number of connections opened to BaseURL at
+	// startup, so the first real requests skip DNS, TCP and TLS setup.
+	// Zero disables pre-warming.
+	PrewarmConnections int `yaml:"prewarm_connections"`
+	// ErrorMapping overrides the error codes backend responses map to, for
+	// backends that misuse status codes.
+	ErrorMapping ErrorMappingConfig `yaml:"error_mapping"`
+	// Cache caches successful GET responses per tenant for TTL. A zero TTL
+	// (the default) disables caching.
+	Cache CacheConfig `yaml:"cache"`
+	// MaxConcurrentRequests bounds in-flight requests to the service; a
+	// request over the limit fails immediately with BACKEND_UNAVAILABLE
+	// instead of queueing. Zero means unlimited.
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests"`
+	// HedgeEnabled sends a second, identical request when an idempotent
+	// operation has not responded within HedgeDelay, and uses whichever
+	// response arrives first.
+	HedgeEnabled bool          `yaml:"hedge_enabled"`
+	HedgeDelay   time.Duration `yaml:"hedge_delay"`
+	// TLS configures the client certificate and trusted CAs used to call
+	// the service, for backends that require mutual TLS.
+	TLS TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig describes the TLS client settings of a backend service. Files
+// are PEM encoded and loaded at startup; replacing them requires a restart.
+// The settings only apply to calls to the service itself: its auth strategy
+// still sets credential headers, and a service-account token endpoint is
+// reached with the shared client.
+type TLSConfig struct {
+	// CertFile and KeyFile hold the client certificate presented to the
+	// service. Both or neither must be set.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// CAFile is a bundle of CA certificates trusted for the service, in
+	// place of the system roots.
+	CAFile string `yaml:"ca_file"`
+	// InsecureSkipVerify disables verification of the service's
+	// certificate. Only for development.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// Enabled reports whether any TLS client setting is configured.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != "" || t.CAFile != "" || t.InsecureSkipVerify
+}
+
+// ErrorMappingConfig overrides the error code a failed backend response maps
+// to. Codes are BFF error codes such as "VALIDATION_ERROR"; see ErrorCodes.
+type ErrorMappingConfig struct {
+	// Statuses maps backend status codes to error codes for every operation
+	// of the service.
+	Statuses map[int]string `yaml:"statuses"`
+	// OperationStatuses maps status codes per operationId, taking
+	// precedence over Statuses.
+	OperationStatuses map[string]map[int]string `yaml:"operation_statuses"`
+	// ErrorBodyPath detects errors returned with a 2xx status: a response
+	// body with a non-empty value at this dot path is treated as failed. Its
+	// status is looked up like any other; unmapped, it fails as BAD_REQUEST.
+	ErrorBodyPath string `yaml:"error_body_path"`
+}
+
+// ErrorCodes are the error codes a backend status can be mapped to.
+var ErrorCodes = []string{
+	model.ErrBadRequest, model.ErrUnauthorized, model.ErrForbidden, model.ErrNotFound, model.ErrConflict,
+	model.ErrValidationError, model.ErrRateLimited, model.ErrInternalError,
+	model.ErrBackendUnavailable, model.ErrBackendTimeout,
+}
+
+// CodeFor returns the error code configured for a status of the given
+// operation, or "" if the status is not mapped.
+func (m ErrorMappingConfig) CodeFor(operationID string, status int) string {
+	if code, ok := m.OperationStatuses[operationID][status]; ok {
+		return code
+	}
+	return m.Statuses[status]
+}
+
+// Redirect policies for ServiceConfig.RedirectPolicy.
+const (
+	RedirectNoFollow = "no-follow"
+	RedirectSameHost = "same-host"
+)
+
+// ContextHeaderConfig derives one backend request header from a request
+// context attribute. Source names the attribute as accepted by
+// model.RequestContext.Attribute, including claims.<key>. If Values is set,
+// the attribute value is looked up in it and Default is used when there is no
+// entry; otherwise the attribute value is sent as is. The header is omitted when the result is empty.
+type ContextHeaderConfig struct {
+	Header  string            `yaml:"header"`
+	Source  string            `yaml:"source"`
+	Values  map[string]string `yaml:"values"`
+	Default string            `yaml:"default"`
+}
+
+// ResponseHeaderConfig controls which backend response headers are passed
+// on. Allow extends the built-in allowlist; Deny removes headers even if they
+// are allowlisted. Server, X-Powered-By and X-AspNet-Version are always
+// denied.
+type ResponseHeaderConfig struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// Backend authentication strategies.
+const (
+	// AuthPassthroughBearer forwards the end-user token as a bearer token.
+	AuthPassthroughBearer = "passthrough-bearer"
+	// AuthStaticAPIKey sends a fixed API key in a configurable header.
+	AuthStaticAPIKey = "static-api-key"
+	// AuthServiceAccount sends a service token obtained via the OAuth2
+	// client credentials flow.
+	AuthServiceAccount = "service-account"
+)
+
+// ServiceAuthConfig describes how requests to a backend are authenticated.
+// An empty strategy (or the legacy "forward_token") means passthrough-bearer.
+// The end-user identity is always conveyed via X-Request-Subject.
+type ServiceAuthConfig struct {
+	Strategy string `yaml:"strategy"`
+
+	// static-api-key settings. Header defaults to X-API-Key.
+	Header string `yaml:"header"`
+	APIKey string `yaml:"api_key"`
+
+	// service-account settings.
+	TokenURL     string   `yaml:"token_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// Mode returns the normalized authentication strategy.
+func (a ServiceAuthConfig) Mode() string {
+	switch a.Strategy {
+	case "", "forward_token":
+		return AuthPassthroughBearer
+	}
+	return a.Strategy
 }
 
 // RetryConfig describes retry settings per service.
@@ -78,12 +284,105 @@ type RetryConfig struct {
 	BackoffInitial    time.Duration `yaml:"backoff_initial"`
 	BackoffMultiplier float64       `yaml:"backoff_multiplier"`
 	BackoffMax        time.Duration `yaml:"backoff_max"`
-	IdempotentOnly    bool          `yaml:"idempotent_only"`
+	// IdempotentOnly restricts retries to GET, PUT, DELETE, HEAD and
+	// OPTIONS requests. POST and PATCH requests are then attempted once,
+	// whatever the error or status.
+	IdempotentOnly bool `yaml:"idempotent_only"`
+	// TotalRetryBudget bounds the time spent on all attempts plus backoff.
+	// A retry is not started if its backoff would exceed the budget. Zero
+	// means no budget; attempts are bounded by MaxAttempts only.
+	TotalRetryBudget time.Duration `yaml:"total_retry_budget"`
+	// RetryableStatusCodes, when non-empty, replaces the default retryable
+	// statuses (429, 500, 502, 503 and 504) for every operation of the
+	// service. It only decides which
+	// statuses are worth retrying, not which requests may be retried: with
+	// IdempotentOnly set, a POST or PATCH answered with a listed status is
+	// still returned as is, so listing a code never makes a non-idempotent
+	// request repeat. RetryableStatuses and OperationRetryableStatuses are
+	// retried in addition to either set. All three lists accept only codes
+	// IsConfigurableRetryStatus allows.
+	RetryableStatusCodes []int `yaml:"retryable_status_codes"`
+	// RetryableStatuses adds status codes retried for every operation of the
+	// service, on top of RetryableStatusCodes or the default set.
+	RetryableStatuses []int `yaml:"retryable_statuses"`
+	// OperationRetryableStatuses adds retryable status codes per operationId.
+	OperationRetryableStatuses map[string][]int `yaml:"operation_retryable_statuses"`
+}
+
+// DefaultRetryableStatuses are the status codes retried when a service sets
+// no RetryableStatusCodes.
+var DefaultRetryableStatuses = []int{429, 500, 502, 503, 504}
+
+// AdditionalRetryableStatuses are the status codes outside the default set
+// that may be configured as retryable: transient conditions where repeating
+// the request is safe.
+var AdditionalRetryableStatuses = map[int]bool{
+	408: true, // Request Timeout
+	425: true, // Too Early
+}
+
+// IsConfigurableRetryStatus reports whether code may be listed in any of the
+// retryable status settings.
+func IsConfigurableRetryStatus(code int) bool {
+	return slices.Contains(DefaultRetryableStatuses, code) || AdditionalRetryableStatuses[code]
+}
+
+// StatusesFor returns the configured additional retryable statuses for the
+// given operation.
+func (r RetryConfig) StatusesFor(operationID string) []int {
+	extra := r.OperationRetryableStatuses[operationID]
+	if len(extra) == 0 {
+		return r.RetryableStatuses
+	}
+	return append(append([]int(nil), r.RetryableStatuses...), extra...)
 }
 
 // CapabilityConfig describes authorization cache settings.
 type CapabilityConfig struct {
 	Cache CacheConfig `yaml:"cache"`
+	// ForbiddenDetails lists the missing capabilities on 403 responses.
+	// Disable in deployments where the capability model must not be exposed.
+	ForbiddenDetails bool `yaml:"forbidden_details"`
+	// RequireTenant rejects requests whose token carries no tenant, for
+	// strictly multi-tenant deployments where an empty tenant must never
+	// reach a backend.
+	RequireTenant bool `yaml:"require_tenant"`
+	// Aliases maps alternative capability names to canonical ones. A
+	// canonical capability and its aliases are equivalent: granting any one
+	// satisfies a definition requiring another.
+	Aliases map[string]string `yaml:"aliases"`
+	// DiagnosticsCapability is required to read GET /ui/admin/diagnostics.
+	DiagnosticsCapability string `yaml:"diagnostics_capability"`
+	// CacheAdminCapability is required for cache management endpoints such
+	// as POST /ui/admin/lookups/warm.
+	CacheAdminCapability string `yaml:"cache_admin_capability"`
+	// CommandAdminCapability is required to disable and re-enable commands
+	// at runtime through /ui/admin/commands.
+	CommandAdminCapability string `yaml:"command_admin_capability"`
+	// ExplainCapability is required to get the explain debug block on
+	// navigation, page and form descriptors with ?explain=true. Leave it
+	// empty to disable explain, e.g. in production.
+	ExplainCapability string `yaml:"explain_capability"`
+	// Namespace is the Keto namespace in which the BFF's own capabilities
+	// above are checked.
+	Namespace string `yaml:"namespace"`
+}
+
+// OwnCapabilities returns the configured capabilities that guard the BFF's
+// own endpoints and features, skipping those left empty.
+func (c CapabilityConfig) OwnCapabilities() []string {
+	var caps []string
+	for _, cap := range []string{
+		c.DiagnosticsCapability,
+		c.CacheAdminCapability,
+		c.CommandAdminCapability,
+		c.ExplainCapability,
+	} {
+		if cap != "" && !slices.Contains(caps, cap) {
+			caps = append(caps, cap)
+		}
+	}
+	return caps
 }
 
 // CacheConfig describes cache settings.
@@ -96,13 +395,101 @@ type CacheConfig struct {
 type SearchConfig struct {
 	TimeoutPerProvider    time.Duration `yaml:"timeout_per_provider"`
 	MaxResultsPerProvider int           `yaml:"max_results_per_provider"`
+	// DedupIdentity selects which result fields identify the same entity
+	// across providers: "route_id" (default), "route" or "id". Results with
+	// the same identity collapse into the highest-scoring one, so "id" is
+	// only safe when IDs are unique across all searched domains.
+	DedupIdentity string `yaml:"dedup_identity"`
 }
 
-// LookupCacheConfig describes lookup cache settings.
+// LookupCacheConfig describes lookup cache and result settings.
 type LookupCacheConfig struct {
-	Cache CacheConfig `yaml:"cache"`
+	Cache      CacheConfig `yaml:"cache"`
+	MaxResults int         `yaml:"max_results"`
+	// Warm lists lookups whose cache entries are populated at startup.
+	Warm []string `yaml:"warm"`
+}
+
+// CommandsConfig describes command execution settings.
+type CommandsConfig struct {
+	// ConfirmationSecret is the HMAC key for confirmation tokens. When empty,
+	// a random key is generated at startup, so tokens do not survive a
+	// restart and are not accepted by other replicas.
+	ConfirmationSecret string `yaml:"confirmation_secret"`
+	// ConfirmationTTL is how long a confirmation token stays valid.
+	ConfirmationTTL time.Duration `yaml:"confirmation_ttl"`
+	// Disabled lists commands that are disabled at startup. Executing one
+	// fails with COMMAND_UNAVAILABLE and its actions are hidden.
+	Disabled []string `yaml:"disabled"`
+	// JobRetention is how long finished async command jobs stay queryable.
+	JobRetention time.Duration `yaml:"job_retention"`
+	// JobWorkers bounds the number of async command jobs running at once.
+	JobWorkers int `yaml:"job_workers"`
+	// JobQueueSize bounds the number of async command jobs waiting for a
+	// worker; further commands are rejected with RATE_LIMITED.
+	JobQueueSize int `yaml:"job_queue_size"`
+	// MaxUploadSize bounds the body of a streamed upload command, in bytes;
+	// larger uploads fail with PAYLOAD_TOO_LARGE.
+	MaxUploadSize int64 `yaml:"max_upload_size"`
+}
+
+// LocaleConfig describes the locale and currency requests default to, per
+// tenant.
+type LocaleConfig struct {
+	// Default applies to tenants without an entry in Tenants.
+	Default TenantLocale `yaml:"default"`
+	// Tenants maps tenant IDs to their defaults. Fields a tenant leaves
+	// empty fall back to Default.
+	Tenants map[string]TenantLocale `yaml:"tenants"`
+}
+
+// TenantLocale is a tenant's default locale and currency.
+type TenantLocale struct {
+	// Locale is a language tag such as "de-DE", used when the request has
+	// no Accept-Language header.
+	Locale string `yaml:"locale"`
+	// Currency is an ISO 4217 code such as "EUR", used by currency columns
+	// and fields without an explicit format.
+	Currency string `yaml:"currency"`
 }
 
+// EntitlementsConfig describes which tenants may use separately licensed
+// domains. Domains not listed in Gated are available to every tenant.
+type EntitlementsConfig struct {
+	// Gated lists the domains that require an entitlement.
+	Gated []string `yaml:"gated"`
+	// Tenants maps tenant IDs to the gated domains they are entitled to.
+	Tenants map[string][]string `yaml:"tenants"`
+}
+
+// Unentitled returns the gated domains the given tenant is not entitled to.
+func (c EntitlementsConfig) Unentitled(tenantID string) []string {
+	var denied []string
+	for _, domain := range c.Gated {
+		if !slices.Contains(c.Tenants[tenantID], domain) {
+			denied = append(denied, domain)
+		}
+	}
+	return denied
+}
+
+// For returns the defaults for the given tenant.
+func (c LocaleConfig) For(tenantID string) TenantLocale {
+	result := c.Default
+	if t, ok := c.Tenants[tenantID]; ok {
+		if t.Locale != "" {
+			result.Locale = t.Locale
+		}
+		if t.Currency != "" {
+			result.Currency = t.Currency
+		}
+	}
+	return result
+}
+
+// currencyCode matches an ISO 4217 currency code.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
 // ObservabilityConfig describes logging, tracing, and metrics settings.
 type ObservabilityConfig struct {
 	LogLevel string        `yaml:"log_level"`
@@ -140,6 +527,12 @@ func Defaults() *Config {
 					"X-Correlation-Id", "X-Idempotency-Key"},
 				MaxAge: 86400,
 			},
+			Compression: CompressionConfig{
+				Enabled: true,
+				Brotli:  true,
+				MinSize: 1024,
+			},
+			ETags: true,
 		},
 		Definitions: DefinitionsConfig{
 			Directories:     []string{"/definitions"},
@@ -153,6 +546,11 @@ func Defaults() *Config {
 				TTL:        5 * time.Minute,
 				MaxEntries: 10000,
 			},
+			ForbiddenDetails:       true,
+			DiagnosticsCapability:  "thesa:diagnostics:view",
+			CacheAdminCapability:   "thesa:cache:manage",
+			CommandAdminCapability: "thesa:commands:manage",
+			Namespace:              "service_thesa",
 		},
 		Search: SearchConfig{
 			TimeoutPerProvider:    3 * time.Second,
@@ -163,6 +561,14 @@ func Defaults() *Config {
 				TTL:        5 * time.Minute,
 				MaxEntries: 1000,
 			},
+			MaxResults: 100,
+		},
+		Commands: CommandsConfig{
+			ConfirmationTTL: 5 * time.Minute,
+			JobRetention:    time.Hour,
+			JobWorkers:      4,
+			JobQueueSize:    100,
+			MaxUploadSize:   32 << 20,
 		},
 		Observability: ObservabilityConfig{
 			LogLevel: "info",
@@ -221,12 +627,196 @@ func (c *Config) Validate() error {
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		errs = append(errs, "server.port must be between 1 and 65535")
 	}
+
+	groups := make([]string, 0, len(c.Server.ResponseHeaders))
+	for group := range c.Server.ResponseHeaders {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		if !slices.Contains(RouteGroups, group) {
+			errs = append(errs, fmt.Sprintf("server.response_headers: unknown route group %q", group))
+		}
+	}
+
+	aliases := make([]string, 0, len(c.Capability.Aliases))
+	for alias := range c.Capability.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		canonical := c.Capability.Aliases[alias]
+		switch {
+		case alias == "" || canonical == "":
+			errs = append(errs, fmt.Sprintf("capability.aliases: %q → %q must name two capabilities", alias, canonical))
+		case alias == canonical:
+			errs = append(errs, fmt.Sprintf("capability.aliases.%s aliases itself", alias))
+		case strings.Contains(alias, "*") || strings.Contains(canonical, "*"):
+			errs = append(errs, fmt.Sprintf("capability.aliases.%s: wildcards cannot be aliased", alias))
+		}
+		if _, chained := c.Capability.Aliases[canonical]; chained {
+			errs = append(errs, fmt.Sprintf("capability.aliases.%s: %q is itself an alias; map to its canonical capability", alias, canonical))
+		}
+	}
+
+	if c.Capability.Namespace == "" && len(c.Capability.OwnCapabilities()) > 0 {
+		errs = append(errs, "capability.namespace is required when admin or explain capabilities are set")
+	}
+
+	switch c.Search.DedupIdentity {
+	case "", "route_id", "route", "id":
+	default:
+		errs = append(errs, fmt.Sprintf("search.dedup_identity %q is not supported", c.Search.DedupIdentity))
+	}
+
+	if cur := c.Locale.Default.Currency; cur != "" && !currencyCode.MatchString(cur) {
+		errs = append(errs, fmt.Sprintf("locale.default.currency %q is not an ISO 4217 code", cur))
+	}
+	tenantIDs := make([]string, 0, len(c.Locale.Tenants))
+	for id := range c.Locale.Tenants {
+		tenantIDs = append(tenantIDs, id)
+	}
+	sort.Strings(tenantIDs)
+	for _, id := range tenantIDs {
+		if cur := c.Locale.Tenants[id].Currency; cur != "" && !currencyCode.MatchString(cur) {
+			errs = append(errs, fmt.Sprintf("locale.tenants.%s.currency %q is not an ISO 4217 code", id, cur))
+		}
+	}
+
+	entitledTenants := make([]string, 0, len(c.Entitlements.Tenants))
+	for id := range c.Entitlements.Tenants {
+		entitledTenants = append(entitledTenants, id)
+	}
+	sort.Strings(entitledTenants)
+	for _, id := range entitledTenants {
+		for _, domain := range c.Entitlements.Tenants[id] {
+			if !slices.Contains(c.Entitlements.Gated, domain) {
+				errs = append(errs, fmt.Sprintf("entitlements.tenants.%s: domain %q is not gated", id, domain))
+			}
+		}
+	}
+
+	serviceIDs := make([]string, 0, len(c.Services))
+	for id := range c.Services {
+		serviceIDs = append(serviceIDs, id)
+	}
+	sort.Strings(serviceIDs)
+	for _, id := range serviceIDs {
+		svc := c.Services[id]
+		switch svc.RequestContentType {
+		case "", "application/json", "application/x-www-form-urlencoded":
+		default:
+			errs = append(errs, fmt.Sprintf("services.%s.request_content_type %q is not supported", id, svc.RequestContentType))
+		}
+		if svc.Retry.TotalRetryBudget < 0 {
+			errs = append(errs, fmt.Sprintf("services.%s.retry.total_retry_budget must not be negative", id))
+		}
+		for _, code := range svc.Retry.RetryableStatusCodes {
+			if !IsConfigurableRetryStatus(code) {
+				errs = append(errs, fmt.Sprintf("services.%s.retry.retryable_status_codes: %d is not a retryable status", id, code))
+			}
+		}
+		for _, code := range svc.Retry.RetryableStatuses {
+			if !IsConfigurableRetryStatus(code) {
+				errs = append(errs, fmt.Sprintf("services.%s.retry.retryable_statuses: %d is not a retryable status", id, code))
+			}
+		}
+		opIDs := make([]string, 0, len(svc.Retry.OperationRetryableStatuses))
+		for opID := range svc.Retry.OperationRetryableStatuses {
+			opIDs = append(opIDs, opID)
+		}
+		sort.Strings(opIDs)
+		for _, opID := range opIDs {
+			for _, code := range svc.Retry.OperationRetryableStatuses[opID] {
+				if !IsConfigurableRetryStatus(code) {
+					errs = append(errs, fmt.Sprintf("services.%s.retry.operation_retryable_statuses.%s: %d is not a retryable status", id, opID, code))
+				}
+			}
+		}
+
+		errs = append(errs, validateErrorMapping(id, svc.ErrorMapping)...)
+
+		if svc.Cache.TTL < 0 || svc.Cache.MaxEntries < 0 {
+			errs = append(errs, fmt.Sprintf("services.%s.cache: ttl and max_entries must not be negative", id))
+		}
+		if svc.MaxConcurrentRequests < 0 {
+			errs = append(errs, fmt.Sprintf("services.%s.max_concurrent_requests must not be negative", id))
+		}
+		if svc.PrewarmConnections < 0 {
+			errs = append(errs, fmt.Sprintf("services.%s.prewarm_connections must not be negative", id))
+		}
+		if svc.HedgeDelay < 0 || (svc.HedgeEnabled && svc.HedgeDelay == 0) {
+			errs = append(errs, fmt.Sprintf("services.%s.hedge_delay must be positive when hedging is enabled", id))
+		}
+		if (svc.TLS.CertFile == "") != (svc.TLS.KeyFile == "") {
+			errs = append(errs, fmt.Sprintf("services.%s.tls: cert_file and key_file must be set together", id))
+		}
+		switch svc.RedirectPolicy {
+		case "", RedirectNoFollow, RedirectSameHost:
+		default:
+			errs = append(errs, fmt.Sprintf("services.%s.redirect_policy %q is not supported", id, svc.RedirectPolicy))
+		}
+		for i, ch := range svc.ContextHeaders {
+			if ch.Header == "" {
+				errs = append(errs, fmt.Sprintf("services.%s.context_headers[%d].header is required", id, i))
+			}
+			if _, ok := (&model.RequestContext{}).Attribute(ch.Source); !ok && !strings.HasPrefix(ch.Source, "claims.") {
+				errs = append(errs, fmt.Sprintf("services.%s.context_headers[%d].source %q is not a request context attribute", id, i, ch.Source))
+			}
+		}
+
+		auth := svc.Auth
+		switch auth.Mode() {
+		case AuthPassthroughBearer:
+		case AuthStaticAPIKey:
+			if auth.APIKey == "" {
+				errs = append(errs, fmt.Sprintf("services.%s.auth.api_key is required for static-api-key", id))
+			}
+		case AuthServiceAccount:
+			if auth.TokenURL == "" || auth.ClientID == "" {
+				errs = append(errs, fmt.Sprintf("services.%s.auth.token_url and client_id are required for service-account", id))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("services.%s.auth.strategy %q is not supported", id, auth.Strategy))
+		}
+	}
 	if len(errs) > 0 {
 		return fmt.Errorf("%s", strings.Join(errs, "; "))
 	}
 	return nil
 }
 
+// validateErrorMapping checks that every mapped status is a valid HTTP
+// status and maps to a known error code.
+func validateErrorMapping(serviceID string, m ErrorMappingConfig) []string {
+	var errs []string
+	check := func(prefix string, statuses map[int]string) {
+		codes := make([]int, 0, len(statuses))
+		for status := range statuses {
+			codes = append(codes, status)
+		}
+		sort.Ints(codes)
+		for _, status := range codes {
+			if status < 100 || status > 599 {
+				errs = append(errs, fmt.Sprintf("%s: %d is not an HTTP status", prefix, status))
+			}
+			if code := statuses[status]; !slices.Contains(ErrorCodes, code) {
+				errs = append(errs, fmt.Sprintf("%s.%d: unknown error code %q", prefix, status, code))
+			}
+		}
+	}
+	check(fmt.Sprintf("services.%s.error_mapping.statuses", serviceID), m.Statuses)
+	opIDs := make([]string, 0, len(m.OperationStatuses))
+	for opID := range m.OperationStatuses {
+		opIDs = append(opIDs, opID)
+	}
+	sort.Strings(opIDs)
+	for _, opID := range opIDs {
+		check(fmt.Sprintf("services.%s.error_mapping.operation_statuses.%s", serviceID, opID), m.OperationStatuses[opID])
+	}
+	return errs
+}
+
 // applyEnvOverrides reads THESA_* environment variables and overrides config
 // values. Only the most commonly overridden fields are supported.
 func applyEnvOverrides(cfg *Config) {
@@ -239,4 +829,7 @@ func applyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("THESA_OBSERVABILITY_LOG_LEVEL"); v != "" {
 		cfg.Observability.LogLevel = v
 	}
+	if v := os.Getenv("THESA_COMMANDS_CONFIRMATION_SECRET"); v != "" {
+		cfg.Commands.ConfirmationSecret = v
+	}
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"slices"
 	"testing"
 	"time"
 )
@@ -86,6 +87,32 @@ func TestValidate_invalid_port(t *testing.T) {
 	}
 }
 
+func TestValidate_serviceAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    ServiceAuthConfig
+		wantErr bool
+	}{
+		{"default passthrough", ServiceAuthConfig{}, false},
+		{"legacy forward_token", ServiceAuthConfig{Strategy: "forward_token"}, false},
+		{"api key", ServiceAuthConfig{Strategy: AuthStaticAPIKey, APIKey: "k"}, false},
+		{"api key missing", ServiceAuthConfig{Strategy: AuthStaticAPIKey}, true},
+		{"service account", ServiceAuthConfig{Strategy: AuthServiceAccount, TokenURL: "https://idp/token", ClientID: "c"}, false},
+		{"service account missing token url", ServiceAuthConfig{Strategy: AuthServiceAccount, ClientID: "c"}, true},
+		{"unknown", ServiceAuthConfig{Strategy: "mtls"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.Services = map[string]ServiceConfig{"svc": {Auth: tt.auth}}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestLoad_env_priority_over_file(t *testing.T) {
 	// File sets port 9090, env sets 5555 — env wins
 	t.Setenv("THESA_SERVER_PORT", "5555")
@@ -98,3 +125,271 @@ func TestLoad_env_priority_over_file(t *testing.T) {
 		t.Errorf("Server.Port = %d, want 5555 (env override beats file)", cfg.Server.Port)
 	}
 }
+
+func TestValidate_retryableStatuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   RetryConfig
+		wantErr bool
+	}{
+		{"none", RetryConfig{}, false},
+		{"too early", RetryConfig{RetryableStatuses: []int{425}}, false},
+		{"per operation", RetryConfig{OperationRetryableStatuses: map[string][]int{"op": {429}}}, false},
+		{"bad request", RetryConfig{RetryableStatuses: []int{400}}, true},
+		{"per operation conflict", RetryConfig{OperationRetryableStatuses: map[string][]int{"op": {409}}}, true},
+		{"replacement set", RetryConfig{RetryableStatusCodes: []int{425, 502}}, false},
+		{"replacement set with success status", RetryConfig{RetryableStatusCodes: []int{204}}, true},
+		{"replacement set with conflict status", RetryConfig{RetryableStatusCodes: []int{409}}, true},
+		{"not implemented", RetryConfig{RetryableStatuses: []int{501}}, true},
+		{"retry budget", RetryConfig{TotalRetryBudget: time.Second}, false},
+		{"negative retry budget", RetryConfig{TotalRetryBudget: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.Services = map[string]ServiceConfig{"svc": {Retry: tt.retry}}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_responseHeaderGroups(t *testing.T) {
+	cfg := Defaults()
+	cfg.Server.ResponseHeaders = map[string]map[string]string{
+		RouteGroupData: {"Cache-Control": "no-store"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cfg.Server.ResponseHeaders["reports"] = map[string]string{"Vary": "Accept-Language"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() with an unknown route group should return error")
+	}
+}
+
+func TestValidate_contextHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  ContextHeaderConfig
+		wantErr bool
+	}{
+		{name: "attribute", header: ContextHeaderConfig{Header: "X-Region", Source: "tenant_id"}},
+		{name: "claim", header: ContextHeaderConfig{Header: "X-Plan", Source: "claims.plan"}},
+		{name: "missing header", header: ContextHeaderConfig{Source: "tenant_id"}, wantErr: true},
+		{name: "unknown source", header: ContextHeaderConfig{Header: "X-Region", Source: "region"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.Services = map[string]ServiceConfig{
+				"orders-svc": {ContextHeaders: []ContextHeaderConfig{tt.header}},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_redirectPolicy(t *testing.T) {
+	for policy, wantErr := range map[string]bool{"": false, RedirectNoFollow: false, RedirectSameHost: false, "follow-all": true} {
+		cfg := Defaults()
+		cfg.Services = map[string]ServiceConfig{"orders-svc": {RedirectPolicy: policy}}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("redirect_policy %q: Validate() error = %v, wantErr %v", policy, err, wantErr)
+		}
+	}
+}
+
+func TestValidate_prewarmConnections(t *testing.T) {
+	for n, wantErr := range map[int]bool{0: false, 4: false, -1: true} {
+		cfg := Defaults()
+		cfg.Services = map[string]ServiceConfig{"orders-svc": {PrewarmConnections: n}}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("prewarm_connections %d: Validate() error = %v, wantErr %v", n, err, wantErr)
+		}
+	}
+}
+
+func TestValidate_maxConcurrentRequests(t *testing.T) {
+	for n, wantErr := range map[int]bool{0: false, 8: false, -1: true} {
+		cfg := Defaults()
+		cfg.Services = map[string]ServiceConfig{"orders-svc": {MaxConcurrentRequests: n}}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("max_concurrent_requests %d: Validate() error = %v, wantErr %v", n, err, wantErr)
+		}
+	}
+}
+
+func TestValidate_serviceCache(t *testing.T) {
+	for _, tc := range []struct {
+		cache   CacheConfig
+		wantErr bool
+	}{
+		{cache: CacheConfig{}},
+		{cache: CacheConfig{TTL: time.Minute, MaxEntries: 500}},
+		{cache: CacheConfig{TTL: -time.Second}, wantErr: true},
+		{cache: CacheConfig{TTL: time.Minute, MaxEntries: -1}, wantErr: true},
+	} {
+		cfg := Defaults()
+		cfg.Services = map[string]ServiceConfig{"orders-svc": {Cache: tc.cache}}
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("cache %+v: Validate() error = %v, wantErr %v", tc.cache, err, tc.wantErr)
+		}
+	}
+}
+
+func TestLocaleConfig_For(t *testing.T) {
+	cfg := LocaleConfig{
+		Default: TenantLocale{Locale: "en-US", Currency: "USD"},
+		Tenants: map[string]TenantLocale{"t-eu": {Currency: "EUR"}},
+	}
+	if got := cfg.For("t-eu"); got != (TenantLocale{Locale: "en-US", Currency: "EUR"}) {
+		t.Errorf("For(t-eu) = %+v, want en-US and EUR", got)
+	}
+	if got := cfg.For("t-other"); got != cfg.Default {
+		t.Errorf("For(t-other) = %+v, want the default", got)
+	}
+
+	c := Defaults()
+	c.Locale.Tenants = map[string]TenantLocale{"t-eu": {Currency: "euro"}}
+	if err := c.Validate(); err == nil {
+		t.Error("Validate() accepted a currency that is not an ISO 4217 code")
+	}
+}
+
+func TestEntitlementsConfig_Unentitled(t *testing.T) {
+	cfg := EntitlementsConfig{
+		Gated:   []string{"billing", "reports"},
+		Tenants: map[string][]string{"t-paid": {"billing"}},
+	}
+	if got := cfg.Unentitled("t-paid"); !slices.Equal(got, []string{"reports"}) {
+		t.Errorf("Unentitled(t-paid) = %v, want [reports]", got)
+	}
+	if got := cfg.Unentitled("t-other"); !slices.Equal(got, cfg.Gated) {
+		t.Errorf("Unentitled(t-other) = %v, want every gated domain", got)
+	}
+
+	c := Defaults()
+	c.Entitlements = EntitlementsConfig{Tenants: map[string][]string{"t-paid": {"billing"}}}
+	if err := c.Validate(); err == nil {
+		t.Error("Validate() accepted an entitlement to a domain that is not gated")
+	}
+}
+
+func TestValidate_errorMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping ErrorMappingConfig
+		wantErr bool
+	}{
+		{name: "valid", mapping: ErrorMappingConfig{
+			Statuses:          map[int]string{409: "VALIDATION_ERROR"},
+			OperationStatuses: map[string]map[int]string{"createOrder": {200: "CONFLICT"}},
+		}},
+		{name: "unknown code", mapping: ErrorMappingConfig{Statuses: map[int]string{409: "DUPLICATE"}}, wantErr: true},
+		{name: "invalid status", mapping: ErrorMappingConfig{
+			OperationStatuses: map[string]map[int]string{"createOrder": {42: "CONFLICT"}},
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.Services = map[string]ServiceConfig{"orders-svc": {ErrorMapping: tt.mapping}}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_capabilityAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		wantErr bool
+	}{
+		{name: "valid", aliases: map[string]string{"orders:view": "orders:read", "orders:list": "orders:read"}},
+		{name: "self", aliases: map[string]string{"orders:read": "orders:read"}, wantErr: true},
+		{name: "wildcard", aliases: map[string]string{"orders:*": "orders:read"}, wantErr: true},
+		{name: "chain", aliases: map[string]string{"orders:view": "orders:list", "orders:list": "orders:read"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.Capability.Aliases = tt.aliases
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_capabilityNamespace(t *testing.T) {
+	cfg := Defaults()
+	cfg.Capability.Namespace = ""
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() error = nil, want an error for admin capabilities without a namespace")
+	}
+
+	cfg.Capability.DiagnosticsCapability = ""
+	cfg.Capability.CacheAdminCapability = ""
+	cfg.Capability.CommandAdminCapability = ""
+	cfg.Capability.ExplainCapability = ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil without own capabilities", err)
+	}
+}
+
+func TestCapabilityConfig_OwnCapabilities(t *testing.T) {
+	cfg := CapabilityConfig{
+		DiagnosticsCapability:  "thesa:diagnostics:view",
+		CacheAdminCapability:   "thesa:admin",
+		CommandAdminCapability: "thesa:admin",
+	}
+	want := []string{"thesa:diagnostics:view", "thesa:admin"}
+	if got := cfg.OwnCapabilities(); !slices.Equal(got, want) {
+		t.Errorf("OwnCapabilities() = %v, want %v", got, want)
+	}
+}
+
+func TestValidate_serviceTLS(t *testing.T) {
+	for _, tc := range []struct {
+		tls     TLSConfig
+		wantErr bool
+	}{
+		{tls: TLSConfig{}},
+		{tls: TLSConfig{CAFile: "ca.pem"}},
+		{tls: TLSConfig{CertFile: "client.pem", KeyFile: "client-key.pem"}},
+		{tls: TLSConfig{CertFile: "client.pem"}, wantErr: true},
+		{tls: TLSConfig{KeyFile: "client-key.pem"}, wantErr: true},
+	} {
+		cfg := Defaults()
+		cfg.Services = map[string]ServiceConfig{"orders-svc": {TLS: tc.tls}}
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("tls %+v: Validate() error = %v, wantErr %v", tc.tls, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_hedging(t *testing.T) {
+	for _, tc := range []struct {
+		svc     ServiceConfig
+		wantErr bool
+	}{
+		{svc: ServiceConfig{}},
+		{svc: ServiceConfig{HedgeEnabled: true, HedgeDelay: 50 * time.Millisecond}},
+		{svc: ServiceConfig{HedgeEnabled: true}, wantErr: true},
+		{svc: ServiceConfig{HedgeDelay: -time.Millisecond}, wantErr: true},
+	} {
+		cfg := Defaults()
+		cfg.Services = map[string]ServiceConfig{"orders-svc": tc.svc}
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("hedge_enabled %v, hedge_delay %v: Validate() error = %v, wantErr %v", tc.svc.HedgeEnabled, tc.svc.HedgeDelay, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package definition
+
+import (
+	"fmt"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// deprecatedOperators maps condition operator spellings that are still
+// evaluated but no longer documented to their canonical replacements.
+var deprecatedOperators = map[string]string{
+	"equals":     "eq",
+	"not_equals": "ne",
+	"==":         "eq",
+	"!=":         "ne",
+}
+
+// validateDeprecations reports deprecated constructs in a domain as
+// warnings. They never fail validation.
+func (v *Validator) validateDeprecations(prefix string, def model.DomainDefinition) []VError {
+	var warnings []VError
+
+	operator := func(path, op string) {
+		if repl, ok := deprecatedOperators[op]; ok {
+			warnings = append(warnings, VError{
+				Path:    path,
+				Code:    "DEPRECATED",
+				Message: fmt.Sprintf("operator %q is deprecated, use %q", op, repl),
+				Warning: true,
+			})
+		}
+	}
+	conditions := func(path string, conds []model.ConditionDefinition) {
+		for i, c := range conds {
+			operator(fmt.Sprintf("%s.conditions[%d].operator", path, i), c.Operator)
+		}
+	}
+	actions := func(path string, acts []model.ActionDefinition) {
+		for i, a := range acts {
+			conditions(fmt.Sprintf("%s[%d]", path, i), a.Conditions)
+		}
+	}
+
+	for i, child := range def.Navigation.Children {
+		conditions(fmt.Sprintf("%s.navigation.children[%d]", prefix, i), child.Conditions)
+	}
+	for i, p := range def.Pages {
+		pp := fmt.Sprintf("%s.pages[%d]", prefix, i)
+		actions(pp+".actions", p.Actions)
+		if p.Table == nil {
+			continue
+		}
+		actions(pp+".table.row_actions", p.Table.RowActions)
+		actions(pp+".table.bulk_actions", p.Table.BulkActions)
+		for j, col := range p.Table.Columns {
+			for k, rule := range col.FormatRules {
+				operator(fmt.Sprintf("%s.table.columns[%d].format_rules[%d].operator", pp, j, k), rule.Operator)
+			}
+		}
+	}
+
+	return warnings
+}
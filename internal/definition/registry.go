@@ -3,8 +3,10 @@ package definition
 import (
 	"crypto/sha256"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/pitabwire/thesa/model"
@@ -18,13 +20,29 @@ type snapshot struct {
 	commands map[string]model.CommandDefinition
 	searches map[string]model.SearchDefinition
 	lookups  map[string]model.LookupDefinition
-	checksum string
+	// pageDomains, formDomains, commandDomains and lookupDomains map IDs to
+	// the domain that defines them.
+	pageDomains    map[string]string
+	formDomains    map[string]string
+	commandDomains map[string]string
+	lookupDomains  map[string]string
+	// optionFields holds, per command ID, the option-backed fields of the
+	// forms that submit to it.
+	optionFields map[string][]model.FieldDefinition
+	// maskedFields holds, per command ID, the masked fields of the forms
+	// that submit to it.
+	maskedFields map[string][]model.FieldDefinition
+	checksum     string
 }
 
 // Registry is a read-optimized, thread-safe store of all loaded definitions.
 // It uses atomic pointer swap for lock-free concurrent reads.
 type Registry struct {
-	snap atomic.Pointer[snapshot]
+	snap     atomic.Pointer[snapshot]
+	warnings atomic.Pointer[[]VError]
+	// disabled holds the IDs of commands disabled at runtime. It is kept
+	// across Replace so a definition reload does not re-enable them.
+	disabled sync.Map
 }
 
 // NewRegistry creates a Registry from the given definitions.
@@ -44,6 +62,14 @@ func (r *Registry) Replace(defs []model.DomainDefinition) {
 		commands: make(map[string]model.CommandDefinition),
 		searches: make(map[string]model.SearchDefinition),
 		lookups:  make(map[string]model.LookupDefinition),
+
+		pageDomains:    make(map[string]string),
+		formDomains:    make(map[string]string),
+		commandDomains: make(map[string]string),
+		lookupDomains:  make(map[string]string),
+
+		optionFields: make(map[string][]model.FieldDefinition),
+		maskedFields: make(map[string][]model.FieldDefinition),
 	}
 
 	var checksumParts []string
@@ -54,18 +80,23 @@ func (r *Registry) Replace(defs []model.DomainDefinition) {
 
 		for _, p := range def.Pages {
 			s.pages[p.ID] = p
+			s.pageDomains[p.ID] = def.Domain
 		}
 		for _, f := range def.Forms {
 			s.forms[f.ID] = f
+			s.formDomains[f.ID] = def.Domain
+			s.indexSubmitFields(f)
 		}
 		for _, c := range def.Commands {
 			s.commands[c.ID] = c
+			s.commandDomains[c.ID] = def.Domain
 		}
 		for _, sr := range def.Searches {
 			s.searches[sr.ID] = sr
 		}
 		for _, l := range def.Lookups {
 			s.lookups[l.ID] = l
+			s.lookupDomains[l.ID] = def.Domain
 		}
 	}
 
@@ -76,6 +107,94 @@ func (r *Registry) Replace(defs []model.DomainDefinition) {
 	r.snap.Store(s)
 }
 
+// submitCommands returns the IDs of the commands a form submits to.
+func submitCommands(f model.FormDefinition) []string {
+	commands := make([]string, 0, 1+len(f.SubmitActions))
+	if f.SubmitCommand != "" {
+		commands = append(commands, f.SubmitCommand)
+	}
+	for _, a := range f.SubmitActions {
+		commands = append(commands, a.CommandID)
+	}
+	return commands
+}
+
+// hasOptions reports whether a field takes its value from static or lookup
+// options.
+func hasOptions(field model.FieldDefinition) bool {
+	return field.Lookup != nil && (field.Lookup.LookupID != "" || len(field.Lookup.Static) > 0)
+}
+
+// indexSubmitFields records the form's option-backed and masked fields under
+// each command the form submits to. A field shared by several forms of the
+// same command is recorded once; the validator rejects forms that give it
+// different options or masks.
+func (s *snapshot) indexSubmitFields(f model.FormDefinition) {
+	commands := submitCommands(f)
+
+	add := func(index map[string][]model.FieldDefinition, field model.FieldDefinition) {
+		for _, cmd := range commands {
+			if !slices.ContainsFunc(index[cmd], func(existing model.FieldDefinition) bool {
+				return existing.Field == field.Field
+			}) {
+				index[cmd] = append(index[cmd], field)
+			}
+		}
+	}
+	for _, sec := range f.Sections {
+		for _, field := range sec.Fields {
+			if hasOptions(field) {
+				add(s.optionFields, field)
+			}
+			if field.Mask != nil {
+				add(s.maskedFields, field)
+			}
+		}
+	}
+}
+
+// SetWarnings records the validation warnings for the current definitions,
+// so they can be reported after startup.
+func (r *Registry) SetWarnings(warnings []VError) {
+	r.warnings.Store(&warnings)
+}
+
+// Warnings returns the validation warnings recorded by SetWarnings.
+func (r *Registry) Warnings() []VError {
+	if w := r.warnings.Load(); w != nil {
+		return *w
+	}
+	return nil
+}
+
+// SetCommandDisabled disables or re-enables a command at runtime, e.g.
+// during an incident. The command need not be defined.
+func (r *Registry) SetCommandDisabled(commandID string, disabled bool) {
+	if disabled {
+		r.disabled.Store(commandID, struct{}{})
+	} else {
+		r.disabled.Delete(commandID)
+	}
+}
+
+// CommandDisabled reports whether a command is disabled at runtime.
+func (r *Registry) CommandDisabled(commandID string) bool {
+	_, ok := r.disabled.Load(commandID)
+	return ok
+}
+
+// DisabledCommands returns the IDs of the commands disabled at runtime,
+// sorted.
+func (r *Registry) DisabledCommands() []string {
+	ids := []string{}
+	r.disabled.Range(func(k, _ any) bool {
+		ids = append(ids, k.(string))
+		return true
+	})
+	sort.Strings(ids)
+	return ids
+}
+
 func (r *Registry) current() *snapshot {
 	return r.snap.Load()
 }
@@ -116,23 +235,62 @@ func (r *Registry) GetLookup(lookupID string) (model.LookupDefinition, bool) {
 	return l, ok
 }
 
-// AllDomains returns all domain definitions.
+// PageDomain returns the domain that defines the given page.
+func (r *Registry) PageDomain(pageID string) string {
+	return r.current().pageDomains[pageID]
+}
+
+// FormDomain returns the domain that defines the given form.
+func (r *Registry) FormDomain(formID string) string {
+	return r.current().formDomains[formID]
+}
+
+// CommandDomain returns the domain that defines the given command.
+func (r *Registry) CommandDomain(commandID string) string {
+	return r.current().commandDomains[commandID]
+}
+
+// LookupDomain returns the domain that defines the given lookup.
+func (r *Registry) LookupDomain(lookupID string) string {
+	return r.current().lookupDomains[lookupID]
+}
+
+// OptionFields returns the fields with static or lookup options on the forms
+// that submit to the given command.
+func (r *Registry) OptionFields(commandID string) []model.FieldDefinition {
+	return r.current().optionFields[commandID]
+}
+
+// MaskedFields returns the masked fields on the forms that submit to the
+// given command.
+func (r *Registry) MaskedFields(commandID string) []model.FieldDefinition {
+	return r.current().maskedFields[commandID]
+}
+
+// AllDomains returns all domain definitions, ordered by domain ID so that
+// descriptors built from them are stable across calls.
 func (r *Registry) AllDomains() []model.DomainDefinition {
 	s := r.current()
 	defs := make([]model.DomainDefinition, 0, len(s.domains))
 	for _, d := range s.domains {
 		defs = append(defs, d)
 	}
+	slices.SortFunc(defs, func(a, b model.DomainDefinition) int {
+		return strings.Compare(a.Domain, b.Domain)
+	})
 	return defs
 }
 
-// AllSearches returns all search definitions.
+// AllSearches returns all search definitions, ordered by ID.
 func (r *Registry) AllSearches() []model.SearchDefinition {
 	s := r.current()
 	defs := make([]model.SearchDefinition, 0, len(s.searches))
 	for _, sr := range s.searches {
 		defs = append(defs, sr)
 	}
+	slices.SortFunc(defs, func(a, b model.SearchDefinition) int {
+		return strings.Compare(a.ID, b.ID)
+	})
 	return defs
 }
 
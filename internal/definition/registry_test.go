@@ -207,3 +207,40 @@ func TestRegistry_ConcurrentReadWrite(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestRegistry_OptionFields(t *testing.T) {
+	priority := model.FieldDefinition{Field: "priority", Type: "select", Lookup: &model.LookupRefDefinition{
+		Static: []model.StaticOption{{Label: "Low", Value: "low"}},
+	}}
+	currency := model.FieldDefinition{Field: "currency", Type: "select", Lookup: &model.LookupRefDefinition{LookupID: "currencies"}}
+	reg := NewRegistry([]model.DomainDefinition{{
+		Domain: "orders",
+		Forms: []model.FormDefinition{
+			{
+				ID:            "orders.create",
+				SubmitCommand: "orders.create",
+				Sections: []model.SectionDefinition{{Fields: []model.FieldDefinition{
+					{Field: "notes", Type: "text"},
+					priority,
+					currency,
+				}}},
+			},
+			{
+				ID:            "orders.quick",
+				SubmitActions: []model.SubmitActionDefinition{{ID: "save", CommandID: "orders.create"}, {ID: "draft", CommandID: "orders.draft"}},
+				Sections:      []model.SectionDefinition{{Fields: []model.FieldDefinition{priority}}},
+			},
+		},
+	}})
+
+	fields := reg.OptionFields("orders.create")
+	if len(fields) != 2 || fields[0].Field != "priority" || fields[1].Field != "currency" {
+		t.Errorf("OptionFields(orders.create) = %+v, want priority and currency once each", fields)
+	}
+	if fields := reg.OptionFields("orders.draft"); len(fields) != 1 || fields[0].Field != "priority" {
+		t.Errorf("OptionFields(orders.draft) = %+v, want priority from the submit action", fields)
+	}
+	if fields := reg.OptionFields("orders.cancel"); len(fields) != 0 {
+		t.Errorf("OptionFields(orders.cancel) = %+v, want none", fields)
+	}
+}
@@ -0,0 +1,151 @@
+package definition
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/pitabwire/thesa/internal/openapi"
+	"github.com/pitabwire/thesa/model"
+)
+
+const meterName = "github.com/pitabwire/thesa/internal/definition"
+
+// Reload failure reasons recorded on the failures counter.
+const (
+	ReloadReasonLoad       = "load_error"
+	ReloadReasonValidation = "validation_error"
+)
+
+// reloadMetrics holds the instruments recorded by a Reloader.
+type reloadMetrics struct {
+	attempts       metric.Int64Counter
+	successes      metric.Int64Counter
+	failures       metric.Int64Counter
+	domainsChanged metric.Int64Counter
+}
+
+// Reloader reloads definitions from disk, validates them, and swaps them
+// into a Registry. Each reload records attempt, success and failure counters
+// (failures by reason) and the number of domains added, removed or changed.
+// The current registry checksum is exported as an observable gauge.
+type Reloader struct {
+	loader      *Loader
+	validator   *Validator
+	registry    *Registry
+	index       *openapi.Index
+	directories []string
+	metrics     reloadMetrics
+}
+
+// NewReloader creates a Reloader for the given registry. The validator should
+// be configured the same way as the one used at startup, so a reload applies
+// the same checks. The index may be nil to skip OpenAPI checks. If meter is
+// nil, the global meter provider is used.
+func NewReloader(
+	registry *Registry,
+	validator *Validator,
+	index *openapi.Index,
+	directories []string,
+	meter metric.Meter,
+) (*Reloader, error) {
+	if meter == nil {
+		meter = otel.Meter(meterName)
+	}
+
+	r := &Reloader{
+		loader:      NewLoader(),
+		validator:   validator,
+		registry:    registry,
+		index:       index,
+		directories: directories,
+	}
+
+	var err error
+	if r.metrics.attempts, err = meter.Int64Counter("thesa.definitions.reload.attempts",
+		metric.WithDescription("Number of definition reload attempts.")); err != nil {
+		return nil, fmt.Errorf("definition: reload metrics: %w", err)
+	}
+	if r.metrics.successes, err = meter.Int64Counter("thesa.definitions.reload.successes",
+		metric.WithDescription("Number of successful definition reloads.")); err != nil {
+		return nil, fmt.Errorf("definition: reload metrics: %w", err)
+	}
+	if r.metrics.failures, err = meter.Int64Counter("thesa.definitions.reload.failures",
+		metric.WithDescription("Number of failed definition reloads, by reason.")); err != nil {
+		return nil, fmt.Errorf("definition: reload metrics: %w", err)
+	}
+	if r.metrics.domainsChanged, err = meter.Int64Counter("thesa.definitions.reload.domains_changed",
+		metric.WithDescription("Number of domains added, removed or changed by reloads.")); err != nil {
+		return nil, fmt.Errorf("definition: reload metrics: %w", err)
+	}
+
+	_, err = meter.Int64ObservableGauge("thesa.definitions.checksum",
+		metric.WithDescription("Always 1; the checksum attribute identifies the active definition set."),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, metric.WithAttributes(attribute.String("checksum", registry.Checksum())))
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("definition: reload metrics: %w", err)
+	}
+
+	return r, nil
+}
+
+// Reload loads and validates definitions from the configured directories and
+// replaces the registry contents. On failure the registry is left unchanged.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.metrics.attempts.Add(ctx, 1)
+
+	defs, err := r.loader.LoadAll(r.directories)
+	if err != nil {
+		r.recordFailure(ctx, ReloadReasonLoad)
+		return fmt.Errorf("definition: reload: %w", err)
+	}
+
+	verrs, warnings := SplitWarnings(r.validator.Validate(defs, r.index))
+	if len(verrs) > 0 {
+		r.recordFailure(ctx, ReloadReasonValidation)
+		return fmt.Errorf("definition: reload: %d validation errors, first: %w", len(verrs), verrs[0])
+	}
+
+	changed := countChangedDomains(r.registry.AllDomains(), defs)
+	r.registry.Replace(defs)
+	r.registry.SetWarnings(warnings)
+
+	r.metrics.successes.Add(ctx, 1)
+	r.metrics.domainsChanged.Add(ctx, int64(changed))
+	return nil
+}
+
+func (r *Reloader) recordFailure(ctx context.Context, reason string) {
+	r.metrics.failures.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+// countChangedDomains returns the number of domains that were added, removed,
+// or whose checksum differs between the old and new definition sets.
+func countChangedDomains(old, updated []model.DomainDefinition) int {
+	oldSums := make(map[string]string, len(old))
+	for _, d := range old {
+		oldSums[d.Domain] = d.Checksum
+	}
+
+	changed := 0
+	seen := make(map[string]bool, len(updated))
+	for _, d := range updated {
+		seen[d.Domain] = true
+		if sum, ok := oldSums[d.Domain]; !ok || sum != d.Checksum {
+			changed++
+		}
+	}
+	for domain := range oldSums {
+		if !seen[domain] {
+			changed++
+		}
+	}
+	return changed
+}
@@ -0,0 +1,156 @@
+package definition
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestReloader(t *testing.T, dirs []string) (*Reloader, *Registry, *sdkmetric.ManualReader) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	reg := NewRegistry(nil)
+	r, err := NewReloader(reg, NewValidator(), nil, dirs, provider.Meter("test"))
+	if err != nil {
+		t.Fatalf("NewReloader() error = %v", err)
+	}
+	return r, reg, reader
+}
+
+// counterValue returns the summed value of an int64 counter, optionally
+// restricted to data points carrying the given attribute.
+func counterValue(t *testing.T, reader *sdkmetric.ManualReader, name string, attr *attribute.KeyValue) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("%s data type = %T", name, m.Data)
+			}
+			for _, dp := range sum.DataPoints {
+				if attr != nil {
+					if v, ok := dp.Attributes.Value(attr.Key); !ok || v != attr.Value {
+						continue
+					}
+				}
+				total += dp.Value
+			}
+		}
+	}
+	return total
+}
+
+func TestReloader_Reload_success(t *testing.T) {
+	r, reg, reader := newTestReloader(t, []string{"testdata/orders"})
+
+	if err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if _, ok := reg.GetPage("orders.list"); !ok {
+		t.Error("registry not updated after reload")
+	}
+
+	if got := counterValue(t, reader, "thesa.definitions.reload.attempts", nil); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+	if got := counterValue(t, reader, "thesa.definitions.reload.successes", nil); got != 1 {
+		t.Errorf("successes = %d, want 1", got)
+	}
+	if got := counterValue(t, reader, "thesa.definitions.reload.failures", nil); got != 0 {
+		t.Errorf("failures = %d, want 0", got)
+	}
+	if got := counterValue(t, reader, "thesa.definitions.reload.domains_changed", nil); got != 1 {
+		t.Errorf("domains_changed = %d, want 1", got)
+	}
+
+	// Reloading identical definitions changes no domains.
+	if err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("second Reload() error = %v", err)
+	}
+	if got := counterValue(t, reader, "thesa.definitions.reload.domains_changed", nil); got != 1 {
+		t.Errorf("domains_changed after identical reload = %d, want 1", got)
+	}
+}
+
+func TestReloader_Reload_loadFailure(t *testing.T) {
+	r, reg, reader := newTestReloader(t, []string{"testdata/invalid"})
+	before := reg.Checksum()
+
+	if err := r.Reload(context.Background()); err == nil {
+		t.Fatal("Reload() with invalid YAML should return error")
+	}
+	if reg.Checksum() != before {
+		t.Error("registry changed after failed reload")
+	}
+
+	reason := attribute.String("reason", ReloadReasonLoad)
+	if got := counterValue(t, reader, "thesa.definitions.reload.failures", &reason); got != 1 {
+		t.Errorf("failures{reason=load_error} = %d, want 1", got)
+	}
+	if got := counterValue(t, reader, "thesa.definitions.reload.successes", nil); got != 0 {
+		t.Errorf("successes = %d, want 0", got)
+	}
+}
+
+func TestReloader_Reload_validationFailure(t *testing.T) {
+	dir := t.TempDir()
+	// Missing version and navigation.
+	if err := os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("domain: orders\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, _, reader := newTestReloader(t, []string{dir})
+
+	if err := r.Reload(context.Background()); err == nil {
+		t.Fatal("Reload() with invalid definition should return error")
+	}
+
+	reason := attribute.String("reason", ReloadReasonValidation)
+	if got := counterValue(t, reader, "thesa.definitions.reload.failures", &reason); got != 1 {
+		t.Errorf("failures{reason=validation_error} = %d, want 1", got)
+	}
+}
+
+func TestReloader_Reload_usesConfiguredValidator(t *testing.T) {
+	dir := t.TempDir()
+	data, err := yaml.Marshal(mappedDomain(map[string]string{"order_no": "number"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "orders.yaml"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx := loadMappedOAPIIndex(t)
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	lenient, err := NewReloader(NewRegistry(nil), NewValidator(), idx, []string{dir}, meter)
+	if err != nil {
+		t.Fatalf("NewReloader() error = %v", err)
+	}
+	if err := lenient.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v, want only warnings without strict mappings", err)
+	}
+
+	strict, err := NewReloader(NewRegistry(nil), &Validator{Strict: true}, idx, []string{dir}, meter)
+	if err != nil {
+		t.Fatalf("NewReloader() error = %v", err)
+	}
+	if err := strict.Reload(context.Background()); err == nil {
+		t.Fatal("Reload() with strict mappings should reject the unknown response field")
+	}
+}
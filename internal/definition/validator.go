@@ -2,17 +2,26 @@ package definition
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
 
 	"github.com/pitabwire/thesa/internal/openapi"
+	"github.com/pitabwire/thesa/internal/transform"
 	"github.com/pitabwire/thesa/model"
 )
 
-// VError describes a single validation error in a definition.
+// VError describes a single validation error in a definition. Warnings
+// report spec drift (an error only in strict mode) and deprecated
+// constructs; they never fail validation on their own.
 type VError struct {
 	Path    string `json:"path"`
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
 }
 
 func (e VError) Error() string {
@@ -20,7 +29,11 @@ func (e VError) Error() string {
 }
 
 // Validator validates definitions structurally, referentially, and against OpenAPI specs.
-type Validator struct{}
+type Validator struct {
+	// Strict reports response mapping fields missing from the OpenAPI
+	// response schema as errors instead of warnings.
+	Strict bool
+}
 
 // NewValidator creates a new Validator.
 func NewValidator() *Validator {
@@ -28,11 +41,41 @@ func NewValidator() *Validator {
 }
 
 // Validate checks all definitions. The index may be nil to skip OpenAPI checks.
+// Deprecated constructs are reported as warnings; see SplitWarnings.
 func (v *Validator) Validate(defs []model.DomainDefinition, index *openapi.Index) []VError {
 	var errs []VError
 	for i, def := range defs {
 		prefix := fmt.Sprintf("definitions[%d]", i)
 		errs = append(errs, v.validateDomain(prefix, def, index)...)
+		errs = append(errs, v.validateDeprecations(prefix, def)...)
+	}
+	return errs
+}
+
+// SplitWarnings separates warnings from errors.
+func SplitWarnings(verrs []VError) (errs, warnings []VError) {
+	for _, ve := range verrs {
+		if ve.Warning {
+			warnings = append(warnings, ve)
+		} else {
+			errs = append(errs, ve)
+		}
+	}
+	return errs, warnings
+}
+
+// validateOrderOverrides requires each navigation order override to say
+// whom it applies to.
+func validateOrderOverrides(prefix string, overrides []model.OrderOverride) []VError {
+	var errs []VError
+	for i, o := range overrides {
+		if len(o.Roles) == 0 && len(o.Capabilities) == 0 {
+			errs = append(errs, VError{
+				Path:    fmt.Sprintf("%s.order_overrides[%d]", prefix, i),
+				Code:    "REQUIRED",
+				Message: "order override requires roles or capabilities",
+			})
+		}
 	}
 	return errs
 }
@@ -52,6 +95,10 @@ func (v *Validator) validateDomain(prefix string, def model.DomainDefinition, in
 	if len(def.Navigation.Children) == 0 {
 		errs = append(errs, VError{Path: prefix + ".navigation.children", Code: "REQUIRED", Message: "at least one navigation child is required"})
 	}
+	errs = append(errs, validateOrderOverrides(prefix+".navigation", def.Navigation.OrderOverrides)...)
+	for i, child := range def.Navigation.Children {
+		errs = append(errs, validateOrderOverrides(fmt.Sprintf("%s.navigation.children[%d]", prefix, i), child.OrderOverrides)...)
+	}
 
 	// Build lookup sets for referential validation.
 	formIDs := make(map[string]bool)
@@ -75,6 +122,7 @@ func (v *Validator) validateDomain(prefix string, def model.DomainDefinition, in
 		fp := fmt.Sprintf("%s.forms[%d]", prefix, i)
 		errs = append(errs, v.validateForm(fp, f, commandIDs)...)
 	}
+	errs = append(errs, validateSubmitFields(prefix, def.Forms)...)
 	for i, c := range def.Commands {
 		cp := fmt.Sprintf("%s.commands[%d]", prefix, i)
 		errs = append(errs, v.validateCommand(cp, c, def.Domain, index)...)
@@ -129,6 +177,17 @@ func (v *Validator) validatePage(prefix string, p model.PageDefinition, domain s
 		errs = append(errs, v.validateTable(prefix+".table", *p.Table, domain, index)...)
 	}
 
+	for i, sec := range p.Sections {
+		if sec.DataSource == nil {
+			continue
+		}
+		dp := fmt.Sprintf("%s.sections[%d].data_source", prefix, i)
+		if sec.DataSource.OperationID == "" && sec.DataSource.Handler == "" {
+			errs = append(errs, VError{Path: dp + ".operation_id", Code: "REQUIRED", Message: "operation_id or handler is required"})
+		}
+		errs = append(errs, v.validateDataSource(dp, *sec.DataSource, domain, index)...)
+	}
+
 	return errs
 }
 
@@ -143,24 +202,139 @@ func (v *Validator) validateTable(prefix string, t model.TableDefinition, domain
 		errs = append(errs, VError{Path: prefix + ".page_size", Code: "RANGE", Message: "page_size must be 0-200"})
 	}
 
-	// Validate operation_id against OpenAPI index.
-	if index != nil && t.DataSource.OperationID != "" {
-		serviceID := t.DataSource.ServiceID
-		if serviceID == "" {
-			serviceID = domain + "-svc"
+	for i, col := range t.Columns {
+		cp := fmt.Sprintf("%s.columns[%d]", prefix, i)
+		switch col.Compute {
+		case "":
+		case "days_since", "days_until":
+			if col.Source == "" {
+				errs = append(errs, VError{Path: cp + ".source", Code: "REQUIRED", Message: "source is required for computed columns"})
+			}
+		default:
+			errs = append(errs, VError{Path: cp + ".compute", Code: "INVALID_ENUM", Message: fmt.Sprintf("invalid compute %q", col.Compute)})
 		}
-		if _, ok := index.GetOperation(serviceID, t.DataSource.OperationID); !ok {
-			errs = append(errs, VError{
-				Path:    prefix + ".data_source.operation_id",
-				Code:    "OPERATION_NOT_FOUND",
-				Message: fmt.Sprintf("operation %q not found in service %q", t.DataSource.OperationID, serviceID),
-			})
+	}
+
+	filterFields := make(map[string]bool, len(t.Filters))
+	for _, f := range t.Filters {
+		filterFields[f.Field] = true
+	}
+	for i, f := range t.Filters {
+		if f.Options == nil || len(f.Options.Params) == 0 {
+			continue
+		}
+		fp := fmt.Sprintf("%s.filters[%d].options", prefix, i)
+		if f.Options.LookupID == "" {
+			errs = append(errs, VError{Path: fp + ".lookup_id", Code: "REQUIRED", Message: "lookup_id is required for parameterized options"})
+		}
+		params := make([]string, 0, len(f.Options.Params))
+		for param := range f.Options.Params {
+			params = append(params, param)
+		}
+		sort.Strings(params)
+		for _, param := range params {
+			if dep := f.Options.Params[param]; dep == f.Field || !filterFields[dep] {
+				errs = append(errs, VError{Path: fp + ".params." + param, Code: "REF_NOT_FOUND", Message: fmt.Sprintf("%q is not another filter in this table", dep)})
+			}
+		}
+	}
+
+	errs = append(errs, v.validateDataSource(prefix+".data_source", t.DataSource, domain, index)...)
+
+	return errs
+}
+
+// validateDataSource checks a table or section data source's operation_id
+// against the OpenAPI index and its response mapping against the
+// operation's response schema. The service defaults to "<domain>-svc".
+func (v *Validator) validateDataSource(prefix string, ds model.DataSourceDefinition, domain string, index *openapi.Index) []VError {
+	if index == nil || ds.OperationID == "" {
+		return nil
+	}
+	serviceID := ds.ServiceID
+	if serviceID == "" {
+		serviceID = domain + "-svc"
+	}
+	if _, ok := index.GetOperation(serviceID, ds.OperationID); !ok {
+		return []VError{{
+			Path:    prefix + ".operation_id",
+			Code:    "OPERATION_NOT_FOUND",
+			Message: fmt.Sprintf("operation %q not found in service %q", ds.OperationID, serviceID),
+		}}
+	}
+	schema := index.ResponseSchema(serviceID, ds.OperationID)
+	return v.validateResponseMapping(prefix+".mapping", ds.Mapping, schema)
+}
+
+// validateResponseMapping cross-checks a response mapping against the
+// operation's response schema. At least one items and one total path
+// candidate must be a declared property; field_map sources must be
+// declared properties of the item schema. Free-form schemas (no declared
+// properties) are not checked.
+func (v *Validator) validateResponseMapping(prefix string, m model.ResponseMappingDefinition, schema *openapi3.Schema) []VError {
+	if schema == nil || len(schema.Properties) == 0 {
+		return nil
+	}
+
+	var errs []VError
+	missing := func(path, message string) {
+		errs = append(errs, VError{Path: path, Code: "RESPONSE_FIELD_NOT_FOUND", Message: message, Warning: !v.Strict})
+	}
+
+	var item *openapi3.Schema
+	if candidates := m.ItemsCandidates(); len(candidates) > 0 {
+		found := false
+		for _, path := range candidates {
+			if items, ok := openapi.SchemaPath(schema, path); ok {
+				found = true
+				if items.Items != nil {
+					item = items.Items.Value
+				}
+				break
+			}
+		}
+		if !found {
+			missing(prefix+".items_path", notFoundMessage("items_path", candidates))
+		}
+	}
+	if candidates := m.TotalCandidates(); len(candidates) > 0 {
+		found := false
+		for _, path := range candidates {
+			if _, ok := openapi.SchemaPath(schema, path); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing(prefix+".total_path", notFoundMessage("total_path", candidates))
+		}
+	}
+
+	if item != nil && len(item.Properties) > 0 {
+		sources := make([]string, 0, len(m.FieldMap))
+		for source := range m.FieldMap {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		for _, source := range sources {
+			if _, ok := item.Properties[source]; !ok {
+				missing(prefix+".field_map."+source, fmt.Sprintf("field %q not found in response item schema", source))
+			}
 		}
 	}
 
 	return errs
 }
 
+// notFoundMessage describes response mapping path candidates of which none
+// exist in the response schema.
+func notFoundMessage(field string, candidates []string) string {
+	if len(candidates) == 1 {
+		return fmt.Sprintf("%s %q not found in response schema", field, candidates[0])
+	}
+	return fmt.Sprintf("none of the %s candidates %q found in response schema", field, candidates)
+}
+
 func (v *Validator) validateForm(prefix string, f model.FormDefinition, commandIDs map[string]bool) []VError {
 	var errs []VError
 
@@ -170,19 +344,99 @@ func (v *Validator) validateForm(prefix string, f model.FormDefinition, commandI
 	if f.Title == "" {
 		errs = append(errs, VError{Path: prefix + ".title", Code: "REQUIRED", Message: "title is required"})
 	}
-	if f.SubmitCommand == "" {
-		errs = append(errs, VError{Path: prefix + ".submit_command", Code: "REQUIRED", Message: "submit_command is required"})
-	} else if !commandIDs[f.SubmitCommand] {
+	if f.SubmitCommand == "" && len(f.SubmitActions) == 0 {
+		errs = append(errs, VError{Path: prefix + ".submit_command", Code: "REQUIRED", Message: "submit_command or submit_actions is required"})
+	} else if f.SubmitCommand != "" && !commandIDs[f.SubmitCommand] {
 		errs = append(errs, VError{
 			Path:    prefix + ".submit_command",
 			Code:    "REF_NOT_FOUND",
 			Message: fmt.Sprintf("command %q not found in domain", f.SubmitCommand),
 		})
 	}
+	seen := make(map[string]bool, len(f.SubmitActions))
+	for i, a := range f.SubmitActions {
+		ap := fmt.Sprintf("%s.submit_actions[%d]", prefix, i)
+		if a.ID == "" {
+			errs = append(errs, VError{Path: ap + ".id", Code: "REQUIRED", Message: "id is required"})
+		} else if seen[a.ID] {
+			errs = append(errs, VError{Path: ap + ".id", Code: "DUPLICATE_ID", Message: fmt.Sprintf("submit action %q is declared more than once", a.ID)})
+		}
+		seen[a.ID] = true
+		if a.Label == "" {
+			errs = append(errs, VError{Path: ap + ".label", Code: "REQUIRED", Message: "label is required"})
+		}
+		if a.CommandID == "" {
+			errs = append(errs, VError{Path: ap + ".command_id", Code: "REQUIRED", Message: "command_id is required"})
+		} else if !commandIDs[a.CommandID] {
+			errs = append(errs, VError{
+				Path:    ap + ".command_id",
+				Code:    "REF_NOT_FOUND",
+				Message: fmt.Sprintf("command %q not found in domain", a.CommandID),
+			})
+		}
+	}
 	if len(f.Sections) == 0 {
 		errs = append(errs, VError{Path: prefix + ".sections", Code: "REQUIRED", Message: "at least one section is required"})
 	}
+	for i, sec := range f.Sections {
+		for j, field := range sec.Fields {
+			m := field.Mask
+			if m == nil {
+				continue
+			}
+			mp := fmt.Sprintf("%s.sections[%d].fields[%d].mask", prefix, i, j)
+			if m.ShowLast < 0 {
+				errs = append(errs, VError{Path: mp + ".show_last", Code: "RANGE", Message: "show_last must not be negative"})
+			}
+			if m.Char != "" && utf8.RuneCountInString(m.Char) != 1 {
+				errs = append(errs, VError{Path: mp + ".char", Code: "INVALID_VALUE", Message: "char must be a single character"})
+			}
+		}
+	}
+
+	return errs
+}
 
+// validateSubmitFields rejects forms that submit the same command field with
+// different options or masks. Submitted values are checked against one set
+// per command field, so the forms must agree on it.
+func validateSubmitFields(prefix string, forms []model.FormDefinition) []VError {
+	type fieldSource struct {
+		path   string
+		lookup *model.LookupRefDefinition
+		mask   *model.MaskDefinition
+	}
+	var errs []VError
+	seen := make(map[[2]string]fieldSource)
+	for i, f := range forms {
+		commands := submitCommands(f)
+		for j, sec := range f.Sections {
+			for k, field := range sec.Fields {
+				src := fieldSource{
+					path: fmt.Sprintf("%s.forms[%d].sections[%d].fields[%d]", prefix, i, j, k),
+					mask: field.Mask,
+				}
+				if hasOptions(field) {
+					src.lookup = field.Lookup
+				}
+				for _, cmd := range commands {
+					key := [2]string{cmd, field.Field}
+					prev, ok := seen[key]
+					if !ok {
+						seen[key] = src
+						continue
+					}
+					if !reflect.DeepEqual(prev.lookup, src.lookup) || !reflect.DeepEqual(prev.mask, src.mask) {
+						errs = append(errs, VError{
+							Path:    src.path,
+							Code:    "CONFLICT",
+							Message: fmt.Sprintf("field %q of command %q has different options or mask than %s", field.Field, cmd, prev.path),
+						})
+					}
+				}
+			}
+		}
+	}
 	return errs
 }
 
@@ -193,31 +447,141 @@ func (v *Validator) validateCommand(prefix string, c model.CommandDefinition, do
 		errs = append(errs, VError{Path: prefix + ".id", Code: "REQUIRED", Message: "id is required"})
 	}
 
-	opType := c.Operation.Type
+	errs = append(errs, validateOperation(prefix+".operation", c.Operation, domain, index)...)
+	errs = append(errs, validateInputMapping(prefix+".input", c.Input)...)
+	for i, co := range c.Operations {
+		cp := fmt.Sprintf("%s.operations[%d]", prefix, i)
+		if len(co.When) == 0 {
+			errs = append(errs, VError{Path: cp + ".when", Code: "REQUIRED", Message: "at least one condition is required"})
+		}
+		errs = append(errs, validateOperation(cp+".operation", co.Operation, domain, index)...)
+	}
+
+	if es := c.Output.ExpectedStatus; es != 0 && (es < 200 || es > 299) {
+		errs = append(errs, VError{Path: prefix + ".output.expected_status", Code: "RANGE", Message: "expected_status must be a 2xx status"})
+	}
+	switch c.Output.OnUnexpectedStatus {
+	case "", "warn", "fail":
+	default:
+		errs = append(errs, VError{Path: prefix + ".output.on_unexpected_status", Code: "INVALID_ENUM", Message: fmt.Sprintf("invalid on_unexpected_status %q", c.Output.OnUnexpectedStatus)})
+	}
+	switch c.Output.Merge {
+	case "", model.MergeBackendWins, model.MergeInputWins, model.MergeErrorOnConflict:
+	default:
+		errs = append(errs, VError{Path: prefix + ".output.merge", Code: "INVALID_ENUM", Message: fmt.Sprintf("invalid merge strategy %q", c.Output.Merge)})
+	}
+	for i, rule := range c.Output.SuccessMessages {
+		rp := fmt.Sprintf("%s.output.success_messages[%d]", prefix, i)
+		if rule.Message == "" {
+			errs = append(errs, VError{Path: rp + ".message", Code: "REQUIRED", Message: "message is required"})
+		}
+		if len(rule.When) == 0 {
+			errs = append(errs, VError{Path: rp + ".when", Code: "REQUIRED", Message: "at least one condition is required"})
+		}
+	}
+	if c.Output.Resource != nil && len(c.Output.Resource.Fields) == 0 {
+		errs = append(errs, VError{Path: prefix + ".output.resource.fields", Code: "REQUIRED", Message: "at least one resource field is required"})
+	}
+	if c.Output.Created != nil && c.Output.Created.ResourceType == "" {
+		errs = append(errs, VError{Path: prefix + ".output.created.resource_type", Code: "REQUIRED", Message: "resource_type is required"})
+	}
+	if a := c.Output.Audit; a != nil {
+		if a.Before == "" || a.After == "" {
+			errs = append(errs, VError{Path: prefix + ".output.audit", Code: "REQUIRED", Message: "before and after paths are required"})
+		}
+		if len(a.Fields) == 0 {
+			errs = append(errs, VError{Path: prefix + ".output.audit.fields", Code: "REQUIRED", Message: "at least one audited field is required"})
+		}
+	}
+	if c.Limits != nil {
+		if c.Limits.MaxArrayLength < 0 {
+			errs = append(errs, VError{Path: prefix + ".limits.max_array_length", Code: "RANGE", Message: "max_array_length must not be negative"})
+		}
+		if c.Limits.MaxFields < 0 {
+			errs = append(errs, VError{Path: prefix + ".limits.max_fields", Code: "RANGE", Message: "max_fields must not be negative"})
+		}
+	}
+	if c.Async {
+		streams := c.Operation.StreamResponse
+		for _, op := range c.Operations {
+			streams = streams || op.Operation.StreamResponse
+		}
+		if c.Upload || streams {
+			errs = append(errs, VError{Path: prefix + ".async", Code: "INVALID_VALUE", Message: "async commands cannot accept uploads or stream responses"})
+		}
+	}
+
+	return errs
+}
+
+// validateInputMapping checks that the transform pipelines of the source
+// expressions in an input mapping parse, so an unknown transform or a
+// malformed default fails at load time instead of on every submit.
+func validateInputMapping(path string, m model.InputMapping) []VError {
+	var errs []VError
+	for _, group := range []struct {
+		name  string
+		exprs map[string]string
+	}{
+		{"path_params", m.PathParams},
+		{"query_params", m.QueryParams},
+		{"header_params", m.HeaderParams},
+		{"body_template", m.BodyTemplate},
+		{"field_projection", m.FieldProjection},
+	} {
+		keys := make([]string, 0, len(group.exprs))
+		for k := range group.exprs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, _, err := transform.Split(group.exprs[k]); err != nil {
+				errs = append(errs, VError{Path: path + "." + group.name + "." + k, Code: "INVALID_EXPRESSION", Message: err.Error()})
+			}
+		}
+	}
+	return errs
+}
+
+// validateOperation checks a command's operation binding at path and, when
+// an index is given, that its OpenAPI operation exists.
+func validateOperation(path string, op model.OperationBinding, domain string, index *openapi.Index) []VError {
+	var errs []VError
+
+	opType := op.Type
 	if opType == "" {
-		errs = append(errs, VError{Path: prefix + ".operation.type", Code: "REQUIRED", Message: "operation.type is required"})
-	} else if opType != "openapi" && opType != "sdk" {
-		errs = append(errs, VError{Path: prefix + ".operation.type", Code: "INVALID_ENUM", Message: fmt.Sprintf("invalid operation type %q", opType)})
+		errs = append(errs, VError{Path: path + ".type", Code: "REQUIRED", Message: "operation.type is required"})
+	} else if opType != "openapi" && opType != "sdk" && opType != "noop" {
+		errs = append(errs, VError{Path: path + ".type", Code: "INVALID_ENUM", Message: fmt.Sprintf("invalid operation type %q", opType)})
 	}
 
-	if opType == "openapi" && c.Operation.OperationID == "" {
-		errs = append(errs, VError{Path: prefix + ".operation.operation_id", Code: "REQUIRED", Message: "operation_id required for openapi type"})
+	if opType == "openapi" && op.OperationID == "" {
+		errs = append(errs, VError{Path: path + ".operation_id", Code: "REQUIRED", Message: "operation_id required for openapi type"})
 	}
-	if opType == "sdk" && c.Operation.Handler == "" {
-		errs = append(errs, VError{Path: prefix + ".operation.handler", Code: "REQUIRED", Message: "handler required for sdk type"})
+	if opType == "sdk" && op.Handler == "" {
+		errs = append(errs, VError{Path: path + ".handler", Code: "REQUIRED", Message: "handler required for sdk type"})
+	}
+	switch op.ContentType {
+	case "", model.ContentTypeJSON, model.ContentTypeForm:
+	default:
+		errs = append(errs, VError{Path: path + ".content_type", Code: "INVALID_ENUM", Message: fmt.Sprintf("invalid content_type %q", op.ContentType)})
+	}
+	errs = append(errs, validateEnvelope(path+".request_envelope", op.RequestEnvelope)...)
+	if d, err := op.TimeoutDuration(); err != nil || d < 0 {
+		errs = append(errs, VError{Path: path + ".timeout", Code: "INVALID_VALUE", Message: fmt.Sprintf("invalid timeout %q", op.Timeout)})
 	}
 
 	// Validate against OpenAPI index.
-	if index != nil && opType == "openapi" && c.Operation.OperationID != "" {
-		serviceID := c.Operation.ServiceID
+	if index != nil && opType == "openapi" && op.OperationID != "" {
+		serviceID := op.ServiceID
 		if serviceID == "" {
 			serviceID = domain + "-svc"
 		}
-		if _, ok := index.GetOperation(serviceID, c.Operation.OperationID); !ok {
+		if _, ok := index.GetOperation(serviceID, op.OperationID); !ok {
 			errs = append(errs, VError{
-				Path:    prefix + ".operation.operation_id",
+				Path:    path + ".operation_id",
 				Code:    "OPERATION_NOT_FOUND",
-				Message: fmt.Sprintf("operation %q not found in service %q", c.Operation.OperationID, serviceID),
+				Message: fmt.Sprintf("operation %q not found in service %q", op.OperationID, serviceID),
 			})
 		}
 	}
@@ -225,6 +589,34 @@ func (v *Validator) validateCommand(prefix string, c model.CommandDefinition, do
 	return errs
 }
 
+// validateEnvelope checks that every placeholder in a request envelope refers
+// to the mapped body.
+func validateEnvelope(path string, v any) []VError {
+	var errs []VError
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			errs = append(errs, validateEnvelope(path+"."+k, val[k])...)
+		}
+	case []any:
+		for i, child := range val {
+			errs = append(errs, validateEnvelope(fmt.Sprintf("%s[%d]", path, i), child)...)
+		}
+	case string:
+		for _, m := range model.EnvelopePlaceholder.FindAllStringSubmatch(val, -1) {
+			if ref := m[1]; ref != "body" && (!strings.HasPrefix(ref, "body.") || ref == "body.") {
+				errs = append(errs, VError{Path: path, Code: "INVALID_PLACEHOLDER", Message: fmt.Sprintf("placeholder %q must reference body or body.<field>", m[0])})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *Validator) validateActionRef(prefix string, a model.ActionDefinition, formIDs, commandIDs map[string]bool) []VError {
 	var errs []VError
 
@@ -1,6 +1,8 @@
 package definition
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/pitabwire/thesa/internal/openapi"
@@ -155,6 +157,37 @@ func TestValidator_operation_not_found(t *testing.T) {
 	}
 }
 
+func TestValidator_sectionDataSource(t *testing.T) {
+	idx := loadTestOAPIIndex(t)
+	tests := []struct {
+		name     string
+		ds       model.DataSourceDefinition
+		wantCode string
+	}{
+		{"known operation", model.DataSourceDefinition{OperationID: "listOrders", ServiceID: "orders-svc"}, ""},
+		{"unknown operation", model.DataSourceDefinition{OperationID: "nonexistent", ServiceID: "orders-svc"}, "OPERATION_NOT_FOUND"},
+		{"unknown service", model.DataSourceDefinition{OperationID: "listOrders", ServiceID: "shipping-svc"}, "OPERATION_NOT_FOUND"},
+		{"no operation", model.DataSourceDefinition{ServiceID: "orders-svc"}, "REQUIRED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := validDomain()
+			ds := tt.ds
+			def.Pages[0].Sections = []model.SectionDefinition{{ID: "lines", Title: "Lines", DataSource: &ds}}
+			errs, _ := SplitWarnings(NewValidator().Validate([]model.DomainDefinition{def}, idx))
+			if tt.wantCode == "" {
+				if len(errs) > 0 {
+					t.Errorf("errors = %v, want none", errs)
+				}
+				return
+			}
+			if !hasCode(errs, tt.wantCode) {
+				t.Errorf("errors = %v, want %s", errs, tt.wantCode)
+			}
+		})
+	}
+}
+
 func TestValidator_command_operation_not_found(t *testing.T) {
 	v := NewValidator()
 	idx := loadTestOAPIIndex(t)
@@ -166,6 +199,252 @@ func TestValidator_command_operation_not_found(t *testing.T) {
 	}
 }
 
+func TestValidator_command_invalid_expected_status(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Output.ExpectedStatus = 404
+	def.Commands[0].Output.OnUnexpectedStatus = "ignore"
+	errs := v.Validate([]model.DomainDefinition{def}, nil)
+	if !hasCode(errs, "RANGE") {
+		t.Error("expected RANGE error for non-2xx expected_status")
+	}
+	if !hasCode(errs, "INVALID_ENUM") {
+		t.Error("expected INVALID_ENUM error for on_unexpected_status")
+	}
+}
+
+func TestValidator_command_noop(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Operation = model.OperationBinding{Type: "noop"}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Errorf("Validate() = %v, want no errors for noop command", errs)
+	}
+}
+
+func TestValidator_command_invalid_merge(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Output.Merge = "last-wins"
+	errs := v.Validate([]model.DomainDefinition{def}, nil)
+	if !hasCode(errs, "INVALID_ENUM") {
+		t.Error("expected INVALID_ENUM error for output.merge")
+	}
+}
+
+func TestValidator_command_invalid_content_type(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Operation.ContentType = "text/xml"
+	errs := v.Validate([]model.DomainDefinition{def}, nil)
+	if !hasCode(errs, "INVALID_ENUM") {
+		t.Error("expected INVALID_ENUM error for operation.content_type")
+	}
+}
+
+func TestValidator_command_requestEnvelope(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Operation.RequestEnvelope = map[string]any{
+		"method": "orders.create",
+		"params": "{{body}}",
+		"id":     "{{body.id}}",
+	}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); hasCode(errs, "INVALID_PLACEHOLDER") {
+		t.Errorf("valid envelope rejected: %v", errs)
+	}
+
+	def.Commands[0].Operation.RequestEnvelope["meta"] = map[string]any{"user": "{{context.subject_id}}"}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "INVALID_PLACEHOLDER") {
+		t.Error("expected INVALID_PLACEHOLDER error for a non-body placeholder")
+	}
+}
+
+func TestValidator_command_inputTransforms(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Input.FieldProjection = map[string]string{
+		"country":  "input.country | trim | upper",
+		"priority": "input.priority ?? 'normal' | default('normal')",
+	}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid transforms rejected: %v", errs)
+	}
+
+	def.Commands[0].Input.FieldProjection["country"] = "input.country | reverse"
+	def.Commands[0].Input.QueryParams = map[string]string{"limit": "input.limit | default(ten)"}
+	errs := v.Validate([]model.DomainDefinition{def}, nil)
+	var paths []string
+	for _, e := range errs {
+		if e.Code == "INVALID_EXPRESSION" {
+			paths = append(paths, e.Path)
+		}
+	}
+	want := []string{
+		"definitions[0].commands[0].input.query_params.limit",
+		"definitions[0].commands[0].input.field_projection.country",
+	}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("INVALID_EXPRESSION paths = %v, want %v", paths, want)
+	}
+}
+
+func TestValidator_command_successMessages(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Output.SuccessMessages = []model.SuccessMessageRule{
+		{When: []model.ConditionDefinition{{Field: "status", Operator: "eq", Value: "queued"}}, Message: "Order queued for review"},
+	}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid success_messages rejected: %v", errs)
+	}
+
+	def.Commands[0].Output.SuccessMessages = append(def.Commands[0].Output.SuccessMessages, model.SuccessMessageRule{Message: "Done"})
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "REQUIRED") {
+		t.Error("expected REQUIRED error for a success message rule without conditions")
+	}
+}
+
+func TestValidator_command_audit(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Output.Audit = &model.AuditDiff{Before: "before", After: "after", Fields: map[string]string{"status": "status"}}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid audit rejected: %v", errs)
+	}
+
+	def.Commands[0].Output.Audit = &model.AuditDiff{Fields: map[string]string{"status": "status"}}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "REQUIRED") {
+		t.Error("expected REQUIRED error for an audit without snapshot paths")
+	}
+}
+
+func TestValidator_command_timeout(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Operation.Timeout = "60s"
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid timeout rejected: %v", errs)
+	}
+
+	def.Commands[0].Operation.Timeout = "a minute"
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "INVALID_VALUE") {
+		t.Error("expected INVALID_VALUE error for an unparseable timeout")
+	}
+}
+
+func TestValidator_command_async(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Async = true
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid async command rejected: %v", errs)
+	}
+
+	def.Commands[0].Operation.StreamResponse = true
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "INVALID_VALUE") {
+		t.Error("expected INVALID_VALUE error for an async command that streams its response")
+	}
+
+	def = validDomain()
+	def.Commands[0].Async = true
+	def.Commands[0].Operations = []model.ConditionalOperation{{
+		When:      []model.ConditionDefinition{{Field: "type", Operator: "eq", Value: "export"}},
+		Operation: model.OperationBinding{Type: "sdk", Handler: "orders.export", StreamResponse: true},
+	}}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "INVALID_VALUE") {
+		t.Error("expected INVALID_VALUE error for an async command with a streaming conditional operation")
+	}
+}
+
+func TestValidator_command_conditionalOperations(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Operations = []model.ConditionalOperation{{
+		When:      []model.ConditionDefinition{{Field: "type", Operator: "eq", Value: "bulk"}},
+		Operation: model.OperationBinding{Type: "sdk", Handler: "orders.bulkCreate"},
+	}}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid conditional operation rejected: %v", errs)
+	}
+
+	def.Commands[0].Operations[0].When = nil
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "REQUIRED") {
+		t.Error("expected REQUIRED error for a conditional operation without conditions")
+	}
+}
+
+func TestValidator_navigationOrderOverrides(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Navigation.OrderOverrides = []model.OrderOverride{{Roles: []string{"approver"}, Order: 1}}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid order override rejected: %v", errs)
+	}
+
+	def.Navigation.Children[0].OrderOverrides = []model.OrderOverride{{Order: 1}}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "REQUIRED") {
+		t.Error("expected REQUIRED error for an order override without roles or capabilities")
+	}
+}
+
+func TestValidator_filterOptionParams(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Pages[0].Table.Filters = []model.FilterDefinition{
+		{Field: "region", Label: "Region", Type: "select"},
+		{Field: "status", Label: "Status", Type: "select", Options: &model.FilterOptionsDefinition{
+			LookupID: "orders.statuses",
+			Params:   map[string]string{"region": "region"},
+		}},
+	}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Fatalf("valid filter params rejected: %v", errs)
+	}
+
+	def.Pages[0].Table.Filters[1].Options.Params = map[string]string{"region": "country"}
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); !hasCode(errs, "REF_NOT_FOUND") {
+		t.Error("expected REF_NOT_FOUND for a parameter supplied by an unknown filter")
+	}
+}
+
+func TestValidator_deprecatedOperatorWarns(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Pages[0].Actions = append(def.Pages[0].Actions, model.ActionDefinition{
+		ID:         "archive",
+		Label:      "Archive",
+		Type:       "navigate",
+		NavigateTo: "/archive",
+		Conditions: []model.ConditionDefinition{{Field: "status", Operator: "==", Value: "closed", Effect: "show"}},
+	})
+
+	errs, warnings := SplitWarnings(v.Validate([]model.DomainDefinition{def}, nil))
+	if len(errs) > 0 {
+		t.Errorf("errors = %v, want none for a deprecated operator", errs)
+	}
+	if !hasCode(warnings, "DEPRECATED") {
+		t.Errorf("warnings = %v, want a DEPRECATED warning", warnings)
+	}
+
+	for _, op := range []string{"eq", "neq"} {
+		def.Pages[0].Actions[len(def.Pages[0].Actions)-1].Conditions[0].Operator = op
+		if _, warnings := SplitWarnings(v.Validate([]model.DomainDefinition{def}, nil)); hasCode(warnings, "DEPRECATED") {
+			t.Errorf("warnings = %v, want none for the documented operator %q", warnings, op)
+		}
+	}
+}
+
+func TestValidator_command_negative_limits(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Commands[0].Limits = &model.InputLimits{MaxArrayLength: -1}
+	errs := v.Validate([]model.DomainDefinition{def}, nil)
+	if !hasCode(errs, "RANGE") {
+		t.Error("expected RANGE error for limits.max_array_length")
+	}
+}
+
 func TestValidator_form_missing_command(t *testing.T) {
 	v := NewValidator()
 	def := validDomain()
@@ -176,6 +455,67 @@ func TestValidator_form_missing_command(t *testing.T) {
 	}
 }
 
+func TestValidator_form_submit_actions(t *testing.T) {
+	v := NewValidator()
+	def := validDomain()
+	def.Forms[0].SubmitActions = []model.SubmitActionDefinition{
+		{ID: "save", Label: "Save", CommandID: def.Forms[0].SubmitCommand},
+	}
+	def.Forms[0].SubmitCommand = ""
+	if errs := v.Validate([]model.DomainDefinition{def}, nil); len(errs) > 0 {
+		t.Errorf("Validate() = %v, want no errors for submit actions", errs)
+	}
+
+	def.Forms[0].SubmitActions = append(def.Forms[0].SubmitActions,
+		model.SubmitActionDefinition{ID: "save", Label: "Submit", CommandID: "nonexistent.command"})
+	errs := v.Validate([]model.DomainDefinition{def}, nil)
+	if !hasCode(errs, "REF_NOT_FOUND") {
+		t.Error("expected REF_NOT_FOUND error for submit action command_id")
+	}
+	if !hasCode(errs, "DUPLICATE_ID") {
+		t.Error("expected DUPLICATE_ID error for repeated submit action id")
+	}
+}
+
+func TestValidator_form_sharedSubmitFields(t *testing.T) {
+	statusField := func(values ...string) model.FieldDefinition {
+		f := model.FieldDefinition{Field: "status", Label: "Status", Type: "select", Lookup: &model.LookupRefDefinition{}}
+		for _, v := range values {
+			f.Lookup.Static = append(f.Lookup.Static, model.StaticOption{Label: v, Value: v})
+		}
+		return f
+	}
+	tests := []struct {
+		name      string
+		other     model.FieldDefinition
+		wantError bool
+	}{
+		{name: "same options", other: statusField("open", "closed"), wantError: false},
+		{name: "different options", other: statusField("open"), wantError: true},
+		{name: "options only on one form", other: model.FieldDefinition{Field: "status", Label: "Status", Type: "text"}, wantError: true},
+		{name: "mask only on one form", other: func() model.FieldDefinition {
+			f := statusField("open", "closed")
+			f.Mask = &model.MaskDefinition{ShowLast: 2}
+			return f
+		}(), wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := validDomain()
+			def.Forms[0].Sections[0].Fields = append(def.Forms[0].Sections[0].Fields, statusField("open", "closed"))
+			quick := def.Forms[0]
+			quick.ID = "orders.quick_form"
+			quick.Sections = []model.SectionDefinition{{ID: "main", Fields: []model.FieldDefinition{tt.other}}}
+			def.Forms = append(def.Forms, quick)
+
+			errs := NewValidator().Validate([]model.DomainDefinition{def}, nil)
+			if got := hasCode(errs, "CONFLICT"); got != tt.wantError {
+				t.Errorf("CONFLICT error = %v, want %v; errs = %v", got, tt.wantError, errs)
+			}
+		})
+	}
+}
+
 func TestValidator_capability_invalid_format(t *testing.T) {
 	v := NewValidator()
 	def := validDomain()
@@ -204,3 +544,151 @@ func hasCode(errs []VError, code string) bool {
 	}
 	return false
 }
+
+// mappedOrdersSpec declares a response schema for listOrders so response
+// mappings can be cross-checked.
+const mappedOrdersSpec = `openapi: "3.0.3"
+info:
+  title: Orders
+  version: "1.0"
+paths:
+  /orders:
+    get:
+      operationId: listOrders
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  data:
+                    type: object
+                    properties:
+                      total:
+                        type: integer
+                      orders:
+                        type: array
+                        items:
+                          type: object
+                          properties:
+                            id:
+                              type: string
+                            order_number:
+                              type: string
+`
+
+func loadMappedOAPIIndex(t *testing.T) *openapi.Index {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "orders-svc.yaml")
+	if err := os.WriteFile(path, []byte(mappedOrdersSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx := openapi.NewIndex()
+	if err := idx.Load([]openapi.SpecSource{{ServiceID: "orders-svc", SpecPath: path}}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return idx
+}
+
+func mappedDomain(fieldMap map[string]string) model.DomainDefinition {
+	def := validDomain()
+	def.Commands = nil
+	def.Forms = nil
+	def.Pages[0].Table.DataSource.Mapping = model.ResponseMappingDefinition{
+		ItemsPath: "data.orders",
+		TotalPath: "data.total",
+		FieldMap:  fieldMap,
+	}
+	return def
+}
+
+func TestValidator_responseMapping_valid(t *testing.T) {
+	v := &Validator{Strict: true}
+	def := mappedDomain(map[string]string{"order_number": "number"})
+	errs := v.Validate([]model.DomainDefinition{def}, loadMappedOAPIIndex(t))
+	if len(errs) > 0 {
+		t.Fatalf("Validate() = %v, want no errors", errs)
+	}
+}
+
+func TestValidator_responseMapping_missingFieldStrict(t *testing.T) {
+	v := &Validator{Strict: true}
+	def := mappedDomain(map[string]string{"order_no": "number"})
+	errs, warnings := SplitWarnings(v.Validate([]model.DomainDefinition{def}, loadMappedOAPIIndex(t)))
+	if !hasCode(errs, "RESPONSE_FIELD_NOT_FOUND") {
+		t.Errorf("errors = %v, want RESPONSE_FIELD_NOT_FOUND", errs)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none in strict mode", warnings)
+	}
+}
+
+func TestValidator_responseMapping_missingFieldWarns(t *testing.T) {
+	v := NewValidator()
+	def := mappedDomain(map[string]string{"order_no": "number"})
+	def.Pages[0].Table.DataSource.Mapping.TotalPath = "meta.total"
+	errs, warnings := SplitWarnings(v.Validate([]model.DomainDefinition{def}, loadMappedOAPIIndex(t)))
+	if len(errs) != 0 {
+		t.Errorf("errors = %v, want none outside strict mode", errs)
+	}
+	if len(warnings) != 2 || !hasCode(warnings, "RESPONSE_FIELD_NOT_FOUND") {
+		t.Errorf("warnings = %v, want 2 RESPONSE_FIELD_NOT_FOUND", warnings)
+	}
+}
+
+func TestValidator_operationAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders-svc.yaml")
+	if err := os.WriteFile(path, []byte(mappedOrdersSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx := openapi.NewIndex()
+	if err := idx.Load([]openapi.SpecSource{{
+		ServiceID: "orders-svc",
+		SpecPath:  path,
+		Aliases:   map[string]string{"orders.list": "listOrders"},
+	}}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	v := &Validator{Strict: true}
+	def := mappedDomain(map[string]string{"order_number": "number"})
+	def.Pages[0].Table.DataSource.OperationID = "orders.list"
+	if errs := v.Validate([]model.DomainDefinition{def}, idx); len(errs) > 0 {
+		t.Fatalf("Validate() = %v, want the alias to resolve to listOrders", errs)
+	}
+
+	def.Pages[0].Table.DataSource.OperationID = "orders.index"
+	if errs := v.Validate([]model.DomainDefinition{def}, idx); !hasCode(errs, "OPERATION_NOT_FOUND") {
+		t.Errorf("Validate() = %v, want OPERATION_NOT_FOUND for an unknown alias", errs)
+	}
+}
+
+func TestValidator_responseMapping_candidatePaths(t *testing.T) {
+	v := &Validator{Strict: true}
+	def := mappedDomain(nil)
+	mapping := &def.Pages[0].Table.DataSource.Mapping
+	mapping.ItemsPath = "content"
+	mapping.ItemsPaths = []string{"data.orders"}
+	mapping.TotalPath = "totalElements"
+	mapping.TotalPaths = []string{"meta.total", "data.total"}
+	if errs := v.Validate([]model.DomainDefinition{def}, loadMappedOAPIIndex(t)); len(errs) > 0 {
+		t.Fatalf("Validate() = %v, want no errors when a candidate matches", errs)
+	}
+
+	mapping.TotalPaths = []string{"meta.total"}
+	errs := v.Validate([]model.DomainDefinition{def}, loadMappedOAPIIndex(t))
+	if !hasCode(errs, "RESPONSE_FIELD_NOT_FOUND") {
+		t.Errorf("Validate() = %v, want RESPONSE_FIELD_NOT_FOUND when no total candidate matches", errs)
+	}
+}
+
+func TestValidator_responseMapping_undeclaredSchemaSkipped(t *testing.T) {
+	v := &Validator{Strict: true}
+	def := mappedDomain(map[string]string{"anything": "x"})
+	errs := v.Validate([]model.DomainDefinition{def}, loadTestOAPIIndex(t))
+	if hasCode(errs, "RESPONSE_FIELD_NOT_FOUND") {
+		t.Errorf("errors = %v, want no response checks without a response schema", errs)
+	}
+}
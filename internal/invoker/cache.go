@@ -0,0 +1,167 @@
+package invoker
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/thesa/internal/config"
+	"github.com/pitabwire/thesa/model"
+)
+
+// defaultCacheMaxEntries caps a service's response cache when its
+// configuration leaves max_entries unset.
+const defaultCacheMaxEntries = 1000
+
+// responseCache holds successful GET responses of one service for a fixed
+// TTL. Bodies are stored encoded, so callers never share decoded values.
+type responseCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	statusCode int
+	headers    map[string]string
+	body       []byte
+	expiresAt  time.Time
+}
+
+// newResponseCache returns the response cache for cfg, or nil if caching is
+// disabled.
+func newResponseCache(cfg config.CacheConfig) *responseCache {
+	if cfg.TTL <= 0 {
+		return nil
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &responseCache{
+		ttl:        cfg.TTL,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]cachedResponse),
+	}
+}
+
+// cacheKey identifies a request by tenant, partition, subject, operation,
+// URL (path and query parameters) and the values of the service's context
+// headers as sent, so callers never share an entry across anything that
+// can change the response. It returns "" when the input carries headers the
+// key does not cover, and such responses are not cached.
+func cacheKey(
+	operationID, reqURL string,
+	rctx *model.RequestContext,
+	input map[string]string,
+	contextHeaders []config.ContextHeaderConfig,
+	headers http.Header,
+) string {
+	for name := range input {
+		if !strings.EqualFold(name, "Cache-Control") {
+			return ""
+		}
+	}
+
+	var b strings.Builder
+	if rctx != nil {
+		b.WriteString(rctx.TenantID + "|" + rctx.PartitionID + "|" + rctx.SubjectID)
+	}
+	b.WriteString("|" + operationID + "|" + reqURL)
+	for _, ch := range contextHeaders {
+		b.WriteString("|" + headers.Get(ch.Header))
+	}
+	return b.String()
+}
+
+// get returns a copy of the unexpired response stored under key.
+func (c *responseCache) get(key string) (model.InvocationResult, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return model.InvocationResult{}, false
+	}
+
+	result := model.InvocationResult{
+		StatusCode: entry.statusCode,
+		Headers:    maps.Clone(entry.headers),
+	}
+	if entry.body != nil {
+		if err := json.Unmarshal(entry.body, &result.Body); err != nil {
+			return model.InvocationResult{}, false
+		}
+	}
+	return result, true
+}
+
+// put stores result under key if it is a successful response. When the
+// cache is full, expired entries are evicted first, then the entry closest
+// to expiry.
+func (c *responseCache) put(key string, result model.InvocationResult) {
+	if result.StatusCode < 200 || result.StatusCode >= 300 || result.ErrorCode != "" {
+		return
+	}
+	var body []byte
+	if result.Body != nil {
+		var err error
+		if body, err = json.Marshal(result.Body); err != nil {
+			return
+		}
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = cachedResponse{
+		statusCode: result.StatusCode,
+		headers:    maps.Clone(result.Headers),
+		body:       body,
+		expiresAt:  now.Add(c.ttl),
+	}
+}
+
+// evict removes expired entries, or the entry closest to expiry if none
+// have expired. Must be called with mu held.
+func (c *responseCache) evict(now time.Time) {
+	var oldest string
+	var oldestExpiry time.Time
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldest == "" || e.expiresAt.Before(oldestExpiry) {
+			oldest, oldestExpiry = k, e.expiresAt
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+// bypassesCache reports whether the request headers ask for a fresh
+// response with Cache-Control: no-cache.
+func bypassesCache(headers map[string]string) bool {
+	for name, value := range headers {
+		if !strings.EqualFold(name, "Cache-Control") {
+			continue
+		}
+		for directive := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+				return true
+			}
+		}
+	}
+	return false
+}
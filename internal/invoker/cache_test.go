@@ -0,0 +1,178 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/thesa/internal/config"
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestOpenAPIOperationInvoker_Invoke_responseCache(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		_, _ = w.Write([]byte(`{"call":` + strconv.FormatInt(n, 10) + `}`))
+	}))
+	defer server.Close()
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.Cache = config.CacheConfig{TTL: time.Minute}
+	inv := newTestInvoker(t, server.URL, svcCfg)
+
+	invoke := func(tenantID, operationID string, input model.InvocationInput) model.InvocationResult {
+		t.Helper()
+		result, err := inv.Invoke(context.Background(), &model.RequestContext{TenantID: tenantID},
+			model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: operationID}, input)
+		if err != nil {
+			t.Fatalf("Invoke error: %v", err)
+		}
+		return result
+	}
+	call := func(result model.InvocationResult) any {
+		body, _ := result.Body.(map[string]any)
+		return body["call"]
+	}
+
+	first := invoke("t1", "listUsers", model.InvocationInput{})
+	first.Body.(map[string]any)["call"] = "modified"
+	if got := call(invoke("t1", "listUsers", model.InvocationInput{})); got != float64(1) {
+		t.Errorf("repeated GET served call %v, want cached call 1", got)
+	}
+	if got := call(invoke("t2", "listUsers", model.InvocationInput{})); got != float64(2) {
+		t.Errorf("other tenant served call %v, want its own call 2", got)
+	}
+	if got := call(invoke("t1", "listUsers", model.InvocationInput{QueryParams: map[string]string{"page": "2"}})); got != float64(3) {
+		t.Errorf("different query served call %v, want new call 3", got)
+	}
+	if got := call(invoke("t1", "listUsers", model.InvocationInput{Headers: map[string]string{"Cache-Control": "no-cache"}})); got != float64(4) {
+		t.Errorf("no-cache request served call %v, want new call 4", got)
+	}
+	if got := call(invoke("t1", "listUsers", model.InvocationInput{})); got != float64(4) {
+		t.Errorf("GET after no-cache served call %v, want refreshed call 4", got)
+	}
+
+	invoke("t1", "createUser", model.InvocationInput{Body: map[string]any{"name": "a"}})
+	invoke("t1", "createUser", model.InvocationInput{Body: map[string]any{"name": "a"}})
+	if got := calls.Load(); got != 6 {
+		t.Errorf("backend calls = %d, want 6 (POST is never cached)", got)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_responseCacheKey(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"call":` + strconv.FormatInt(n, 10) + `}`))
+	}))
+	defer server.Close()
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.Cache = config.CacheConfig{TTL: time.Minute}
+	svcCfg.ContextHeaders = []config.ContextHeaderConfig{{Header: "X-Plan", Source: "claims.plan"}}
+	inv := newTestInvoker(t, server.URL, svcCfg)
+
+	invoke := func(rctx *model.RequestContext, input model.InvocationInput) any {
+		t.Helper()
+		result, err := inv.Invoke(context.Background(), rctx,
+			model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"}, input)
+		if err != nil {
+			t.Fatalf("Invoke error: %v", err)
+		}
+		body, _ := result.Body.(map[string]any)
+		return body["call"]
+	}
+
+	base := model.RequestContext{TenantID: "t1", PartitionID: "p1", SubjectID: "u1", Claims: map[string]any{"plan": "free"}}
+	otherPartition, otherSubject, otherPlan := base, base, base
+	otherPartition.PartitionID = "p2"
+	otherSubject.SubjectID = "u2"
+	otherPlan.Claims = map[string]any{"plan": "pro"}
+
+	tests := []struct {
+		name  string
+		rctx  model.RequestContext
+		input model.InvocationInput
+		want  float64
+	}{
+		{name: "first request", rctx: base, want: 1},
+		{name: "same caller is cached", rctx: base, want: 1},
+		{name: "partition", rctx: otherPartition, want: 2},
+		{name: "subject", rctx: otherSubject, want: 3},
+		{name: "context header", rctx: otherPlan, want: 4},
+		{name: "uncovered input header", rctx: base, input: model.InvocationInput{Headers: map[string]string{"Accept-Language": "fr"}}, want: 5},
+		{name: "uncovered header is not stored", rctx: base, input: model.InvocationInput{Headers: map[string]string{"Accept-Language": "fr"}}, want: 6},
+	}
+	for _, tt := range tests {
+		if got := invoke(&tt.rctx, tt.input); got != tt.want {
+			t.Errorf("%s: served call %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResponseCache_eviction(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newResponseCache(config.CacheConfig{TTL: time.Minute, MaxEntries: 2})
+	c.now = func() time.Time { return now }
+	ok := model.InvocationResult{StatusCode: http.StatusOK}
+
+	c.put("a", ok)
+	now = now.Add(10 * time.Second)
+	c.put("b", ok)
+	c.put("c", ok)
+	if _, hit := c.get("a"); hit {
+		t.Error("entry closest to expiry should be evicted when full")
+	}
+	if _, hit := c.get("b"); !hit {
+		t.Error("entry b should be cached")
+	}
+
+	now = now.Add(time.Minute)
+	if _, hit := c.get("b"); hit {
+		t.Error("expired entry should not be served")
+	}
+	c.put("d", ok)
+	if len(c.entries) != 1 {
+		t.Errorf("entries = %d, want 1 after expired entries are evicted", len(c.entries))
+	}
+
+	c.put("e", model.InvocationResult{StatusCode: http.StatusNotFound})
+	c.put("f", model.InvocationResult{StatusCode: http.StatusOK, ErrorCode: model.ErrBadRequest})
+	if len(c.entries) != 1 {
+		t.Errorf("entries = %d, want 1: failed responses are not cached", len(c.entries))
+	}
+}
+
+func TestNewResponseCache_disabled(t *testing.T) {
+	if c := newResponseCache(config.CacheConfig{MaxEntries: 10}); c != nil {
+		t.Error("cache without a TTL should be disabled")
+	}
+}
+
+func TestBypassesCache(t *testing.T) {
+	tests := []struct {
+		headers map[string]string
+		want    bool
+	}{
+		{headers: nil},
+		{headers: map[string]string{"Cache-Control": "no-cache"}, want: true},
+		{headers: map[string]string{"cache-control": "max-age=0, No-Cache"}, want: true},
+		{headers: map[string]string{"Cache-Control": "no-store"}},
+	}
+	for _, tt := range tests {
+		if got := bypassesCache(tt.headers); got != tt.want {
+			t.Errorf("bypassesCache(%v) = %v, want %v", tt.headers, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package invoker
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pitabwire/util"
+
+	"github.com/pitabwire/thesa/internal/openapi"
+	"github.com/pitabwire/thesa/model"
+)
+
+// decodedBody reads a response body through a decompressor and closes both.
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	if c, ok := b.Reader.(io.Closer); ok {
+		_ = c.Close()
+	}
+	return b.body.Close()
+}
+
+// decodeBody replaces resp.Body with a decompressing reader when the backend
+// sent it with Content-Encoding gzip or deflate. The HTTP client only does
+// this itself for gzip it asked for. Deflate bodies may be zlib-wrapped, as
+// the spec requires, or raw, as some servers send them.
+func decodeBody(resp *http.Response) error {
+	var decoder io.Reader
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if errors.Is(err, io.EOF) {
+			decoder = http.NoBody
+			break
+		}
+		if err != nil {
+			return err
+		}
+		decoder = zr
+	case "deflate":
+		br := bufio.NewReader(resp.Body)
+		if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return err
+			}
+			decoder = zr
+		} else {
+			decoder = flate.NewReader(br)
+		}
+	default:
+		return nil
+	}
+
+	resp.Body = &decodedBody{Reader: decoder, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return nil
+}
+
+// isZlibHeader reports whether b starts a zlib stream: deflate compression
+// and a header checksum that is a multiple of 31.
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+// isDecodeError reports whether err comes from corrupt compressed data rather
+// than from reading the connection.
+func isDecodeError(err error) bool {
+	var corrupt flate.CorruptInputError
+	return errors.As(err, &corrupt) ||
+		errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) ||
+		errors.Is(err, zlib.ErrHeader) || errors.Is(err, zlib.ErrChecksum) || errors.Is(err, zlib.ErrDictionary)
+}
+
+// decodeFailure logs a response that could not be decompressed and returns
+// the error for it. It is an ErrorEnvelope so the request is not retried:
+// the backend answered, and asking again would not fix its encoding.
+func decodeFailure(ctx context.Context, op openapi.IndexedOperation, err error) error {
+	util.Log(ctx).Warn("invoker: cannot decompress backend response",
+		"operation", op.OperationID,
+		"error", err,
+	)
+	return model.NewInternalError()
+}
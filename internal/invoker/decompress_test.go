@@ -0,0 +1,109 @@
+package invoker
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+func compress(t *testing.T, newWriter func(io.Writer) io.WriteCloser, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenAPIOperationInvoker_Invoke_compressedResponse(t *testing.T) {
+	const payload = `{"id":"u-1","name":"Alice"}`
+	gzipWriter := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	tests := []struct {
+		name      string
+		encoding  string
+		newWriter func(io.Writer) io.WriteCloser
+		// headers are forwarded by the caller. An explicit Accept-Encoding
+		// stops the HTTP client from decompressing gzip itself.
+		headers map[string]string
+	}{
+		{"gzip", "gzip", gzipWriter, nil},
+		{"gzip asked for by the caller", "gzip", gzipWriter, map[string]string{"Accept-Encoding": "gzip"}},
+		{"zlib deflate", "deflate", func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }, nil},
+		{"raw deflate", "Deflate", func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := compress(t, tt.newWriter, payload)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", tt.encoding)
+				_, _ = w.Write(body)
+			}))
+			defer server.Close()
+
+			inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+			result, err := inv.Invoke(context.Background(), nil,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+				model.InvocationInput{Headers: tt.headers})
+			if err != nil {
+				t.Fatalf("Invoke error: %v", err)
+			}
+			got, ok := result.Body.(map[string]any)
+			if !ok || got["id"] != "u-1" || got["name"] != "Alice" {
+				t.Errorf("Body = %v, want the decompressed JSON", result.Body)
+			}
+		})
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_corruptCompressedResponse(t *testing.T) {
+	valid := compress(t, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, `{"id":"u-1"}`)
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"bad header", []byte("not gzip at all")},
+		{"bad checksum", append(valid[:len(valid)-8:len(valid)-8], 0, 0, 0, 0, 0, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", "gzip")
+				_, _ = w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			svcCfg := defaultServiceConfig()
+			svcCfg.Retry.MaxAttempts = 3
+			inv := newTestInvoker(t, server.URL, svcCfg)
+			_, err := inv.Invoke(context.Background(), nil,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+				model.InvocationInput{Headers: map[string]string{"Accept-Encoding": "gzip"}})
+			if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrInternalError {
+				t.Fatalf("error = %v, want %s", err, model.ErrInternalError)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("backend calls = %d, want 1 (decompression failures are not retried)", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package invoker
+
+import (
+	"strings"
+
+	"github.com/pitabwire/thesa/internal/config"
+	"github.com/pitabwire/thesa/model"
+)
+
+// mappedErrorCode returns the error code a backend response maps to under the
+// service's error mapping, or "" to leave the response to the default status
+// handling. 4xx and 5xx responses use the status overrides; 2xx responses
+// only map when their body carries an error at ErrorBodyPath.
+func mappedErrorCode(m config.ErrorMappingConfig, operationID string, result model.InvocationResult) string {
+	status := result.StatusCode
+	if status >= 200 && status < 300 {
+		if m.ErrorBodyPath == "" || !hasErrorBody(result.Body, m.ErrorBodyPath) {
+			return ""
+		}
+		if code := m.CodeFor(operationID, status); code != "" {
+			return code
+		}
+		return model.ErrBadRequest
+	}
+	if status >= 400 {
+		return m.CodeFor(operationID, status)
+	}
+	return ""
+}
+
+// hasErrorBody reports whether body holds a non-empty value at the dot path.
+func hasErrorBody(body any, path string) bool {
+	val := body
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := val.(map[string]any)
+		if !ok {
+			return false
+		}
+		val = obj[key]
+	}
+	switch v := val.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case bool:
+		return v
+	case map[string]any:
+		return len(v) > 0
+	case []any:
+		return len(v) > 0
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pitabwire/thesa/internal/config"
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestOpenAPIOperationInvoker_Invoke_errorMapping(t *testing.T) {
+	mapping := config.ErrorMappingConfig{
+		Statuses:          map[int]string{409: model.ErrValidationError},
+		OperationStatuses: map[string]map[int]string{"createUser": {409: model.ErrConflict}},
+		ErrorBodyPath:     "error",
+	}
+	tests := []struct {
+		name        string
+		operationID string
+		status      int
+		body        string
+		want        string
+	}{
+		{name: "service status override", operationID: "listUsers", status: 409, body: `{"message":"duplicate"}`, want: model.ErrValidationError},
+		{name: "operation status override", operationID: "createUser", status: 409, want: model.ErrConflict},
+		{name: "unmapped status", operationID: "listUsers", status: 404},
+		{name: "200 with error body", operationID: "listUsers", status: 200, body: `{"error":{"code":"INVALID"}}`, want: model.ErrBadRequest},
+		{name: "200 with empty error", operationID: "listUsers", status: 200, body: `{"error":null,"items":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			cfg := defaultServiceConfig()
+			cfg.ErrorMapping = mapping
+			inv := newTestInvoker(t, server.URL, cfg)
+			result, err := inv.Invoke(context.Background(), &model.RequestContext{},
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: tt.operationID},
+				model.InvocationInput{},
+			)
+			if err != nil {
+				t.Fatalf("Invoke error: %v", err)
+			}
+			if result.ErrorCode != tt.want {
+				t.Errorf("ErrorCode = %q, want %q", result.ErrorCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/pitabwire/thesa/internal/openapi"
+	"github.com/pitabwire/thesa/model"
+)
+
+// hedges reports whether requests to op are hedged. Only idempotent
+// operations are, and never streamed ones, whose body outlives the attempt.
+func (s *serviceClient) hedges(op openapi.IndexedOperation, stream bool) bool {
+	return s.cfg.HedgeEnabled && s.cfg.HedgeDelay > 0 && !stream && isIdempotentMethod(op.Method)
+}
+
+type hedgeOutcome struct {
+	result model.InvocationResult
+	err    error
+}
+
+// executeHedged sends the request and, if no response has arrived within
+// the service's HedgeDelay, an identical second one. The first response
+// wins and the other request is cancelled. An error only settles the
+// attempt once no request is left in flight, so a failed request does not
+// discard a hedge that may still succeed; the cancelled loser is never
+// reported.
+func (inv *OpenAPIOperationInvoker) executeHedged(
+	ctx context.Context,
+	svc *serviceClient,
+	op openapi.IndexedOperation,
+	reqURL string,
+	headers http.Header,
+	bodyBytes []byte,
+	timeout time.Duration,
+) (model.InvocationResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make(chan hedgeOutcome, 2)
+	launch := func() {
+		// Each request gets its own headers: send sets per-request ones.
+		h := headers.Clone()
+		go func() {
+			result, err := inv.send(ctx, svc, op, reqURL, h, bodyBytes, timeout, false)
+			outcomes <- hedgeOutcome{result: result, err: err}
+		}()
+	}
+
+	launch()
+	inFlight := 1
+	hedge := time.NewTimer(svc.cfg.HedgeDelay)
+	defer hedge.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-hedge.C:
+			util.Log(ctx).Debug("invoker: hedging slow request",
+				"operation", op.OperationID,
+				"delay", svc.cfg.HedgeDelay,
+			)
+			launch()
+			inFlight++
+		case out := <-outcomes:
+			inFlight--
+			if out.err == nil {
+				return out.result, nil
+			}
+			lastErr = out.err
+			if inFlight == 0 {
+				return model.InvocationResult{}, lastErr
+			}
+		}
+	}
+}
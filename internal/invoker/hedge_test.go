@@ -0,0 +1,88 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestOpenAPIOperationInvoker_Invoke_hedging(t *testing.T) {
+	var calls atomic.Int32
+	loserCancelled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == 1 {
+			// The first request is slow; it should be hedged and cancelled.
+			select {
+			case <-r.Context().Done():
+				loserCancelled <- struct{}{}
+				return
+			case <-time.After(2 * time.Second):
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if n == 1 {
+			_, _ = w.Write([]byte(`{"from":"slow"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"from":"fast"}`))
+	}))
+	defer server.Close()
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.HedgeEnabled = true
+	svcCfg.HedgeDelay = 20 * time.Millisecond
+	inv := newTestInvoker(t, server.URL, svcCfg)
+
+	start := time.Now()
+	result, err := inv.Invoke(context.Background(), nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{})
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if body, _ := result.Body.(map[string]any); body["from"] != "fast" {
+		t.Errorf("body = %v, want the hedged request's response", result.Body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hedged call took %v, want it not to wait for the slow request", elapsed)
+	}
+	select {
+	case <-loserCancelled:
+	case <-time.After(time.Second):
+		t.Error("slow request was not cancelled after the hedge won")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("backend calls = %d, want 2", got)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_hedgingSkipped(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.HedgeEnabled = true
+	svcCfg.HedgeDelay = 5 * time.Millisecond
+	inv := newTestInvoker(t, server.URL, svcCfg)
+
+	_, err := inv.Invoke(context.Background(), nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "createUser"},
+		model.InvocationInput{Body: map[string]any{"name": "a"}})
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("backend calls = %d, want 1: POST is never hedged", got)
+	}
+}
@@ -0,0 +1,95 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/pitabwire/thesa/internal/openapi"
+)
+
+const meterName = "github.com/pitabwire/thesa/internal/invoker"
+
+// SizeRecorder observes backend request and response body sizes, in bytes,
+// per service and operation.
+type SizeRecorder interface {
+	RecordRequestSize(ctx context.Context, serviceID, operationID string, size int64)
+	RecordResponseSize(ctx context.Context, serviceID, operationID string, size int64)
+}
+
+// metricsSizeRecorder records body sizes as OpenTelemetry histograms.
+type metricsSizeRecorder struct {
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+}
+
+// NewMetricsSizeRecorder returns a SizeRecorder backed by the
+// thesa.backend.request.size and thesa.backend.response.size histograms,
+// labeled by service and operation. If meter is nil, the global meter
+// provider is used.
+func NewMetricsSizeRecorder(meter metric.Meter) (SizeRecorder, error) {
+	if meter == nil {
+		meter = otel.Meter(meterName)
+	}
+
+	r := &metricsSizeRecorder{}
+	var err error
+	if r.requestSize, err = meter.Int64Histogram("thesa.backend.request.size",
+		metric.WithDescription("Size of backend request bodies."),
+		metric.WithUnit("By")); err != nil {
+		return nil, fmt.Errorf("invoker: size metrics: %w", err)
+	}
+	if r.responseSize, err = meter.Int64Histogram("thesa.backend.response.size",
+		metric.WithDescription("Size of backend response bodies."),
+		metric.WithUnit("By")); err != nil {
+		return nil, fmt.Errorf("invoker: size metrics: %w", err)
+	}
+	return r, nil
+}
+
+func (r *metricsSizeRecorder) RecordRequestSize(ctx context.Context, serviceID, operationID string, size int64) {
+	r.requestSize.Record(ctx, size, operationAttributes(serviceID, operationID))
+}
+
+func (r *metricsSizeRecorder) RecordResponseSize(ctx context.Context, serviceID, operationID string, size int64) {
+	r.responseSize.Record(ctx, size, operationAttributes(serviceID, operationID))
+}
+
+func operationAttributes(serviceID, operationID string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		attribute.String("service", serviceID),
+		attribute.String("operation", operationID),
+	)
+}
+
+// SetSizeRecorder installs a recorder for request and response body sizes.
+// A nil recorder disables recording.
+func (inv *OpenAPIOperationInvoker) SetSizeRecorder(r SizeRecorder) {
+	inv.sizes = r
+}
+
+func (inv *OpenAPIOperationInvoker) recordRequestSize(ctx context.Context, op openapi.IndexedOperation, size int64) {
+	if inv.sizes != nil {
+		inv.sizes.RecordRequestSize(ctx, op.ServiceID, op.OperationID, size)
+	}
+}
+
+func (inv *OpenAPIOperationInvoker) recordResponseSize(ctx context.Context, op openapi.IndexedOperation, size int64) {
+	if inv.sizes != nil {
+		inv.sizes.RecordResponseSize(ctx, op.ServiceID, op.OperationID, size)
+	}
+}
+
+// byteCounter is an io.Writer that only counts the bytes written to it.
+type byteCounter struct {
+	n *atomic.Int64
+}
+
+func (c byteCounter) Write(p []byte) (int, error) {
+	c.n.Add(int64(len(p)))
+	return len(p), nil
+}
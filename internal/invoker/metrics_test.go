@@ -0,0 +1,54 @@
+package invoker
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricsSizeRecorder_histograms(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	rec, err := NewMetricsSizeRecorder(provider.Meter("test"))
+	if err != nil {
+		t.Fatalf("NewMetricsSizeRecorder() error = %v", err)
+	}
+
+	ctx := context.Background()
+	rec.RecordRequestSize(ctx, "orders-svc", "createOrder", 120)
+	rec.RecordResponseSize(ctx, "orders-svc", "createOrder", 2048)
+	rec.RecordResponseSize(ctx, "orders-svc", "createOrder", 1024)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	got := map[string]metricdata.HistogramDataPoint[int64]{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			h, ok := m.Data.(metricdata.Histogram[int64])
+			if !ok || len(h.DataPoints) != 1 {
+				t.Fatalf("%s data = %#v, want one histogram point", m.Name, m.Data)
+			}
+			got[m.Name] = h.DataPoints[0]
+		}
+	}
+
+	req := got["thesa.backend.request.size"]
+	if req.Count != 1 || req.Sum != 120 {
+		t.Errorf("request size count/sum = %d/%d, want 1/120", req.Count, req.Sum)
+	}
+	resp := got["thesa.backend.response.size"]
+	if resp.Count != 2 || resp.Sum != 3072 {
+		t.Errorf("response size count/sum = %d/%d, want 2/3072", resp.Count, resp.Sum)
+	}
+	if v, _ := resp.Attributes.Value(attribute.Key("operation")); v.AsString() != "createOrder" {
+		t.Errorf("operation attribute = %q, want createOrder", v.AsString())
+	}
+	if v, _ := resp.Attributes.Value(attribute.Key("service")); v.AsString() != "orders-svc" {
+		t.Errorf("service attribute = %q, want orders-svc", v.AsString())
+	}
+}
@@ -7,24 +7,45 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"net/textproto"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/pitabwire/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 
 	"github.com/pitabwire/thesa/internal/config"
 	"github.com/pitabwire/thesa/internal/openapi"
 	"github.com/pitabwire/thesa/model"
 )
 
-// serviceClient holds the HTTP client and retry config for a single backend
-// service.
+// serviceClient holds the HTTP client, retry config and credentials for a
+// single backend service.
 type serviceClient struct {
 	cfg    config.ServiceConfig
 	client *http.Client
+	// untimed is client without its timeout, for operations whose binding
+	// overrides the timeout with a context deadline instead.
+	untimed *http.Client
+	// tokens issues service tokens for the service-account auth strategy.
+	tokens oauth2.TokenSource
+	// cache holds successful GET responses; nil when caching is disabled.
+	cache *responseCache
+	// slots bounds in-flight requests to the service; nil when unlimited.
+	slots chan struct{}
 }
 
 // OpenAPIOperationInvoker dynamically builds and executes HTTP requests
@@ -32,25 +53,63 @@ type serviceClient struct {
 type OpenAPIOperationInvoker struct {
 	index   *openapi.Index
 	clients map[string]*serviceClient
+	sizes   SizeRecorder
+	tracer  trace.Tracer
 }
 
 // NewOpenAPIOperationInvoker creates an invoker with a shared HTTP client
-// and retry policies.
-func NewOpenAPIOperationInvoker(idx *openapi.Index, services map[string]config.ServiceConfig, httpClient *http.Client) *OpenAPIOperationInvoker {
+// and retry policies. Services with TLS settings get their own transport,
+// wrapped into a client by newClient when set; an error is returned if their
+// certificates cannot be loaded.
+func NewOpenAPIOperationInvoker(
+	idx *openapi.Index,
+	services map[string]config.ServiceConfig,
+	httpClient *http.Client,
+	newClient ClientFactory,
+) (*OpenAPIOperationInvoker, error) {
 	if httpClient == nil {
 		httpClient = &http.Client{Timeout: 10 * time.Second}
 	}
 	clients := make(map[string]*serviceClient, len(services))
 	for id, svcCfg := range services {
-		clients[id] = &serviceClient{
+		client := httpClient
+		if svcCfg.TLS.Enabled() {
+			var err error
+			if client, err = tlsClient(httpClient, svcCfg.TLS, newClient); err != nil {
+				return nil, fmt.Errorf("invoker: service %q tls: %w", id, err)
+			}
+		}
+		svc := &serviceClient{
 			cfg:    svcCfg,
-			client: httpClient,
+			client: clientFor(client, svcCfg),
+			cache:  newResponseCache(svcCfg.Cache),
+		}
+		if svcCfg.MaxConcurrentRequests > 0 {
+			svc.slots = make(chan struct{}, svcCfg.MaxConcurrentRequests)
 		}
+		untimed := *svc.client
+		untimed.Timeout = 0
+		svc.untimed = &untimed
+		if svcCfg.Auth.Mode() == config.AuthServiceAccount {
+			cc := &clientcredentials.Config{
+				ClientID:     svcCfg.Auth.ClientID,
+				ClientSecret: svcCfg.Auth.ClientSecret,
+				TokenURL:     svcCfg.Auth.TokenURL,
+				Scopes:       svcCfg.Auth.Scopes,
+			}
+			// Fetch tokens through the shared client: the identity
+			// provider is not the backend, so the service's client
+			// certificate, CA bundle and redirect policy must not apply.
+			tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+			svc.tokens = cc.TokenSource(tokenCtx)
+		}
+		clients[id] = svc
 	}
 	return &OpenAPIOperationInvoker{
 		index:   idx,
 		clients: clients,
-	}
+		tracer:  otel.Tracer(tracerName),
+	}, nil
 }
 
 // Supports returns true for operation bindings with type "openapi".
@@ -59,12 +118,25 @@ func (inv *OpenAPIOperationInvoker) Supports(binding model.OperationBinding) boo
 }
 
 // Invoke looks up the operation in the OpenAPI index, builds an HTTP request,
-// and executes it with retry support.
+// and executes it with retry support. Each call runs in an invoker.call
+// client span whose context is propagated to the backend.
 func (inv *OpenAPIOperationInvoker) Invoke(
 	ctx context.Context,
 	rctx *model.RequestContext,
 	binding model.OperationBinding,
 	input model.InvocationInput,
+) (model.InvocationResult, error) {
+	ctx, span := inv.startCallSpan(ctx, binding.ServiceID, binding.OperationID)
+	result, err := inv.invoke(ctx, rctx, binding, input)
+	endCallSpan(span, result.StatusCode, err)
+	return result, err
+}
+
+func (inv *OpenAPIOperationInvoker) invoke(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	binding model.OperationBinding,
+	input model.InvocationInput,
 ) (model.InvocationResult, error) {
 	op, ok := inv.index.GetOperation(binding.ServiceID, binding.OperationID)
 	if !ok {
@@ -81,28 +153,97 @@ func (inv *OpenAPIOperationInvoker) Invoke(
 		)
 	}
 
+	// Report missing required query parameters here rather than as an
+	// opaque backend 400.
+	if missing := missingQueryParams(op, input); len(missing) > 0 {
+		return model.InvocationResult{}, model.NewBadRequestError(fmt.Sprintf(
+			"operation %s: missing required query parameter(s): %s",
+			op.OperationID, strings.Join(missing, ", "),
+		))
+	}
+
 	reqURL := buildRequestURL(op, input)
+
 	headers := buildRequestHeaders(rctx, input, op.Method)
+	if binding.StreamResponse {
+		headers.Set("Accept", "*/*")
+	}
+	svc.applyContextHeaders(headers, rctx)
+
+	// Serve GETs from the service's response cache unless the caller asks
+	// for a fresh response, which then refreshes the entry.
+	var key string
+	if svc.cache != nil && op.Method == http.MethodGet && input.BodyStream == nil && !binding.StreamResponse {
+		key = cacheKey(op.OperationID, reqURL, rctx, input.Headers, svc.cfg.ContextHeaders, headers)
+		if key != "" && !bypassesCache(input.Headers) {
+			if result, ok := svc.cache.get(key); ok {
+				return result, nil
+			}
+		}
+	}
+	injectTraceContext(ctx, headers)
+	if err := svc.applyAuth(headers); err != nil {
+		return model.InvocationResult{}, err
+	}
+
+	timeout, _ := binding.TimeoutDuration()
+	if input.BodyStream != nil {
+		write := func(w io.Writer) error {
+			_, err := io.Copy(w, input.BodyStream)
+			return err
+		}
+		return inv.executeStream(ctx, svc, op, reqURL, headers, write, timeout, binding.StreamResponse)
+	}
+	if len(input.Files) > 0 {
+		write, contentType, err := multipartBody(input.Body, input.Files)
+		if err != nil {
+			return model.InvocationResult{}, err
+		}
+		headers.Set("Content-Type", contentType)
+		return inv.executeStream(ctx, svc, op, reqURL, headers, write, timeout, binding.StreamResponse)
+	}
 
 	var bodyBytes []byte
 	if input.Body != nil {
+		contentType := binding.ContentType
+		if contentType == "" {
+			contentType = svc.cfg.RequestContentType
+		}
 		var err error
-		bodyBytes, err = json.Marshal(input.Body)
+		bodyBytes, err = encodeBody(input.Body, contentType)
 		if err != nil {
-			return model.InvocationResult{}, fmt.Errorf("invoker: marshal body: %w", err)
+			return model.InvocationResult{}, err
+		}
+		if contentType == model.ContentTypeForm {
+			headers.Set("Content-Type", model.ContentTypeForm)
 		}
 	}
 
-	return inv.executeWithRetry(ctx, svc, op.Method, reqURL, headers, bodyBytes)
+	retryable := svc.cfg.Retry.StatusesFor(op.OperationID)
+	result, err := inv.executeWithRetry(ctx, svc, op, reqURL, headers, bodyBytes, retryable, timeout, binding.StreamResponse)
+	if err == nil && key != "" && result.BodyReader == nil {
+		svc.cache.put(key, result)
+	}
+	return result, err
 }
 
 // executeWithRetry wraps executeOnce with retry logic and exponential backoff.
+// extraRetryable lists configured status codes retried in addition to the
+// default set. A Retry-After header on a retried response sets the next delay,
+// capped by BackoffMax. Retries stop early once the service's
+// TotalRetryBudget would be exceeded. A non-zero timeout bounds each attempt
+// in place of the service timeout. With stream set, a successful non-JSON
+// response is returned unread (see streamedBody).
 func (inv *OpenAPIOperationInvoker) executeWithRetry(
 	ctx context.Context,
 	svc *serviceClient,
-	method, reqURL string,
+	op openapi.IndexedOperation,
+	reqURL string,
 	headers http.Header,
 	bodyBytes []byte,
+	extraRetryable []int,
+	timeout time.Duration,
+	stream bool,
 ) (model.InvocationResult, error) {
 	retryCfg := svc.cfg.Retry
 	maxAttempts := retryCfg.MaxAttempts
@@ -110,14 +251,28 @@ func (inv *OpenAPIOperationInvoker) executeWithRetry(
 		maxAttempts = 1
 	}
 
-	canRetry := isIdempotentMethod(method) || !retryCfg.IdempotentOnly
+	canRetry := isIdempotentMethod(op.Method) || !retryCfg.IdempotentOnly
 
 	var lastErr error
 	var lastResult model.InvocationResult
+	// retryAfter is the Retry-After header of the response being retried.
+	var retryAfter string
+	start := time.Now()
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		if attempt > 0 {
 			delay := calculateBackoff(retryCfg, attempt)
+			if d, ok := parseRetryAfter(retryAfter, time.Now()); ok {
+				delay = min(d, backoffMax(retryCfg))
+			}
+			if budget := retryCfg.TotalRetryBudget; budget > 0 && time.Since(start)+delay >= budget {
+				util.Log(ctx).Debug("invoker: retry budget exhausted",
+					"attempt", attempt,
+					"max", maxAttempts,
+					"budget", budget,
+				)
+				break
+			}
 			select {
 			case <-ctx.Done():
 				return model.InvocationResult{}, ctx.Err()
@@ -125,9 +280,10 @@ func (inv *OpenAPIOperationInvoker) executeWithRetry(
 			}
 		}
 
-		result, err := inv.executeOnce(ctx, svc, method, reqURL, headers, bodyBytes)
+		result, err := inv.executeOnce(ctx, svc, op, reqURL, headers, bodyBytes, timeout, stream)
 		if err != nil {
 			lastErr = err
+			retryAfter = ""
 			if !canRetry || !isRetryableError(err) {
 				return model.InvocationResult{}, err
 			}
@@ -139,8 +295,9 @@ func (inv *OpenAPIOperationInvoker) executeWithRetry(
 			continue
 		}
 
-		if isRetryableStatus(result.StatusCode) && canRetry && attempt < maxAttempts-1 {
+		if retriesStatus(retryCfg, result.StatusCode, extraRetryable) && canRetry && attempt < maxAttempts-1 {
 			lastResult = result
+			retryAfter = result.Headers["Retry-After"]
 			util.Log(ctx).Debug("invoker: retrying after status",
 				"attempt", attempt+1,
 				"max", maxAttempts,
@@ -158,26 +315,119 @@ func (inv *OpenAPIOperationInvoker) executeWithRetry(
 	return lastResult, nil
 }
 
-// executeOnce performs a single HTTP request.
+// executeOnce performs one request attempt, hedged when the service
+// enables hedging for the operation (see executeHedged).
 func (inv *OpenAPIOperationInvoker) executeOnce(
 	ctx context.Context,
 	svc *serviceClient,
-	method, reqURL string,
+	op openapi.IndexedOperation,
+	reqURL string,
 	headers http.Header,
 	bodyBytes []byte,
+	timeout time.Duration,
+	stream bool,
 ) (model.InvocationResult, error) {
+	if svc.hedges(op, stream) {
+		return inv.executeHedged(ctx, svc, op, reqURL, headers, bodyBytes, timeout)
+	}
+	return inv.send(ctx, svc, op, reqURL, headers, bodyBytes, timeout, stream)
+}
+
+// send performs a single HTTP request.
+func (inv *OpenAPIOperationInvoker) send(
+	ctx context.Context,
+	svc *serviceClient,
+	op openapi.IndexedOperation,
+	reqURL string,
+	headers http.Header,
+	bodyBytes []byte,
+	timeout time.Duration,
+	stream bool,
+) (model.InvocationResult, error) {
+	ctx, client, cancel := svc.attempt(ctx, timeout)
+
 	var body io.Reader
 	if bodyBytes != nil {
 		body = bytes.NewReader(bodyBytes)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	req, err := http.NewRequestWithContext(ctx, op.Method, reqURL, body)
 	if err != nil {
+		cancel()
 		return model.InvocationResult{}, fmt.Errorf("invoker: build request: %w", err)
 	}
 	req.Header = headers
+	inv.recordRequestSize(ctx, op, int64(len(bodyBytes)))
+
+	return inv.do(ctx, svc, client, op, req, stream, cancel)
+}
+
+// attempt returns the context and client for one request attempt. A
+// non-zero timeout overrides the service timeout: the attempt gets its own
+// deadline and runs on the untimed client, so the override may exceed the
+// service timeout.
+func (s *serviceClient) attempt(ctx context.Context, timeout time.Duration) (context.Context, *http.Client, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, s.client, func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, s.untimed, cancel
+}
+
+// acquire takes a slot for one in-flight request without waiting and
+// reports whether one was free. Every successful acquire must be paired with
+// release.
+func (s *serviceClient) acquire() bool {
+	if s.slots == nil {
+		return true
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release frees the slot taken by acquire.
+func (s *serviceClient) release() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// do sends the request with client and parses the backend response. It
+// calls cancel once the response is consumed: on return, or for a streamed
+// response when its body is closed.
+func (inv *OpenAPIOperationInvoker) do(
+	ctx context.Context,
+	svc *serviceClient,
+	client *http.Client,
+	op openapi.IndexedOperation,
+	req *http.Request,
+	stream bool,
+	cancel context.CancelFunc,
+) (model.InvocationResult, error) {
+	if !svc.acquire() {
+		cancel()
+		util.Log(ctx).Warn("invoker: service concurrency limit reached",
+			"operation", op.OperationID,
+			"limit", svc.cfg.MaxConcurrentRequests,
+		)
+		return model.InvocationResult{}, model.NewBackendUnavailableError()
+	}
+	// A streamed body takes over the slot and the context.
+	streaming := false
+	defer func() {
+		if !streaming {
+			svc.release()
+			cancel()
+		}
+	}()
 
-	resp, err := svc.client.Do(req)
+	setRequestTimeoutHeader(ctx, req.Header)
+
+	resp, err := client.Do(req)
 	if err != nil {
 		if isConnectionError(err) {
 			return model.InvocationResult{}, model.NewBackendUnavailableError()
@@ -187,16 +437,38 @@ func (inv *OpenAPIOperationInvoker) executeOnce(
 		}
 		return model.InvocationResult{}, fmt.Errorf("invoker: request failed: %w", err)
 	}
+	if err := decodeBody(resp); err != nil {
+		_ = resp.Body.Close()
+		return model.InvocationResult{}, decodeFailure(ctx, op, err)
+	}
+	if stream && isStreamable(resp) {
+		streaming = true
+		result := model.InvocationResult{
+			StatusCode: resp.StatusCode,
+			Headers:    streamedHeaders(resp, svc.cfg.ResponseHeaders),
+			BodyReader: &streamedBody{body: resp.Body, done: func(n int64) {
+				inv.recordResponseSize(ctx, op, n)
+				svc.release()
+				cancel()
+			}},
+		}
+		result.ErrorCode = mappedErrorCode(svc.cfg.ErrorMapping, op.OperationID, result)
+		return result, nil
+	}
 	defer func() { _ = resp.Body.Close() }()
 
 	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB limit
 	if err != nil {
+		if isDecodeError(err) {
+			return model.InvocationResult{}, decodeFailure(ctx, op, err)
+		}
 		return model.InvocationResult{}, fmt.Errorf("invoker: read response: %w", err)
 	}
+	inv.recordResponseSize(ctx, op, int64(len(respBody)))
 
 	result := model.InvocationResult{
 		StatusCode: resp.StatusCode,
-		Headers:    extractResponseHeaders(resp),
+		Headers:    extractResponseHeaders(resp, svc.cfg.ResponseHeaders),
 	}
 
 	// Parse JSON response body if present.
@@ -206,11 +478,180 @@ func (inv *OpenAPIOperationInvoker) executeOnce(
 			result.Body = parsed
 		}
 	}
+	result.ErrorCode = mappedErrorCode(svc.cfg.ErrorMapping, op.OperationID, result)
 
 	return result, nil
 }
 
-// --- URL and header building ---
+// requestTimeoutHeader carries the remaining request budget in milliseconds
+// so backends can shed work they cannot finish in time.
+const requestTimeoutHeader = "X-Request-Timeout-Ms"
+
+// setRequestTimeoutHeader sets requestTimeoutHeader from the context
+// deadline. It is evaluated per attempt, so retries forward the budget that
+// is actually left. Without a deadline the header is omitted.
+func setRequestTimeoutHeader(ctx context.Context, h http.Header) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		h.Del(requestTimeoutHeader)
+		return
+	}
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 0 {
+		remaining = 0
+	}
+	h.Set(requestTimeoutHeader, strconv.FormatInt(remaining, 10))
+}
+
+// executeStream performs a single HTTP request whose body is written by
+// write through an io.Pipe, so the payload is never held in memory as a
+// whole. The request is not retried because the source can only be read
+// once.
+func (inv *OpenAPIOperationInvoker) executeStream(
+	ctx context.Context,
+	svc *serviceClient,
+	op openapi.IndexedOperation,
+	reqURL string,
+	headers http.Header,
+	write func(io.Writer) error,
+	timeout time.Duration,
+	stream bool,
+) (model.InvocationResult, error) {
+	ctx, client, cancel := svc.attempt(ctx, timeout)
+
+	pr, pw := io.Pipe()
+	var sent atomic.Int64
+	go func() {
+		_ = pw.CloseWithError(write(io.MultiWriter(pw, byteCounter{&sent})))
+	}()
+	defer func() { _ = pr.Close() }()
+
+	req, err := http.NewRequestWithContext(ctx, op.Method, reqURL, pr)
+	if err != nil {
+		cancel()
+		return model.InvocationResult{}, fmt.Errorf("invoker: build request: %w", err)
+	}
+	req.Header = headers
+
+	result, err := inv.do(ctx, svc, client, op, req, stream, cancel)
+	inv.recordRequestSize(ctx, op, sent.Load())
+	return result, err
+}
+
+// --- URL, body and header building ---
+
+// encodeBody serialises a request body as JSON or, for ContentTypeForm, as
+// form-urlencoded fields. Form bodies must be objects; nested objects use
+// bracket notation (address[city]) and arrays repeat the key.
+func encodeBody(body any, contentType string) ([]byte, error) {
+	switch contentType {
+	case "", model.ContentTypeJSON:
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("invoker: marshal body: %w", err)
+		}
+		return b, nil
+	case model.ContentTypeForm:
+		m, ok := body.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invoker: form body must be an object, got %T", body)
+		}
+		values := url.Values{}
+		addFormValues(values, "", m)
+		return []byte(values.Encode()), nil
+	default:
+		return nil, fmt.Errorf("invoker: unsupported request content type %q", contentType)
+	}
+}
+
+// multipartBody returns a function writing files and the fields of body as a
+// multipart/form-data body, with its content type. Form fields are
+// flattened as for form-encoded bodies. File contents are copied from
+// FilePart.Open as the body is written.
+func multipartBody(body any, files map[string]model.FilePart) (func(io.Writer) error, string, error) {
+	values := url.Values{}
+	if body != nil {
+		m, ok := body.(map[string]any)
+		if !ok {
+			return nil, "", fmt.Errorf("invoker: multipart body must be an object, got %T", body)
+		}
+		addFormValues(values, "", m)
+	}
+
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	write := func(w io.Writer) error {
+		mw := multipart.NewWriter(w)
+		if err := mw.SetBoundary(boundary); err != nil {
+			return err
+		}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			for _, v := range values[key] {
+				if err := mw.WriteField(key, v); err != nil {
+					return fmt.Errorf("invoker: write multipart field: %w", err)
+				}
+			}
+		}
+		for _, field := range slices.Sorted(maps.Keys(files)) {
+			if err := writeFilePart(mw, field, files[field]); err != nil {
+				return fmt.Errorf("invoker: write multipart file: %w", err)
+			}
+		}
+		if err := mw.Close(); err != nil {
+			return fmt.Errorf("invoker: close multipart body: %w", err)
+		}
+		return nil
+	}
+	return write, mime.FormatMediaType("multipart/form-data", map[string]string{"boundary": boundary}), nil
+}
+
+// writeFilePart writes one file as a part of mw.
+func writeFilePart(mw *multipart.Writer, field string, file model.FilePart) error {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name": field, "filename": file.Filename,
+	}))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, content)
+	return errors.Join(err, content.Close())
+}
+
+func addFormValues(values url.Values, prefix string, m map[string]any) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "[" + k + "]"
+		}
+		addFormValue(values, key, v)
+	}
+}
+
+func addFormValue(values url.Values, key string, v any) {
+	switch val := v.(type) {
+	case nil:
+	case map[string]any:
+		addFormValues(values, key, val)
+	case []any:
+		for _, item := range val {
+			addFormValue(values, key, item)
+		}
+	case float64:
+		values.Add(key, strconv.FormatFloat(val, 'f', -1, 64))
+	default:
+		values.Add(key, fmt.Sprint(val))
+	}
+}
 
 func buildRequestURL(op openapi.IndexedOperation, input model.InvocationInput) string {
 	path := op.PathTemplate
@@ -234,6 +675,21 @@ func buildRequestURL(op openapi.IndexedOperation, input model.InvocationInput) s
 	return result
 }
 
+// missingQueryParams returns the names of the operation's required query
+// parameters that input leaves absent or empty, in declaration order.
+func missingQueryParams(op openapi.IndexedOperation, input model.InvocationInput) []string {
+	var missing []string
+	for _, p := range op.Parameters {
+		if p.In != openapi3.ParameterInQuery || !p.Required {
+			continue
+		}
+		if input.QueryParams[p.Name] == "" {
+			missing = append(missing, p.Name)
+		}
+	}
+	return missing
+}
+
 func buildRequestHeaders(rctx *model.RequestContext, input model.InvocationInput, method string) http.Header {
 	h := make(http.Header)
 
@@ -260,6 +716,53 @@ func buildRequestHeaders(rctx *model.RequestContext, input model.InvocationInput
 	return h
 }
 
+// applyContextHeaders sets the service's configured headers derived from
+// the request context. They are applied after the standard and input
+// headers, so they take precedence.
+func (svc *serviceClient) applyContextHeaders(h http.Header, rctx *model.RequestContext) {
+	for _, ch := range svc.cfg.ContextHeaders {
+		value, _ := rctx.Attribute(ch.Source)
+		if ch.Values != nil {
+			mapped, ok := ch.Values[value]
+			if !ok {
+				mapped = ch.Default
+			}
+			value = mapped
+		}
+		if value == "" {
+			continue
+		}
+		h.Set(sanitizeHeader(ch.Header), sanitizeHeader(value))
+	}
+}
+
+// applyAuth sets the backend credential according to the service's auth
+// strategy. Passthrough keeps the user bearer token set by
+// buildRequestHeaders; the other strategies replace it with a service
+// credential.
+func (svc *serviceClient) applyAuth(h http.Header) error {
+	auth := svc.cfg.Auth
+	switch auth.Mode() {
+	case config.AuthStaticAPIKey:
+		header := auth.Header
+		if header == "" {
+			header = "X-API-Key"
+		}
+		h.Del("Authorization")
+		h.Set(header, sanitizeHeader(auth.APIKey))
+	case config.AuthServiceAccount:
+		if svc.tokens == nil {
+			return fmt.Errorf("invoker: service account token source not configured")
+		}
+		tok, err := svc.tokens.Token()
+		if err != nil {
+			return fmt.Errorf("invoker: service account token: %w", err)
+		}
+		h.Set("Authorization", "Bearer "+sanitizeHeader(tok.AccessToken))
+	}
+	return nil
+}
+
 // sanitizeHeader strips newlines and carriage returns to prevent header injection.
 func sanitizeHeader(s string) string {
 	s = strings.ReplaceAll(s, "\r", "")
@@ -267,12 +770,35 @@ func sanitizeHeader(s string) string {
 	return s
 }
 
-func extractResponseHeaders(resp *http.Response) map[string]string {
+// allowedResponseHeaders are always extracted from backend responses.
+var allowedResponseHeaders = []string{
+	"Content-Type", "X-Correlation-Id", "X-Trace-Id",
+	"X-Request-Id", "Retry-After",
+}
+
+// deniedResponseHeaders are never extracted, whatever the allowlist says,
+// because they leak backend implementation details.
+var deniedResponseHeaders = []string{
+	"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version",
+}
+
+// extractResponseHeaders returns the allowlisted response headers, extended
+// by cfg.Allow, minus the built-in and configured deny-lists.
+func extractResponseHeaders(resp *http.Response, cfg config.ResponseHeaderConfig) map[string]string {
+	denied := make(map[string]bool, len(deniedResponseHeaders)+len(cfg.Deny))
+	for _, key := range deniedResponseHeaders {
+		denied[key] = true
+	}
+	for _, key := range cfg.Deny {
+		denied[http.CanonicalHeaderKey(key)] = true
+	}
+
 	headers := make(map[string]string)
-	for _, key := range []string{
-		"Content-Type", "X-Correlation-Id", "X-Trace-Id",
-		"X-Request-Id", "Retry-After",
-	} {
+	for _, key := range slices.Concat(allowedResponseHeaders, cfg.Allow) {
+		key = http.CanonicalHeaderKey(key)
+		if denied[key] {
+			continue
+		}
 		if v := resp.Header.Get(key); v != "" {
 			headers[key] = v
 		}
@@ -299,15 +825,19 @@ func isClientError(code int) bool {
 	return code >= 400 && code < 500
 }
 
-func isRetryableStatus(code int) bool {
-	switch code {
-	case http.StatusInternalServerError,
-		http.StatusBadGateway,
-		http.StatusServiceUnavailable,
-		http.StatusGatewayTimeout:
-		return true
+// retriesStatus reports whether a response with the given status is worth
+// retrying under cfg: the configured RetryableStatusCodes replace the default
+// set when present, and extra codes are retried either way. Whether the
+// request may be retried at all (IdempotentOnly) is checked by the caller.
+func retriesStatus(cfg config.RetryConfig, code int, extra []int) bool {
+	if len(cfg.RetryableStatusCodes) > 0 {
+		return slices.Contains(cfg.RetryableStatusCodes, code) || slices.Contains(extra, code)
 	}
-	return false
+	return isRetryableStatus(code, extra)
+}
+
+func isRetryableStatus(code int, extra []int) bool {
+	return slices.Contains(config.DefaultRetryableStatuses, code) || slices.Contains(extra, code)
 }
 
 func isRetryableError(err error) bool {
@@ -340,9 +870,7 @@ func calculateBackoff(cfg config.RetryConfig, attempt int) time.Duration {
 	if cfg.BackoffMultiplier <= 0 {
 		cfg.BackoffMultiplier = 2
 	}
-	if cfg.BackoffMax <= 0 {
-		cfg.BackoffMax = 2 * time.Second
-	}
+	cfg.BackoffMax = backoffMax(cfg)
 
 	delay := cfg.BackoffInitial
 	for i := 1; i < attempt; i++ {
@@ -354,3 +882,32 @@ func calculateBackoff(cfg config.RetryConfig, attempt int) time.Duration {
 	}
 	return delay
 }
+
+// backoffMax returns the configured maximum retry delay, defaulting to 2s.
+func backoffMax(cfg config.RetryConfig) time.Duration {
+	if cfg.BackoffMax <= 0 {
+		return 2 * time.Second
+	}
+	return cfg.BackoffMax
+}
+
+// parseRetryAfter parses a Retry-After header value in either delay-seconds
+// or HTTP-date form. A date in the past yields zero. It returns false if the
+// value is empty or malformed.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
+}
@@ -6,8 +6,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -97,6 +102,23 @@ paths:
       responses:
         "204":
           description: No Content
+  /reports:
+    get:
+      operationId: listReports
+      parameters:
+        - name: scope
+          in: query
+          required: true
+          schema:
+            type: string
+        - name: region
+          in: query
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
 `
 
 func writeSpecFile(t *testing.T) string {
@@ -137,9 +159,13 @@ func defaultServiceConfig() config.ServiceConfig {
 func newTestInvoker(t *testing.T, serverURL string, svcCfg config.ServiceConfig) *OpenAPIOperationInvoker {
 	t.Helper()
 	idx := loadTestIndex(t, serverURL)
-	return NewOpenAPIOperationInvoker(idx, map[string]config.ServiceConfig{
+	inv, err := NewOpenAPIOperationInvoker(idx, map[string]config.ServiceConfig{
 		"test-svc": svcCfg,
-	}, nil)
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+	}
+	return inv
 }
 
 // --- Supports ---
@@ -158,6 +184,31 @@ func TestOpenAPIOperationInvoker_Supports(t *testing.T) {
 	}
 }
 
+func TestOpenAPIOperationInvoker_Invoke_missingRequiredQueryParams(t *testing.T) {
+	var called atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+	_, err := inv.Invoke(context.Background(), &model.RequestContext{},
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listReports"},
+		model.InvocationInput{QueryParams: map[string]string{"region": "eu"}},
+	)
+
+	envErr, ok := err.(*model.ErrorEnvelope)
+	if !ok || envErr.Code != model.ErrBadRequest {
+		t.Fatalf("error = %v, want BAD_REQUEST", err)
+	}
+	if !strings.Contains(envErr.Message, "scope") || strings.Contains(envErr.Message, "region") {
+		t.Errorf("Message = %q, want only scope reported missing", envErr.Message)
+	}
+	if called.Load() {
+		t.Error("backend called despite missing required query parameter")
+	}
+}
+
 // --- Successful invocations ---
 
 func TestOpenAPIOperationInvoker_Invoke_GETSuccess(t *testing.T) {
@@ -254,6 +305,61 @@ func TestOpenAPIOperationInvoker_Invoke_pathParamsEscaped(t *testing.T) {
 	}
 }
 
+func TestOpenAPIOperationInvoker_Invoke_operationAlias(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/users/abc-123" {
+			t.Errorf("request = %s %s, want GET /users/abc-123", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "abc-123"})
+	}))
+	defer server.Close()
+
+	// The second spec version renames getUser; only the alias target changes.
+	renamed := strings.ReplaceAll(testSpec, "operationId: getUser", "operationId: fetchUserById")
+	versions := []struct {
+		spec   string
+		target string
+	}{
+		{testSpec, "getUser"},
+		{renamed, "fetchUserById"},
+	}
+	for _, v := range versions {
+		t.Run(v.target, func(t *testing.T) {
+			specPath := filepath.Join(t.TempDir(), "spec.yaml")
+			if err := os.WriteFile(specPath, []byte(v.spec), 0644); err != nil {
+				t.Fatal(err)
+			}
+			idx := openapi.NewIndex()
+			if err := idx.Load([]openapi.SpecSource{{
+				ServiceID: "test-svc",
+				BaseURL:   server.URL,
+				SpecPath:  specPath,
+				Aliases:   map[string]string{"users.get": v.target},
+			}}); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			inv, err := NewOpenAPIOperationInvoker(idx, map[string]config.ServiceConfig{"test-svc": defaultServiceConfig()}, nil, nil)
+			if err != nil {
+				t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+			}
+
+			result, err := inv.Invoke(
+				context.Background(),
+				nil,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "users.get"},
+				model.InvocationInput{PathParams: map[string]string{"id": "abc-123"}},
+			)
+			if err != nil {
+				t.Fatalf("Invoke error: %v", err)
+			}
+			if result.StatusCode != http.StatusOK {
+				t.Errorf("StatusCode = %d, want 200", result.StatusCode)
+			}
+		})
+	}
+}
+
 func TestOpenAPIOperationInvoker_Invoke_queryParams(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Query().Get("page") != "3" {
@@ -321,6 +427,204 @@ func TestOpenAPIOperationInvoker_Invoke_POSTWithBody(t *testing.T) {
 	}
 }
 
+func TestOpenAPIOperationInvoker_Invoke_formURLEncodedBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		svcType     string
+		bindingType string
+	}{
+		{"binding", "", model.ContentTypeForm},
+		{"service default", model.ContentTypeForm, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ct := r.Header.Get("Content-Type"); ct != model.ContentTypeForm {
+					t.Errorf("Content-Type = %s, want %s", ct, model.ContentTypeForm)
+				}
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("ParseForm: %v", err)
+				}
+				want := url.Values{
+					"name":          {"Alice Smith"},
+					"age":           {"1000000"},
+					"active":        {"true"},
+					"tags":          {"a", "b&c"},
+					"address[city]": {"Nairobi"},
+				}
+				for k, v := range want {
+					if got := r.PostForm[k]; !slices.Equal(got, v) {
+						t.Errorf("form[%s] = %v, want %v", k, got, v)
+					}
+				}
+				if len(r.PostForm) != len(want) {
+					t.Errorf("form = %v, want %d keys", r.PostForm, len(want))
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			svcCfg := defaultServiceConfig()
+			svcCfg.RequestContentType = tt.svcType
+			inv := newTestInvoker(t, server.URL, svcCfg)
+
+			result, err := inv.Invoke(
+				context.Background(),
+				nil,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "createUser", ContentType: tt.bindingType},
+				model.InvocationInput{Body: map[string]any{
+					"name":    "Alice Smith",
+					"age":     float64(1000000),
+					"active":  true,
+					"tags":    []any{"a", "b&c"},
+					"address": map[string]any{"city": "Nairobi"},
+					"missing": nil,
+				}},
+			)
+			if err != nil {
+				t.Fatalf("Invoke error: %v", err)
+			}
+			if result.StatusCode != http.StatusCreated {
+				t.Errorf("StatusCode = %d, want 201", result.StatusCode)
+			}
+		})
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_multipartBody(t *testing.T) {
+	opened := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != -1 {
+			t.Errorf("ContentLength = %d, want a streamed body of unknown length", r.ContentLength)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("ParseMultipartForm: %v", err)
+		}
+		if got := r.MultipartForm.Value["name"]; !slices.Equal(got, []string{"Alice"}) {
+			t.Errorf("form[name] = %v, want [Alice]", got)
+		}
+		if got := r.MultipartForm.Value["address[city]"]; !slices.Equal(got, []string{"Nairobi"}) {
+			t.Errorf("form[address[city]] = %v, want [Nairobi]", got)
+		}
+		headers := r.MultipartForm.File["invoice"]
+		if len(headers) != 1 {
+			t.Fatalf("invoice parts = %d, want 1", len(headers))
+		}
+		if headers[0].Filename != "inv.pdf" || headers[0].Header.Get("Content-Type") != "application/pdf" {
+			t.Errorf("invoice part = %q %q, want inv.pdf application/pdf", headers[0].Filename, headers[0].Header.Get("Content-Type"))
+		}
+		f, _ := headers[0].Open()
+		content, _ := io.ReadAll(f)
+		if string(content) != "%PDF-1.4" {
+			t.Errorf("invoice content = %q", content)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+	result, err := inv.Invoke(
+		context.Background(),
+		nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "createUser"},
+		model.InvocationInput{
+			Body: map[string]any{"name": "Alice", "address": map[string]any{"city": "Nairobi"}},
+			Files: map[string]model.FilePart{
+				"invoice": {Filename: "inv.pdf", ContentType: "application/pdf", Open: func() (io.ReadCloser, error) {
+					opened++
+					return io.NopCloser(strings.NewReader("%PDF-1.4")), nil
+				}},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if result.StatusCode != http.StatusCreated {
+		t.Errorf("StatusCode = %d, want 201", result.StatusCode)
+	}
+	if opened != 1 {
+		t.Errorf("file opened %d times, want 1", opened)
+	}
+}
+
+// gatedReader yields size bytes, but blocks after the first chunk until
+// release is closed. If the invoker buffered the whole payload before
+// sending, the backend would never see the first chunk and the reader
+// would time out.
+type gatedReader struct {
+	remaining int
+	sent      int
+	release   chan struct{}
+	timedOut  atomic.Bool
+}
+
+func (g *gatedReader) Read(p []byte) (int, error) {
+	if g.remaining == 0 {
+		return 0, io.EOF
+	}
+	if g.sent > 0 && g.release != nil {
+		select {
+		case <-g.release:
+		case <-time.After(5 * time.Second):
+			g.timedOut.Store(true)
+		}
+		g.release = nil
+	}
+	n := min(len(p), g.remaining, 32<<10)
+	for i := range n {
+		p[i] = 'x'
+	}
+	g.remaining -= n
+	g.sent += n
+	return n, nil
+}
+
+func TestOpenAPIOperationInvoker_Invoke_streamsBody(t *testing.T) {
+	const size = 32 << 20 // 32MB
+	src := &gatedReader{remaining: size, release: make(chan struct{})}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/octet-stream" {
+			t.Errorf("Content-Type = %s, want application/octet-stream", ct)
+		}
+		if got := r.Header.Get("X-Tenant-Id"); got != "tenant-1" {
+			t.Errorf("X-Tenant-Id = %q, want tenant-1", got)
+		}
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(r.Body, buf); err != nil {
+			t.Errorf("read first byte: %v", err)
+		}
+		close(src.release)
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"received": n + 1})
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+
+	result, err := inv.Invoke(
+		context.Background(),
+		&model.RequestContext{TenantID: "tenant-1", Token: "tok"},
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "createUser"},
+		model.InvocationInput{
+			Headers:    map[string]string{"Content-Type": "application/octet-stream"},
+			BodyStream: src,
+		},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if src.timedOut.Load() {
+		t.Error("backend did not receive data before the source was fully read")
+	}
+	body, _ := result.Body.(map[string]any)
+	if body["received"] != float64(size) {
+		t.Errorf("received = %v, want %d", body["received"], size)
+	}
+}
+
 func TestOpenAPIOperationInvoker_Invoke_PUTWithPathAndBody(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPut {
@@ -435,6 +739,228 @@ func TestOpenAPIOperationInvoker_Invoke_forwardsHeaders(t *testing.T) {
 	}
 }
 
+func TestOpenAPIOperationInvoker_Invoke_contextHeaders(t *testing.T) {
+	var got http.Header
+	server := authCapture(t, &got)
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.ContextHeaders = []config.ContextHeaderConfig{
+		{Header: "X-Region", Source: "tenant_id", Values: map[string]string{"acme": "eu-west-1"}, Default: "us-east-1"},
+		{Header: "X-Tenant-Plan", Source: "claims.plan"},
+	}
+	inv := newTestInvoker(t, server.URL, svcCfg)
+
+	tests := []struct {
+		name       string
+		rctx       *model.RequestContext
+		wantRegion string
+		wantPlan   string
+	}{
+		{
+			name:       "mapped tenant",
+			rctx:       &model.RequestContext{TenantID: "acme", Claims: map[string]any{"plan": "enterprise"}},
+			wantRegion: "eu-west-1",
+			wantPlan:   "enterprise",
+		},
+		{
+			name:       "unmapped tenant uses default",
+			rctx:       &model.RequestContext{TenantID: "globex"},
+			wantRegion: "us-east-1",
+		},
+		{
+			name:       "no request context",
+			wantRegion: "us-east-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := inv.Invoke(
+				context.Background(),
+				tt.rctx,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+				model.InvocationInput{},
+			)
+			if err != nil {
+				t.Fatalf("Invoke error: %v", err)
+			}
+			if region := got.Get("X-Region"); region != tt.wantRegion {
+				t.Errorf("X-Region = %q, want %q", region, tt.wantRegion)
+			}
+			if plan := got.Get("X-Tenant-Plan"); plan != tt.wantPlan {
+				t.Errorf("X-Tenant-Plan = %q, want %q", plan, tt.wantPlan)
+			}
+		})
+	}
+}
+
+// authCapture returns a backend that records the Authorization, X-API-Key
+// and X-Request-Subject headers of the last request.
+func authCapture(t *testing.T, got *http.Header) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func invokeGetUser(t *testing.T, inv *OpenAPIOperationInvoker) {
+	t.Helper()
+	_, err := inv.Invoke(
+		context.Background(),
+		&model.RequestContext{SubjectID: "user-1", Token: "user-jwt"},
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "getUser"},
+		model.InvocationInput{PathParams: map[string]string{"id": "1"}},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_authPassthroughBearer(t *testing.T) {
+	var got http.Header
+	server := authCapture(t, &got)
+
+	cfg := defaultServiceConfig()
+	cfg.Auth.Strategy = config.AuthPassthroughBearer
+	invokeGetUser(t, newTestInvoker(t, server.URL, cfg))
+
+	if auth := got.Get("Authorization"); auth != "Bearer user-jwt" {
+		t.Errorf("Authorization = %q, want Bearer user-jwt", auth)
+	}
+	if sub := got.Get("X-Request-Subject"); sub != "user-1" {
+		t.Errorf("X-Request-Subject = %q, want user-1", sub)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_authStaticAPIKey(t *testing.T) {
+	var got http.Header
+	server := authCapture(t, &got)
+
+	cfg := defaultServiceConfig()
+	cfg.Auth = config.ServiceAuthConfig{Strategy: config.AuthStaticAPIKey, Header: "X-Api-Token", APIKey: "k-123"}
+	invokeGetUser(t, newTestInvoker(t, server.URL, cfg))
+
+	if auth := got.Get("Authorization"); auth != "" {
+		t.Errorf("Authorization = %q, want empty (user token must not be forwarded)", auth)
+	}
+	if key := got.Get("X-Api-Token"); key != "k-123" {
+		t.Errorf("X-Api-Token = %q, want k-123", key)
+	}
+	if sub := got.Get("X-Request-Subject"); sub != "user-1" {
+		t.Errorf("X-Request-Subject = %q, want user-1", sub)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_authServiceAccount(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		_ = r.ParseForm()
+		if gt := r.PostForm.Get("grant_type"); gt != "client_credentials" {
+			t.Errorf("grant_type = %q, want client_credentials", gt)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "svc-token",
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	var got http.Header
+	server := authCapture(t, &got)
+
+	cfg := defaultServiceConfig()
+	cfg.Auth = config.ServiceAuthConfig{
+		Strategy:     config.AuthServiceAccount,
+		TokenURL:     tokenServer.URL,
+		ClientID:     "thesa",
+		ClientSecret: "secret",
+	}
+	inv := newTestInvoker(t, server.URL, cfg)
+	invokeGetUser(t, inv)
+	invokeGetUser(t, inv)
+
+	if auth := got.Get("Authorization"); auth != "Bearer svc-token" {
+		t.Errorf("Authorization = %q, want Bearer svc-token", auth)
+	}
+	if sub := got.Get("X-Request-Subject"); sub != "user-1" {
+		t.Errorf("X-Request-Subject = %q, want user-1", sub)
+	}
+	if n := tokenRequests.Load(); n != 1 {
+		t.Errorf("token requests = %d, want 1 (token should be reused)", n)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_authServiceAccountUsesSharedClient(t *testing.T) {
+	// The token endpoint's certificate is trusted only by the shared
+	// client, not by http.DefaultClient.
+	tokenServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "svc-token", "token_type": "bearer", "expires_in": 3600})
+	}))
+	defer tokenServer.Close()
+
+	var got http.Header
+	server := authCapture(t, &got)
+
+	cfg := defaultServiceConfig()
+	cfg.Auth = config.ServiceAuthConfig{
+		Strategy:     config.AuthServiceAccount,
+		TokenURL:     tokenServer.URL,
+		ClientID:     "thesa",
+		ClientSecret: "secret",
+	}
+	inv, err := NewOpenAPIOperationInvoker(loadTestIndex(t, server.URL), map[string]config.ServiceConfig{"test-svc": cfg}, tokenServer.Client(), nil)
+	if err != nil {
+		t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+	}
+	invokeGetUser(t, inv)
+
+	if auth := got.Get("Authorization"); auth != "Bearer svc-token" {
+		t.Errorf("Authorization = %q, want Bearer svc-token", auth)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_authServiceAccountIgnoresServicePolicy(t *testing.T) {
+	// The token endpoint redirects, which the service's no-follow redirect
+	// policy would refuse; tokens must be fetched with the shared client.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/oauth/token", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "svc-token", "token_type": "bearer", "expires_in": 3600})
+	})
+	tokenServer := httptest.NewServer(mux)
+	defer tokenServer.Close()
+
+	var got http.Header
+	server := authCapture(t, &got)
+
+	cfg := defaultServiceConfig()
+	cfg.Auth = config.ServiceAuthConfig{
+		Strategy:     config.AuthServiceAccount,
+		TokenURL:     tokenServer.URL + "/token",
+		ClientID:     "thesa",
+		ClientSecret: "secret",
+	}
+	inv, err := NewOpenAPIOperationInvoker(loadTestIndex(t, server.URL), map[string]config.ServiceConfig{"test-svc": cfg}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+	}
+	invokeGetUser(t, inv)
+
+	if auth := got.Get("Authorization"); auth != "Bearer svc-token" {
+		t.Errorf("Authorization = %q, want Bearer svc-token", auth)
+	}
+}
+
 func TestOpenAPIOperationInvoker_Invoke_customInputHeaders(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if got := r.Header.Get("X-Custom-Header"); got != "custom-value" {
@@ -798,6 +1324,48 @@ func TestOpenAPIOperationInvoker_Invoke_retryExhaustedReturnsLastResult(t *testi
 	}
 }
 
+func TestOpenAPIOperationInvoker_Invoke_retryBudgetStopsRetries(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cfg := defaultServiceConfig()
+	cfg.Retry = config.RetryConfig{
+		MaxAttempts:       10,
+		BackoffInitial:    100 * time.Millisecond,
+		BackoffMultiplier: 1,
+		BackoffMax:        100 * time.Millisecond,
+		IdempotentOnly:    true,
+		// Attempts start at ~0, ~100 and ~200ms; a fourth would begin past
+		// the budget.
+		TotalRetryBudget: 250 * time.Millisecond,
+	}
+	inv := newTestInvoker(t, server.URL, cfg)
+
+	start := time.Now()
+	result, err := inv.Invoke(
+		context.Background(),
+		nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if result.StatusCode != http.StatusBadGateway {
+		t.Errorf("StatusCode = %d, want 502", result.StatusCode)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3 within the retry budget", got)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("elapsed = %v, want retries bounded by the 250ms budget", elapsed)
+	}
+}
+
 // --- Context cancellation ---
 
 func TestOpenAPIOperationInvoker_Invoke_contextCancelled(t *testing.T) {
@@ -850,6 +1418,152 @@ func TestOpenAPIOperationInvoker_Invoke_contextDeadlineExceeded(t *testing.T) {
 	}
 }
 
+func TestOpenAPIOperationInvoker_Invoke_forwardsRemainingDeadline(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Request-Timeout-Ms")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := inv.Invoke(
+		ctx,
+		nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	ms, err := strconv.Atoi(got)
+	if err != nil {
+		t.Fatalf("X-Request-Timeout-Ms = %q, want integer", got)
+	}
+	if ms <= 0 || ms > 2000 {
+		t.Errorf("X-Request-Timeout-Ms = %d, want within (0, 2000]", ms)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_remainingDeadlineDecreasesAcrossRetry(t *testing.T) {
+	var mu sync.Mutex
+	var budgets []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms, _ := strconv.Atoi(r.Header.Get("X-Request-Timeout-Ms"))
+		mu.Lock()
+		budgets = append(budgets, ms)
+		n := len(budgets)
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := defaultServiceConfig()
+	cfg.Retry = config.RetryConfig{
+		MaxAttempts:       2,
+		BackoffInitial:    50 * time.Millisecond,
+		BackoffMultiplier: 1,
+		BackoffMax:        50 * time.Millisecond,
+		IdempotentOnly:    true,
+	}
+	inv := newTestInvoker(t, server.URL, cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := inv.Invoke(
+		ctx,
+		nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if len(budgets) != 2 {
+		t.Fatalf("server called %d times, want 2", len(budgets))
+	}
+	if budgets[1] >= budgets[0] {
+		t.Errorf("retry budget = %dms, want less than first attempt %dms", budgets[1], budgets[0])
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_operationTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		timeout string
+		wantErr bool
+	}{
+		{name: "service timeout", wantErr: true},
+		{name: "longer override", timeout: "2s"},
+		{name: "shorter override", timeout: "20ms", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The shared client carries the 50ms service timeout.
+			idx := loadTestIndex(t, server.URL)
+			inv, err := NewOpenAPIOperationInvoker(idx, map[string]config.ServiceConfig{
+				"test-svc": defaultServiceConfig(),
+			}, &http.Client{Timeout: 50 * time.Millisecond}, nil)
+			if err != nil {
+				t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+			}
+
+			result, err := inv.Invoke(context.Background(), nil,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers", Timeout: tt.timeout},
+				model.InvocationInput{},
+			)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected timeout error")
+				}
+				return
+			}
+			if err != nil || result.StatusCode != http.StatusOK {
+				t.Fatalf("Invoke = %d, %v; want 200", result.StatusCode, err)
+			}
+		})
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_noDeadlineOmitsTimeoutHeader(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Values("X-Request-Timeout-Ms")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+
+	_, err := inv.Invoke(
+		context.Background(),
+		nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{Headers: map[string]string{"X-Request-Timeout-Ms": "999999"}},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("X-Request-Timeout-Ms = %v, want omitted without a deadline", got)
+	}
+}
+
 func TestOpenAPIOperationInvoker_Invoke_contextCancelDuringRetryBackoff(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -910,6 +1624,46 @@ func TestOpenAPIOperationInvoker_Invoke_connectionError(t *testing.T) {
 	}
 }
 
+func TestOpenAPIOperationInvoker_Invoke_concurrencyLimit(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.MaxConcurrentRequests = 1
+	inv := newTestInvoker(t, server.URL, svcCfg)
+	binding := model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := inv.Invoke(context.Background(), nil, binding, model.InvocationInput{})
+		done <- err
+	}()
+	<-arrived
+
+	start := time.Now()
+	_, err := inv.Invoke(context.Background(), nil, binding, model.InvocationInput{})
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrBackendUnavailable {
+		t.Fatalf("overflow error = %v, want %s", err, model.ErrBackendUnavailable)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("overflow request took %v, want immediate rejection", elapsed)
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("in-flight request error: %v", err)
+	}
+	if _, err := inv.Invoke(context.Background(), nil, binding, model.InvocationInput{}); err != nil {
+		t.Errorf("request after the slot was freed: %v", err)
+	}
+}
+
 // --- Direct helper tests ---
 
 func TestBuildRequestURL_basic(t *testing.T) {
@@ -1027,20 +1781,194 @@ func TestIsIdempotentMethod(t *testing.T) {
 }
 
 func TestIsRetryableStatus(t *testing.T) {
-	retryable := []int{500, 502, 503, 504}
+	retryable := []int{429, 500, 502, 503, 504}
 	for _, code := range retryable {
-		if !isRetryableStatus(code) {
+		if !isRetryableStatus(code, nil) {
 			t.Errorf("isRetryableStatus(%d) = false, want true", code)
 		}
 	}
 	nonRetryable := []int{200, 201, 400, 401, 403, 404, 409, 501}
 	for _, code := range nonRetryable {
-		if isRetryableStatus(code) {
+		if isRetryableStatus(code, nil) {
 			t.Errorf("isRetryableStatus(%d) = true, want false", code)
 		}
 	}
 }
 
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "3", want: 3 * time.Second, wantOK: true},
+		{value: " 0 ", want: 0, wantOK: true},
+		{value: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second, wantOK: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{value: ""},
+		{value: "-1"},
+		{value: "soon"},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_honorsRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		backoffMax time.Duration
+		minWait    time.Duration
+		maxWait    time.Duration
+	}{
+		{name: "seconds", retryAfter: "1", backoffMax: 5 * time.Second, minWait: time.Second, maxWait: 3 * time.Second},
+		{name: "capped by backoff max", retryAfter: "30", backoffMax: 50 * time.Millisecond, minWait: 50 * time.Millisecond, maxWait: time.Second},
+		{name: "absent falls back to backoff", backoffMax: 5 * time.Second, maxWait: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var callCount atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if callCount.Add(1) == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			cfg := defaultServiceConfig()
+			cfg.Retry = config.RetryConfig{
+				MaxAttempts:    2,
+				BackoffInitial: time.Millisecond,
+				BackoffMax:     tt.backoffMax,
+				IdempotentOnly: true,
+			}
+			inv := newTestInvoker(t, server.URL, cfg)
+
+			start := time.Now()
+			result, err := inv.Invoke(context.Background(), nil,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+				model.InvocationInput{},
+			)
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatalf("Invoke error: %v", err)
+			}
+			if result.StatusCode != http.StatusOK || callCount.Load() != 2 {
+				t.Fatalf("StatusCode = %d after %d calls, want 200 after 2", result.StatusCode, callCount.Load())
+			}
+			if elapsed < tt.minWait || elapsed > tt.maxWait {
+				t.Errorf("retry took %v, want between %v and %v", elapsed, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+}
+
+func TestIsRetryableStatus_configured(t *testing.T) {
+	if !isRetryableStatus(425, []int{425}) {
+		t.Error("isRetryableStatus(425, [425]) = false, want true")
+	}
+	if isRetryableStatus(425, []int{429}) {
+		t.Error("isRetryableStatus(425, [429]) = true, want false")
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_retriesConfiguredStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retry     config.RetryConfig
+		wantCalls int32
+	}{
+		{
+			name:      "service-level 425 retries",
+			status:    http.StatusTooEarly,
+			retry:     config.RetryConfig{RetryableStatuses: []int{425}},
+			wantCalls: 2,
+		},
+		{
+			name:      "operation-level 425 retries",
+			status:    http.StatusTooEarly,
+			retry:     config.RetryConfig{OperationRetryableStatuses: map[string][]int{"listUsers": {425}}},
+			wantCalls: 2,
+		},
+		{
+			name:      "other operation's 425 does not retry",
+			status:    http.StatusTooEarly,
+			retry:     config.RetryConfig{OperationRetryableStatuses: map[string][]int{"getUser": {425}}},
+			wantCalls: 1,
+		},
+		{
+			name:      "unconfigured 400 does not retry",
+			status:    http.StatusBadRequest,
+			retry:     config.RetryConfig{RetryableStatuses: []int{425}},
+			wantCalls: 1,
+		},
+		{
+			name:      "replacement set retries 425",
+			status:    http.StatusTooEarly,
+			retry:     config.RetryConfig{RetryableStatusCodes: []int{425, 502}},
+			wantCalls: 2,
+		},
+		{
+			name:      "replacement set drops default 503",
+			status:    http.StatusServiceUnavailable,
+			retry:     config.RetryConfig{RetryableStatusCodes: []int{425, 502}},
+			wantCalls: 1,
+		},
+		{
+			name:      "operation statuses add to replacement set",
+			status:    http.StatusTooManyRequests,
+			retry:     config.RetryConfig{RetryableStatusCodes: []int{425}, OperationRetryableStatuses: map[string][]int{"listUsers": {429}}},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var callCount atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if callCount.Add(1) == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			cfg := defaultServiceConfig()
+			cfg.Retry = tt.retry
+			cfg.Retry.MaxAttempts = 3
+			cfg.Retry.BackoffInitial = time.Millisecond
+			cfg.Retry.BackoffMultiplier = 1
+			cfg.Retry.BackoffMax = time.Millisecond
+			cfg.Retry.IdempotentOnly = true
+			inv := newTestInvoker(t, server.URL, cfg)
+
+			_, err := inv.Invoke(
+				context.Background(),
+				nil,
+				model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+				model.InvocationInput{},
+			)
+			if err != nil {
+				t.Fatalf("Invoke error: %v", err)
+			}
+			if got := callCount.Load(); got != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
 func TestIsServerError(t *testing.T) {
 	if !isServerError(500) {
 		t.Error("isServerError(500) = false")
@@ -1134,7 +2062,7 @@ func TestExtractResponseHeaders(t *testing.T) {
 			"X-Custom":         {"should-be-ignored"},
 		},
 	}
-	headers := extractResponseHeaders(resp)
+	headers := extractResponseHeaders(resp, config.ResponseHeaderConfig{})
 	if headers["Content-Type"] != "application/json" {
 		t.Errorf("Content-Type = %q", headers["Content-Type"])
 	}
@@ -1154,3 +2082,124 @@ func TestExtractResponseHeaders(t *testing.T) {
 		t.Error("X-Custom should not be extracted")
 	}
 }
+
+func TestExtractResponseHeaders_denyList(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{
+			"Content-Type":     {"application/json"},
+			"Server":           {"nginx/1.25.3"},
+			"X-Powered-By":     {"Express"},
+			"X-Internal-Debug": {"shard=7"},
+			"X-Correlation-Id": {"c-1"},
+			"X-Ratelimit-Left": {"42"},
+		},
+	}
+	cfg := config.ResponseHeaderConfig{
+		Allow: []string{"server", "X-Powered-By", "X-Internal-Debug", "X-Correlation-Id", "X-RateLimit-Left"},
+		Deny:  []string{"x-internal-debug", "X-Correlation-Id"},
+	}
+	headers := extractResponseHeaders(resp, cfg)
+
+	for _, key := range []string{"Server", "X-Powered-By", "X-Internal-Debug", "X-Correlation-Id"} {
+		if v, exists := headers[key]; exists {
+			t.Errorf("%s = %q, want denied even though allowlisted", key, v)
+		}
+	}
+	if headers["X-Ratelimit-Left"] != "42" {
+		t.Errorf("X-Ratelimit-Left = %q, want 42 from the extended allowlist", headers["X-Ratelimit-Left"])
+	}
+	if headers["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type = %q", headers["Content-Type"])
+	}
+}
+
+// --- Body size metrics ---
+
+type sizeObservation struct {
+	service, operation string
+	size               int64
+}
+
+type fakeSizeRecorder struct {
+	mu        sync.Mutex
+	requests  []sizeObservation
+	responses []sizeObservation
+}
+
+func (f *fakeSizeRecorder) RecordRequestSize(_ context.Context, serviceID, operationID string, size int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, sizeObservation{serviceID, operationID, size})
+}
+
+func (f *fakeSizeRecorder) RecordResponseSize(_ context.Context, serviceID, operationID string, size int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, sizeObservation{serviceID, operationID, size})
+}
+
+func TestOpenAPIOperationInvoker_Invoke_recordsBodySizes(t *testing.T) {
+	const respBody = `{"id":"new-1","name":"Alice"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, respBody)
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+	rec := &fakeSizeRecorder{}
+	inv.SetSizeRecorder(rec)
+
+	_, err := inv.Invoke(
+		context.Background(),
+		nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "createUser"},
+		model.InvocationInput{Body: map[string]any{"name": "Alice"}},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+
+	wantReq := sizeObservation{"test-svc", "createUser", int64(len(`{"name":"Alice"}`))}
+	if len(rec.requests) != 1 || rec.requests[0] != wantReq {
+		t.Errorf("request sizes = %v, want [%v]", rec.requests, wantReq)
+	}
+	wantResp := sizeObservation{"test-svc", "createUser", int64(len(respBody))}
+	if len(rec.responses) != 1 || rec.responses[0] != wantResp {
+		t.Errorf("response sizes = %v, want [%v]", rec.responses, wantResp)
+	}
+}
+
+func TestOpenAPIOperationInvoker_Invoke_recordsStreamedRequestSize(t *testing.T) {
+	const size = 100 << 10
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+	rec := &fakeSizeRecorder{}
+	inv.SetSizeRecorder(rec)
+
+	_, err := inv.Invoke(
+		context.Background(),
+		nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "createUser"},
+		model.InvocationInput{
+			Headers:    map[string]string{"Content-Type": "application/octet-stream"},
+			BodyStream: &gatedReader{remaining: size},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+
+	if len(rec.requests) != 1 || rec.requests[0].size != size {
+		t.Errorf("request sizes = %v, want one observation of %d", rec.requests, size)
+	}
+	if len(rec.responses) != 1 || rec.responses[0].size != 0 {
+		t.Errorf("response sizes = %v, want one observation of 0", rec.responses)
+	}
+}
@@ -0,0 +1,74 @@
+package invoker
+
+import (
+	"context"
+	"maps"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// InputPreprocessor adjusts an invocation's input after input mapping and
+// before the backend call, e.g. to inject a tenant-specific parameter. It may
+// modify input in place; its parameter maps are copies and never nil. A
+// returned error aborts the invocation and reaches the caller unchanged, so a
+// *model.ErrorEnvelope controls the client-facing error.
+type InputPreprocessor func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input *model.InvocationInput) error
+
+type scopedPreprocessor struct {
+	serviceID   string
+	operationID string
+	fn          InputPreprocessor
+}
+
+// matches reports whether the preprocessor applies to binding. SDK bindings
+// match on their handler name in place of an operation ID.
+func (p scopedPreprocessor) matches(binding model.OperationBinding) bool {
+	if p.serviceID != "" && p.serviceID != binding.ServiceID {
+		return false
+	}
+	if p.operationID == "" {
+		return true
+	}
+	return p.operationID == binding.OperationID || (binding.OperationID == "" && p.operationID == binding.Handler)
+}
+
+// AddPreprocessor registers fn for invocations of operationID on serviceID.
+// An empty serviceID or operationID matches any. Preprocessors run in
+// registration order. It must be called before the registry serves requests.
+func (r *Registry) AddPreprocessor(serviceID, operationID string, fn InputPreprocessor) {
+	r.preprocessors = append(r.preprocessors, scopedPreprocessor{
+		serviceID:   serviceID,
+		operationID: operationID,
+		fn:          fn,
+	})
+}
+
+// preprocess runs the matching preprocessors on a copy of input, so callers'
+// parameter maps are never modified.
+func (r *Registry) preprocess(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationInput, error) {
+	copied := false
+	for _, p := range r.preprocessors {
+		if !p.matches(binding) {
+			continue
+		}
+		if !copied {
+			input.PathParams = cloneParams(input.PathParams)
+			input.QueryParams = cloneParams(input.QueryParams)
+			input.Headers = cloneParams(input.Headers)
+			copied = true
+		}
+		if err := p.fn(ctx, rctx, binding, &input); err != nil {
+			return model.InvocationInput{}, err
+		}
+	}
+	return input, nil
+}
+
+// cloneParams copies m, allocating an empty map for nil so preprocessors can
+// add entries without a nil check.
+func cloneParams(m map[string]string) map[string]string {
+	if m == nil {
+		return make(map[string]string)
+	}
+	return maps.Clone(m)
+}
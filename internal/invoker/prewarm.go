@@ -0,0 +1,85 @@
+package invoker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// PrewarmResult reports the outcome of pre-warming one service's connections.
+type PrewarmResult struct {
+	ServiceID string
+	// Opened is the number of requests that reached the service.
+	Opened int
+	// Err is the first failure, if any request failed.
+	Err error
+}
+
+// Prewarm opens connections to the base URL of every service configured with
+// prewarm_connections, so that DNS resolution and TCP and TLS handshakes are
+// done before the first real request. Each connection is opened by a HEAD
+// request through the service's own client, so its transport and TLS
+// settings apply; any response counts, and the connection is returned to the
+// idle pool. Requests for a service run concurrently, so that each opens its
+// own connection. The transport keeps at most its MaxIdleConnsPerHost of
+// them idle.
+//
+// Failures are reported in the results, ordered by service ID, and never
+// prevent the invoker from serving.
+func (inv *OpenAPIOperationInvoker) Prewarm(ctx context.Context) []PrewarmResult {
+	ids := make([]string, 0, len(inv.clients))
+	for id, svc := range inv.clients {
+		if svc.cfg.PrewarmConnections > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	results := make([]PrewarmResult, len(ids))
+	for n, id := range ids {
+		results[n] = inv.clients[id].prewarm(ctx)
+		results[n].ServiceID = id
+	}
+	return results
+}
+
+func (svc *serviceClient) prewarm(ctx context.Context) PrewarmResult {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		res PrewarmResult
+	)
+	for range svc.cfg.PrewarmConnections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := svc.head(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if res.Err == nil {
+					res.Err = err
+				}
+				return
+			}
+			res.Opened++
+		}()
+	}
+	wg.Wait()
+	return res
+}
+
+func (svc *serviceClient) head(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, svc.cfg.BaseURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
@@ -0,0 +1,90 @@
+package invoker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/thesa/internal/config"
+)
+
+func TestPrewarm_opensConnections(t *testing.T) {
+	const conns = 3
+	var (
+		mu     sync.Mutex
+		opened int
+	)
+	// Hold each request until all have arrived, so none can reuse another's
+	// connection.
+	var arrived sync.WaitGroup
+	arrived.Add(conns)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		arrived.Done()
+		arrived.Wait()
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			opened++
+			mu.Unlock()
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = conns
+	inv, err := NewOpenAPIOperationInvoker(nil, map[string]config.ServiceConfig{
+		"orders-svc": {BaseURL: srv.URL, PrewarmConnections: conns},
+		"cold-svc":   {BaseURL: srv.URL},
+	}, &http.Client{Transport: transport, Timeout: 5 * time.Second}, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+	}
+
+	results := inv.Prewarm(context.Background())
+	if len(results) != 1 {
+		t.Fatalf("results = %+v, want one for orders-svc", results)
+	}
+	if r := results[0]; r.ServiceID != "orders-svc" || r.Opened != conns || r.Err != nil {
+		t.Errorf("result = %+v, want %d connections opened", r, conns)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if opened != conns {
+		t.Errorf("server saw %d connections, want %d", opened, conns)
+	}
+}
+
+func TestPrewarm_failureIsReported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	inv, err := NewOpenAPIOperationInvoker(nil, map[string]config.ServiceConfig{
+		"down-svc":   {BaseURL: down.URL, PrewarmConnections: 1},
+		"orders-svc": {BaseURL: srv.URL, PrewarmConnections: 1},
+	}, &http.Client{Timeout: 5 * time.Second}, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+	}
+
+	results := inv.Prewarm(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want two", results)
+	}
+	if r := results[0]; r.ServiceID != "down-svc" || r.Err == nil || r.Opened != 0 {
+		t.Errorf("down-svc result = %+v, want an error", r)
+	}
+	if r := results[1]; r.ServiceID != "orders-svc" || r.Err != nil || r.Opened != 1 {
+		t.Errorf("orders-svc result = %+v, want warmed despite the other failure", r)
+	}
+}
@@ -0,0 +1,57 @@
+package invoker
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pitabwire/thesa/internal/config"
+)
+
+// maxRedirects matches the net/http default redirect limit.
+const maxRedirects = 10
+
+// clientFor returns a copy of the shared client that applies the service's
+// redirect policy. The copy shares the shared client's transport.
+func clientFor(shared *http.Client, cfg config.ServiceConfig) *http.Client {
+	c := *shared
+	c.CheckRedirect = redirectPolicy(cfg)
+	return &c
+}
+
+// redirectPolicy returns the CheckRedirect function for a service. By
+// default redirects are not followed and the 3xx response is returned as
+// is. With the same-host policy, redirects to the original scheme and host
+// are followed, while others are returned unfollowed; credential headers are
+// dropped from a followed request unless ForwardAuthOnRedirect is set.
+func redirectPolicy(cfg config.ServiceConfig) func(*http.Request, []*http.Request) error {
+	if cfg.RedirectPolicy != config.RedirectSameHost {
+		return func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	credentialHeaders := []string{"Authorization", "Cookie"}
+	if cfg.Auth.Mode() == config.AuthStaticAPIKey {
+		header := cfg.Auth.Header
+		if header == "" {
+			header = "X-API-Key"
+		}
+		credentialHeaders = append(credentialHeaders, header)
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("invoker: stopped after %d redirects", maxRedirects)
+		}
+		origin := via[0].URL
+		if req.URL.Scheme != origin.Scheme || req.URL.Host != origin.Host {
+			return http.ErrUseLastResponse
+		}
+		if !cfg.ForwardAuthOnRedirect {
+			for _, h := range credentialHeaders {
+				req.Header.Del(h)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pitabwire/thesa/internal/config"
+	"github.com/pitabwire/thesa/model"
+)
+
+// redirectBackend serves /users as a redirect to target and records the
+// Authorization header of requests to any other path.
+type redirectBackend struct {
+	*httptest.Server
+	hits int
+	auth string
+}
+
+func newRedirectBackend(t *testing.T, target func(self *redirectBackend) string) *redirectBackend {
+	t.Helper()
+	b := &redirectBackend{}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users" {
+			http.Redirect(w, r, target(b), http.StatusFound)
+			return
+		}
+		b.hits++
+		b.auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"moved":true}`))
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func invokeListUsers(t *testing.T, inv *OpenAPIOperationInvoker) model.InvocationResult {
+	t.Helper()
+	result, err := inv.Invoke(
+		context.Background(),
+		&model.RequestContext{SubjectID: "user-1", Token: "user-jwt"},
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{},
+	)
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	return result
+}
+
+func TestOpenAPIOperationInvoker_redirect_notFollowedByDefault(t *testing.T) {
+	backend := newRedirectBackend(t, func(b *redirectBackend) string { return b.URL + "/users-v2" })
+
+	result := invokeListUsers(t, newTestInvoker(t, backend.URL, defaultServiceConfig()))
+	if result.StatusCode != http.StatusFound || backend.hits != 0 {
+		t.Errorf("status = %d, redirect target hits = %d, want 302 and 0", result.StatusCode, backend.hits)
+	}
+}
+
+func TestOpenAPIOperationInvoker_redirect_sameHostOnly(t *testing.T) {
+	other := newRedirectBackend(t, nil)
+	backend := newRedirectBackend(t, func(*redirectBackend) string { return other.URL + "/users-v2" })
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.RedirectPolicy = config.RedirectSameHost
+	svcCfg.ForwardAuthOnRedirect = true
+	result := invokeListUsers(t, newTestInvoker(t, backend.URL, svcCfg))
+	if result.StatusCode != http.StatusFound || other.hits != 0 {
+		t.Errorf("status = %d, other host hits = %d, want 302 and 0", result.StatusCode, other.hits)
+	}
+}
+
+func TestOpenAPIOperationInvoker_redirect_sameHostCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		forwardAuth bool
+		wantAuth    string
+	}{
+		{name: "stripped by default", forwardAuth: false, wantAuth: ""},
+		{name: "forwarded when configured", forwardAuth: true, wantAuth: "Bearer user-jwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newRedirectBackend(t, func(b *redirectBackend) string { return b.URL + "/users-v2" })
+
+			svcCfg := defaultServiceConfig()
+			svcCfg.RedirectPolicy = config.RedirectSameHost
+			svcCfg.ForwardAuthOnRedirect = tt.forwardAuth
+			result := invokeListUsers(t, newTestInvoker(t, backend.URL, svcCfg))
+			if result.StatusCode != http.StatusOK || backend.hits != 1 {
+				t.Fatalf("status = %d, hits = %d, want 200 and 1", result.StatusCode, backend.hits)
+			}
+			if backend.auth != tt.wantAuth {
+				t.Errorf("Authorization on redirect = %q, want %q", backend.auth, tt.wantAuth)
+			}
+		})
+	}
+}
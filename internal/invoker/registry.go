@@ -12,7 +12,8 @@ import (
 // Registry holds all OperationInvoker implementations and dispatches
 // invocations to the appropriate one based on the operation binding type.
 type Registry struct {
-	invokers []model.OperationInvoker
+	invokers      []model.OperationInvoker
+	preprocessors []scopedPreprocessor
 }
 
 // NewRegistry creates a new empty InvokerRegistry.
@@ -25,11 +26,16 @@ func (r *Registry) Register(invoker model.OperationInvoker) {
 	r.invokers = append(r.invokers, invoker)
 }
 
-// Invoke finds the first registered invoker that supports the given binding
-// and delegates the call. Returns an error if no invoker supports the binding.
+// Invoke finds the first registered invoker that supports the given binding,
+// applies the matching input preprocessors and delegates the call. Returns an
+// error if no invoker supports the binding.
 func (r *Registry) Invoke(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
 	for _, inv := range r.invokers {
 		if inv.Supports(binding) {
+			input, err := r.preprocess(ctx, rctx, binding, input)
+			if err != nil {
+				return model.InvocationResult{}, err
+			}
 			return inv.Invoke(ctx, rctx, binding, input)
 		}
 	}
@@ -70,3 +70,79 @@ func TestRegistry_Invoke_empty(t *testing.T) {
 		t.Fatal("Invoke on empty registry should return error")
 	}
 }
+
+// capturingInvoker records the input of each invocation.
+type capturingInvoker struct {
+	inputs []model.InvocationInput
+}
+
+func (c *capturingInvoker) Supports(model.OperationBinding) bool { return true }
+
+func (c *capturingInvoker) Invoke(_ context.Context, _ *model.RequestContext, _ model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+	c.inputs = append(c.inputs, input)
+	return model.InvocationResult{StatusCode: 200}, nil
+}
+
+func TestRegistry_Invoke_preprocessors(t *testing.T) {
+	inv := &capturingInvoker{}
+	r := NewRegistry()
+	r.Register(inv)
+	r.AddPreprocessor("orders-svc", "listOrders", func(_ context.Context, rctx *model.RequestContext, _ model.OperationBinding, input *model.InvocationInput) error {
+		input.QueryParams["region"] = rctx.TenantID + "-eu"
+		return nil
+	})
+	r.AddPreprocessor("orders-svc", "", func(_ context.Context, _ *model.RequestContext, _ model.OperationBinding, input *model.InvocationInput) error {
+		input.Headers["X-Api-Version"] = "2"
+		return nil
+	})
+
+	rctx := &model.RequestContext{TenantID: "acme"}
+	query := map[string]string{"page": "1"}
+	bindings := []model.OperationBinding{
+		{Type: "openapi", ServiceID: "orders-svc", OperationID: "listOrders"},
+		{Type: "openapi", ServiceID: "orders-svc", OperationID: "getOrder"},
+		{Type: "openapi", ServiceID: "customers-svc", OperationID: "listOrders"},
+	}
+	for _, b := range bindings {
+		if _, err := r.Invoke(context.Background(), rctx, b, model.InvocationInput{QueryParams: query}); err != nil {
+			t.Fatalf("Invoke(%s/%s) error = %v", b.ServiceID, b.OperationID, err)
+		}
+	}
+
+	if got := inv.inputs[0].QueryParams["region"]; got != "acme-eu" {
+		t.Errorf("listOrders region = %q, want acme-eu", got)
+	}
+	if got := inv.inputs[0].QueryParams["page"]; got != "1" {
+		t.Errorf("listOrders page = %q, want the mapped param kept", got)
+	}
+	if got := inv.inputs[1].QueryParams["region"]; got != "" {
+		t.Errorf("getOrder region = %q, want unset", got)
+	}
+	if inv.inputs[0].Headers["X-Api-Version"] != "2" || inv.inputs[1].Headers["X-Api-Version"] != "2" {
+		t.Error("service-wide preprocessor should apply to every orders-svc operation")
+	}
+	if in := inv.inputs[2]; in.QueryParams["region"] != "" || in.Headers["X-Api-Version"] != "" {
+		t.Errorf("customers-svc input = %+v, want it untouched", in)
+	}
+	if len(query) != 1 {
+		t.Errorf("caller's query params = %v, want them unmodified", query)
+	}
+}
+
+func TestRegistry_Invoke_preprocessorError(t *testing.T) {
+	inv := &capturingInvoker{}
+	r := NewRegistry()
+	r.Register(inv)
+	r.AddPreprocessor("", "", func(context.Context, *model.RequestContext, model.OperationBinding, *model.InvocationInput) error {
+		return model.NewForbiddenError("tenant not provisioned")
+	})
+
+	_, err := r.Invoke(context.Background(), &model.RequestContext{},
+		model.OperationBinding{Type: "openapi", ServiceID: "orders-svc", OperationID: "listOrders"}, model.InvocationInput{})
+	if err == nil {
+		t.Fatal("Invoke should return the preprocessor error")
+	}
+	if len(inv.inputs) != 0 {
+		t.Error("backend invoked after a preprocessor failed")
+	}
+}
@@ -0,0 +1,62 @@
+package invoker
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pitabwire/thesa/internal/config"
+)
+
+// streamedResponseHeaders are passed on with a streamed body, in addition to
+// the extracted response headers, so clients can save it as a file.
+var streamedResponseHeaders = []string{"Content-Disposition", "Content-Length"}
+
+// streamedBody is a backend response body handed to the caller unread.
+// Closing it closes the response and calls done with the bytes read, which
+// frees what the request held.
+type streamedBody struct {
+	body io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *streamedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *streamedBody) Close() error {
+	err := b.body.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
+
+// isStreamable reports whether a response may be streamed: it succeeded and
+// declares a non-JSON body. Error and JSON responses are parsed as usual.
+func isStreamable(resp *http.Response) bool {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")
+}
+
+// streamedHeaders returns the extracted response headers plus
+// streamedResponseHeaders.
+func streamedHeaders(resp *http.Response, cfg config.ResponseHeaderConfig) map[string]string {
+	headers := extractResponseHeaders(resp, cfg)
+	for _, key := range streamedResponseHeaders {
+		if v := resp.Header.Get(key); v != "" {
+			headers[key] = v
+		}
+	}
+	return headers
+}
@@ -0,0 +1,70 @@
+package invoker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestOpenAPIOperationInvoker_Invoke_streamResponse(t *testing.T) {
+	const size = 12 << 20 // above the 10MB limit for parsed bodies
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"ok":true}`))
+			return
+		}
+		if got := r.Header.Get("Accept"); got != "*/*" {
+			t.Errorf("Accept = %q, want */*", got)
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+		chunk := []byte(strings.Repeat("a", 1<<20))
+		for range size >> 20 {
+			_, _ = w.Write(chunk)
+		}
+	}))
+	defer server.Close()
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.MaxConcurrentRequests = 1
+	inv := newTestInvoker(t, server.URL, svcCfg)
+	binding := model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers", StreamResponse: true}
+
+	result, err := inv.Invoke(context.Background(), nil, binding, model.InvocationInput{})
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if result.BodyReader == nil || result.Body != nil {
+		t.Fatalf("result = %+v, want an unread body", result)
+	}
+	if got := result.Headers["Content-Disposition"]; got != `attachment; filename="users.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	// The open stream holds the service's only slot.
+	if _, err := inv.Invoke(context.Background(), nil, binding, model.InvocationInput{}); err == nil {
+		t.Error("second request succeeded while the stream was open")
+	}
+	n, err := io.Copy(io.Discard, result.BodyReader)
+	if err != nil || n != size {
+		t.Errorf("streamed %d bytes (err %v), want %d", n, err, size)
+	}
+	if err := result.BodyReader.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	// JSON responses are parsed as usual.
+	result, err = inv.Invoke(context.Background(), nil, binding, model.InvocationInput{QueryParams: map[string]string{"format": "json"}})
+	if err != nil {
+		t.Fatalf("Invoke after close: %v", err)
+	}
+	if result.BodyReader != nil || result.Body == nil {
+		t.Errorf("result = %+v, want a parsed JSON body", result)
+	}
+}
@@ -0,0 +1,69 @@
+package invoker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/pitabwire/thesa/internal/config"
+)
+
+// newTLSConfig loads the client certificate and CA bundle of a service.
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate %s: %w", cfg.CertFile, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	return tlsCfg, nil
+}
+
+// ClientFactory builds the HTTP client of a service that needs its own
+// transport, such as one presenting a client certificate. It should apply
+// the same wrapping round trippers (tracing, retries, credentials) as the
+// shared client, which cannot be rebuilt around a new transport here.
+type ClientFactory func(transport *http.Transport) *http.Client
+
+// tlsClient returns a client whose transport presents the service's TLS
+// settings. The transport is cloned from the shared client's own when that
+// is an *http.Transport, and from http.DefaultTransport otherwise. It is
+// wrapped by newClient when set; otherwise the shared client is copied with
+// the bare transport.
+func tlsClient(client *http.Client, cfg config.TLSConfig, newClient ClientFactory) (*http.Client, error) {
+	tlsCfg, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	base, ok := client.Transport.(*http.Transport)
+	if !ok {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	transport.TLSClientConfig = tlsCfg
+
+	if newClient != nil {
+		return newClient(transport), nil
+	}
+	c := *client
+	c.Transport = transport
+	return &c, nil
+}
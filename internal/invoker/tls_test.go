@@ -0,0 +1,187 @@
+package invoker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/thesa/internal/config"
+	"github.com/pitabwire/thesa/model"
+)
+
+// writePEM writes a PEM block of the given type to a file in dir.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newClientCert writes a self-signed client certificate and its key to dir
+// and returns their paths along with the parsed certificate.
+func newClientCert(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "thesa-bff"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, dir, "client.crt", "CERTIFICATE", der), writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER), cert
+}
+
+func TestOpenAPIOperationInvoker_Invoke_mutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := newClientCert(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"subject":"` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}`))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	caFile := writePEM(t, dir, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+
+	invoke := func(tlsCfg config.TLSConfig) (model.InvocationResult, error) {
+		svcCfg := defaultServiceConfig()
+		svcCfg.TLS = tlsCfg
+		return newTestInvoker(t, server.URL, svcCfg).Invoke(context.Background(), nil,
+			model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+			model.InvocationInput{})
+	}
+
+	result, err := invoke(config.TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: caFile})
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	if body, _ := result.Body.(map[string]any); body["subject"] != "thesa-bff" {
+		t.Errorf("body = %v, want the client certificate presented", result.Body)
+	}
+
+	if _, err := invoke(config.TLSConfig{CAFile: caFile}); err == nil {
+		t.Error("call without a client certificate should fail")
+	}
+}
+
+func TestNewOpenAPIOperationInvoker_tlsFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := newClientCert(t, dir)
+	notPEM := filepath.Join(dir, "empty.crt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tls  config.TLSConfig
+		want string
+	}{
+		{name: "missing cert", tls: config.TLSConfig{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: keyFile}, want: "missing.crt"},
+		{name: "missing CA", tls: config.TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: filepath.Join(dir, "missing-ca.crt")}, want: "missing-ca.crt"},
+		{name: "CA without certificates", tls: config.TLSConfig{CAFile: notPEM}, want: "no PEM certificates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOpenAPIOperationInvoker(nil, map[string]config.ServiceConfig{"orders-svc": {TLS: tt.tls}}, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), `"orders-svc"`) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one naming orders-svc and %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// layerTransport stands in for the shared client's wrapping round
+// trippers: it sets a credential and a trace header on every request.
+type layerTransport struct {
+	base http.RoundTripper
+}
+
+func (t layerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer service-token")
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	return t.base.RoundTrip(req)
+}
+
+func TestOpenAPIOperationInvoker_Invoke_mutualTLSKeepsClientLayers(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := newClientCert(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"subject":"` + r.TLS.PeerCertificates[0].Subject.CommonName +
+			`","auth":"` + r.Header.Get("Authorization") + `","trace":"` + r.Header.Get("Traceparent") + `"}`))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	caFile := writePEM(t, dir, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+
+	svcCfg := defaultServiceConfig()
+	svcCfg.TLS = config.TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: caFile}
+	shared := &http.Client{Transport: layerTransport{base: http.DefaultTransport}, Timeout: 5 * time.Second}
+	newClient := func(transport *http.Transport) *http.Client {
+		return &http.Client{Transport: layerTransport{base: transport}, Timeout: shared.Timeout}
+	}
+	inv, err := NewOpenAPIOperationInvoker(loadTestIndex(t, server.URL), map[string]config.ServiceConfig{"test-svc": svcCfg}, shared, newClient)
+	if err != nil {
+		t.Fatalf("NewOpenAPIOperationInvoker error: %v", err)
+	}
+
+	result, err := inv.Invoke(context.Background(), nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{})
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+	body, _ := result.Body.(map[string]any)
+	if body["subject"] != "thesa-bff" {
+		t.Errorf("subject = %v, want the client certificate presented", body["subject"])
+	}
+	if body["auth"] != "Bearer service-token" || body["trace"] == "" {
+		t.Errorf("body = %v, want the credential and trace layers applied", body)
+	}
+}
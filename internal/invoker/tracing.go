@@ -0,0 +1,55 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/pitabwire/thesa/internal/invoker"
+
+// SetTracerProvider installs the provider backend call spans are created
+// with. By default the global tracer provider is used.
+func (inv *OpenAPIOperationInvoker) SetTracerProvider(tp trace.TracerProvider) {
+	inv.tracer = tp.Tracer(tracerName)
+}
+
+// startCallSpan starts the client span of one backend call.
+func (inv *OpenAPIOperationInvoker) startCallSpan(ctx context.Context, serviceID, operationID string) (context.Context, trace.Span) {
+	return inv.tracer.Start(ctx, "invoker.call",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("invoker.service_id", serviceID),
+			attribute.String("invoker.operation_id", operationID),
+		),
+	)
+}
+
+// endCallSpan ends a backend call span with the response status, marking
+// it failed if err is set or the backend responded with a server error.
+func endCallSpan(span trace.Span, statusCode int, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case statusCode != 0:
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+		if isServerError(statusCode) {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+	}
+	span.End()
+}
+
+// injectTraceContext adds the W3C traceparent and tracestate headers of the
+// span in ctx, using the global propagator, so backend spans join the trace.
+// Frame installs that propagator when its telemetry is enabled; without it
+// this is a no-op. Injected headers replace any already set.
+func injectTraceContext(ctx context.Context, headers http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(headers))
+}
@@ -0,0 +1,81 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestOpenAPIOperationInvoker_Invoke_tracing(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	inv := newTestInvoker(t, server.URL, defaultServiceConfig())
+	inv.SetTracerProvider(tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	_, err := inv.Invoke(ctx, nil,
+		model.OperationBinding{Type: "openapi", ServiceID: "test-svc", OperationID: "listUsers"},
+		model.InvocationInput{})
+	parent.End()
+	if err != nil {
+		t.Fatalf("Invoke error: %v", err)
+	}
+
+	var call tracetest.SpanStub
+	for _, s := range exporter.GetSpans() {
+		if s.Name == "invoker.call" {
+			call = s
+		}
+	}
+	if call.Name == "" {
+		t.Fatal("no invoker.call span recorded")
+	}
+	if call.Parent.SpanID() != parent.SpanContext().SpanID() || call.SpanKind != trace.SpanKindClient {
+		t.Errorf("span parent = %v kind = %v, want a client child of the request span", call.Parent.SpanID(), call.SpanKind)
+	}
+	want := map[attribute.Key]attribute.Value{
+		"invoker.service_id":        attribute.StringValue("test-svc"),
+		"invoker.operation_id":      attribute.StringValue("listUsers"),
+		"http.response.status_code": attribute.IntValue(http.StatusServiceUnavailable),
+	}
+	for _, kv := range call.Attributes {
+		if v, ok := want[kv.Key]; ok && v == kv.Value {
+			delete(want, kv.Key)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("span attributes = %v, missing %v", call.Attributes, want)
+	}
+	if call.Status.Code != codes.Error {
+		t.Errorf("span status = %v, want error for a 503 response", call.Status.Code)
+	}
+
+	wantParent := "00-" + call.SpanContext.TraceID().String() + "-" + call.SpanContext.SpanID().String() + "-01"
+	if traceparent != wantParent {
+		t.Errorf("traceparent = %q, want %q", traceparent, wantParent)
+	}
+}
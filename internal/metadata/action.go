@@ -1,20 +1,31 @@
 package metadata
 
 import (
-	"fmt"
-
 	"github.com/pitabwire/thesa/model"
 )
 
 // ActionProvider resolves ActionDefinition lists into ActionDescriptor lists,
 // filtering by capabilities and evaluating static conditions.
-type ActionProvider struct{}
+type ActionProvider struct {
+	commands CommandStatus
+}
+
+// CommandStatus reports whether a command is disabled at runtime. It is
+// implemented by definition.Registry.
+type CommandStatus interface {
+	CommandDisabled(commandID string) bool
+}
 
 // NewActionProvider creates a new ActionProvider.
 func NewActionProvider() *ActionProvider {
 	return &ActionProvider{}
 }
 
+// SetCommandStatus hides actions whose command is disabled at runtime.
+func (p *ActionProvider) SetCommandStatus(s CommandStatus) {
+	p.commands = s
+}
+
 // ResolveActions resolves a list of action definitions into descriptors,
 // filtering by capabilities. Static conditions are evaluated for
 // enabled/visible state, while data-dependent conditions are passed through
@@ -30,6 +41,10 @@ func (p *ActionProvider) ResolveActions(
 		if len(action.Capabilities) > 0 && !caps.HasAll(action.Capabilities...) {
 			continue
 		}
+		// Omit actions whose command is disabled.
+		if action.CommandID != "" && p.commands != nil && p.commands.CommandDisabled(action.CommandID) {
+			continue
+		}
 
 		desc := model.ActionDescriptor{
 			ID:         action.ID,
@@ -61,7 +76,7 @@ func (p *ActionProvider) ResolveActions(
 		for _, cond := range action.Conditions {
 			if isStaticCondition(cond, resourceData) {
 				// Evaluate static condition and apply its effect.
-				met := evaluateStaticCondition(cond, resourceData)
+				met := cond.Holds(resourceData)
 				applyConditionEffect(&desc, cond.Effect, met)
 			} else {
 				// Pass data-dependent conditions through for client-side evaluation.
@@ -94,36 +109,6 @@ func isStaticCondition(cond model.ConditionDefinition, data map[string]any) bool
 	return exists
 }
 
-// evaluateStaticCondition evaluates a condition against resource data.
-func evaluateStaticCondition(cond model.ConditionDefinition, data map[string]any) bool {
-	if data == nil {
-		return false
-	}
-	fieldVal, exists := data[cond.Field]
-	if !exists {
-		return false
-	}
-
-	condValue := cond.Value
-
-	switch cond.Operator {
-	case "eq", "equals", "==":
-		return fmt.Sprint(fieldVal) == fmt.Sprint(condValue)
-	case "neq", "not_equals", "!=":
-		return fmt.Sprint(fieldVal) != fmt.Sprint(condValue)
-	case "in":
-		return valueInSlice(fieldVal, condValue)
-	case "not_in":
-		return !valueInSlice(fieldVal, condValue)
-	case "exists":
-		return exists
-	case "not_exists":
-		return !exists
-	default:
-		return false
-	}
-}
-
 // applyConditionEffect applies the result of a condition evaluation to the descriptor.
 func applyConditionEffect(desc *model.ActionDescriptor, effect string, conditionMet bool) {
 	switch effect {
@@ -145,58 +130,3 @@ func applyConditionEffect(desc *model.ActionDescriptor, effect string, condition
 		}
 	}
 }
-
-// valueInSlice checks if fieldVal matches any value in condValue (expected to be a slice-like value).
-func valueInSlice(fieldVal, condValue any) bool {
-	// condValue could be a comma-separated string or a slice.
-	fieldStr := fmt.Sprint(fieldVal)
-
-	switch cv := condValue.(type) {
-	case []any:
-		for _, v := range cv {
-			if fmt.Sprint(v) == fieldStr {
-				return true
-			}
-		}
-	case string:
-		// Treat as comma-separated list.
-		for _, v := range splitComma(cv) {
-			if v == fieldStr {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-// splitComma splits a string by commas and trims whitespace.
-func splitComma(s string) []string {
-	if s == "" {
-		return nil
-	}
-	var parts []string
-	start := 0
-	for i := 0; i <= len(s); i++ {
-		if i == len(s) || s[i] == ',' {
-			part := trimSpace(s[start:i])
-			if part != "" {
-				parts = append(parts, part)
-			}
-			start = i + 1
-		}
-	}
-	return parts
-}
-
-// trimSpace trims leading and trailing spaces.
-func trimSpace(s string) string {
-	i := 0
-	for i < len(s) && s[i] == ' ' {
-		i++
-	}
-	j := len(s)
-	for j > i && s[j-1] == ' ' {
-		j--
-	}
-	return s[i:j]
-}
@@ -48,6 +48,29 @@ func TestActionProvider_ResolveActions_filtersUncapable(t *testing.T) {
 	}
 }
 
+type disabledCommands map[string]bool
+
+func (d disabledCommands) CommandDisabled(commandID string) bool { return d[commandID] }
+
+func TestActionProvider_ResolveActions_hidesDisabledCommands(t *testing.T) {
+	ap := NewActionProvider()
+	ap.SetCommandStatus(disabledCommands{"delete-user": true})
+
+	actions := []model.ActionDefinition{
+		{ID: "delete", Label: "Delete", Type: "command", CommandID: "delete-user"},
+		{ID: "create", Label: "Create", Type: "command", CommandID: "create-user"},
+		{ID: "view", Label: "View", Type: "navigate", NavigateTo: "/users/{id}"},
+	}
+
+	result := ap.ResolveActions(model.CapabilitySet{"*": true}, actions, nil)
+	if len(result) != 2 {
+		t.Fatalf("len(result) = %d, want 2 (disabled command hidden)", len(result))
+	}
+	if result[0].ID != "create" || result[1].ID != "view" {
+		t.Errorf("result = [%s %s], want [create view]", result[0].ID, result[1].ID)
+	}
+}
+
 func TestActionProvider_ResolveActions_requiresAllCapabilities(t *testing.T) {
 	ap := NewActionProvider()
 
@@ -447,29 +470,3 @@ func TestActionProvider_ResolveActions_styleAndIcon(t *testing.T) {
 		t.Errorf("Style = %q, want danger", result[0].Style)
 	}
 }
-
-// --- splitComma and trimSpace ---
-
-func TestSplitComma(t *testing.T) {
-	tests := []struct {
-		input string
-		want  []string
-	}{
-		{"a,b,c", []string{"a", "b", "c"}},
-		{"a, b , c", []string{"a", "b", "c"}},
-		{"single", []string{"single"}},
-		{"", nil},
-	}
-	for _, tt := range tests {
-		got := splitComma(tt.input)
-		if len(got) != len(tt.want) {
-			t.Errorf("splitComma(%q) = %v, want %v", tt.input, got, tt.want)
-			continue
-		}
-		for i, v := range got {
-			if v != tt.want[i] {
-				t.Errorf("splitComma(%q)[%d] = %q, want %q", tt.input, i, v, tt.want[i])
-			}
-		}
-	}
-}
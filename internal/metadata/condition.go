@@ -0,0 +1,22 @@
+package metadata
+
+import "github.com/pitabwire/thesa/model"
+
+// conditionsMet reports whether every "show" condition holds and no "hide"
+// condition holds. Conditions with other effects are ignored.
+func conditionsMet(conds []model.ConditionDefinition, data map[string]any) bool {
+	for _, cond := range conds {
+		met := cond.Holds(data)
+		switch cond.Effect {
+		case "show":
+			if !met {
+				return false
+			}
+		case "hide":
+			if met {
+				return false
+			}
+		}
+	}
+	return true
+}
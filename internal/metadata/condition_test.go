@@ -0,0 +1,68 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pitabwire/thesa/internal/definition"
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestConditionsMet(t *testing.T) {
+	data := map[string]any{"plan": "pro"}
+	tests := []struct {
+		name  string
+		conds []model.ConditionDefinition
+		want  bool
+	}{
+		{"none", nil, true},
+		{"show met", []model.ConditionDefinition{{Field: "plan", Operator: "eq", Value: "pro", Effect: "show"}}, true},
+		{"show unmet", []model.ConditionDefinition{{Field: "plan", Operator: "eq", Value: "free", Effect: "show"}}, false},
+		{"hide met", []model.ConditionDefinition{{Field: "plan", Operator: "in", Value: "pro,team", Effect: "hide"}}, false},
+		{"disable ignored", []model.ConditionDefinition{{Field: "plan", Operator: "eq", Value: "pro", Effect: "disable"}}, true},
+	}
+	for _, tt := range tests {
+		if got := conditionsMet(tt.conds, data); got != tt.want {
+			t.Errorf("%s: conditionsMet = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// The same condition drives an action's visibility (resource data) and a
+// navigation item's inclusion (claims).
+func TestConditionsMet_sharedAcrossProviders(t *testing.T) {
+	proOnly := model.ConditionDefinition{Field: "plan", Operator: "eq", Value: "pro", Effect: "show"}
+
+	actions := NewActionProvider().ResolveActions(model.CapabilitySet{}, []model.ActionDefinition{
+		{ID: "export", Label: "Export", Type: "command", Conditions: []model.ConditionDefinition{proOnly}},
+	}, map[string]any{"plan": "free"})
+	if len(actions) != 1 || actions[0].Visible {
+		t.Errorf("action Visible = %v, want false for free plan", actions[0].Visible)
+	}
+
+	reg := definition.NewRegistry([]model.DomainDefinition{{
+		Domain: "reports",
+		Navigation: model.NavigationDefinition{
+			Label: "Reports",
+			Children: []model.NavigationChildDefinition{
+				{Label: "Summary", Route: "/reports", PageID: "reports.summary", Order: 1},
+				{Label: "Exports", Route: "/reports/exports", PageID: "reports.exports", Order: 2, Conditions: []model.ConditionDefinition{proOnly}},
+			},
+		},
+	}})
+	menu := NewMenuProvider(reg, nil)
+
+	for _, tc := range []struct {
+		plan string
+		want int
+	}{{"free", 1}, {"pro", 2}} {
+		rctx := &model.RequestContext{Claims: map[string]any{"plan": tc.plan}}
+		tree, err := menu.GetMenu(context.Background(), rctx, model.CapabilitySet{})
+		if err != nil {
+			t.Fatalf("GetMenu error: %v", err)
+		}
+		if got := len(tree.Items[0].Children); got != tc.want {
+			t.Errorf("plan %s: %d navigation children, want %d", tc.plan, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package metadata
+
+import "github.com/pitabwire/thesa/model"
+
+// currencyType is the column and field type formatted as a money amount,
+// with Format holding the ISO 4217 currency code.
+const currencyType = "currency"
+
+// callerCurrency returns the default currency of the caller's tenant.
+func callerCurrency(rctx *model.RequestContext) string {
+	if rctx == nil {
+		return ""
+	}
+	return rctx.Currency
+}
+
+// defaultColumnCurrency sets currency as the format of currency columns that
+// specify none.
+func defaultColumnCurrency(columns []model.ColumnDescriptor, currency string) {
+	if currency == "" {
+		return
+	}
+	for i := range columns {
+		if columns[i].Type == currencyType && columns[i].Format == "" {
+			columns[i].Format = currency
+		}
+	}
+}
+
+// defaultFieldCurrency sets currency as the format of currency fields that
+// specify none.
+func defaultFieldCurrency(sections []model.SectionDescriptor, currency string) {
+	if currency == "" {
+		return
+	}
+	for i := range sections {
+		for j := range sections[i].Fields {
+			f := &sections[i].Fields[j]
+			if f.Type == currencyType && f.Format == "" {
+				f.Format = currency
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package metadata
+
+import "github.com/pitabwire/thesa/model"
+
+// explainer collects the definition elements a descriptor omits for lack of
+// capabilities. It applies the same HasAll rule as the providers.
+type explainer struct {
+	caps  model.CapabilitySet
+	block model.ExplainBlock
+}
+
+func newExplainer(caps model.CapabilitySet) *explainer {
+	return &explainer{caps: caps, block: model.ExplainBlock{Filtered: []model.FilteredElement{}}}
+}
+
+// check records the element if caps does not match all of required.
+func (e *explainer) check(kind, id string, required []string) {
+	if missing := e.caps.Missing(required...); len(missing) > 0 {
+		e.block.Filtered = append(e.block.Filtered, model.FilteredElement{Kind: kind, ID: id, Missing: missing})
+	}
+}
+
+func (e *explainer) actions(kind string, actions []model.ActionDefinition) {
+	for _, a := range actions {
+		e.check(kind, a.ID, a.Capabilities)
+	}
+}
+
+func (e *explainer) sections(sections []model.SectionDefinition) {
+	for _, sec := range sections {
+		e.check("section", sec.ID, sec.Capabilities)
+	}
+}
+
+// ExplainMenu lists the domains and navigation items omitted from the
+// caller's navigation tree for lack of capabilities. Domains the caller is
+// not entitled to are not listed, so their existence is not disclosed.
+func (p *MenuProvider) ExplainMenu(rctx *model.RequestContext, caps model.CapabilitySet) *model.ExplainBlock {
+	e := newExplainer(caps)
+	for _, domain := range p.registry.AllDomains() {
+		if !rctx.EntitledTo(domain.Domain) {
+			continue
+		}
+		e.check("domain", domain.Domain, domain.Navigation.Capabilities)
+		for _, child := range domain.Navigation.Children {
+			e.check("navigation_item", child.PageID, child.Capabilities)
+		}
+	}
+	return &e.block
+}
+
+// ExplainPage lists the sections and actions omitted from a page descriptor
+// for lack of capabilities. It returns nil for an unknown page.
+func (p *PageProvider) ExplainPage(caps model.CapabilitySet, pageID string) *model.ExplainBlock {
+	pageDef, ok := p.registry.GetPage(pageID)
+	if !ok {
+		return nil
+	}
+	e := newExplainer(caps)
+	if pageDef.Table != nil {
+		e.actions("row_action", pageDef.Table.RowActions)
+		e.actions("bulk_action", pageDef.Table.BulkActions)
+	}
+	e.sections(pageDef.Sections)
+	e.actions("action", pageDef.Actions)
+	return &e.block
+}
+
+// ExplainForm lists the sections and submit actions omitted from a form
+// descriptor for lack of capabilities. It returns nil for an unknown form.
+func (p *FormProvider) ExplainForm(caps model.CapabilitySet, formID string) *model.ExplainBlock {
+	formDef, ok := p.registry.GetForm(formID)
+	if !ok {
+		return nil
+	}
+	e := newExplainer(caps)
+	for _, a := range formDef.SubmitActions {
+		e.check("submit_action", a.ID, a.Capabilities)
+	}
+	e.sections(formDef.Sections)
+	return &e.block
+}
@@ -0,0 +1,44 @@
+package metadata
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pitabwire/thesa/internal/definition"
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestMenuProvider_ExplainMenu_skipsUnentitledDomains(t *testing.T) {
+	provider := NewMenuProvider(definition.NewRegistry(testDomains()), nil)
+	rctx := &model.RequestContext{TenantID: "t1", UnentitledDomains: []string{"orders"}}
+
+	block := provider.ExplainMenu(rctx, model.CapabilitySet{})
+	if len(block.Filtered) == 0 {
+		t.Fatal("ExplainMenu() filtered nothing, want the entitled domains")
+	}
+	for _, el := range block.Filtered {
+		if strings.HasPrefix(el.ID, "orders") {
+			t.Errorf("ExplainMenu() lists %s %q of an unentitled domain", el.Kind, el.ID)
+		}
+	}
+}
+
+func TestPageProvider_ExplainPage(t *testing.T) {
+	p := newTestPageProvider(nil)
+	caps := model.CapabilitySet{"orders:list:view": true, "orders:cancel": true}
+
+	block := p.ExplainPage(caps, "orders-list")
+	want := []model.FilteredElement{
+		{Kind: "bulk_action", ID: "export", Missing: []string{"orders:export"}},
+		{Kind: "section", ID: "summary", Missing: []string{"orders:summary"}},
+		{Kind: "action", ID: "create-order", Missing: []string{"orders:create"}},
+	}
+	if block == nil || !reflect.DeepEqual(block.Filtered, want) {
+		t.Errorf("ExplainPage() = %+v, want %+v", block, want)
+	}
+
+	if block := p.ExplainPage(caps, "missing"); block != nil {
+		t.Errorf("ExplainPage(unknown) = %+v, want nil", block)
+	}
+}
@@ -0,0 +1,167 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// OptionSource resolves the options of a lookup for the given parameter
+// values. It is implemented by search.LookupProvider.
+type OptionSource interface {
+	OptionsWithParams(ctx context.Context, rctx *model.RequestContext, lookupID string, params map[string]string) ([]model.OptionDescriptor, error)
+}
+
+// SetOptionSource installs the source used to resolve lookup-backed filter
+// options. Without one, only static filter options are resolved. It must be
+// called before the provider serves requests.
+func (p *PageProvider) SetOptionSource(src OptionSource) {
+	p.options = src
+}
+
+// FilterOptions resolves the options of a lookup-backed filter on a list
+// page, given the current values of the page's filters keyed by field.
+// Returns an error with code NOT_FOUND or FORBIDDEN.
+func (p *PageProvider) FilterOptions(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	pageID string,
+	field string,
+	values map[string]string,
+) ([]model.OptionDescriptor, error) {
+	pageDef, ok := p.registry.GetPage(pageID)
+	if !ok || !rctx.EntitledTo(p.registry.PageDomain(pageID)) {
+		return nil, model.NewNotFoundError(
+			fmt.Sprintf("page %q not found", pageID),
+		)
+	}
+	if missing := caps.Missing(pageDef.Capabilities...); len(missing) > 0 {
+		return nil, model.NewInsufficientCapabilitiesError(
+			fmt.Sprintf("insufficient capabilities for page %q", pageID),
+			missing,
+		)
+	}
+
+	var filter *model.FilterDefinition
+	if pageDef.Table != nil {
+		for i := range pageDef.Table.Filters {
+			if pageDef.Table.Filters[i].Field == field {
+				filter = &pageDef.Table.Filters[i]
+				break
+			}
+		}
+	}
+	if filter == nil || filter.Options == nil || filter.Options.LookupID == "" || p.options == nil {
+		return nil, model.NewNotFoundError(
+			fmt.Sprintf("page %q has no lookup-backed filter %q", pageID, field),
+		)
+	}
+	return p.options.OptionsWithParams(ctx, rctx, filter.Options.LookupID, filterParams(filter.Options, values))
+}
+
+// resolveFilterOptions fills in the options of a lookup-backed filter
+// descriptor, using the default values of the filters it depends on. A
+// lookup that cannot be resolved leaves the options empty, so the frontend
+// can still fetch them from the options endpoint.
+func (p *PageProvider) resolveFilterOptions(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	table *model.TableDefinition,
+	pageID string,
+	f model.FilterDefinition,
+	fd *model.FilterDescriptor,
+) {
+	if f.Options == nil || f.Options.LookupID == "" || p.options == nil {
+		return
+	}
+	fd.OptionsEndpoint = fmt.Sprintf("/ui/pages/%s/filters/%s/options", pageID, f.Field)
+	fd.DependsOn = slices.Compact(slices.Sorted(maps.Values(f.Options.Params)))
+
+	defaults := make(map[string]string, len(table.Filters))
+	for _, other := range table.Filters {
+		defaults[other.Field] = other.Default
+	}
+	options, err := p.options.OptionsWithParams(ctx, rctx, f.Options.LookupID, filterParams(f.Options, defaults))
+	if err != nil {
+		util.Log(ctx).WithError(err).Warn("page: filter options unavailable",
+			"page", pageID,
+			"filter", f.Field,
+			"lookup", f.Options.LookupID,
+		)
+		return
+	}
+	fd.Options = options
+}
+
+// filterParams maps filter values to the lookup parameters they supply.
+func filterParams(opts *model.FilterOptionsDefinition, values map[string]string) map[string]string {
+	if len(opts.Params) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(opts.Params))
+	for param, field := range opts.Params {
+		params[param] = values[field]
+	}
+	return params
+}
+
+// normalizeDateRanges validates the bounds of the table's date_range
+// filters, supplied as {field}_from and {field}_to, and returns a copy of
+// filters with each bound in canonical form: YYYY-MM-DD for dates, UTC
+// RFC 3339 for timestamps. Returns an error with code BAD_REQUEST if a
+// bound is not an ISO date or the range ends before it starts.
+func normalizeDateRanges(table *model.TableDefinition, filters map[string]string) (map[string]string, error) {
+	if table == nil || len(filters) == 0 {
+		return filters, nil
+	}
+	normalized := maps.Clone(filters)
+	for _, f := range table.Filters {
+		if f.Type != "date_range" {
+			continue
+		}
+		from, err := normalizeDateBound(normalized, f.Field+"_from")
+		if err != nil {
+			return nil, err
+		}
+		to, err := normalizeDateBound(normalized, f.Field+"_to")
+		if err != nil {
+			return nil, err
+		}
+		if !from.IsZero() && !to.IsZero() && to.Before(from) {
+			return nil, model.NewBadRequestError(
+				fmt.Sprintf("filter %q: range ends before it starts", f.Field),
+			)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeDateBound parses the date bound stored under key, rewrites it
+// in canonical form and returns it. An absent or empty bound returns the
+// zero time.
+func normalizeDateBound(filters map[string]string, key string) (time.Time, error) {
+	raw, ok := filters[key]
+	if !ok || raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		filters[key] = t.Format(time.DateOnly)
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, model.NewBadRequestError(
+			fmt.Sprintf("filter %q: %q is not an ISO 8601 date", key, raw),
+		)
+	}
+	t = t.UTC()
+	filters[key] = t.Format(time.RFC3339)
+	return t, nil
+}
@@ -0,0 +1,167 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/thesa/internal/definition"
+	"github.com/pitabwire/thesa/internal/invoker"
+	"github.com/pitabwire/thesa/internal/search"
+	"github.com/pitabwire/thesa/model"
+)
+
+// newDependentFilterProvider returns a page provider whose orders-list
+// status filter draws its options from a lookup narrowed by the region
+// filter. The lookup backend returns the statuses available in the region
+// query parameter.
+func newDependentFilterProvider(t *testing.T, fail bool) *PageProvider {
+	t.Helper()
+	defs := testPageDefinitions()
+	table := defs[0].Pages[0].Table
+	table.Filters = []model.FilterDefinition{
+		{Field: "region", Label: "Region", Type: "select", Default: "eu"},
+		{Field: "status", Label: "Status", Type: "select", Options: &model.FilterOptionsDefinition{
+			LookupID: "orders.statuses",
+			Params:   map[string]string{"region": "region"},
+		}},
+	}
+	defs[0].Lookups = []model.LookupDefinition{{
+		ID:         "orders.statuses",
+		Operation:  model.OperationBinding{Type: "openapi", OperationID: "listStatuses"},
+		LabelField: "name",
+		ValueField: "code",
+		Params:     []string{"region"},
+	}}
+
+	statuses := map[string][]string{
+		"eu": {"pending", "shipped"},
+		"us": {"pending", "shipped", "backordered"},
+	}
+	invokerReg := invoker.NewRegistry()
+	invokerReg.Register(&mockInvokerForMenu{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		if fail {
+			return model.InvocationResult{}, errors.New("lookup backend down")
+		}
+		var items []any
+		for _, code := range statuses[input.QueryParams["region"]] {
+			items = append(items, map[string]any{"code": code, "name": code})
+		}
+		return model.InvocationResult{StatusCode: 200, Body: items}, nil
+	}})
+
+	reg := definition.NewRegistry(defs)
+	p := NewPageProvider(reg, invokerReg, NewActionProvider())
+	p.SetOptionSource(search.NewLookupProvider(reg, invokerReg, time.Minute, 100, 100))
+	return p
+}
+
+func optionValues(options []model.OptionDescriptor) []string {
+	values := make([]string, len(options))
+	for i, o := range options {
+		values[i] = o.Value
+	}
+	return values
+}
+
+func TestPageProvider_lookupFilterOptions(t *testing.T) {
+	p := newDependentFilterProvider(t, false)
+	caps := model.CapabilitySet{"orders:list:view": true}
+	rctx := &model.RequestContext{SubjectID: "user-1", TenantID: "acme"}
+
+	desc, err := p.GetPage(context.Background(), rctx, caps, "orders-list")
+	if err != nil {
+		t.Fatalf("GetPage error: %v", err)
+	}
+	status := desc.Table.Filters[1]
+	if got := optionValues(status.Options); len(got) != 2 || got[1] != "shipped" {
+		t.Errorf("initial options = %v, want the statuses of the default region", got)
+	}
+	if len(status.DependsOn) != 1 || status.DependsOn[0] != "region" {
+		t.Errorf("DependsOn = %v, want [region]", status.DependsOn)
+	}
+	if status.OptionsEndpoint != "/ui/pages/orders-list/filters/status/options" {
+		t.Errorf("OptionsEndpoint = %q", status.OptionsEndpoint)
+	}
+
+	options, err := p.FilterOptions(context.Background(), rctx, caps, "orders-list", "status", map[string]string{"region": "us"})
+	if err != nil {
+		t.Fatalf("FilterOptions error: %v", err)
+	}
+	if got := optionValues(options); len(got) != 3 || got[2] != "backordered" {
+		t.Errorf("options for region us = %v, want the us statuses", got)
+	}
+
+	if _, err := p.FilterOptions(context.Background(), rctx, caps, "orders-list", "region", nil); err == nil {
+		t.Error("FilterOptions for a filter without a lookup should fail")
+	}
+	if _, err := p.FilterOptions(context.Background(), rctx, model.CapabilitySet{}, "orders-list", "status", nil); err == nil {
+		t.Error("FilterOptions without the page capability should fail")
+	}
+}
+
+func TestPageProvider_lookupFilterOptions_unavailable(t *testing.T) {
+	p := newDependentFilterProvider(t, true)
+	caps := model.CapabilitySet{"orders:list:view": true}
+
+	desc, err := p.GetPage(context.Background(), &model.RequestContext{}, caps, "orders-list")
+	if err != nil {
+		t.Fatalf("GetPage error = %v, want the page despite the lookup failure", err)
+	}
+	if status := desc.Table.Filters[1]; len(status.Options) != 0 || status.OptionsEndpoint == "" {
+		t.Errorf("status filter = %+v, want no options but an options endpoint", status)
+	}
+}
+
+func TestPageProvider_GetPageData_dateRangeFilter(t *testing.T) {
+	defs := testPageDefinitions()
+	table := defs[0].Pages[0].Table
+	table.Filters = append(table.Filters, model.FilterDefinition{Field: "created_at", Label: "Created", Type: "date_range"})
+
+	var query map[string]string
+	invokerReg := invoker.NewRegistry()
+	invokerReg.Register(&mockInvokerForMenu{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		query = input.QueryParams
+		return model.InvocationResult{StatusCode: 200, Body: map[string]any{"data": map[string]any{"items": []any{}}}}, nil
+	}})
+	p := NewPageProvider(definition.NewRegistry(defs), invokerReg, NewActionProvider())
+	caps := model.CapabilitySet{"orders:list:view": true}
+
+	tests := []struct {
+		name     string
+		filters  map[string]string
+		wantErr  bool
+		wantFrom string
+		wantTo   string
+	}{
+		{name: "dates", filters: map[string]string{"created_at_from": "2025-01-01", "created_at_to": "2025-01-31"}, wantFrom: "2025-01-01", wantTo: "2025-01-31"},
+		{name: "timestamps normalized to UTC", filters: map[string]string{"created_at_from": "2025-01-01T02:00:00+02:00", "created_at_to": "2025-01-01T00:00:00Z"}, wantFrom: "2025-01-01T00:00:00Z", wantTo: "2025-01-01T00:00:00Z"},
+		{name: "open-ended", filters: map[string]string{"created_at_from": "2025-01-01"}, wantFrom: "2025-01-01"},
+		{name: "reversed", filters: map[string]string{"created_at_from": "2025-02-01", "created_at_to": "2025-01-01"}, wantErr: true},
+		{name: "malformed", filters: map[string]string{"created_at_from": "01/02/2025"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query = nil
+			_, err := p.GetPageData(context.Background(), &model.RequestContext{}, caps, "orders-list", model.DataParams{Filters: tt.filters})
+			if tt.wantErr {
+				envErr, ok := err.(*model.ErrorEnvelope)
+				if !ok || envErr.Code != model.ErrBadRequest {
+					t.Fatalf("error = %v, want BAD_REQUEST", err)
+				}
+				if query != nil {
+					t.Error("invalid range should not reach the backend")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPageData error: %v", err)
+			}
+			if query["created_at_from"] != tt.wantFrom || query["created_at_to"] != tt.wantTo {
+				t.Errorf("forwarded range = %q..%q, want %q..%q",
+					query["created_at_from"], query["created_at_to"], tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
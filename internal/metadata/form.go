@@ -35,28 +35,33 @@ func (p *FormProvider) GetForm(
 	formID string,
 ) (model.FormDescriptor, error) {
 	formDef, ok := p.registry.GetForm(formID)
-	if !ok {
+	if !ok || !rctx.EntitledTo(p.registry.FormDomain(formID)) {
 		return model.FormDescriptor{}, model.NewNotFoundError(
 			fmt.Sprintf("form %q not found", formID),
 		)
 	}
 
-	if len(formDef.Capabilities) > 0 && !caps.HasAll(formDef.Capabilities...) {
-		return model.FormDescriptor{}, model.NewForbiddenError(
+	if missing := caps.Missing(formDef.Capabilities...); len(missing) > 0 {
+		return model.FormDescriptor{}, model.NewInsufficientCapabilitiesError(
 			fmt.Sprintf("insufficient capabilities for form %q", formID),
+			missing,
 		)
 	}
 
 	desc := model.FormDescriptor{
 		ID:             formDef.ID,
 		Title:          formDef.Title,
-		SubmitEndpoint: fmt.Sprintf("/api/commands/%s", formDef.SubmitCommand),
 		SuccessRoute:   formDef.SuccessRoute,
 		SuccessMessage: formDef.SuccessMessage,
 	}
+	if formDef.SubmitCommand != "" && !p.registry.CommandDisabled(formDef.SubmitCommand) {
+		desc.SubmitEndpoint = commandEndpoint(formDef.SubmitCommand)
+	}
+	desc.SubmitActions = resolveSubmitActions(caps, p.registry, formDef.SubmitActions)
 
 	// Resolve sections.
 	desc.Sections = p.resolveSections(caps, formDef.Sections)
+	defaultFieldCurrency(desc.Sections, callerCurrency(rctx))
 
 	return desc, nil
 }
@@ -72,15 +77,16 @@ func (p *FormProvider) GetFormData(
 	params map[string]string,
 ) (map[string]any, error) {
 	formDef, ok := p.registry.GetForm(formID)
-	if !ok {
+	if !ok || !rctx.EntitledTo(p.registry.FormDomain(formID)) {
 		return nil, model.NewNotFoundError(
 			fmt.Sprintf("form %q not found", formID),
 		)
 	}
 
-	if len(formDef.Capabilities) > 0 && !caps.HasAll(formDef.Capabilities...) {
-		return nil, model.NewForbiddenError(
+	if missing := caps.Missing(formDef.Capabilities...); len(missing) > 0 {
+		return nil, model.NewInsufficientCapabilitiesError(
 			fmt.Sprintf("insufficient capabilities for form %q", formID),
+			missing,
 		)
 	}
 
@@ -88,7 +94,75 @@ func (p *FormProvider) GetFormData(
 		return nil, nil // No load source → empty form.
 	}
 
-	ds := formDef.LoadSource
+	body, err := p.load(ctx, rctx, formDef.LoadSource, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter to only include fields that appear in the resolved form.
+	formFields := collectFormFields(formDef.Sections)
+	data := filterToFields(body, formFields)
+	maskFields(data, formDef.Sections)
+	return data, nil
+}
+
+// MaskedOriginals returns the stored values of the masked fields on the
+// first form that submits to commandID and has a load source, loaded with
+// the given route parameters. The command executor compares them with
+// submitted masked values.
+func (p *FormProvider) MaskedOriginals(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	commandID string,
+	params map[string]string,
+) (map[string]any, error) {
+	for _, domain := range p.registry.AllDomains() {
+		for _, formDef := range domain.Forms {
+			if formDef.LoadSource == nil || !submitsTo(formDef, commandID) {
+				continue
+			}
+			masked := make(map[string]bool)
+			for _, sec := range formDef.Sections {
+				for _, f := range sec.Fields {
+					if f.Mask != nil {
+						masked[f.Field] = true
+					}
+				}
+			}
+			if len(masked) == 0 {
+				continue
+			}
+			body, err := p.load(ctx, rctx, formDef.LoadSource, params)
+			if err != nil {
+				return nil, err
+			}
+			return filterToFields(body, masked), nil
+		}
+	}
+	return nil, nil
+}
+
+// submitsTo reports whether the form submits to the given command.
+func submitsTo(formDef model.FormDefinition, commandID string) bool {
+	if formDef.SubmitCommand == commandID {
+		return true
+	}
+	for _, a := range formDef.SubmitActions {
+		if a.CommandID == commandID {
+			return true
+		}
+	}
+	return false
+}
+
+// load invokes a form's load source and returns its body with the field map
+// applied. A body that is not an object loads as an empty one.
+func (p *FormProvider) load(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	ds *model.DataSourceDefinition,
+	params map[string]string,
+) (map[string]any, error) {
 	binding := model.OperationBinding{
 		Type:        "openapi",
 		ServiceID:   ds.ServiceID,
@@ -117,10 +191,7 @@ func (p *FormProvider) GetFormData(
 	if len(ds.Mapping.FieldMap) > 0 {
 		body = renameFields(body, ds.Mapping.FieldMap)
 	}
-
-	// Filter to only include fields that appear in the resolved form.
-	formFields := collectFormFields(formDef.Sections)
-	return filterToFields(body, formFields), nil
+	return body, nil
 }
 
 // resolveSections builds SectionDescriptors from form SectionDefinitions,
@@ -214,6 +285,19 @@ func filterToFields(data map[string]any, fields map[string]bool) map[string]any
 	return result
 }
 
+// maskFields replaces the values of masked fields in data with their masked
+// form. Nil values are left as they are.
+func maskFields(data map[string]any, sections []model.SectionDefinition) {
+	for _, sec := range sections {
+		for _, f := range sec.Fields {
+			if f.Mask == nil || data[f.Field] == nil {
+				continue
+			}
+			data[f.Field] = f.Mask.Apply(fmt.Sprint(data[f.Field]))
+		}
+	}
+}
+
 // renameFields renames keys in data according to the field map.
 func renameFields(data map[string]any, fieldMap map[string]string) map[string]any {
 	result := make(map[string]any, len(data))
@@ -226,3 +310,30 @@ func renameFields(data map[string]any, fieldMap map[string]string) map[string]an
 	}
 	return result
 }
+
+// resolveSubmitActions returns the submit buttons the caller may use, each
+// posting to its own command endpoint. Buttons of disabled commands are
+// omitted.
+func resolveSubmitActions(caps model.CapabilitySet, commands CommandStatus, defs []model.SubmitActionDefinition) []model.SubmitActionDescriptor {
+	var out []model.SubmitActionDescriptor
+	for _, a := range defs {
+		if len(a.Capabilities) > 0 && !caps.HasAll(a.Capabilities...) {
+			continue
+		}
+		if commands.CommandDisabled(a.CommandID) {
+			continue
+		}
+		out = append(out, model.SubmitActionDescriptor{
+			ID:             a.ID,
+			Label:          a.Label,
+			Style:          a.Style,
+			SubmitEndpoint: commandEndpoint(a.CommandID),
+		})
+	}
+	return out
+}
+
+// commandEndpoint returns the submit endpoint for the given command.
+func commandEndpoint(commandID string) string {
+	return "/api/commands/" + commandID
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"reflect"
 	"testing"
 
 	"github.com/pitabwire/thesa/internal/definition"
@@ -161,6 +162,30 @@ func testFormDefinitions() []model.DomainDefinition {
 						},
 					},
 				},
+				{
+					ID:    "expense-report",
+					Title: "Expense Report",
+					SubmitActions: []model.SubmitActionDefinition{
+						{ID: "save", Label: "Save", CommandID: "expense-save-cmd"},
+						{
+							ID:           "submit",
+							Label:        "Save and Submit",
+							Style:        "primary",
+							CommandID:    "expense-submit-cmd",
+							Capabilities: []string{"expenses:submit"},
+						},
+					},
+					Sections: []model.SectionDefinition{
+						{
+							ID:     "expense-info",
+							Title:  "Expense",
+							Layout: "form",
+							Fields: []model.FieldDefinition{
+								{Field: "amount", Label: "Amount", Type: "number"},
+							},
+						},
+					},
+				},
 				{
 					ID:            "sdk-form",
 					Title:         "SDK Form",
@@ -233,6 +258,55 @@ func TestFormProvider_GetForm_submitEndpoint(t *testing.T) {
 	}
 }
 
+func TestFormProvider_GetForm_submitEndpointHiddenWhenDisabled(t *testing.T) {
+	p := newTestFormProvider(nil)
+	p.registry.SetCommandDisabled("create-user-cmd", true)
+
+	desc, err := p.GetForm(context.Background(), nil, model.CapabilitySet{}, "create-user")
+	if err != nil {
+		t.Fatalf("GetForm error: %v", err)
+	}
+	if desc.SubmitEndpoint != "" {
+		t.Errorf("SubmitEndpoint = %q, want empty for a disabled command", desc.SubmitEndpoint)
+	}
+}
+
+func TestFormProvider_GetForm_submitActions(t *testing.T) {
+	p := newTestFormProvider(nil)
+
+	desc, err := p.GetForm(context.Background(), nil, model.CapabilitySet{"expenses:submit": true}, "expense-report")
+	if err != nil {
+		t.Fatalf("GetForm error: %v", err)
+	}
+	if desc.SubmitEndpoint != "" {
+		t.Errorf("SubmitEndpoint = %q, want empty for a form with submit actions", desc.SubmitEndpoint)
+	}
+	want := []model.SubmitActionDescriptor{
+		{ID: "save", Label: "Save", SubmitEndpoint: "/api/commands/expense-save-cmd"},
+		{ID: "submit", Label: "Save and Submit", Style: "primary", SubmitEndpoint: "/api/commands/expense-submit-cmd"},
+	}
+	if len(desc.SubmitActions) != len(want) {
+		t.Fatalf("SubmitActions = %+v, want %d actions", desc.SubmitActions, len(want))
+	}
+	for i := range want {
+		if desc.SubmitActions[i] != want[i] {
+			t.Errorf("SubmitActions[%d] = %+v, want %+v", i, desc.SubmitActions[i], want[i])
+		}
+	}
+}
+
+func TestFormProvider_GetForm_submitActionsFilteredByCapability(t *testing.T) {
+	p := newTestFormProvider(nil)
+
+	desc, err := p.GetForm(context.Background(), nil, model.CapabilitySet{}, "expense-report")
+	if err != nil {
+		t.Fatalf("GetForm error: %v", err)
+	}
+	if len(desc.SubmitActions) != 1 || desc.SubmitActions[0].ID != "save" {
+		t.Errorf("SubmitActions = %+v, want only save", desc.SubmitActions)
+	}
+}
+
 func TestFormProvider_GetForm_notFound(t *testing.T) {
 	p := newTestFormProvider(nil)
 
@@ -722,6 +796,53 @@ func TestFormProvider_GetFormData_filtersToFormFields(t *testing.T) {
 	}
 }
 
+func TestFormProvider_GetFormData_masksFields(t *testing.T) {
+	reg := definition.NewRegistry([]model.DomainDefinition{{
+		Domain: "payments",
+		Forms: []model.FormDefinition{{
+			ID: "payment-edit", SubmitCommand: "payments.update",
+			LoadSource: &model.DataSourceDefinition{Handler: "getPayment"},
+			Sections: []model.SectionDefinition{{ID: "card", Fields: []model.FieldDefinition{
+				{Field: "card_number", Mask: &model.MaskDefinition{ShowLast: 4}},
+				{Field: "pin", Mask: &model.MaskDefinition{ShowLast: 4, Char: "•"}},
+				{Field: "ssn", Mask: &model.MaskDefinition{}},
+				{Field: "holder"},
+			}}},
+		}},
+	}})
+	invokerReg := invoker.NewRegistry()
+	invokerReg.Register(&mockInvokerForMenu{invokeFn: func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{StatusCode: http.StatusOK, Body: map[string]any{
+			"card_number": "4242424242424242",
+			"pin":         float64(1234),
+			"ssn":         nil,
+			"holder":      "Alice",
+		}}, nil
+	}})
+	p := NewFormProvider(reg, invokerReg, NewActionProvider())
+
+	data, err := p.GetFormData(context.Background(), nil, model.CapabilitySet{}, "payment-edit", nil)
+	if err != nil {
+		t.Fatalf("GetFormData error: %v", err)
+	}
+	want := map[string]any{"card_number": "************4242", "pin": "••••", "ssn": nil, "holder": "Alice"}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("data = %v, want %v", data, want)
+	}
+
+	originals, err := p.MaskedOriginals(context.Background(), nil, "payments.update", nil)
+	if err != nil {
+		t.Fatalf("MaskedOriginals error: %v", err)
+	}
+	wantOriginals := map[string]any{"card_number": "4242424242424242", "pin": float64(1234), "ssn": nil}
+	if !reflect.DeepEqual(originals, wantOriginals) {
+		t.Errorf("MaskedOriginals() = %v, want %v", originals, wantOriginals)
+	}
+	if originals, _ := p.MaskedOriginals(context.Background(), nil, "payments.other", nil); originals != nil {
+		t.Errorf("MaskedOriginals(other command) = %v, want nil", originals)
+	}
+}
+
 func TestFormProvider_GetFormData_noFieldMapWhenEmpty(t *testing.T) {
 	p := newTestFormProvider(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
 		return model.InvocationResult{
@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"context"
+	"slices"
 	"sort"
 
 	"github.com/pitabwire/util"
@@ -33,10 +34,14 @@ func NewMenuProvider(registry *definition.Registry, invokers *invoker.Registry)
 func (p *MenuProvider) GetMenu(ctx context.Context, rctx *model.RequestContext, caps model.CapabilitySet) (model.NavigationTree, error) {
 	domains := p.registry.AllDomains()
 
-	var nodes []model.NavigationNode
+	var nodes []orderedChild
 	for _, domain := range domains {
 		nav := domain.Navigation
 
+		if !rctx.EntitledTo(domain.Domain) {
+			continue
+		}
+
 		// Check domain-level capabilities.
 		if len(nav.Capabilities) > 0 && !caps.HasAll(nav.Capabilities...) {
 			continue
@@ -54,6 +59,9 @@ func (p *MenuProvider) GetMenu(ctx context.Context, rctx *model.RequestContext,
 			if len(child.Capabilities) > 0 && !caps.HasAll(child.Capabilities...) {
 				continue
 			}
+			if len(child.Conditions) > 0 && !conditionsMet(child.Conditions, requestClaims(rctx)) {
+				continue
+			}
 
 			childNode := model.NavigationNode{
 				ID:     child.PageID,
@@ -72,13 +80,13 @@ func (p *MenuProvider) GetMenu(ctx context.Context, rctx *model.RequestContext,
 			}
 
 			children = append(children, orderedChild{
-				order: child.Order,
+				order: navOrder(child.Order, child.OrderOverrides, rctx, caps),
 				node:  childNode,
 			})
 		}
 
 		// Sort children by their order field.
-		sort.Slice(children, func(i, j int) bool {
+		sort.SliceStable(children, func(i, j int) bool {
 			return children[i].order < children[j].order
 		})
 
@@ -87,18 +95,23 @@ func (p *MenuProvider) GetMenu(ctx context.Context, rctx *model.RequestContext,
 			node.Children[i] = c.node
 		}
 
-		nodes = append(nodes, node)
+		nodes = append(nodes, orderedChild{
+			order: navOrder(nav.Order, nav.OrderOverrides, rctx, caps),
+			node:  node,
+		})
 	}
 
-	// Sort top-level nodes by their navigation order.
-	sort.Slice(nodes, func(i, j int) bool {
-		// Look up the order from the domain definitions.
-		orderI := p.domainOrder(domains, nodes[i].ID)
-		orderJ := p.domainOrder(domains, nodes[j].ID)
-		return orderI < orderJ
+	// Sort top-level nodes by their navigation order. Domains arrive in ID
+	// order, so ties keep a stable order.
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].order < nodes[j].order
 	})
 
-	return model.NavigationTree{Items: nodes}, nil
+	items := make([]model.NavigationNode, len(nodes))
+	for i, n := range nodes {
+		items[i] = n.node
+	}
+	return model.NavigationTree{Items: items}, nil
 }
 
 // orderedChild pairs a navigation node with its sort order.
@@ -107,14 +120,21 @@ type orderedChild struct {
 	node  model.NavigationNode
 }
 
-// domainOrder returns the navigation order for the domain with the given ID.
-func (p *MenuProvider) domainOrder(domains []model.DomainDefinition, domainID string) int {
-	for _, d := range domains {
-		if d.Domain == domainID {
-			return d.Navigation.Order
+// navOrder returns the order of the first override matching the caller, or
+// order if none does.
+func navOrder(order int, overrides []model.OrderOverride, rctx *model.RequestContext, caps model.CapabilitySet) int {
+	for _, o := range overrides {
+		if len(o.Capabilities) > 0 && !caps.HasAll(o.Capabilities...) {
+			continue
+		}
+		if len(o.Roles) > 0 && (rctx == nil || !slices.ContainsFunc(o.Roles, func(role string) bool {
+			return slices.Contains(rctx.Roles, role)
+		})) {
+			continue
 		}
+		return o.Order
 	}
-	return 0
+	return order
 }
 
 // resolveBadge attempts to fetch a badge count by invoking the badge operation.
@@ -183,3 +203,12 @@ func extractBadgeCount(body any, field string) int {
 		return 0
 	}
 }
+
+// requestClaims returns the caller's token claims, or nil without a request
+// context.
+func requestClaims(rctx *model.RequestContext) map[string]any {
+	if rctx == nil {
+		return nil
+	}
+	return rctx.Claims
+}
@@ -3,6 +3,7 @@ package metadata
 import (
 	"context"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/pitabwire/thesa/internal/definition"
@@ -147,6 +148,27 @@ func TestMenuProvider_GetMenu_filtersDomainsByCapability(t *testing.T) {
 	}
 }
 
+func TestMenuProvider_GetMenu_hidesUnentitledDomain(t *testing.T) {
+	reg := definition.NewRegistry(testDomains())
+	provider := NewMenuProvider(reg, nil)
+
+	caps := model.CapabilitySet{"*": true}
+	rctx := &model.RequestContext{TenantID: "t1", UnentitledDomains: []string{"orders"}}
+
+	tree, err := provider.GetMenu(context.Background(), rctx, caps)
+	if err != nil {
+		t.Fatalf("GetMenu error: %v", err)
+	}
+	for _, item := range tree.Items {
+		if item.ID == "orders" {
+			t.Errorf("unentitled domain %q in navigation despite matching capabilities", item.ID)
+		}
+	}
+	if len(tree.Items) != 2 {
+		t.Errorf("len(Items) = %d, want 2 entitled domains", len(tree.Items))
+	}
+}
+
 func TestMenuProvider_GetMenu_filtersChildrenByCapability(t *testing.T) {
 	reg := definition.NewRegistry(testDomains())
 	provider := NewMenuProvider(reg, nil)
@@ -205,6 +227,60 @@ func TestMenuProvider_GetMenu_childrenSortedByOrder(t *testing.T) {
 	}
 }
 
+func TestMenuProvider_GetMenu_orderOverrides(t *testing.T) {
+	domains := testDomains()
+	// Approvers see pending approvals first, and the orders domain first.
+	domains[0].Navigation.OrderOverrides = []model.OrderOverride{{Roles: []string{"approver"}, Order: 0}}
+	domains[0].Navigation.Children[1].OrderOverrides = []model.OrderOverride{
+		{Roles: []string{"approver"}, Order: 0},
+		{Capabilities: []string{"orders:audit"}, Order: 5},
+	}
+	provider := NewMenuProvider(definition.NewRegistry(domains), nil)
+	caps := model.CapabilitySet{
+		"orders:view":         true,
+		"orders:list:view":    true,
+		"orders:pending:view": true,
+		"users:view":          true,
+	}
+
+	ids := func(nodes []model.NavigationNode) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+	tests := []struct {
+		name         string
+		roles        []string
+		wantDomains  []string
+		wantChildren []string
+	}{
+		{"clerk keeps the default order", []string{"clerk"}, []string{"users", "orders"}, []string{"orders-list", "orders-pending"}},
+		{"approver gets the override", []string{"clerk", "approver"}, []string{"orders", "users"}, []string{"orders-pending", "orders-list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := provider.GetMenu(context.Background(), &model.RequestContext{Roles: tt.roles}, caps)
+			if err != nil {
+				t.Fatalf("GetMenu error: %v", err)
+			}
+			if got := ids(tree.Items); !slices.Equal(got, tt.wantDomains) {
+				t.Errorf("domains = %v, want %v", got, tt.wantDomains)
+			}
+			var orders model.NavigationNode
+			for _, n := range tree.Items {
+				if n.ID == "orders" {
+					orders = n
+				}
+			}
+			if got := ids(orders.Children); !slices.Equal(got, tt.wantChildren) {
+				t.Errorf("orders children = %v, want %v", got, tt.wantChildren)
+			}
+		})
+	}
+}
+
 func TestMenuProvider_GetMenu_noCapabilitiesRequiredOnChild(t *testing.T) {
 	reg := definition.NewRegistry(testDomains())
 	provider := NewMenuProvider(reg, nil)
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/pitabwire/util"
 
 	"github.com/pitabwire/thesa/internal/definition"
 	"github.com/pitabwire/thesa/internal/invoker"
@@ -16,6 +19,8 @@ type PageProvider struct {
 	registry *definition.Registry
 	invokers *invoker.Registry
 	actions  *ActionProvider
+	options  OptionSource
+	now      func() time.Time
 }
 
 // NewPageProvider creates a PageProvider backed by the given registries.
@@ -24,6 +29,7 @@ func NewPageProvider(registry *definition.Registry, invokers *invoker.Registry,
 		registry: registry,
 		invokers: invokers,
 		actions:  actions,
+		now:      time.Now,
 	}
 }
 
@@ -36,15 +42,16 @@ func (p *PageProvider) GetPage(
 	pageID string,
 ) (model.PageDescriptor, error) {
 	pageDef, ok := p.registry.GetPage(pageID)
-	if !ok {
+	if !ok || !rctx.EntitledTo(p.registry.PageDomain(pageID)) {
 		return model.PageDescriptor{}, model.NewNotFoundError(
 			fmt.Sprintf("page %q not found", pageID),
 		)
 	}
 
-	if len(pageDef.Capabilities) > 0 && !caps.HasAll(pageDef.Capabilities...) {
-		return model.PageDescriptor{}, model.NewForbiddenError(
+	if missing := caps.Missing(pageDef.Capabilities...); len(missing) > 0 {
+		return model.PageDescriptor{}, model.NewInsufficientCapabilitiesError(
 			fmt.Sprintf("insufficient capabilities for page %q", pageID),
+			missing,
 		)
 	}
 
@@ -63,12 +70,18 @@ func (p *PageProvider) GetPage(
 
 	// Resolve table.
 	if pageDef.Table != nil {
-		desc.Table = p.resolveTable(caps, pageDef.Table, pageDef.ID)
+		desc.Table = p.resolveTable(ctx, rctx, caps, pageDef.Table, pageDef.ID)
 	}
 
 	// Resolve sections.
 	desc.Sections = p.resolveSections(caps, pageDef.Sections)
 
+	// Currency columns and fields without a format use the tenant's currency.
+	if desc.Table != nil {
+		defaultColumnCurrency(desc.Table.Columns, callerCurrency(rctx))
+	}
+	defaultFieldCurrency(desc.Sections, callerCurrency(rctx))
+
 	// Resolve page-level actions.
 	desc.Actions = p.actions.ResolveActions(caps, pageDef.Actions, nil)
 
@@ -85,50 +98,168 @@ func (p *PageProvider) GetPageData(
 	params model.DataParams,
 ) (model.DataResponse, error) {
 	pageDef, ok := p.registry.GetPage(pageID)
-	if !ok {
+	if !ok || !rctx.EntitledTo(p.registry.PageDomain(pageID)) {
 		return model.DataResponse{}, model.NewNotFoundError(
 			fmt.Sprintf("page %q not found", pageID),
 		)
 	}
 
-	if len(pageDef.Capabilities) > 0 && !caps.HasAll(pageDef.Capabilities...) {
-		return model.DataResponse{}, model.NewForbiddenError(
+	if missing := caps.Missing(pageDef.Capabilities...); len(missing) > 0 {
+		return model.DataResponse{}, model.NewInsufficientCapabilitiesError(
 			fmt.Sprintf("insufficient capabilities for page %q", pageID),
+			missing,
 		)
 	}
 
-	if pageDef.Table == nil {
+	if pageDef.Table == nil && !hasSectionDataSources(pageDef.Sections) {
 		return model.DataResponse{}, model.NewBadRequestError(
 			fmt.Sprintf("page %q has no data source", pageID),
 		)
 	}
 
-	ds := pageDef.Table.DataSource
-	binding := model.OperationBinding{
-		Type:        "openapi",
-		ServiceID:   ds.ServiceID,
-		OperationID: ds.OperationID,
-		Handler:     ds.Handler,
-	}
-	if ds.Handler != "" {
-		binding.Type = "sdk"
+	filters, err := normalizeDateRanges(pageDef.Table, params.Filters)
+	if err != nil {
+		return model.DataResponse{}, err
 	}
+	params.Filters = filters
 
 	// Build invocation input from DataParams.
 	input := buildDataInput(params)
 
-	result, err := p.invokers.Invoke(ctx, rctx, binding, input)
+	resp := model.DataResponse{
+		Data: model.DataPayload{
+			Items:    []map[string]any{},
+			Page:     params.Page,
+			PageSize: params.PageSize,
+		},
+	}
+
+	if pageDef.Table != nil {
+		ds := pageDef.Table.DataSource
+		result, err := p.invokers.Invoke(ctx, rctx, dataSourceBinding(ds), input)
+		if err == nil {
+			err = mappedBackendError(result)
+		}
+		if err != nil {
+			return model.DataResponse{}, err
+		}
+
+		// Apply response mapping.
+		resp = applyResponseMapping(result, ds.Mapping, params)
+		applyComputedColumns(pageDef.Table.Columns, resp.Data.Items, p.now().In(rctx.Location()))
+
+		if pageDef.Table.EvaluateFormatRules {
+			resp.Data.RowStyles = evaluateRowStyles(pageDef.Table.Columns, resp.Data.Items)
+		}
+	}
+
+	sections, err := p.fetchSectionData(ctx, rctx, caps, pageDef.Sections, input, params)
 	if err != nil {
 		return model.DataResponse{}, err
 	}
+	resp.Data.Sections = sections
+
+	return resp, nil
+}
+
+// fetchSectionData loads data for each visible section that declares its own
+// data source. A failed required data source fails the page; a failed
+// optional one marks the section as unavailable.
+func (p *PageProvider) fetchSectionData(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	sections []model.SectionDefinition,
+	input model.InvocationInput,
+	params model.DataParams,
+) (map[string]model.SectionData, error) {
+	var result map[string]model.SectionData
+	for _, sec := range sections {
+		if sec.DataSource == nil {
+			continue
+		}
+		if len(sec.Capabilities) > 0 && !caps.HasAll(sec.Capabilities...) {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]model.SectionData)
+		}
+
+		ds := *sec.DataSource
+		invResult, err := p.invokers.Invoke(ctx, rctx, dataSourceBinding(ds), input)
+		if err == nil {
+			err = mappedBackendError(invResult)
+		}
+		if err == nil && invResult.StatusCode >= 500 {
+			err = model.NewBackendUnavailableError()
+		}
+		if err != nil {
+			if !ds.Optional {
+				return nil, err
+			}
+			util.Log(ctx).Warn("metadata: optional section data source failed",
+				"section", sec.ID,
+				"error", err,
+			)
+			result[sec.ID] = model.SectionData{Items: []map[string]any{}, Unavailable: true}
+			continue
+		}
+
+		mapped := applyResponseMapping(invResult, ds.Mapping, params)
+		result[sec.ID] = model.SectionData{
+			Items:      mapped.Data.Items,
+			TotalCount: mapped.Data.TotalCount,
+		}
+	}
+	return result, nil
+}
 
-	// Apply response mapping.
-	return applyResponseMapping(result, ds.Mapping, params), nil
+// mappedBackendError returns an error for a backend response the service's
+// error mapping assigned an error code to, or nil.
+func mappedBackendError(result model.InvocationResult) error {
+	if result.ErrorCode == "" {
+		return nil
+	}
+	return &model.ErrorEnvelope{
+		Code:    result.ErrorCode,
+		Message: fmt.Sprintf("Backend returned status %d", result.StatusCode),
+	}
+}
+
+// hasSectionDataSources reports whether any section declares a data source.
+func hasSectionDataSources(sections []model.SectionDefinition) bool {
+	for _, sec := range sections {
+		if sec.DataSource != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dataSourceBinding builds the operation binding for a data source. A handler
+// selects an SDK binding; otherwise the OpenAPI operation is used.
+func dataSourceBinding(ds model.DataSourceDefinition) model.OperationBinding {
+	binding := model.OperationBinding{
+		Type:        "openapi",
+		ServiceID:   ds.ServiceID,
+		OperationID: ds.OperationID,
+		Handler:     ds.Handler,
+	}
+	if ds.Handler != "" {
+		binding.Type = "sdk"
+	}
+	return binding
 }
 
 // resolveTable builds a TableDescriptor from a TableDefinition, filtering
 // by capabilities.
-func (p *PageProvider) resolveTable(caps model.CapabilitySet, table *model.TableDefinition, pageID string) *model.TableDescriptor {
+func (p *PageProvider) resolveTable(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	table *model.TableDefinition,
+	pageID string,
+) *model.TableDescriptor {
 	desc := &model.TableDescriptor{
 		DataEndpoint: fmt.Sprintf("/api/pages/%s/data", pageID),
 		DefaultSort:  table.DefaultSort,
@@ -159,6 +290,12 @@ func (p *PageProvider) resolveTable(caps model.CapabilitySet, table *model.Table
 				Params: col.Link.Params,
 			}
 		}
+		for _, rule := range col.FormatRules {
+			desc.Columns[len(desc.Columns)-1].FormatRules = append(
+				desc.Columns[len(desc.Columns)-1].FormatRules,
+				model.FormatRuleDescriptor(rule),
+			)
+		}
 	}
 
 	// Resolve filters.
@@ -178,6 +315,7 @@ func (p *PageProvider) resolveTable(caps model.CapabilitySet, table *model.Table
 				})
 			}
 		}
+		p.resolveFilterOptions(ctx, rctx, table, pageID, f, &fd)
 		desc.Filters = append(desc.Filters, fd)
 	}
 
@@ -261,6 +399,71 @@ func (p *PageProvider) resolveSections(caps model.CapabilitySet, sections []mode
 	return result
 }
 
+// evaluateRowStyles evaluates column format rules against each item and
+// returns the matched style per field. Items with no matching rule get an
+// empty map so the result stays parallel to items.
+func evaluateRowStyles(columns []model.ColumnDefinition, items []map[string]any) []map[string]string {
+	hasRules := false
+	for _, col := range columns {
+		if len(col.FormatRules) > 0 {
+			hasRules = true
+			break
+		}
+	}
+	if !hasRules {
+		return nil
+	}
+
+	styles := make([]map[string]string, len(items))
+	for i, item := range items {
+		styles[i] = make(map[string]string)
+		for _, col := range columns {
+			for _, rule := range col.FormatRules {
+				cond := model.ConditionDefinition{Field: col.Field, Operator: rule.Operator, Value: rule.Value}
+				if cond.Holds(item) {
+					styles[i][col.Field] = rule.Style
+					break
+				}
+			}
+		}
+	}
+	return styles
+}
+
+// applyComputedColumns sets the value of each computed column on every item.
+// Dates are compared as calendar days in now's location, so "days since" is
+// relative to the user's local midnight rather than UTC. Items whose source
+// is missing or not an RFC3339 timestamp are left without a value.
+func applyComputedColumns(columns []model.ColumnDefinition, items []map[string]any, now time.Time) {
+	for _, col := range columns {
+		if col.Compute == "" {
+			continue
+		}
+		for _, item := range items {
+			raw, _ := extractPath(item, col.Source).(string)
+			ts, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				continue
+			}
+			days := calendarDaysBetween(ts.In(now.Location()), now)
+			switch col.Compute {
+			case "days_since":
+				item[col.Field] = days
+			case "days_until":
+				item[col.Field] = -days
+			}
+		}
+	}
+}
+
+// calendarDaysBetween returns the number of calendar days from a to b, using
+// the dates of a and b in their own locations.
+func calendarDaysBetween(a, b time.Time) int {
+	ad := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	bd := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(bd.Sub(ad).Hours() / 24)
+}
+
 // buildDataInput constructs an InvocationInput from DataParams.
 func buildDataInput(params model.DataParams) model.InvocationInput {
 	query := make(map[string]string)
@@ -299,8 +502,14 @@ func applyResponseMapping(result model.InvocationResult, mapping model.ResponseM
 		}
 	}
 
-	// Extract items using items_path.
-	rawItems := extractPath(body, mapping.ItemsPath)
+	// Extract items from the first candidate path holding a list.
+	var rawItems any
+	for _, path := range mapping.ItemsCandidates() {
+		if v, ok := extractPath(body, path).([]any); ok {
+			rawItems = v
+			break
+		}
+	}
 	items := toMapSlice(rawItems)
 
 	// Apply field_map renaming.
@@ -308,11 +517,12 @@ func applyResponseMapping(result model.InvocationResult, mapping model.ResponseM
 		items = applyFieldMap(items, mapping.FieldMap)
 	}
 
-	// Extract total count.
+	// Extract total count from the first candidate path holding a number.
 	total := 0
-	if mapping.TotalPath != "" {
-		if v, ok := extractPath(body, mapping.TotalPath).(float64); ok {
+	for _, path := range mapping.TotalCandidates() {
+		if v, ok := extractPath(body, path).(float64); ok {
 			total = int(v)
+			break
 		}
 	}
 	if total == 0 {
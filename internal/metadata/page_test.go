@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/pitabwire/thesa/internal/definition"
 	"github.com/pitabwire/thesa/internal/invoker"
@@ -121,8 +122,12 @@ func testPageDefinitions() []model.DomainDefinition {
 	}
 }
 
-func newTestPageProvider(invokeFn func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error)) *PageProvider {
-	reg := definition.NewRegistry(testPageDefinitions())
+func newTestPageProvider(invokeFn func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error), edits ...func(*model.DomainDefinition)) *PageProvider {
+	defs := testPageDefinitions()
+	for _, edit := range edits {
+		edit(&defs[0])
+	}
+	reg := definition.NewRegistry(defs)
 	ap := NewActionProvider()
 
 	var invokerReg *invoker.Registry
@@ -136,6 +141,17 @@ func newTestPageProvider(invokeFn func(ctx context.Context, rctx *model.RequestC
 	return NewPageProvider(reg, invokerReg, ap)
 }
 
+// withPage applies edit to the test page with the given ID.
+func withPage(id string, edit func(*model.PageDefinition)) func(*model.DomainDefinition) {
+	return func(d *model.DomainDefinition) {
+		for i := range d.Pages {
+			if d.Pages[i].ID == id {
+				edit(&d.Pages[i])
+			}
+		}
+	}
+}
+
 // --- GetPage ---
 
 func TestPageProvider_GetPage_success(t *testing.T) {
@@ -190,6 +206,27 @@ func TestPageProvider_GetPage_forbidden(t *testing.T) {
 	if envErr.Code != model.ErrForbidden {
 		t.Errorf("error code = %s, want %s", envErr.Code, model.ErrForbidden)
 	}
+	if len(envErr.RequiredCapabilities) != 1 || envErr.RequiredCapabilities[0] != "orders:list:view" {
+		t.Errorf("RequiredCapabilities = %v, want [orders:list:view]", envErr.RequiredCapabilities)
+	}
+}
+
+func TestPageProvider_GetPage_unentitledDomain(t *testing.T) {
+	p := newTestPageProvider(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		t.Error("backend invoked for an unentitled domain")
+		return model.InvocationResult{StatusCode: 200}, nil
+	})
+	caps := model.CapabilitySet{"orders:list:view": true}
+	rctx := &model.RequestContext{TenantID: "t1", UnentitledDomains: []string{"orders"}}
+
+	_, err := p.GetPage(context.Background(), rctx, caps, "orders-list")
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrNotFound {
+		t.Errorf("GetPage error = %v, want NOT_FOUND", err)
+	}
+	_, err = p.GetPageData(context.Background(), rctx, caps, "orders-list", model.DataParams{})
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrNotFound {
+		t.Errorf("GetPageData error = %v, want NOT_FOUND", err)
+	}
 }
 
 func TestPageProvider_GetPage_breadcrumb(t *testing.T) {
@@ -262,6 +299,54 @@ func TestPageProvider_GetPage_tableColumns(t *testing.T) {
 	}
 }
 
+func TestPageProvider_GetPage_tenantCurrency(t *testing.T) {
+	defs := []model.DomainDefinition{{
+		Domain: "orders",
+		Pages: []model.PageDefinition{{
+			ID: "orders-list", Title: "Orders", Route: "/orders", Layout: "list",
+			Table: &model.TableDefinition{Columns: []model.ColumnDefinition{
+				{Field: "total", Label: "Total", Type: "currency"},
+				{Field: "fee", Label: "Fee", Type: "currency", Format: "USD"},
+				{Field: "id", Label: "ID", Type: "text"},
+			}},
+			Sections: []model.SectionDefinition{{ID: "summary", Fields: []model.FieldDefinition{
+				{Field: "balance", Label: "Balance", Type: "currency"},
+			}}},
+		}},
+	}}
+	p := NewPageProvider(definition.NewRegistry(defs), nil, NewActionProvider())
+
+	tests := []struct {
+		name      string
+		rctx      *model.RequestContext
+		wantTotal string
+	}{
+		{name: "EUR tenant", rctx: &model.RequestContext{TenantID: "t-eu", Currency: "EUR"}, wantTotal: "EUR"},
+		{name: "no tenant default", rctx: &model.RequestContext{TenantID: "t-other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc, err := p.GetPage(context.Background(), tt.rctx, model.CapabilitySet{}, "orders-list")
+			if err != nil {
+				t.Fatalf("GetPage error: %v", err)
+			}
+			cols := desc.Table.Columns
+			if cols[0].Format != tt.wantTotal {
+				t.Errorf("unspecified currency column format = %q, want %q", cols[0].Format, tt.wantTotal)
+			}
+			if cols[1].Format != "USD" {
+				t.Errorf("explicit USD column format = %q, want USD", cols[1].Format)
+			}
+			if cols[2].Format != "" {
+				t.Errorf("text column format = %q, want empty", cols[2].Format)
+			}
+			if got := desc.Sections[0].Fields[0].Format; got != tt.wantTotal {
+				t.Errorf("currency field format = %q, want %q", got, tt.wantTotal)
+			}
+		})
+	}
+}
+
 func TestPageProvider_GetPage_tableFilters(t *testing.T) {
 	p := newTestPageProvider(nil)
 	caps := model.CapabilitySet{"orders:list:view": true}
@@ -569,6 +654,189 @@ func TestPageProvider_GetPageData_backendError(t *testing.T) {
 	}
 }
 
+func newFormatRulesPageProvider() *PageProvider {
+	return newTestPageProvider(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{
+			StatusCode: http.StatusOK,
+			Body: map[string]any{"data": map[string]any{"items": []any{
+				map[string]any{"order_id": "1", "amount": float64(25000)},
+				map[string]any{"order_id": "2", "amount": float64(1500)},
+				map[string]any{"order_id": "3", "amount": float64(20)},
+			}}},
+		}, nil
+	}, withPage("orders-list", func(pg *model.PageDefinition) {
+		pg.Table.Columns = append(pg.Table.Columns, model.ColumnDefinition{
+			Field: "amount", Label: "Amount", Type: "currency", FormatRules: []model.FormatRuleDefinition{
+				{Operator: "gt", Value: 10000, Style: "danger"},
+				{Operator: "gte", Value: 1000, Style: "warning"},
+			},
+		})
+		pg.Table.EvaluateFormatRules = true
+	}))
+}
+
+func TestPageProvider_GetPage_columnFormatRules(t *testing.T) {
+	p := newFormatRulesPageProvider()
+	caps := model.CapabilitySet{"orders:list:view": true}
+
+	desc, err := p.GetPage(context.Background(), nil, caps, "orders-list")
+	if err != nil {
+		t.Fatalf("GetPage error: %v", err)
+	}
+	rules := desc.Table.Columns[len(desc.Table.Columns)-1].FormatRules
+	if len(rules) != 2 {
+		t.Fatalf("len(FormatRules) = %d, want 2", len(rules))
+	}
+	if rules[0].Operator != "gt" || rules[0].Style != "danger" {
+		t.Errorf("FormatRules[0] = %+v", rules[0])
+	}
+	if desc.Table.Columns[0].FormatRules != nil {
+		t.Errorf("id column FormatRules = %v, want nil", desc.Table.Columns[0].FormatRules)
+	}
+}
+
+func TestPageProvider_GetPageData_rowStyles(t *testing.T) {
+	p := newFormatRulesPageProvider()
+	caps := model.CapabilitySet{"orders:list:view": true}
+
+	resp, err := p.GetPageData(context.Background(), nil, caps, "orders-list", model.DataParams{})
+	if err != nil {
+		t.Fatalf("GetPageData error: %v", err)
+	}
+	if len(resp.Data.RowStyles) != 3 {
+		t.Fatalf("len(RowStyles) = %d, want 3", len(resp.Data.RowStyles))
+	}
+	want := []string{"danger", "warning", ""}
+	for i, w := range want {
+		if got := resp.Data.RowStyles[i]["amount"]; got != w {
+			t.Errorf("RowStyles[%d][amount] = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func newComputedColumnPageProvider(now time.Time) *PageProvider {
+	p := newTestPageProvider(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return model.InvocationResult{
+			StatusCode: http.StatusOK,
+			Body: map[string]any{"data": map[string]any{"items": []any{
+				map[string]any{"order_id": "1", "created_at": "2026-03-01T23:30:00Z", "due_at": "2026-03-04T02:00:00Z"},
+				map[string]any{"order_id": "2", "created_at": "not-a-date"},
+			}}},
+		}, nil
+	}, withPage("orders-list", func(pg *model.PageDefinition) {
+		pg.Table.Columns = append(pg.Table.Columns,
+			model.ColumnDefinition{Field: "age_days", Label: "Age", Type: "number", Compute: "days_since", Source: "date"},
+			model.ColumnDefinition{Field: "due_in", Label: "Due In", Type: "number", Compute: "days_until", Source: "due_at"},
+		)
+	}))
+	p.now = func() time.Time { return now }
+	return p
+}
+
+func TestPageProvider_GetPageData_computedColumnsRespectTimezone(t *testing.T) {
+	// 01:00 UTC on 2 March is still 1 March in New York.
+	now := time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		timezone string
+		wantAge  int
+		wantDue  int
+	}{
+		{"", 1, 2},
+		{"UTC", 1, 2},
+		{"America/New_York", 0, 2},
+		{"Pacific/Auckland", 0, 2},
+		{"Not/AZone", 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			p := newComputedColumnPageProvider(now)
+			rctx := &model.RequestContext{Timezone: tt.timezone}
+
+			caps := model.CapabilitySet{"orders:list:view": true}
+
+			resp, err := p.GetPageData(context.Background(), rctx, caps, "orders-list", model.DataParams{})
+			if err != nil {
+				t.Fatalf("GetPageData error: %v", err)
+			}
+			item := resp.Data.Items[0]
+			if item["age_days"] != tt.wantAge {
+				t.Errorf("age_days = %v, want %d", item["age_days"], tt.wantAge)
+			}
+			if item["due_in"] != tt.wantDue {
+				t.Errorf("due_in = %v, want %d", item["due_in"], tt.wantDue)
+			}
+			if _, ok := resp.Data.Items[1]["age_days"]; ok {
+				t.Error("age_days set for unparseable source")
+			}
+		})
+	}
+}
+
+func newSectionDataPageProvider(optional bool) *PageProvider {
+	return newTestPageProvider(func(ctx context.Context, rctx *model.RequestContext, binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		if binding.ServiceID == "shipping-svc" {
+			return model.InvocationResult{}, fmt.Errorf("shipping-svc unavailable")
+		}
+		return model.InvocationResult{
+			StatusCode: http.StatusOK,
+			Body:       map[string]any{"items": []any{map[string]any{"sku": "A-1"}}},
+		}, nil
+	}, withPage("orders-detail", func(pg *model.PageDefinition) {
+		pg.Sections = append(pg.Sections,
+			model.SectionDefinition{
+				ID:         "lines",
+				Title:      "Lines",
+				Layout:     "table",
+				DataSource: &model.DataSourceDefinition{OperationID: "listLines", ServiceID: "order-svc", Mapping: model.ResponseMappingDefinition{ItemsPath: "items"}},
+			},
+			model.SectionDefinition{
+				ID:         "shipments",
+				Title:      "Shipments",
+				Layout:     "table",
+				DataSource: &model.DataSourceDefinition{OperationID: "listShipments", ServiceID: "shipping-svc", Optional: optional},
+			},
+		)
+	}))
+}
+
+func TestPageProvider_GetPageData_requiredSectionFailure(t *testing.T) {
+	p := newSectionDataPageProvider(false)
+
+	_, err := p.GetPageData(context.Background(), nil, model.CapabilitySet{}, "orders-detail", model.DataParams{})
+	if err == nil {
+		t.Fatal("expected error when a required section data source fails")
+	}
+}
+
+func TestPageProvider_GetPageData_optionalSectionFailure(t *testing.T) {
+	p := newSectionDataPageProvider(true)
+
+	resp, err := p.GetPageData(context.Background(), nil, model.CapabilitySet{}, "orders-detail", model.DataParams{})
+	if err != nil {
+		t.Fatalf("GetPageData error: %v", err)
+	}
+
+	lines, ok := resp.Data.Sections["lines"]
+	if !ok {
+		t.Fatal("missing lines section data")
+	}
+	if lines.Unavailable {
+		t.Error("lines.Unavailable = true, want false")
+	}
+	if len(lines.Items) != 1 || lines.Items[0]["sku"] != "A-1" {
+		t.Errorf("lines.Items = %v", lines.Items)
+	}
+
+	shipments, ok := resp.Data.Sections["shipments"]
+	if !ok {
+		t.Fatal("missing shipments section data")
+	}
+	if !shipments.Unavailable {
+		t.Error("shipments.Unavailable = false, want true")
+	}
+}
+
 // --- Helper tests ---
 
 func TestExtractPath(t *testing.T) {
@@ -635,3 +903,58 @@ func TestBuildDataInput(t *testing.T) {
 		t.Errorf("status = %q", input.QueryParams["status"])
 	}
 }
+
+func TestApplyResponseMapping_candidatePaths(t *testing.T) {
+	mapping := model.ResponseMappingDefinition{
+		ItemsPath:  "items",
+		ItemsPaths: []string{"content", "data.results"},
+		TotalPath:  "total",
+		TotalPaths: []string{"total_count", "totalElements", "meta.total"},
+	}
+	tests := []struct {
+		name      string
+		body      map[string]any
+		wantItems int
+		wantTotal int
+	}{
+		{
+			name:      "primary paths",
+			body:      map[string]any{"items": []any{map[string]any{"id": "1"}}, "total": float64(40)},
+			wantItems: 1, wantTotal: 40,
+		},
+		{
+			name: "first matching candidate wins",
+			body: map[string]any{
+				"content":       []any{map[string]any{"id": "1"}, map[string]any{"id": "2"}},
+				"data":          map[string]any{"results": []any{map[string]any{"id": "x"}}},
+				"totalElements": float64(75),
+				"meta":          map[string]any{"total": float64(99)},
+			},
+			wantItems: 2, wantTotal: 75,
+		},
+		{
+			name: "nested candidates",
+			body: map[string]any{
+				"data": map[string]any{"results": []any{map[string]any{"id": "1"}}},
+				"meta": map[string]any{"total": float64(12)},
+			},
+			wantItems: 1, wantTotal: 12,
+		},
+		{
+			name:      "non-numeric candidate skipped",
+			body:      map[string]any{"items": []any{}, "total": "many", "total_count": float64(3)},
+			wantItems: 0, wantTotal: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := applyResponseMapping(model.InvocationResult{StatusCode: 200, Body: tt.body}, mapping, model.DataParams{Page: 1, PageSize: 25})
+			if len(resp.Data.Items) != tt.wantItems {
+				t.Errorf("items = %d, want %d", len(resp.Data.Items), tt.wantItems)
+			}
+			if resp.Data.TotalCount != tt.wantTotal {
+				t.Errorf("TotalCount = %d, want %d", resp.Data.TotalCount, tt.wantTotal)
+			}
+		})
+	}
+}
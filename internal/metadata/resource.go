@@ -3,6 +3,7 @@ package metadata
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/pitabwire/thesa/internal/definition"
@@ -11,10 +12,13 @@ import (
 	"github.com/pitabwire/thesa/model"
 )
 
-// resourceIndex maps a resource type to its list page and detail operation.
+// resourceIndex maps a resource type to its list and detail pages and its
+// detail operation. Either page may be nil.
 type resourceIndex struct {
-	listPage  *model.PageDefinition
-	serviceID string
+	domain     string
+	listPage   *model.PageDefinition
+	detailPage *model.PageDefinition
+	serviceID  string
 	// getOpID is the operation ID for fetching a single item (e.g., "getTenant").
 	getOpID string
 }
@@ -25,6 +29,7 @@ type ResourceProvider struct {
 	registry *definition.Registry
 	invokers *invoker.Registry
 	oaIndex  *openapi.Index
+	actions  *ActionProvider
 }
 
 // NewResourceProvider creates a ResourceProvider.
@@ -32,11 +37,13 @@ func NewResourceProvider(
 	registry *definition.Registry,
 	invokers *invoker.Registry,
 	oaIndex *openapi.Index,
+	actions *ActionProvider,
 ) *ResourceProvider {
 	return &ResourceProvider{
 		registry: registry,
 		invokers: invokers,
 		oaIndex:  oaIndex,
+		actions:  actions,
 	}
 }
 
@@ -49,7 +56,7 @@ func (p *ResourceProvider) GetResourceList(
 	resourceType string,
 	params model.DataParams,
 ) (model.DataResponse, error) {
-	idx := p.findResource(resourceType)
+	idx := p.findResource(rctx, resourceType)
 	if idx == nil || idx.listPage == nil {
 		return model.DataResponse{}, model.NewNotFoundError(
 			fmt.Sprintf("resource type %q not found", resourceType),
@@ -57,9 +64,10 @@ func (p *ResourceProvider) GetResourceList(
 	}
 
 	page := idx.listPage
-	if len(page.Capabilities) > 0 && !caps.HasAll(page.Capabilities...) {
-		return model.DataResponse{}, model.NewForbiddenError(
+	if missing := caps.Missing(page.Capabilities...); len(missing) > 0 {
+		return model.DataResponse{}, model.NewInsufficientCapabilitiesError(
 			fmt.Sprintf("insufficient capabilities for resource %q", resourceType),
+			missing,
 		)
 	}
 
@@ -99,7 +107,7 @@ func (p *ResourceProvider) GetResourceItem(
 	resourceType string,
 	id string,
 ) (map[string]any, error) {
-	idx := p.findResource(resourceType)
+	idx := p.findResource(rctx, resourceType)
 	if idx == nil {
 		return nil, model.NewNotFoundError(
 			fmt.Sprintf("resource type %q not found", resourceType),
@@ -107,9 +115,10 @@ func (p *ResourceProvider) GetResourceItem(
 	}
 
 	if idx.listPage != nil {
-		if len(idx.listPage.Capabilities) > 0 && !caps.HasAll(idx.listPage.Capabilities...) {
-			return nil, model.NewForbiddenError(
+		if missing := caps.Missing(idx.listPage.Capabilities...); len(missing) > 0 {
+			return nil, model.NewInsufficientCapabilitiesError(
 				fmt.Sprintf("insufficient capabilities for resource %q", resourceType),
+				missing,
 			)
 		}
 	}
@@ -143,51 +152,170 @@ func (p *ResourceProvider) GetResourceItem(
 	return body, nil
 }
 
-// findResource searches the definition registry for a list page and get
-// operation that match the given resource type. Resource types are matched
-// by: exact page ID prefix (e.g., "tenants" matches "tenants.list"),
-// or domain name.
-func (p *ResourceProvider) findResource(resourceType string) *resourceIndex {
-	for _, domain := range p.registry.AllDomains() {
-		var listPage *model.PageDefinition
-		var serviceID string
-
-		for i := range domain.Pages {
-			pg := &domain.Pages[i]
+// ResolveRoute returns the detail page route for a resource reference. The
+// resource type is resolved as by findResource. The {id} placeholder in the
+// page route is replaced with the given ID.
+func (p *ResourceProvider) ResolveRoute(rctx *model.RequestContext, resourceType, id string) (model.RouteResolution, error) {
+	var page *model.PageDefinition
+	if idx := p.findResource(rctx, resourceType); idx != nil {
+		page = idx.detailPage
+	}
+	if page == nil {
+		return model.RouteResolution{}, model.NewNotFoundError(
+			fmt.Sprintf("no page handles resource type %q", resourceType),
+		)
+	}
 
-			// Match by page ID prefix (e.g., "tenants" matches "tenants.list").
-			idPrefix := strings.SplitN(pg.ID, ".", 2)[0]
-			domainMatch := domain.Domain == resourceType
-			prefixMatch := idPrefix == resourceType
+	return model.RouteResolution{
+		Type:         resourceType,
+		ID:           id,
+		PageID:       page.ID,
+		Route:        strings.ReplaceAll(page.Route, "{id}", url.PathEscape(id)),
+		Capabilities: page.Capabilities,
+	}, nil
+}
 
-			if (domainMatch || prefixMatch) && pg.Table != nil && pg.Layout == "table" {
-				listPage = pg
-				serviceID = pg.Table.DataSource.ServiceID
-				break
+// GetResourceActions returns the actions available on a single resource:
+// the detail page actions followed by the list page row actions, filtered by
+// capability. When the resource can be fetched, action conditions are
+// evaluated against it and actions that end up hidden or disabled are
+// excluded; otherwise data-dependent conditions are passed through.
+func (p *ResourceProvider) GetResourceActions(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	resourceType string,
+	id string,
+) (model.ResourceActions, error) {
+	var defs []model.ActionDefinition
+	seen := make(map[string]bool)
+	add := func(actions []model.ActionDefinition) {
+		for _, a := range actions {
+			if !seen[a.ID] {
+				seen[a.ID] = true
+				defs = append(defs, a)
 			}
 		}
+	}
+
+	idx := p.findResource(rctx, resourceType)
+	if idx == nil {
+		return model.ResourceActions{}, model.NewNotFoundError(
+			fmt.Sprintf("resource type %q not found", resourceType),
+		)
+	}
+	if idx.detailPage != nil {
+		add(idx.detailPage.Actions)
+	}
+	if idx.listPage != nil {
+		add(idx.listPage.Table.RowActions)
+	}
+
+	var data map[string]any
+	if idx.getOpID != "" {
+		item, err := p.GetResourceItem(ctx, rctx, caps, resourceType, id)
+		if err != nil {
+			return model.ResourceActions{}, err
+		}
+		data = item
+	}
+
+	actions := []model.ActionDescriptor{}
+	for _, a := range p.actions.ResolveActions(caps, defs, data) {
+		if a.Visible && a.Enabled {
+			actions = append(actions, a)
+		}
+	}
 
-		if listPage == nil {
+	return model.ResourceActions{
+		Type:    resourceType,
+		ID:      id,
+		Actions: actions,
+	}, nil
+}
+
+// findResource resolves a resource type to the list and detail pages of one
+// domain. A table page matches by page ID prefix (e.g., "tenants" matches
+// "tenants.list") or domain name. A detail page whose route takes an {id}
+// parameter matches by its resource_type or, when that is unset, its page ID
+// prefix. When only one of the two matches, the other is the page of the
+// same domain sharing its ID prefix, so a resource is found by its singular
+// resource_type and its plural page prefix alike. Domains the caller is not
+// entitled to are skipped.
+func (p *ResourceProvider) findResource(rctx *model.RequestContext, resourceType string) *resourceIndex {
+	for _, domain := range p.registry.AllDomains() {
+		if !rctx.EntitledTo(domain.Domain) {
 			continue
 		}
 
-		// Search for a "get" operation in the service's OpenAPI spec.
-		getOpID := findGetOperation(p.oaIndex, serviceID, resourceType)
+		list := findListPage(domain, func(prefix string) bool {
+			return prefix == resourceType || domain.Domain == resourceType
+		})
+		detail := findDetailPage(domain, func(pg *model.PageDefinition) bool {
+			if pg.ResourceType != "" {
+				return pg.ResourceType == resourceType
+			}
+			return pageIDPrefix(pg) == resourceType
+		})
+		switch {
+		case list == nil && detail == nil:
+			continue
+		case list == nil:
+			want := pageIDPrefix(detail)
+			list = findListPage(domain, func(prefix string) bool { return prefix == want })
+		case detail == nil:
+			want := pageIDPrefix(list)
+			detail = findDetailPage(domain, func(pg *model.PageDefinition) bool { return pageIDPrefix(pg) == want })
+		}
 
-		return &resourceIndex{
-			listPage:  listPage,
-			serviceID: serviceID,
-			getOpID:   getOpID,
+		idx := &resourceIndex{domain: domain.Domain, listPage: list, detailPage: detail}
+		if list != nil {
+			// Search for a "get" operation in the service's OpenAPI spec.
+			idx.serviceID = list.Table.DataSource.ServiceID
+			idx.getOpID = findGetOperation(p.oaIndex, idx.serviceID, resourceType)
 		}
+		return idx
 	}
 
 	return nil
 }
 
+// findListPage returns the first table page of the domain whose page ID
+// prefix satisfies match.
+func findListPage(domain model.DomainDefinition, match func(prefix string) bool) *model.PageDefinition {
+	for i := range domain.Pages {
+		pg := &domain.Pages[i]
+		if pg.Table != nil && pg.Layout == "table" && match(pageIDPrefix(pg)) {
+			return pg
+		}
+	}
+	return nil
+}
+
+// findDetailPage returns the first detail page of the domain whose route
+// takes an {id} parameter and that satisfies match.
+func findDetailPage(domain model.DomainDefinition, match func(pg *model.PageDefinition) bool) *model.PageDefinition {
+	for i := range domain.Pages {
+		pg := &domain.Pages[i]
+		if pg.Layout == "detail" && strings.Contains(pg.Route, "{id}") && match(pg) {
+			return pg
+		}
+	}
+	return nil
+}
+
+// pageIDPrefix returns the part of a page ID before the first dot.
+func pageIDPrefix(pg *model.PageDefinition) string {
+	return strings.SplitN(pg.ID, ".", 2)[0]
+}
+
 // findGetOperation searches a service's operations for one that looks like
 // a "get single item" operation for the given resource type.
 // It tries patterns like "getProfile", "getTenant", "getFile", etc.
 func findGetOperation(idx *openapi.Index, serviceID, resourceType string) string {
+	if idx == nil {
+		return ""
+	}
 	ops := idx.AllOperationIDs(serviceID)
 
 	// Normalize resource type for matching: "profiles" → "profile", "tenants" → "tenant".
@@ -66,20 +66,21 @@ type schemaResponse struct {
 }
 
 // GetSchema derives a schema from definitions. It first tries to find a
-// matching form, then a page. Returns the schema or NOT_FOUND.
-func (p *SchemaProvider) GetSchema(schemaID string) (schemaResponse, error) {
+// matching form, then a page. Definitions of domains the caller is not
+// entitled to are skipped. Returns the schema or NOT_FOUND.
+func (p *SchemaProvider) GetSchema(rctx *model.RequestContext, schemaID string) (schemaResponse, error) {
 	// Try as a form ID first.
-	if formDef, ok := p.registry.GetForm(schemaID); ok {
+	if formDef, ok := p.registry.GetForm(schemaID); ok && rctx.EntitledTo(p.registry.FormDomain(schemaID)) {
 		return p.schemaFromForm(schemaID, formDef), nil
 	}
 
 	// Try as a page ID.
-	if pageDef, ok := p.registry.GetPage(schemaID); ok {
+	if pageDef, ok := p.registry.GetPage(schemaID); ok && rctx.EntitledTo(p.registry.PageDomain(schemaID)) {
 		return p.schemaFromPage(schemaID, pageDef), nil
 	}
 
 	// Try fuzzy match: "tenants" might match "tenants.list" or "access-control.createTenant".
-	if resp, ok := p.schemaByPrefix(schemaID); ok {
+	if resp, ok := p.schemaByPrefix(rctx, schemaID); ok {
 		return resp, nil
 	}
 
@@ -143,8 +144,11 @@ func (p *SchemaProvider) schemaFromPage(id string, page model.PageDefinition) sc
 }
 
 // schemaByPrefix tries to find a form or page whose ID starts with the given prefix.
-func (p *SchemaProvider) schemaByPrefix(prefix string) (schemaResponse, bool) {
+func (p *SchemaProvider) schemaByPrefix(rctx *model.RequestContext, prefix string) (schemaResponse, bool) {
 	for _, domain := range p.registry.AllDomains() {
+		if !rctx.EntitledTo(domain.Domain) {
+			continue
+		}
 		for _, form := range domain.Forms {
 			if strings.HasPrefix(form.ID, prefix) {
 				return p.schemaFromForm(form.ID, form), true
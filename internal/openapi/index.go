@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -15,6 +16,9 @@ type SpecSource struct {
 	ServiceID string
 	BaseURL   string
 	SpecPath  string
+	// Aliases maps stable alias names to the spec's current operation IDs,
+	// so definitions survive an operation being renamed.
+	Aliases map[string]string
 }
 
 // IndexedOperation holds a resolved OpenAPI operation with its context.
@@ -39,6 +43,7 @@ type ValidationError struct {
 type Index struct {
 	operations map[string]IndexedOperation // key: "serviceID:operationID"
 	byService  map[string][]string         // serviceID → []operationID
+	aliases    map[string]string           // key: "serviceID:alias" → operationID
 }
 
 // NewIndex creates an empty OpenAPI index.
@@ -46,6 +51,7 @@ func NewIndex() *Index {
 	return &Index{
 		operations: make(map[string]IndexedOperation),
 		byService:  make(map[string][]string),
+		aliases:    make(map[string]string),
 	}
 }
 
@@ -113,17 +119,53 @@ func (idx *Index) Load(specs []SpecSource) error {
 				idx.byService[src.ServiceID] = append(idx.byService[src.ServiceID], op.OperationID)
 			}
 		}
+
+		if err := idx.addAliases(src); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// GetOperation returns the indexed operation for the given service and operation ID.
-func (idx *Index) GetOperation(serviceID, operationID string) (IndexedOperation, bool) {
+// addAliases registers a source's operation aliases. An alias must target an
+// operation in the same spec and must not shadow a real operation ID.
+func (idx *Index) addAliases(src SpecSource) error {
+	aliases := make([]string, 0, len(src.Aliases))
+	for alias := range src.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		target := src.Aliases[alias]
+		if _, ok := idx.operations[operationKey(src.ServiceID, alias)]; ok {
+			return fmt.Errorf("openapi: %s: alias %q shadows an existing operation", src.ServiceID, alias)
+		}
+		if _, ok := idx.operations[operationKey(src.ServiceID, target)]; !ok {
+			return fmt.Errorf("openapi: %s: alias %q targets unknown operation %q", src.ServiceID, alias, target)
+		}
+		idx.aliases[operationKey(src.ServiceID, alias)] = target
+	}
+	return nil
+}
+
+// lookup returns the operation for an operation ID or alias.
+func (idx *Index) lookup(serviceID, operationID string) (IndexedOperation, bool) {
+	if target, ok := idx.aliases[operationKey(serviceID, operationID)]; ok {
+		operationID = target
+	}
 	op, ok := idx.operations[operationKey(serviceID, operationID)]
 	return op, ok
 }
 
+// GetOperation returns the indexed operation for the given service and
+// operation ID or alias. The returned operation carries the current
+// operation ID.
+func (idx *Index) GetOperation(serviceID, operationID string) (IndexedOperation, bool) {
+	return idx.lookup(serviceID, operationID)
+}
+
 // AllOperationIDs returns all operation IDs for the given service, sorted.
 func (idx *Index) AllOperationIDs(serviceID string) []string {
 	ids := make([]string, len(idx.byService[serviceID]))
@@ -132,35 +174,130 @@ func (idx *Index) AllOperationIDs(serviceID string) []string {
 	return ids
 }
 
-// ValidateRequest validates a request body against the operation's request schema.
-// Returns an empty slice if valid, or a list of validation errors.
+// ResponseSchema returns the JSON schema of the operation's success response,
+// preferring 200 over other 2xx codes. Returns nil if the operation is
+// unknown or declares no JSON success response.
+func (idx *Index) ResponseSchema(serviceID, operationID string) *openapi3.Schema {
+	op, ok := idx.lookup(serviceID, operationID)
+	if !ok || op.Responses == nil {
+		return nil
+	}
+
+	codes := []string{"200"}
+	for code := range op.Responses.Map() {
+		if code != "200" && len(code) == 3 && code[0] == '2' {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes[1:])
+
+	for _, code := range codes {
+		resp := op.Responses.Value(code)
+		if resp == nil || resp.Value == nil {
+			continue
+		}
+		ct := resp.Value.Content.Get("application/json")
+		if ct != nil && ct.Schema != nil && ct.Schema.Value != nil {
+			return ct.Schema.Value
+		}
+	}
+	return nil
+}
+
+// SchemaPath resolves a dot-separated path of object properties within a
+// schema. Returns the schema at that path, and false if any segment is not a
+// declared property. An empty path resolves to the schema itself.
+func SchemaPath(schema *openapi3.Schema, path string) (*openapi3.Schema, bool) {
+	if schema == nil {
+		return nil, false
+	}
+	if path == "" {
+		return schema, true
+	}
+	current := schema
+	for _, part := range strings.Split(path, ".") {
+		prop, ok := current.Properties[part]
+		if !ok || prop == nil || prop.Value == nil {
+			return nil, false
+		}
+		current = prop.Value
+	}
+	return current, true
+}
+
+// ValidateRequest validates a request body against the operation's request
+// schema: required properties, types and enums, at every level of nesting.
+// All errors are collected, sorted by dotted field path (array elements as
+// items[0].sku). Returns an empty slice if valid.
 func (idx *Index) ValidateRequest(serviceID, operationID string, body map[string]any) []ValidationError {
-	op, ok := idx.operations[operationKey(serviceID, operationID)]
+	op, ok := idx.lookup(serviceID, operationID)
 	if !ok {
 		return []ValidationError{{Message: fmt.Sprintf("operation %s/%s not found", serviceID, operationID)}}
 	}
 
-	if op.RequestBody == nil {
+	schema := requestSchema(op)
+	if schema == nil {
 		return nil
 	}
 
+	var errs []ValidationError
+	validateObject(schema, "", body, &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// UnknownFields returns the dotted paths of body fields that the operation's
+// JSON request schema does not declare, in sorted order. Nested objects are
+// checked against their property schemas. An object schema that declares no
+// properties, allows additional properties, or is composed with allOf, oneOf
+// or anyOf accepts any field. Returns nil if the operation is unknown or has
+// no JSON request schema.
+func (idx *Index) UnknownFields(serviceID, operationID string, body map[string]any) []string {
+	op, ok := idx.lookup(serviceID, operationID)
+	if !ok {
+		return nil
+	}
+	schema := requestSchema(op)
+	if schema == nil {
+		return nil
+	}
+	var unknown []string
+	collectUnknownFields(schema, "", body, &unknown)
+	sort.Strings(unknown)
+	return unknown
+}
+
+// requestSchema returns the operation's JSON request body schema, or nil.
+func requestSchema(op IndexedOperation) *openapi3.Schema {
+	if op.RequestBody == nil {
+		return nil
+	}
 	ct := op.RequestBody.Content.Get("application/json")
 	if ct == nil || ct.Schema == nil || ct.Schema.Value == nil {
 		return nil
 	}
+	return ct.Schema.Value
+}
 
-	schema := ct.Schema.Value
-	var errs []ValidationError
-
-	// Validate required fields.
-	for _, req := range schema.Required {
-		if _, exists := body[req]; !exists {
-			errs = append(errs, ValidationError{
-				Field:   req,
-				Message: fmt.Sprintf("%s is required", req),
-			})
+func collectUnknownFields(schema *openapi3.Schema, prefix string, obj map[string]any, unknown *[]string) {
+	if len(schema.Properties) == 0 || len(schema.AllOf) > 0 || len(schema.OneOf) > 0 || len(schema.AnyOf) > 0 {
+		return
+	}
+	if ap := schema.AdditionalProperties; ap.Schema != nil || (ap.Has != nil && *ap.Has) {
+		return
+	}
+	for key, value := range obj {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		prop, ok := schema.Properties[key]
+		if !ok || prop == nil {
+			*unknown = append(*unknown, path)
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok && prop.Value != nil {
+			collectUnknownFields(prop.Value, path, nested, unknown)
 		}
 	}
-
-	return errs
 }
@@ -126,6 +126,27 @@ func TestIndex_ValidateRequest_missing_required(t *testing.T) {
 	}
 }
 
+func TestIndex_ValidateRequest_collectsAllErrors(t *testing.T) {
+	idx := loadTestIndex(t)
+	errs := idx.ValidateRequest("orders-svc", "createOrder", map[string]any{
+		"customer_id": float64(7),
+		"shipping":    map[string]any{"city": true},
+		"notes":       nil,
+	})
+	want := []string{"customer_id", "items", "shipping.city"}
+	if len(errs) != len(want) {
+		t.Fatalf("ValidateRequest() = %v, want errors for %v", errs, want)
+	}
+	for i, field := range want {
+		if errs[i].Field != field {
+			t.Errorf("errs[%d].Field = %q, want %q", i, errs[i].Field, field)
+		}
+	}
+	if errs[0].Message != "customer_id must be of type string" {
+		t.Errorf("type error message = %q", errs[0].Message)
+	}
+}
+
 func TestIndex_ValidateRequest_no_body(t *testing.T) {
 	idx := loadTestIndex(t)
 	errs := idx.ValidateRequest("orders-svc", "listOrders", map[string]any{})
@@ -142,6 +163,25 @@ func TestIndex_ValidateRequest_unknown_operation(t *testing.T) {
 	}
 }
 
+func TestIndex_UnknownFields(t *testing.T) {
+	idx := loadTestIndex(t)
+	got := idx.UnknownFields("orders-svc", "createOrder", map[string]any{
+		"customer_id": "c-1",
+		"items":       []any{map[string]any{"sku": "anything"}},
+		"shipping":    map[string]any{"city": "Nairobi", "zip": "00100"},
+		"metadata":    map[string]any{"source": "import"},
+		"nickname":    "typo",
+	})
+	want := []string{"nickname", "shipping.zip"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("UnknownFields() = %v, want %v", got, want)
+	}
+
+	if got := idx.UnknownFields("orders-svc", "listOrders", map[string]any{"x": 1}); got != nil {
+		t.Errorf("UnknownFields(listOrders) = %v, want nil (no request body)", got)
+	}
+}
+
 func TestIndex_Load_bad_file(t *testing.T) {
 	idx := NewIndex()
 	err := idx.Load([]SpecSource{
@@ -169,3 +209,54 @@ func TestIndex_BaseURL_from_spec(t *testing.T) {
 		t.Errorf("BaseURL = %q, want https://orders.internal (from spec servers)", op.BaseURL)
 	}
 }
+
+func TestIndex_operationAliases(t *testing.T) {
+	idx := NewIndex()
+	err := idx.Load([]SpecSource{{
+		ServiceID: "orders-svc",
+		SpecPath:  "testdata/orders-svc.yaml",
+		Aliases:   map[string]string{"orders.list": "listOrders", "orders.create": "createOrder"},
+	}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	op, ok := idx.GetOperation("orders-svc", "orders.list")
+	if !ok {
+		t.Fatal("GetOperation(orders.list) not found")
+	}
+	if op.OperationID != "listOrders" || op.PathTemplate != "/orders" {
+		t.Errorf("alias resolved to %s %s, want listOrders /orders", op.OperationID, op.PathTemplate)
+	}
+	if errs := idx.ValidateRequest("orders-svc", "orders.create", map[string]any{}); len(errs) == 0 {
+		t.Error("ValidateRequest(orders.create) should validate against createOrder")
+	}
+	if _, ok := idx.GetOperation("other-svc", "orders.list"); ok {
+		t.Error("alias should be scoped to its service")
+	}
+	if got := len(idx.AllOperationIDs("orders-svc")); got != 5 {
+		t.Errorf("AllOperationIDs() len = %d, want 5 (aliases are not operations)", got)
+	}
+}
+
+func TestIndex_operationAliases_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string]string
+	}{
+		{"unknown target", map[string]string{"orders.list": "listAllOrders"}},
+		{"shadows operation", map[string]string{"getOrder": "listOrders"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewIndex().Load([]SpecSource{{
+				ServiceID: "orders-svc",
+				SpecPath:  "testdata/orders-svc.yaml",
+				Aliases:   tt.aliases,
+			}})
+			if err == nil {
+				t.Error("Load() should reject the alias")
+			}
+		})
+	}
+}
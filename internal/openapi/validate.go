@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// validateObject collects the errors of obj against an object schema:
+// missing required properties, then the errors of each declared property.
+func validateObject(schema *openapi3.Schema, prefix string, obj map[string]any, errs *[]ValidationError) {
+	for _, req := range schema.Required {
+		if _, exists := obj[req]; !exists {
+			field := joinField(prefix, req)
+			*errs = append(*errs, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("%s is required", field),
+			})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		prop, ok := schema.Properties[key]
+		if !ok || prop == nil || prop.Value == nil {
+			continue
+		}
+		validateValue(prop.Value, joinField(prefix, key), obj[key], errs)
+	}
+}
+
+// validateValue collects the errors of one value: a type mismatch, a value
+// outside the enum, or the errors of its properties or items. Null values
+// are not checked.
+func validateValue(schema *openapi3.Schema, field string, value any, errs *[]ValidationError) {
+	if value == nil {
+		return
+	}
+	if want := schema.Type.Slice(); len(want) > 0 && !slices.ContainsFunc(want, func(t string) bool { return hasType(value, t) }) {
+		*errs = append(*errs, ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("%s must be of type %s", field, strings.Join(want, " or ")),
+		})
+		return
+	}
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		allowed := make([]string, len(schema.Enum))
+		for i, e := range schema.Enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		*errs = append(*errs, ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("%s must be one of: %s", field, strings.Join(allowed, ", ")),
+		})
+		return
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		validateObject(schema, field, v, errs)
+	case []any:
+		if schema.Items == nil || schema.Items.Value == nil {
+			return
+		}
+		for i, item := range v {
+			validateValue(schema.Items.Value, fmt.Sprintf("%s[%d]", field, i), item, errs)
+		}
+	}
+}
+
+// hasType reports whether a decoded JSON value is of the given JSON schema
+// type.
+func hasType(value any, typ string) bool {
+	switch typ {
+	case openapi3.TypeString:
+		_, ok := value.(string)
+		return ok
+	case openapi3.TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case openapi3.TypeObject:
+		_, ok := value.(map[string]any)
+		return ok
+	case openapi3.TypeArray:
+		_, ok := value.([]any)
+		return ok
+	case openapi3.TypeNumber:
+		_, ok := toFloat(value)
+		return ok
+	case openapi3.TypeInteger:
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case openapi3.TypeNull:
+		return false
+	}
+	return true
+}
+
+func toFloat(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func joinField(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -3,8 +3,10 @@ package search
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pitabwire/thesa/internal/definition"
@@ -18,9 +20,12 @@ type LookupProvider struct {
 	invokers   *invoker.Registry
 	defaultTTL time.Duration
 	maxEntries int
+	maxResults int
 
-	mu    sync.RWMutex
-	cache map[string]cacheEntry
+	mu     sync.RWMutex
+	cache  map[string]cacheEntry
+	hits   atomic.Int64
+	misses atomic.Int64
 }
 
 type cacheEntry struct {
@@ -28,12 +33,15 @@ type cacheEntry struct {
 	expiresAt time.Time
 }
 
-// NewLookupProvider creates a new LookupProvider.
+// NewLookupProvider creates a new LookupProvider. maxResults is the default
+// cap on options returned by a single lookup; a lookup definition may
+// override it.
 func NewLookupProvider(
 	registry *definition.Registry,
 	invokers *invoker.Registry,
 	defaultTTL time.Duration,
 	maxEntries int,
+	maxResults int,
 ) *LookupProvider {
 	if defaultTTL <= 0 {
 		defaultTTL = 5 * time.Minute
@@ -41,11 +49,15 @@ func NewLookupProvider(
 	if maxEntries <= 0 {
 		maxEntries = 1000
 	}
+	if maxResults <= 0 {
+		maxResults = 100
+	}
 	return &LookupProvider{
 		registry:   registry,
 		invokers:   invokers,
 		defaultTTL: defaultTTL,
 		maxEntries: maxEntries,
+		maxResults: maxResults,
 		cache:      make(map[string]cacheEntry),
 	}
 }
@@ -56,50 +68,178 @@ func (lp *LookupProvider) GetLookup(
 	rctx *model.RequestContext,
 	lookupID string,
 	query string,
+	pagination model.Pagination,
 ) (model.LookupResponse, error) {
 	def, ok := lp.registry.GetLookup(lookupID)
-	if !ok {
+	if !ok || !rctx.EntitledTo(lp.registry.LookupDomain(lookupID)) {
 		return model.LookupResponse{}, model.NewNotFoundError(
 			fmt.Sprintf("lookup %q not found", lookupID),
 		)
 	}
 
-	// Build cache key based on scope.
+	options, cached, err := lp.resolve(ctx, rctx, def, nil)
+	if err != nil {
+		return model.LookupResponse{}, err
+	}
+
+	// Apply query filter, then page through the filtered options. Pages are
+	// at most the result limit in size.
+	filtered := filterOptions(options, query)
+	limit := lp.resultLimit(def)
+	if pagination.PageSize <= 0 || pagination.PageSize > limit {
+		pagination.PageSize = limit
+	}
+	if pagination.Page <= 0 {
+		pagination.Page = 1
+	}
+	page, hasMore := pageOptions(filtered, pagination)
+
+	return model.LookupResponse{
+		Data: model.LookupPayload{
+			Options:    page,
+			HasMore:    hasMore,
+			TotalCount: len(filtered),
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+		},
+		Meta: map[string]any{"cached": cached},
+	}, nil
+}
+
+// OptionsWithParams returns every option of a lookup for the given
+// parameter values, unfiltered and without the result limit, e.g. to
+// validate a submitted value. It shares the cache with GetLookup. Only the
+// parameters the lookup declares are forwarded to the backend; others, and
+// empty values, are ignored.
+func (lp *LookupProvider) OptionsWithParams(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	lookupID string,
+	params map[string]string,
+) ([]model.OptionDescriptor, error) {
+	def, ok := lp.registry.GetLookup(lookupID)
+	if !ok {
+		return nil, model.NewNotFoundError(
+			fmt.Sprintf("lookup %q not found", lookupID),
+		)
+	}
+	options, _, err := lp.resolve(ctx, rctx, def, declaredParams(def, params))
+	return options, err
+}
+
+// declaredParams returns the non-empty values of the parameters def
+// declares, or nil if there are none.
+func declaredParams(def model.LookupDefinition, params map[string]string) map[string]string {
+	var declared map[string]string
+	for _, name := range def.Params {
+		if v := params[name]; v != "" {
+			if declared == nil {
+				declared = make(map[string]string, len(def.Params))
+			}
+			declared[name] = v
+		}
+	}
+	return declared
+}
+
+// resolve returns a lookup's options from the cache, or from the backend on
+// a miss, and reports whether they were cached.
+func (lp *LookupProvider) resolve(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	def model.LookupDefinition,
+	params map[string]string,
+) ([]model.OptionDescriptor, bool, error) {
+	// Build cache key based on scope and parameters.
 	cacheKey := lp.buildCacheKey(def, rctx)
+	if len(params) > 0 {
+		q := make(url.Values, len(params))
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		cacheKey += "?" + q.Encode()
+	}
 
 	// Check cache.
 	if options, hit := lp.getFromCache(cacheKey); hit {
-		filtered := filterOptions(options, query)
-		return model.LookupResponse{
-			Data: model.LookupPayload{Options: filtered},
-			Meta: map[string]any{"cached": true},
-		}, nil
+		return options, true, nil
 	}
 
 	// Cache miss: invoke backend.
-	options, err := lp.fetchFromBackend(ctx, rctx, def)
+	options, err := lp.fetchFromBackend(ctx, rctx, def, params)
 	if err != nil {
-		return model.LookupResponse{}, err
+		return nil, false, err
 	}
 
-	// Determine TTL.
-	ttl := lp.defaultTTL
-	if def.Cache != nil && def.Cache.TTL != "" {
-		if parsed, parseErr := time.ParseDuration(def.Cache.TTL); parseErr == nil {
-			ttl = parsed
+	// Store in cache.
+	lp.putInCache(cacheKey, options, lp.cacheTTL(def))
+	return options, false, nil
+}
+
+// Warm pre-populates the cache for the given lookups, e.g. at startup so the
+// first page loads after a deploy are not served cold. Only globally scoped
+// lookups can be warmed, since tenant and partition entries need a caller's
+// context. Warming stops adding entries once the cache is full. Backends are
+// called without a user context, so lookups whose service forwards the
+// caller's token fail to warm.
+func (lp *LookupProvider) Warm(ctx context.Context, lookupIDs []string) []model.LookupWarmResult {
+	results := make([]model.LookupWarmResult, 0, len(lookupIDs))
+	for _, id := range lookupIDs {
+		result := model.LookupWarmResult{LookupID: id, Status: model.WarmStatusWarmed}
+		if reason, skip := lp.warm(ctx, id); reason != "" {
+			result.Status = model.WarmStatusFailed
+			if skip {
+				result.Status = model.WarmStatusSkipped
+			}
+			result.Reason = reason
 		}
+		results = append(results, result)
 	}
+	return results
+}
 
-	// Store in cache.
-	lp.putInCache(cacheKey, options, ttl)
+// warm fetches and caches one lookup. It returns a reason when the lookup
+// was not warmed, and whether it was skipped rather than failed.
+func (lp *LookupProvider) warm(ctx context.Context, lookupID string) (string, bool) {
+	def, ok := lp.registry.GetLookup(lookupID)
+	if !ok {
+		return "lookup not found", false
+	}
+	if def.Cache != nil && def.Cache.Scope != "" && def.Cache.Scope != "global" {
+		return fmt.Sprintf("%s-scoped lookups cannot be warmed", def.Cache.Scope), true
+	}
 
-	// Apply query filter.
-	filtered := filterOptions(options, query)
+	rctx := &model.RequestContext{}
+	key := lp.buildCacheKey(def, rctx)
+	if !lp.hasRoom(key) {
+		return "cache is full", true
+	}
+	options, err := lp.fetchFromBackend(ctx, rctx, def, nil)
+	if err != nil {
+		return err.Error(), false
+	}
+	if !lp.putIfRoom(key, options, lp.cacheTTL(def)) {
+		return "cache is full", true
+	}
+	return "", false
+}
 
-	return model.LookupResponse{
-		Data: model.LookupPayload{Options: filtered},
-		Meta: map[string]any{"cached": false},
-	}, nil
+// cacheTTL returns the cache TTL for a lookup, falling back to the default.
+func (lp *LookupProvider) cacheTTL(def model.LookupDefinition) time.Duration {
+	if def.Cache != nil && def.Cache.TTL != "" {
+		if parsed, err := time.ParseDuration(def.Cache.TTL); err == nil {
+			return parsed
+		}
+	}
+	return lp.defaultTTL
+}
+
+// resultLimit returns the maximum number of options returned for a lookup.
+func (lp *LookupProvider) resultLimit(def model.LookupDefinition) int {
+	if def.MaxResults > 0 {
+		return def.MaxResults
+	}
+	return lp.maxResults
 }
 
 // buildCacheKey constructs a cache key scoped to the lookup and tenant context.
@@ -126,11 +266,21 @@ func (lp *LookupProvider) getFromCache(key string) ([]model.OptionDescriptor, bo
 
 	entry, exists := lp.cache[key]
 	if !exists || time.Now().After(entry.expiresAt) {
+		lp.misses.Add(1)
 		return nil, false
 	}
+	lp.hits.Add(1)
 	return entry.options, true
 }
 
+// CacheStats reports the lookup cache size and hit rate.
+func (lp *LookupProvider) CacheStats() model.CacheStats {
+	lp.mu.RLock()
+	entries := len(lp.cache)
+	lp.mu.RUnlock()
+	return model.NewCacheStats(entries, lp.hits.Load(), lp.misses.Load())
+}
+
 // putInCache stores options in the cache with TTL.
 func (lp *LookupProvider) putInCache(key string, options []model.OptionDescriptor, ttl time.Duration) {
 	lp.mu.Lock()
@@ -147,6 +297,37 @@ func (lp *LookupProvider) putInCache(key string, options []model.OptionDescripto
 	}
 }
 
+// hasRoom reports whether key can be stored without exceeding maxEntries.
+func (lp *LookupProvider) hasRoom(key string) bool {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.hasRoomLocked(key)
+}
+
+func (lp *LookupProvider) hasRoomLocked(key string) bool {
+	if _, exists := lp.cache[key]; exists || len(lp.cache) < lp.maxEntries {
+		return true
+	}
+	lp.evictExpired()
+	return len(lp.cache) < lp.maxEntries
+}
+
+// putIfRoom stores options like putInCache, but only if that does not
+// exceed maxEntries.
+func (lp *LookupProvider) putIfRoom(key string, options []model.OptionDescriptor, ttl time.Duration) bool {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if !lp.hasRoomLocked(key) {
+		return false
+	}
+	lp.cache[key] = cacheEntry{
+		options:   options,
+		expiresAt: time.Now().Add(ttl),
+	}
+	return true
+}
+
 // evictExpired removes expired entries. Must be called with mu held.
 func (lp *LookupProvider) evictExpired() {
 	now := time.Now()
@@ -179,13 +360,15 @@ func (lp *LookupProvider) CacheLen() int {
 	return len(lp.cache)
 }
 
-// fetchFromBackend invokes the lookup operation and maps results.
+// fetchFromBackend invokes the lookup operation, passing params as query
+// parameters, and maps results.
 func (lp *LookupProvider) fetchFromBackend(
 	ctx context.Context,
 	rctx *model.RequestContext,
 	def model.LookupDefinition,
+	params map[string]string,
 ) ([]model.OptionDescriptor, error) {
-	result, err := lp.invokers.Invoke(ctx, rctx, def.Operation, model.InvocationInput{})
+	result, err := lp.invokers.Invoke(ctx, rctx, def.Operation, model.InvocationInput{QueryParams: params})
 	if err != nil {
 		return nil, fmt.Errorf("lookup %q: %w", def.ID, err)
 	}
@@ -258,3 +441,18 @@ func filterOptions(options []model.OptionDescriptor, query string) []model.Optio
 	}
 	return filtered
 }
+
+// pageOptions returns the requested page of options and reports whether
+// later pages follow. A page past the end is empty; the bound is checked
+// before multiplying so a huge page number cannot overflow the offset.
+func pageOptions(options []model.OptionDescriptor, pagination model.Pagination) ([]model.OptionDescriptor, bool) {
+	if pagination.Page-1 > len(options)/pagination.PageSize {
+		return []model.OptionDescriptor{}, false
+	}
+	offset := (pagination.Page - 1) * pagination.PageSize
+	if offset >= len(options) {
+		return []model.OptionDescriptor{}, false
+	}
+	end := min(offset+pagination.PageSize, len(options))
+	return options[offset:end], end < len(options)
+}
@@ -3,6 +3,7 @@ package search
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -85,7 +86,7 @@ func newTestLookupProvider(inv *mockSearchInvoker) *LookupProvider {
 	reg := definition.NewRegistry(testLookupDefinitions())
 	invReg := invoker.NewRegistry()
 	invReg.Register(inv)
-	return NewLookupProvider(reg, invReg, 5*time.Minute, 100)
+	return NewLookupProvider(reg, invReg, 5*time.Minute, 100, 100)
 }
 
 // --- GetLookup tests ---
@@ -100,7 +101,7 @@ func TestLookupProvider_GetLookup_success(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "")
+	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
 	if err != nil {
 		t.Fatalf("GetLookup error: %v", err)
 	}
@@ -132,13 +133,13 @@ func TestLookupProvider_GetLookup_cached(t *testing.T) {
 	rctx := testRctx()
 
 	// First call.
-	_, _ = lp.GetLookup(ctx, rctx, "orders.statuses", "")
+	_, _ = lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
 	if callCount != 1 {
 		t.Fatalf("expected 1 backend call, got %d", callCount)
 	}
 
 	// Second call should be cached.
-	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "")
+	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
 	if err != nil {
 		t.Fatalf("GetLookup error: %v", err)
 	}
@@ -158,7 +159,7 @@ func TestLookupProvider_GetLookup_notFound(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	_, err := lp.GetLookup(ctx, rctx, "nonexistent", "")
+	_, err := lp.GetLookup(ctx, rctx, "nonexistent", "", model.Pagination{})
 	if err == nil {
 		t.Fatal("expected not found error")
 	}
@@ -171,6 +172,19 @@ func TestLookupProvider_GetLookup_notFound(t *testing.T) {
 	}
 }
 
+func TestLookupProvider_GetLookup_unentitledDomain(t *testing.T) {
+	lp := newTestLookupProvider(&mockSearchInvoker{handler: func(binding model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+		return statusesResponse(), nil
+	}})
+	rctx := testRctx()
+	rctx.UnentitledDomains = []string{"orders"}
+
+	_, err := lp.GetLookup(context.Background(), rctx, "orders.statuses", "", model.Pagination{})
+	if envErr, ok := err.(*model.ErrorEnvelope); !ok || envErr.Code != model.ErrNotFound {
+		t.Errorf("GetLookup() error = %v, want NOT_FOUND for an unentitled domain", err)
+	}
+}
+
 func TestLookupProvider_GetLookup_queryFilter(t *testing.T) {
 	inv := &mockSearchInvoker{
 		handler: func(_ model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
@@ -181,7 +195,7 @@ func TestLookupProvider_GetLookup_queryFilter(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "act")
+	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "act", model.Pagination{})
 	if err != nil {
 		t.Fatalf("GetLookup error: %v", err)
 	}
@@ -203,7 +217,7 @@ func TestLookupProvider_GetLookup_queryFilterCaseInsensitive(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "PEND")
+	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "PEND", model.Pagination{})
 	if err != nil {
 		t.Fatalf("GetLookup error: %v", err)
 	}
@@ -226,10 +240,10 @@ func TestLookupProvider_GetLookup_queryFilterOnCache(t *testing.T) {
 	rctx := testRctx()
 
 	// First call populates cache with all options.
-	_, _ = lp.GetLookup(ctx, rctx, "orders.statuses", "")
+	_, _ = lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
 
 	// Second call with query should filter cached results.
-	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "comp")
+	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "comp", model.Pagination{})
 	if err != nil {
 		t.Fatalf("GetLookup error: %v", err)
 	}
@@ -251,7 +265,7 @@ func TestLookupProvider_GetLookup_dataWrapper(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	resp, err := lp.GetLookup(ctx, rctx, "orders.categories", "")
+	resp, err := lp.GetLookup(ctx, rctx, "orders.categories", "", model.Pagination{})
 	if err != nil {
 		t.Fatalf("GetLookup error: %v", err)
 	}
@@ -273,7 +287,7 @@ func TestLookupProvider_GetLookup_backendError(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	_, err := lp.GetLookup(ctx, rctx, "orders.statuses", "")
+	_, err := lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -289,7 +303,7 @@ func TestLookupProvider_GetLookup_backendNon2xx(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	_, err := lp.GetLookup(ctx, rctx, "orders.statuses", "")
+	_, err := lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
 	if err == nil {
 		t.Fatal("expected error for 500 status")
 	}
@@ -311,8 +325,8 @@ func TestLookupProvider_CacheScope_global(t *testing.T) {
 	rctx1 := &model.RequestContext{SubjectID: "alice", TenantID: "tenant-1"}
 	rctx2 := &model.RequestContext{SubjectID: "bob", TenantID: "tenant-2"}
 
-	_, _ = lp.GetLookup(ctx, rctx1, "orders.statuses", "")
-	_, _ = lp.GetLookup(ctx, rctx2, "orders.statuses", "")
+	_, _ = lp.GetLookup(ctx, rctx1, "orders.statuses", "", model.Pagination{})
+	_, _ = lp.GetLookup(ctx, rctx2, "orders.statuses", "", model.Pagination{})
 
 	// Global scope: both tenants share the same cache entry.
 	if callCount != 1 {
@@ -334,8 +348,8 @@ func TestLookupProvider_CacheScope_tenant(t *testing.T) {
 	rctx1 := &model.RequestContext{SubjectID: "alice", TenantID: "tenant-1"}
 	rctx2 := &model.RequestContext{SubjectID: "bob", TenantID: "tenant-2"}
 
-	_, _ = lp.GetLookup(ctx, rctx1, "orders.categories", "")
-	_, _ = lp.GetLookup(ctx, rctx2, "orders.categories", "")
+	_, _ = lp.GetLookup(ctx, rctx1, "orders.categories", "", model.Pagination{})
+	_, _ = lp.GetLookup(ctx, rctx2, "orders.categories", "", model.Pagination{})
 
 	// Tenant scope: different tenants get separate cache entries.
 	if callCount != 2 {
@@ -360,8 +374,8 @@ func TestLookupProvider_CacheScope_partition(t *testing.T) {
 	rctx1 := &model.RequestContext{SubjectID: "alice", TenantID: "tenant-1", PartitionID: "part-a"}
 	rctx2 := &model.RequestContext{SubjectID: "bob", TenantID: "tenant-1", PartitionID: "part-b"}
 
-	_, _ = lp.GetLookup(ctx, rctx1, "orders.warehouses", "")
-	_, _ = lp.GetLookup(ctx, rctx2, "orders.warehouses", "")
+	_, _ = lp.GetLookup(ctx, rctx1, "orders.warehouses", "", model.Pagination{})
+	_, _ = lp.GetLookup(ctx, rctx2, "orders.warehouses", "", model.Pagination{})
 
 	// Partition scope: different partitions get separate entries.
 	if callCount != 2 {
@@ -369,6 +383,180 @@ func TestLookupProvider_CacheScope_partition(t *testing.T) {
 	}
 }
 
+// --- Result limit ---
+
+func TestLookupProvider_GetLookup_resultLimit(t *testing.T) {
+	inv := &mockSearchInvoker{
+		handler: func(_ model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			return statusesResponse(), nil
+		},
+	}
+	reg := definition.NewRegistry(testLookupDefinitions())
+	invReg := invoker.NewRegistry()
+	invReg.Register(inv)
+	lp := NewLookupProvider(reg, invReg, 5*time.Minute, 100, 2)
+	ctx := context.Background()
+	rctx := testRctx()
+
+	resp, err := lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
+	if err != nil {
+		t.Fatalf("GetLookup error: %v", err)
+	}
+	if len(resp.Data.Options) != 2 {
+		t.Fatalf("Options count = %d, want 2", len(resp.Data.Options))
+	}
+	if !resp.Data.HasMore {
+		t.Error("HasMore = false, want true")
+	}
+
+	// Cached path applies the same limit; filtering first leaves one match.
+	resp, _ = lp.GetLookup(ctx, rctx, "orders.statuses", "act", model.Pagination{})
+	if len(resp.Data.Options) != 1 {
+		t.Fatalf("filtered Options count = %d, want 1", len(resp.Data.Options))
+	}
+	if resp.Data.HasMore {
+		t.Error("HasMore = true after filtering below the limit, want false")
+	}
+}
+
+func TestLookupProvider_OptionsWithParams_unlimited(t *testing.T) {
+	calls := 0
+	inv := &mockSearchInvoker{
+		handler: func(_ model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			calls++
+			return statusesResponse(), nil
+		},
+	}
+	invReg := invoker.NewRegistry()
+	invReg.Register(inv)
+	lp := NewLookupProvider(definition.NewRegistry(testLookupDefinitions()), invReg, 5*time.Minute, 100, 2)
+
+	options, err := lp.OptionsWithParams(context.Background(), testRctx(), "orders.statuses", nil)
+	if err != nil {
+		t.Fatalf("OptionsWithParams error: %v", err)
+	}
+	if len(options) != 3 {
+		t.Errorf("Options count = %d, want all 3 despite the result limit", len(options))
+	}
+	if _, err := lp.GetLookup(context.Background(), testRctx(), "orders.statuses", "", model.Pagination{}); err != nil {
+		t.Fatalf("GetLookup error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("backend calls = %d, want 1 (cache shared with GetLookup)", calls)
+	}
+	if _, err := lp.OptionsWithParams(context.Background(), testRctx(), "orders.missing", nil); err == nil {
+		t.Error("OptionsWithParams(unknown) should return an error")
+	}
+}
+
+func TestLookupProvider_OptionsWithParams(t *testing.T) {
+	var queries []map[string]string
+	inv := &mockSearchInvoker{
+		handler: func(_ model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+			queries = append(queries, input.QueryParams)
+			return statusesResponse(), nil
+		},
+	}
+	defs := testLookupDefinitions()
+	defs[0].Lookups[0].Params = []string{"region"}
+	invReg := invoker.NewRegistry()
+	invReg.Register(inv)
+	lp := NewLookupProvider(definition.NewRegistry(defs), invReg, 5*time.Minute, 100, 100)
+	ctx, rctx := context.Background(), testRctx()
+
+	for _, params := range []map[string]string{
+		{"region": "eu", "other": "ignored"},
+		{"region": "eu"},
+		{"region": "us"},
+		{"region": ""},
+	} {
+		if _, err := lp.OptionsWithParams(ctx, rctx, "orders.statuses", params); err != nil {
+			t.Fatalf("OptionsWithParams(%v) error: %v", params, err)
+		}
+	}
+
+	want := []map[string]string{{"region": "eu"}, {"region": "us"}, nil}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("backend queries = %v, want %v (cached per declared parameter value)", queries, want)
+	}
+}
+
+func TestLookupProvider_GetLookup_perLookupLimit(t *testing.T) {
+	defs := testLookupDefinitions()
+	defs[0].Lookups[0].MaxResults = 1
+	reg := definition.NewRegistry(defs)
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockSearchInvoker{
+		handler: func(_ model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			return statusesResponse(), nil
+		},
+	})
+	lp := NewLookupProvider(reg, invReg, 5*time.Minute, 100, 100)
+
+	resp, err := lp.GetLookup(context.Background(), testRctx(), "orders.statuses", "", model.Pagination{})
+	if err != nil {
+		t.Fatalf("GetLookup error: %v", err)
+	}
+	if len(resp.Data.Options) != 1 || !resp.Data.HasMore {
+		t.Errorf("Options count = %d, HasMore = %v; want 1, true", len(resp.Data.Options), resp.Data.HasMore)
+	}
+}
+
+func TestLookupProvider_GetLookup_pagination(t *testing.T) {
+	items := make([]any, 25)
+	for i := range items {
+		items[i] = map[string]any{"code": fmt.Sprintf("s%02d", i), "name": fmt.Sprintf("Status %02d", i)}
+	}
+	calls := 0
+	invReg := invoker.NewRegistry()
+	invReg.Register(&mockSearchInvoker{
+		handler: func(_ model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			calls++
+			return model.InvocationResult{StatusCode: 200, Body: items}, nil
+		},
+	})
+	lp := NewLookupProvider(definition.NewRegistry(testLookupDefinitions()), invReg, 5*time.Minute, 100, 20)
+
+	tests := []struct {
+		name         string
+		pagination   model.Pagination
+		wantFirst    string
+		wantCount    int
+		wantPage     int
+		wantPageSize int
+		wantMore     bool
+	}{
+		{name: "first page", pagination: model.Pagination{Page: 1, PageSize: 10}, wantFirst: "s00", wantCount: 10, wantPage: 1, wantPageSize: 10, wantMore: true},
+		{name: "middle page", pagination: model.Pagination{Page: 2, PageSize: 10}, wantFirst: "s10", wantCount: 10, wantPage: 2, wantPageSize: 10, wantMore: true},
+		{name: "last page", pagination: model.Pagination{Page: 3, PageSize: 10}, wantFirst: "s20", wantCount: 5, wantPage: 3, wantPageSize: 10},
+		{name: "past the end", pagination: model.Pagination{Page: 4, PageSize: 10}, wantPage: 4, wantPageSize: 10},
+		{name: "defaults to result limit", wantFirst: "s00", wantCount: 20, wantPage: 1, wantPageSize: 20, wantMore: true},
+		{name: "page size capped", pagination: model.Pagination{Page: 2, PageSize: 50}, wantFirst: "s20", wantCount: 5, wantPage: 2, wantPageSize: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := lp.GetLookup(context.Background(), testRctx(), "orders.statuses", "", tt.pagination)
+			if err != nil {
+				t.Fatalf("GetLookup error: %v", err)
+			}
+			data := resp.Data
+			if len(data.Options) != tt.wantCount {
+				t.Fatalf("Options count = %d, want %d", len(data.Options), tt.wantCount)
+			}
+			if tt.wantCount > 0 && data.Options[0].Value != tt.wantFirst {
+				t.Errorf("first option = %v, want %s", data.Options[0].Value, tt.wantFirst)
+			}
+			if data.TotalCount != 25 || data.Page != tt.wantPage || data.PageSize != tt.wantPageSize || data.HasMore != tt.wantMore {
+				t.Errorf("meta = total %d, page %d, page_size %d, has_more %v; want 25, %d, %d, %v",
+					data.TotalCount, data.Page, data.PageSize, data.HasMore, tt.wantPage, tt.wantPageSize, tt.wantMore)
+			}
+		})
+	}
+	if calls != 1 {
+		t.Errorf("backend calls = %d, want 1 (pages are served from the cached set)", calls)
+	}
+}
+
 // --- Invalidation ---
 
 func TestLookupProvider_Invalidate(t *testing.T) {
@@ -381,7 +569,7 @@ func TestLookupProvider_Invalidate(t *testing.T) {
 	ctx := context.Background()
 	rctx := testRctx()
 
-	_, _ = lp.GetLookup(ctx, rctx, "orders.statuses", "")
+	_, _ = lp.GetLookup(ctx, rctx, "orders.statuses", "", model.Pagination{})
 	if lp.CacheLen() != 1 {
 		t.Fatalf("CacheLen = %d, want 1", lp.CacheLen())
 	}
@@ -406,8 +594,8 @@ func TestLookupProvider_Invalidate_tenantSpecific(t *testing.T) {
 	rctx1 := &model.RequestContext{SubjectID: "alice", TenantID: "tenant-1"}
 	rctx2 := &model.RequestContext{SubjectID: "bob", TenantID: "tenant-2"}
 
-	_, _ = lp.GetLookup(ctx, rctx1, "orders.categories", "")
-	_, _ = lp.GetLookup(ctx, rctx2, "orders.categories", "")
+	_, _ = lp.GetLookup(ctx, rctx1, "orders.categories", "", model.Pagination{})
+	_, _ = lp.GetLookup(ctx, rctx2, "orders.categories", "", model.Pagination{})
 	if lp.CacheLen() != 2 {
 		t.Fatalf("CacheLen = %d, want 2", lp.CacheLen())
 	}
@@ -544,3 +732,88 @@ func TestBuildCacheKey(t *testing.T) {
 		}
 	}
 }
+
+// --- Warm tests ---
+
+func TestLookupProvider_Warm(t *testing.T) {
+	var calls []string
+	inv := &mockSearchInvoker{
+		handler: func(b model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			calls = append(calls, b.OperationID)
+			if b.OperationID == "getThings" {
+				return model.InvocationResult{StatusCode: 503}, nil
+			}
+			return statusesResponse(), nil
+		},
+	}
+	lp := newTestLookupProvider(inv)
+
+	results := lp.Warm(context.Background(), []string{"orders.statuses", "orders.categories", "orders.no-cache", "orders.missing"})
+	want := map[string]string{
+		"orders.statuses":   model.WarmStatusWarmed,
+		"orders.categories": model.WarmStatusSkipped,
+		"orders.no-cache":   model.WarmStatusFailed,
+		"orders.missing":    model.WarmStatusFailed,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("results = %+v, want %d entries", results, len(want))
+	}
+	for _, r := range results {
+		if r.Status != want[r.LookupID] {
+			t.Errorf("%s status = %q (%s), want %q", r.LookupID, r.Status, r.Reason, want[r.LookupID])
+		}
+	}
+	if lp.CacheLen() != 1 {
+		t.Errorf("CacheLen = %d, want 1", lp.CacheLen())
+	}
+
+	// A subsequent request is served from the warmed cache.
+	calls = nil
+	resp, err := lp.GetLookup(context.Background(), testRctx(), "orders.statuses", "", model.Pagination{})
+	if err != nil {
+		t.Fatalf("GetLookup error: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("backend calls = %v, want none after warm-up", calls)
+	}
+	if resp.Meta["cached"] != true || len(resp.Data.Options) != 3 {
+		t.Errorf("response = %+v, want 3 cached options", resp)
+	}
+}
+
+func TestLookupProvider_Warm_respectsMaxEntries(t *testing.T) {
+	calls := 0
+	inv := &mockSearchInvoker{
+		handler: func(_ model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			calls++
+			return statusesResponse(), nil
+		},
+	}
+	invReg := invoker.NewRegistry()
+	invReg.Register(inv)
+	lp := NewLookupProvider(definition.NewRegistry(testLookupDefinitions()), invReg, 5*time.Minute, 1, 100)
+
+	results := lp.Warm(context.Background(), []string{"orders.statuses", "orders.no-cache"})
+	if results[0].Status != model.WarmStatusWarmed || results[1].Status != model.WarmStatusSkipped {
+		t.Errorf("results = %+v, want warmed then skipped", results)
+	}
+	if calls != 1 {
+		t.Errorf("backend calls = %d, want 1 (no fetch once the cache is full)", calls)
+	}
+	if lp.CacheLen() != 1 {
+		t.Errorf("CacheLen = %d, want 1", lp.CacheLen())
+	}
+
+	// Re-warming an entry already cached is allowed when full.
+	if res := lp.Warm(context.Background(), []string{"orders.statuses"}); res[0].Status != model.WarmStatusWarmed {
+		t.Errorf("re-warm = %+v, want warmed", res)
+	}
+}
+
+func TestPageOptions_hugePage(t *testing.T) {
+	options := make([]model.OptionDescriptor, 25)
+	got, hasMore := pageOptions(options, model.Pagination{Page: 184467440737095518, PageSize: 50})
+	if len(got) != 0 || hasMore {
+		t.Errorf("pageOptions() = %d options, hasMore %v; want an empty last page", len(got), hasMore)
+	}
+}
@@ -13,12 +13,41 @@ import (
 	"github.com/pitabwire/thesa/model"
 )
 
+// Identity selects which result fields identify the same entity when
+// deduplicating results across providers.
+type Identity string
+
+// Supported deduplication identities.
+const (
+	// IdentityRouteAndID treats results as duplicates when both route and
+	// ID match. This is the default.
+	IdentityRouteAndID Identity = "route_id"
+	// IdentityRoute treats results with the same route as duplicates.
+	IdentityRoute Identity = "route"
+	// IdentityID treats results with the same ID as duplicates, regardless
+	// of which provider or route produced them.
+	IdentityID Identity = "id"
+)
+
+// key returns the deduplication key of r under identity i.
+func (i Identity) key(r model.SearchResult) string {
+	switch i {
+	case IdentityRoute:
+		return r.Route
+	case IdentityID:
+		return r.ID
+	default:
+		return r.Route + "|" + r.ID
+	}
+}
+
 // SearchProvider orchestrates global search across all registered domains.
 type SearchProvider struct {
 	registry           *definition.Registry
 	invokers           *invoker.Registry
 	timeoutPerProvider time.Duration
 	maxResultsDefault  int
+	identity           Identity
 }
 
 // NewSearchProvider creates a new SearchProvider.
@@ -39,7 +68,17 @@ func NewSearchProvider(
 		invokers:           invokers,
 		timeoutPerProvider: timeoutPerProvider,
 		maxResultsDefault:  maxResultsPerProvider,
+		identity:           IdentityRouteAndID,
+	}
+}
+
+// SetIdentity sets the identity used to collapse duplicate results returned
+// by different providers. An empty identity restores the default.
+func (sp *SearchProvider) SetIdentity(identity Identity) {
+	if identity == "" {
+		identity = IdentityRouteAndID
 	}
+	sp.identity = identity
 }
 
 // providerResult collects the outcome of a single search provider invocation.
@@ -58,10 +97,8 @@ func (sp *SearchProvider) Search(
 	pagination model.Pagination,
 ) (model.SearchResponse, error) {
 	// 1. Validate query.
-	if len(query) < 2 {
-		return model.SearchResponse{}, model.NewBadRequestError(
-			"Search query must be at least 2 characters",
-		)
+	if err := validateQuery(query); err != nil {
+		return model.SearchResponse{}, err
 	}
 
 	// 2. Normalize pagination.
@@ -76,17 +113,7 @@ func (sp *SearchProvider) Search(
 	}
 
 	// 3. Get all search definitions and filter by capability and domain.
-	allDefs := sp.registry.AllSearches()
-	var eligible []model.SearchDefinition
-	for _, def := range allDefs {
-		if pagination.Domain != "" && def.Domain != pagination.Domain {
-			continue
-		}
-		if len(def.Capabilities) > 0 && !caps.HasAll(def.Capabilities...) {
-			continue
-		}
-		eligible = append(eligible, def)
-	}
+	eligible := sp.eligibleSearches(rctx, caps, pagination.Domain)
 
 	// 4. Execute providers in parallel.
 	startTime := time.Now()
@@ -100,8 +127,8 @@ func (sp *SearchProvider) Search(
 		merged = append(merged, r.Results...)
 	}
 
-	// 6. Deduplicate by route + id (keep highest score).
-	merged = deduplicate(merged)
+	// 6. Deduplicate by the configured identity (keep highest score).
+	merged = deduplicate(merged, sp.identity)
 
 	// 7. Sort by score descending.
 	sort.Slice(merged, func(i, j int) bool {
@@ -137,6 +164,79 @@ func (sp *SearchProvider) Search(
 	}, nil
 }
 
+// ProviderResults is the outcome of one search provider in a streamed
+// search.
+type ProviderResults struct {
+	Provider string               `json:"provider"`
+	Status   string               `json:"status"` // "ok", "timeout", "error"
+	Results  []model.SearchResult `json:"results"`
+}
+
+// StreamSearch runs the same providers as Search concurrently and calls
+// emit with each provider's results as soon as it completes. Results
+// already emitted for another provider are dropped, using the configured
+// identity. emit is never called concurrently. StreamSearch returns once
+// every provider has reported, or a BAD_REQUEST error, before any provider
+// runs, for a query that is too short.
+func (sp *SearchProvider) StreamSearch(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	query string,
+	domain string,
+	emit func(ProviderResults),
+) error {
+	if err := validateQuery(query); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for r := range sp.runProviders(ctx, rctx, sp.eligibleSearches(rctx, caps, domain), query) {
+		results := make([]model.SearchResult, 0, len(r.Results))
+		for _, res := range r.Results {
+			if key := sp.identity.key(res); !seen[key] {
+				seen[key] = true
+				results = append(results, res)
+			}
+		}
+		emit(ProviderResults{Provider: r.ProviderID, Status: r.Status, Results: results})
+	}
+	return nil
+}
+
+// validateQuery rejects queries too short to search for.
+func validateQuery(query string) error {
+	if len(query) < 2 {
+		return model.NewBadRequestError(
+			"Search query must be at least 2 characters",
+		)
+	}
+	return nil
+}
+
+// eligibleSearches returns the search definitions the caller may run, so
+// providers they are not authorized for are never invoked. A search requires
+// its owning domain's entitlement, its own capabilities and those of the
+// domain's navigation.
+func (sp *SearchProvider) eligibleSearches(rctx *model.RequestContext, caps model.CapabilitySet, domain string) []model.SearchDefinition {
+	var eligible []model.SearchDefinition
+	for _, def := range sp.registry.AllSearches() {
+		if (domain != "" && def.Domain != domain) || !rctx.EntitledTo(def.Domain) {
+			continue
+		}
+		if len(def.Capabilities) > 0 && !caps.HasAll(def.Capabilities...) {
+			continue
+		}
+		if dom, ok := sp.registry.GetDomain(def.Domain); ok {
+			if nav := dom.Navigation.Capabilities; len(nav) > 0 && !caps.HasAll(nav...) {
+				continue
+			}
+		}
+		eligible = append(eligible, def)
+	}
+	return eligible
+}
+
 // executeProviders runs all eligible providers concurrently and collects results.
 func (sp *SearchProvider) executeProviders(
 	ctx context.Context,
@@ -144,10 +244,21 @@ func (sp *SearchProvider) executeProviders(
 	defs []model.SearchDefinition,
 	query string,
 ) []providerResult {
-	if len(defs) == 0 {
-		return nil
+	var results []providerResult
+	for r := range sp.runProviders(ctx, rctx, defs, query) {
+		results = append(results, r)
 	}
+	return results
+}
 
+// runProviders starts all providers concurrently and returns a channel that
+// yields each result as it completes and is closed after the last one.
+func (sp *SearchProvider) runProviders(
+	ctx context.Context,
+	rctx *model.RequestContext,
+	defs []model.SearchDefinition,
+	query string,
+) <-chan providerResult {
 	ch := make(chan providerResult, len(defs))
 	var wg sync.WaitGroup
 
@@ -164,12 +275,7 @@ func (sp *SearchProvider) executeProviders(
 		wg.Wait()
 		close(ch)
 	}()
-
-	var results []providerResult
-	for r := range ch {
-		results = append(results, r)
-	}
-	return results
+	return ch
 }
 
 // executeProvider runs a single search provider with a timeout.
@@ -310,9 +416,9 @@ func resolveRoute(template, id string) string {
 	return strings.ReplaceAll(template, "{id}", id)
 }
 
-// deduplicate removes duplicate results (same route + id), keeping the one
+// deduplicate removes results that share an identity key, keeping the one
 // with the highest score.
-func deduplicate(results []model.SearchResult) []model.SearchResult {
+func deduplicate(results []model.SearchResult, identity Identity) []model.SearchResult {
 	if len(results) == 0 {
 		return results
 	}
@@ -321,7 +427,7 @@ func deduplicate(results []model.SearchResult) []model.SearchResult {
 	var output []model.SearchResult
 
 	for _, r := range results {
-		key := r.Route + "|" + r.ID
+		key := identity.key(r)
 		if idx, exists := seen[key]; exists {
 			// Keep the one with the higher score.
 			if r.Score > output[idx].Score {
@@ -198,6 +198,53 @@ func TestSearchProvider_Search_queryTooShort(t *testing.T) {
 	}
 }
 
+func TestSearchProvider_StreamSearch_incremental(t *testing.T) {
+	// The customers provider answers only once the orders results have been
+	// emitted, so the search completes only if results stream per provider.
+	firstEmitted := make(chan struct{})
+	inv := &mockSearchInvoker{
+		handler: func(b model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			if b.OperationID == "searchOrders" {
+				return ordersResponse(), nil
+			}
+			select {
+			case <-firstEmitted:
+				return customersResponse(), nil
+			case <-time.After(2 * time.Second):
+				return model.InvocationResult{}, fmt.Errorf("orders results were not streamed first")
+			}
+		},
+	}
+	sp := newTestProvider(inv)
+	caps := model.CapabilitySet{"*": true}
+
+	var events []ProviderResults
+	err := sp.StreamSearch(context.Background(), testRctx(), caps, "ACME", "", func(res ProviderResults) {
+		events = append(events, res)
+		if len(events) == 1 {
+			close(firstEmitted)
+		}
+	})
+	if err != nil {
+		t.Fatalf("StreamSearch error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want one per provider", len(events))
+	}
+	if events[0].Provider != "orders.search" || len(events[0].Results) != 2 {
+		t.Errorf("first event = %+v, want the 2 orders results", events[0])
+	}
+	if events[1].Provider != "customers.search" || events[1].Status != "ok" || len(events[1].Results) != 1 {
+		t.Errorf("second event = %+v, want the customers result", events[1])
+	}
+
+	if err := sp.StreamSearch(context.Background(), testRctx(), caps, "A", "", func(ProviderResults) {
+		t.Error("emit called for a rejected query")
+	}); err == nil {
+		t.Error("expected bad request error for a short query")
+	}
+}
+
 func TestSearchProvider_Search_capabilityFilter(t *testing.T) {
 	inv := &mockSearchInvoker{
 		handler: func(b model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
@@ -229,6 +276,148 @@ func TestSearchProvider_Search_capabilityFilter(t *testing.T) {
 	}
 }
 
+func TestSearchProvider_Search_skipsUnauthorizedProviders(t *testing.T) {
+	defs := testSearchDefinitions()
+	// Customers search declares no capabilities of its own, but the domain
+	// is restricted.
+	defs[1].Searches[0].Capabilities = nil
+	defs[1].Navigation.Capabilities = []string{"customers:nav:view"}
+
+	var mu sync.Mutex
+	var called []string
+	inv := &mockSearchInvoker{
+		handler: func(b model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			mu.Lock()
+			called = append(called, b.OperationID)
+			mu.Unlock()
+			if b.OperationID == "searchOrders" {
+				return ordersResponse(), nil
+			}
+			return customersResponse(), nil
+		},
+	}
+	invReg := invoker.NewRegistry()
+	invReg.Register(inv)
+	sp := NewSearchProvider(definition.NewRegistry(defs), invReg, 3*time.Second, 50)
+
+	viewer := model.CapabilitySet{"orders:search:execute": true}
+	resp, err := sp.Search(context.Background(), testRctx(), viewer, "ACME", model.Pagination{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(called) != 1 || called[0] != "searchOrders" {
+		t.Errorf("backend calls = %v, want only searchOrders", called)
+	}
+	providers := resp.Meta["providers"].(map[string]string)
+	if _, ok := providers["customers.search"]; ok {
+		t.Error("customers.search should not be reported as a provider")
+	}
+
+	// With the domain capability, the customers provider runs.
+	called = nil
+	viewer["customers:nav:view"] = true
+	if _, err := sp.Search(context.Background(), testRctx(), viewer, "ACME", model.Pagination{Page: 1, PageSize: 20}); err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(called) != 2 {
+		t.Errorf("backend calls = %v, want both providers", called)
+	}
+}
+
+// newOverlappingProvider adds an archive provider that indexes ord-001 (also
+// returned by orders.search) and ord-900, routed through archiveRoute.
+func newOverlappingProvider(archiveRoute string) *SearchProvider {
+	defs := testSearchDefinitions()
+	defs[0].Searches = append(defs[0].Searches, model.SearchDefinition{
+		ID:        "orders.archive",
+		Domain:    "orders",
+		Operation: model.OperationBinding{Type: "openapi", OperationID: "searchArchive"},
+		ResultMapping: model.SearchResultMapping{
+			ItemsPath:  "results",
+			TitleField: "orderNumber",
+			Route:      archiveRoute,
+			IDField:    "id",
+		},
+		Weight: 20,
+	})
+	inv := &mockSearchInvoker{
+		handler: func(b model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
+			switch b.OperationID {
+			case "searchOrders":
+				return ordersResponse(), nil
+			case "searchArchive":
+				return model.InvocationResult{StatusCode: 200, Body: map[string]any{
+					"results": []any{
+						map[string]any{"id": "ord-001", "orderNumber": "ORD-2024-001"},
+						map[string]any{"id": "ord-900", "orderNumber": "ORD-2019-900"},
+					},
+				}}, nil
+			}
+			return customersResponse(), nil
+		},
+	}
+	invReg := invoker.NewRegistry()
+	invReg.Register(inv)
+	return NewSearchProvider(definition.NewRegistry(defs), invReg, 3*time.Second, 50)
+}
+
+func searchOrders(t *testing.T, sp *SearchProvider) []model.SearchResult {
+	t.Helper()
+	caps := model.CapabilitySet{"orders:search:execute": true}
+	resp, err := sp.Search(context.Background(), testRctx(), caps, "ORD", model.Pagination{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if resp.Data.TotalCount != len(resp.Data.Results) {
+		t.Errorf("TotalCount = %d, want %d", resp.Data.TotalCount, len(resp.Data.Results))
+	}
+	return resp.Data.Results
+}
+
+func countByID(results []model.SearchResult) map[string]int {
+	counts := make(map[string]int, len(results))
+	for _, r := range results {
+		counts[r.ID]++
+	}
+	return counts
+}
+
+func TestSearchProvider_Search_deduplicatesAcrossProviders(t *testing.T) {
+	results := searchOrders(t, newOverlappingProvider("/orders/{id}"))
+
+	counts := countByID(results)
+	if len(results) != 3 || counts["ord-001"] != 1 || counts["ord-002"] != 1 || counts["ord-900"] != 1 {
+		t.Fatalf("results = %+v, want ord-001, ord-002 and ord-900 once each", results)
+	}
+	// The archive provider has the higher weight, so its instance is kept.
+	for _, r := range results {
+		if r.ID == "ord-001" && r.Score != 20 {
+			t.Errorf("ord-001 score = %f, want 20 (highest-scored instance)", r.Score)
+		}
+	}
+}
+
+func TestSearchProvider_Search_deduplicateIdentity(t *testing.T) {
+	// Different routes are different entities under the default identity.
+	results := searchOrders(t, newOverlappingProvider("/archive/orders/{id}"))
+	if got := countByID(results)["ord-001"]; got != 2 {
+		t.Errorf("ord-001 count = %d, want 2 with the route_id identity", got)
+	}
+
+	sp := newOverlappingProvider("/archive/orders/{id}")
+	sp.SetIdentity(IdentityID)
+	results = searchOrders(t, sp)
+	counts := countByID(results)
+	if len(results) != 3 || counts["ord-001"] != 1 {
+		t.Fatalf("results = %+v, want 3 distinct entities", results)
+	}
+	for _, r := range results {
+		if r.ID == "ord-001" && r.Route != "/archive/orders/ord-001" {
+			t.Errorf("ord-001 route = %q, want the higher-scored archive instance", r.Route)
+		}
+	}
+}
+
 func TestSearchProvider_Search_domainFilter(t *testing.T) {
 	inv := &mockSearchInvoker{
 		handler: func(b model.OperationBinding, _ model.InvocationInput) (model.InvocationResult, error) {
@@ -582,7 +771,7 @@ func TestDeduplicate(t *testing.T) {
 		{ID: "2", Route: "/test/2", Score: 3.0, Category: "a"},
 	}
 
-	deduped := deduplicate(results)
+	deduped := deduplicate(results, IdentityRouteAndID)
 	if len(deduped) != 2 {
 		t.Fatalf("deduped count = %d, want 2", len(deduped))
 	}
@@ -597,7 +786,7 @@ func TestDeduplicate(t *testing.T) {
 
 func TestDeduplicate_empty(t *testing.T) {
 	var results []model.SearchResult
-	deduped := deduplicate(results)
+	deduped := deduplicate(results, IdentityRouteAndID)
 	if deduped != nil {
 		t.Errorf("expected nil for empty input, got %v", deduped)
 	}
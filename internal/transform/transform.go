@@ -0,0 +1,227 @@
+// Package transform parses and applies the transform pipelines of source
+// expressions, such as "input.country | trim | upper". The command input
+// mapper applies them. The definition validator parses them, so malformed
+// pipelines are rejected when definitions load.
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Step is one transform of a pipeline, with its argument if it takes one.
+type Step struct {
+	Name string
+	Arg  string
+}
+
+// Blank reports whether val counts as missing: absent (nil) or the empty
+// string. A blank value falls back to the next "??" alternative and is
+// replaced by default(value); every other value, including 0 and false, is
+// kept.
+func Blank(val any) bool {
+	return val == nil || val == ""
+}
+
+// Split splits expr on the "|" characters outside quoted literals into its
+// source expression and transforms. Unknown transforms, wrong arguments and
+// default values that are not literals are rejected.
+func Split(expr string) (string, []Step, error) {
+	parts := SplitUnquoted(expr, "|")
+	steps := make([]Step, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		step, err := parse(strings.TrimSpace(part))
+		if err != nil {
+			return "", nil, fmt.Errorf("%w in %q", err, expr)
+		}
+		steps = append(steps, step)
+	}
+	return strings.TrimSpace(parts[0]), steps, nil
+}
+
+// SplitUnquoted splits s around each sep that is outside a single- or
+// double-quoted literal.
+func SplitUnquoted(s, sep string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Unquote returns the content of a single- or double-quoted literal.
+func Unquote(s string) (string, bool) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// parse parses "name" or "name(arg)" and checks that name is a known
+// transform taking the given argument.
+func parse(s string) (Step, error) {
+	name, arg, hasArg := strings.Cut(s, "(")
+	name = strings.TrimSpace(name)
+	if hasArg {
+		var ok bool
+		if arg, ok = strings.CutSuffix(strings.TrimSpace(arg), ")"); !ok {
+			return Step{}, fmt.Errorf("malformed transform %q", s)
+		}
+		arg = strings.TrimSpace(arg)
+	}
+
+	switch name {
+	case "trim", "lower", "upper", "toInt", "toFloat":
+		if hasArg {
+			return Step{}, fmt.Errorf("transform %q takes no argument", name)
+		}
+	case "default":
+		if arg == "" {
+			return Step{}, fmt.Errorf("transform %q requires an argument", name)
+		}
+		if _, ok := Unquote(arg); !ok && !IsNumericLiteral(arg) {
+			return Step{}, fmt.Errorf("default value %q must be a quoted string or a number", arg)
+		}
+	case "":
+		return Step{}, fmt.Errorf("empty transform")
+	default:
+		return Step{}, fmt.Errorf("unknown transform %q", name)
+	}
+	return Step{Name: name, Arg: arg}, nil
+}
+
+// Apply runs the transform on val. A nil val, left by a missing source,
+// passes through every transform except default, which replaces any blank
+// value.
+func (t Step) Apply(val any) (any, error) {
+	if t.Name == "default" {
+		if !Blank(val) {
+			return val, nil
+		}
+		if s, ok := Unquote(t.Arg); ok {
+			return s, nil
+		}
+		return ParseNumeric(t.Arg)
+	}
+	if val == nil {
+		return nil, nil
+	}
+
+	switch t.Name {
+	case "trim", "lower", "upper":
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s expects a string, got %T", t.Name, val)
+		}
+		switch t.Name {
+		case "trim":
+			return strings.TrimSpace(s), nil
+		case "lower":
+			return strings.ToLower(s), nil
+		}
+		return strings.ToUpper(s), nil
+	case "toInt":
+		return toInt(val)
+	default: // toFloat
+		return toFloat64(val)
+	}
+}
+
+// toInt converts a number or numeric string to int64. Fractional values
+// are rejected rather than truncated.
+func toInt(val any) (any, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("toInt: %v is not a whole number", v)
+		}
+		return int64(v), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("toInt: %q is not an integer", v)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("toInt: cannot convert %T", val)
+}
+
+// toFloat64 converts a number or numeric string to float64.
+func toFloat64(val any) (any, error) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("toFloat: %q is not a number", v)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("toFloat: cannot convert %T", val)
+}
+
+// IsNumericLiteral reports whether s looks like a number.
+func IsNumericLiteral(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	start := 0
+	if s[0] == '-' || s[0] == '+' {
+		start = 1
+		if start >= len(s) {
+			return false
+		}
+	}
+	hasDot := false
+	for i := start; i < len(s); i++ {
+		if s[i] == '.' {
+			if hasDot {
+				return false
+			}
+			hasDot = true
+		} else if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseNumeric parses a numeric literal as int64, or float64 if it has a
+// decimal point.
+func ParseNumeric(s string) (any, error) {
+	if strings.ContainsRune(s, '.') {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid numeric literal %q: %w", s, err)
+		}
+		return v, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid numeric literal %q: %w", s, err)
+	}
+	return v, nil
+}
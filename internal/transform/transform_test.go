@@ -0,0 +1,77 @@
+package transform
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	source, steps, err := Split("input.country ?? 'x|y' | trim | default('n/a')")
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	if source != "input.country ?? 'x|y'" {
+		t.Errorf("source = %q", source)
+	}
+	want := []Step{{Name: "trim"}, {Name: "default", Arg: "'n/a'"}}
+	if len(steps) != len(want) || steps[0] != want[0] || steps[1] != want[1] {
+		t.Errorf("steps = %+v, want %+v", steps, want)
+	}
+}
+
+func TestSplit_invalid(t *testing.T) {
+	for _, expr := range []string{
+		"input.name | reverse",
+		"input.name | trim(1)",
+		"input.name | default()",
+		"input.name | default(n/a)",
+		"input.name | default('n/a'",
+		"input.name |",
+	} {
+		if _, _, err := Split(expr); err == nil {
+			t.Errorf("Split(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestBlank(t *testing.T) {
+	for _, tt := range []struct {
+		val  any
+		want bool
+	}{
+		{nil, true},
+		{"", true},
+		{" ", false},
+		{0, false},
+		{false, false},
+	} {
+		if got := Blank(tt.val); got != tt.want {
+			t.Errorf("Blank(%#v) = %v, want %v", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestIsNumericLiteral(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"42", true},
+		{"-5", true},
+		{"+5", true},
+		{"99.99", true},
+		{"-0.5", true},
+		{"0", true},
+		{"", false},
+		{"abc", false},
+		{"12abc", false},
+		{"1.2.3", false},
+		{"-", false},
+		{"+", false},
+		{"'42'", false},
+		{"input.field", false},
+	}
+	for _, tt := range tests {
+		got := IsNumericLiteral(tt.input)
+		if got != tt.want {
+			t.Errorf("IsNumericLiteral(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/pitabwire/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+const meterName = "github.com/pitabwire/thesa/internal/transport"
+
+// DenialRecorder observes capability denials per route pattern.
+type DenialRecorder interface {
+	RecordDenial(ctx context.Context, route string)
+}
+
+// metricsDenialRecorder counts denials with an OpenTelemetry counter.
+type metricsDenialRecorder struct {
+	denials metric.Int64Counter
+}
+
+// NewMetricsDenialRecorder returns a DenialRecorder backed by the
+// thesa.capability.denials counter, labeled by route pattern. If meter is
+// nil, the global meter provider is used.
+func NewMetricsDenialRecorder(meter metric.Meter) (DenialRecorder, error) {
+	if meter == nil {
+		meter = otel.Meter(meterName)
+	}
+	denials, err := meter.Int64Counter("thesa.capability.denials",
+		metric.WithDescription("Requests rejected for insufficient capabilities."))
+	if err != nil {
+		return nil, fmt.Errorf("transport: denial metrics: %w", err)
+	}
+	return &metricsDenialRecorder{denials: denials}, nil
+}
+
+func (r *metricsDenialRecorder) RecordDenial(ctx context.Context, route string) {
+	r.denials.Add(ctx, 1, metric.WithAttributes(attribute.String("route", route)))
+}
+
+// AuditDenials is middleware that logs every FORBIDDEN error written for the
+// request as a structured "capability denied" warning, separate from the
+// request log, and reports it to recorder. It must run after the request
+// context is built. A nil recorder only logs.
+func AuditDenials(recorder DenialRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&denialWriter{ResponseWriter: w, r: r, recorder: recorder}, r)
+		})
+	}
+}
+
+// denialWriter carries the request whose capability denials are audited.
+type denialWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	recorder DenialRecorder
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *denialWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *denialWriter) record(ee *model.ErrorEnvelope) {
+	ctx := w.r.Context()
+	var subject, tenant string
+	if rctx := model.RequestContextFrom(ctx); rctx != nil {
+		subject, tenant = rctx.SubjectID, rctx.TenantID
+	}
+	util.Log(ctx).Warn("capability denied",
+		"subject_id", subject,
+		"tenant_id", tenant,
+		"method", w.r.Method,
+		"resource", w.r.URL.Path,
+		"route", w.r.Pattern,
+		"required_capabilities", ee.RequiredCapabilities,
+		"correlation_id", CorrelationIDFrom(ctx),
+	)
+	if w.recorder != nil {
+		w.recorder.RecordDenial(ctx, w.r.Pattern)
+	}
+}
+
+// auditDenial records ee against the audited request w belongs to, if any.
+func auditDenial(w http.ResponseWriter, ee *model.ErrorEnvelope) {
+	if ee.Code != model.ErrForbidden {
+		return
+	}
+	if dw, ok := findWriter[*denialWriter](w); ok {
+		dw.record(ee)
+	}
+}
+
+// findWriter returns the first writer of type T in the chain of writers
+// wrapped by w.
+func findWriter[T http.ResponseWriter](w http.ResponseWriter) (T, bool) {
+	for w != nil {
+		if t, ok := w.(T); ok {
+			return t, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
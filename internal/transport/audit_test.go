@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pitabwire/util"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/pitabwire/thesa/model"
+)
+
+// auditedRequest serves path through AuditDenials and handler with a logger
+// writing JSON records to the returned buffer.
+func auditedRequest(t *testing.T, recorder DenialRecorder, handler http.HandlerFunc) (*httptest.ResponseRecorder, *bytes.Buffer) {
+	t.Helper()
+	var logs bytes.Buffer
+	ctx := context.Background()
+	logger := util.NewLogger(ctx, util.WithLogHandler(slog.NewJSONHandler(&logs, nil)), util.WithLogHandlerExclusive())
+	ctx = util.ContextWithLogger(ctx, logger)
+	ctx = model.WithRequestContext(ctx, &model.RequestContext{SubjectID: "user-1", TenantID: "acme"})
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /ui/commands/{commandId}", AuditDenials(recorder)(handler))
+	req := httptest.NewRequest(http.MethodPost, "/ui/commands/orders.cancel", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w, &logs
+}
+
+func TestAuditDenials_logsAndCountsDenial(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	recorder, err := NewMetricsDenialRecorder(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+	if err != nil {
+		t.Fatalf("NewMetricsDenialRecorder() error = %v", err)
+	}
+
+	w, logs := auditedRequest(t, recorder, func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, model.NewInsufficientCapabilitiesError("insufficient capabilities", []string{"orders:cancel"}))
+	})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", w.Code)
+	}
+
+	var entry struct {
+		Level    string   `json:"level"`
+		Msg      string   `json:"msg"`
+		Subject  string   `json:"subject_id"`
+		Tenant   string   `json:"tenant_id"`
+		Resource string   `json:"resource"`
+		Route    string   `json:"route"`
+		Required []string `json:"required_capabilities"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("decoding log %q: %v", logs.String(), err)
+	}
+	if entry.Level != "WARN" || entry.Msg != "capability denied" {
+		t.Errorf("log = %s %q, want WARN \"capability denied\"", entry.Level, entry.Msg)
+	}
+	if entry.Subject != "user-1" || entry.Tenant != "acme" {
+		t.Errorf("subject, tenant = %q, %q, want user-1, acme", entry.Subject, entry.Tenant)
+	}
+	if entry.Resource != "/ui/commands/orders.cancel" || entry.Route != "POST /ui/commands/{commandId}" {
+		t.Errorf("resource, route = %q, %q", entry.Resource, entry.Route)
+	}
+	if len(entry.Required) != 1 || entry.Required[0] != "orders:cancel" {
+		t.Errorf("required_capabilities = %v, want [orders:cancel]", entry.Required)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "thesa.capability.denials" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				if route, _ := dp.Attributes.Value("route"); route.AsString() != "POST /ui/commands/{commandId}" {
+					t.Errorf("route attribute = %q", route.AsString())
+				}
+				total += dp.Value
+			}
+		}
+	}
+	if total != 1 {
+		t.Errorf("thesa.capability.denials = %d, want 1", total)
+	}
+}
+
+type countingDenialRecorder struct{ n int }
+
+func (r *countingDenialRecorder) RecordDenial(context.Context, string) { r.n++ }
+
+func TestAuditDenials_ignoresOtherErrors(t *testing.T) {
+	recorder := &countingDenialRecorder{}
+	_, logs := auditedRequest(t, recorder, func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, model.NewNotFoundError("command not found"))
+	})
+	if recorder.n != 0 || logs.Len() != 0 {
+		t.Errorf("denials = %d, logs = %q, want none", recorder.n, logs.String())
+	}
+}
+
+func TestAuditDenials_redactedResponseStillAudited(t *testing.T) {
+	recorder := &countingDenialRecorder{}
+	w, logs := auditedRequest(t, recorder, func(w http.ResponseWriter, r *http.Request) {
+		CapabilityDetails(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			WriteError(w, model.NewInsufficientCapabilitiesError("denied", []string{"orders:cancel"}))
+		})).ServeHTTP(w, r)
+	})
+	if recorder.n != 1 {
+		t.Errorf("denials = %d, want 1", recorder.n)
+	}
+	if !bytes.Contains(logs.Bytes(), []byte("orders:cancel")) {
+		t.Errorf("log %q does not name the required capability", logs.String())
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("orders:cancel")) {
+		t.Errorf("response %q leaks the required capability", w.Body.String())
+	}
+}
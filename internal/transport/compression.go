@@ -0,0 +1,227 @@
+package transport
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/pitabwire/thesa/internal/config"
+)
+
+// Compression returns middleware that compresses responses with brotli or
+// gzip, negotiated from Accept-Encoding. Brotli is used only when enabled and
+// the client ranks it at least as high as gzip. Responses smaller than
+// MinSize, event streams, already-encoded and non-compressible content types
+// are passed through unchanged.
+func Compression(cfg config.CompressionConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), cfg.Brotli)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        cfg.MinSize,
+				status:         http.StatusOK,
+			}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks "br", "gzip" or "" (identity) from an
+// Accept-Encoding header. Ties between br and gzip favor br.
+func negotiateEncoding(header string, allowBrotli bool) string {
+	if header == "" {
+		return ""
+	}
+	var brQ, gzipQ float64 = -1, -1
+	wildcardQ := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "br":
+			brQ = q
+		case "gzip":
+			gzipQ = q
+		case "*":
+			wildcardQ = q
+		}
+	}
+	if brQ < 0 {
+		brQ = wildcardQ
+	}
+	if gzipQ < 0 {
+		gzipQ = wildcardQ
+	}
+
+	if allowBrotli && brQ > 0 && brQ >= gzipQ {
+		return "br"
+	}
+	if gzipQ > 0 {
+		return "gzip"
+	}
+	return ""
+}
+
+// isCompressible reports whether a content type benefits from compression.
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		mediaType == "application/javascript",
+		mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// compressWriter buffers the start of a response until it is large enough to
+// be worth compressing, then either streams it through an encoder or writes
+// it unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	status   int
+
+	buf         []byte
+	decided     bool
+	wroteHeader bool
+	enc         io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.writeHeader(code)
+		return
+	}
+	w.status = code
+	if code == http.StatusNoContent || code == http.StatusNotModified || code < http.StatusOK {
+		w.passthrough()
+	} else if ct := w.Header().Get("Content-Type"); ct == "text/event-stream" || strings.HasPrefix(ct, "text/event-stream;") {
+		w.passthrough()
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(b))
+	}
+	if h.Get("Content-Encoding") != "" || !isCompressible(h.Get("Content-Type")) {
+		w.passthrough()
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends any buffered data. An undecided response is flushed
+// uncompressed, since the handler wants the bytes delivered now.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.passthrough()
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes any response still buffered and finishes the encoder.
+func (w *compressWriter) Close() {
+	if !w.decided {
+		w.passthrough()
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) startEncoding() error {
+	w.decided = true
+	h := w.Header()
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	w.writeHeader(w.status)
+
+	if w.encoding == "br" {
+		w.enc = brotli.NewWriterLevel(w.ResponseWriter, brotli.DefaultCompression)
+	} else {
+		w.enc = gzip.NewWriter(w.ResponseWriter)
+	}
+	buf := w.buf
+	w.buf = nil
+	_, err := w.enc.Write(buf)
+	return err
+}
+
+func (w *compressWriter) passthrough() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.writeHeader(w.status)
+	if len(w.buf) > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *compressWriter) writeHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/pitabwire/thesa/internal/config"
+)
+
+var largeJSON = `{"items":[` + strings.Repeat(`{"id":"ord-1","status":"pending"},`, 100) + `{}]}`
+
+func testCompressionConfig() config.CompressionConfig {
+	return config.CompressionConfig{Enabled: true, Brotli: true, MinSize: 1024}
+}
+
+func serveCompressed(cfg config.CompressionConfig, acceptEncoding string, h http.HandlerFunc) *httptest.ResponseRecorder {
+	handler := Compression(cfg)(h)
+	req := httptest.NewRequest("GET", "/ui/pages/orders.list", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func jsonHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = w.Body
+	switch w.Header().Get("Content-Encoding") {
+	case "br":
+		r = brotli.NewReader(w.Body)
+	case "gzip":
+		gz, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip.NewReader: %v", err)
+		}
+		r = gz
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return string(b)
+}
+
+func TestCompression_negotiation(t *testing.T) {
+	tests := []struct {
+		name           string
+		brotli         bool
+		acceptEncoding string
+		want           string
+	}{
+		{"brotli preferred", true, "br;q=1.0, gzip;q=0.8", "br"},
+		{"brotli and gzip tie", true, "gzip, deflate, br", "br"},
+		{"gzip preferred", true, "gzip;q=1.0, br;q=0.5", "gzip"},
+		{"gzip only", true, "gzip", "gzip"},
+		{"brotli disabled", false, "br, gzip", "gzip"},
+		{"brotli refused", true, "br;q=0, gzip", "gzip"},
+		{"wildcard", true, "*", "br"},
+		{"identity", true, "identity", ""},
+		{"no header", true, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testCompressionConfig()
+			cfg.Brotli = tt.brotli
+			w := serveCompressed(cfg, tt.acceptEncoding, jsonHandler(largeJSON))
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.want {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.want)
+			}
+			if got := decodeBody(t, w); got != largeJSON {
+				t.Errorf("decoded body length = %d, want %d", len(got), len(largeJSON))
+			}
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCompression_skipsSmallResponses(t *testing.T) {
+	w := serveCompressed(testCompressionConfig(), "br, gzip", jsonHandler(`{"ok":true}`))
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none for small body", got)
+	}
+	if w.Body.String() != `{"ok":true}` {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
+
+func TestCompression_skipsEventStream(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "data: "+largeJSON+"\n\n")
+		w.(http.Flusher).Flush()
+	}
+	w := serveCompressed(testCompressionConfig(), "br, gzip", handler)
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none for SSE", got)
+	}
+	if !strings.HasPrefix(w.Body.String(), "data: ") {
+		t.Errorf("body not passed through: %q", w.Body.String()[:20])
+	}
+}
+
+func TestCompression_preservesStatus(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, largeJSON)
+	}
+	w := serveCompressed(testCompressionConfig(), "gzip", handler)
+	if w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", w.Code)
+	}
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompression_disabled(t *testing.T) {
+	w := serveCompressed(config.CompressionConfig{}, "br, gzip", jsonHandler(largeJSON))
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none when disabled", got)
+	}
+}
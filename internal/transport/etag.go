@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// ETag returns middleware that tags successful GET responses with a weak
+// entity tag derived from the response body, and answers a matching
+// If-None-Match with 304 Not Modified. The tag is weak because compression
+// may change the bytes on the wire without changing the representation.
+//
+// Tags are only useful if equal content serializes to equal bytes. WriteJSON
+// guarantees this for descriptors: encoding/json writes map keys in sorted
+// order, and descriptors are built from registry lists with a fixed order.
+//
+// The global Cache-Control: no-store would keep clients from storing the
+// tagged response at all, so it is relaxed to no-cache, private: clients may
+// keep the response but must revalidate it. A Cache-Control configured for
+// the route group is left as is.
+func ETag(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if w.Header().Get("Cache-Control") == "no-store" {
+				w.Header().Set("Cache-Control", "no-cache, private")
+			}
+			ew := &etagWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(ew, r)
+			ew.finish(r.Header.Get("If-None-Match"))
+		})
+	}
+}
+
+// etagWriter buffers a 200 response so its tag can be computed before the
+// headers are sent. Other statuses are written through unchanged.
+type etagWriter struct {
+	http.ResponseWriter
+	status      int
+	passthrough bool
+	wroteHeader bool
+	buf         []byte
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+	if code != http.StatusOK {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	return len(b), nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the buffered response, or 304 if ifNoneMatch matches its tag.
+func (w *etagWriter) finish(ifNoneMatch string) {
+	if w.passthrough {
+		return
+	}
+	tag := bodyETag(w.buf)
+	h := w.Header()
+	h.Set("ETag", tag)
+	if etagMatches(ifNoneMatch, tag) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.ResponseWriter.WriteHeader(http.StatusOK)
+	_, _ = w.ResponseWriter.Write(w.buf)
+}
+
+// bodyETag returns the weak entity tag for a response body.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches applies the weak comparison of RFC 9110 section 13.1.2 to an
+// If-None-Match header value.
+func etagMatches(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pitabwire/thesa/internal/metadata"
+	"github.com/pitabwire/thesa/model"
+)
+
+func serveETag(h http.Handler, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/ui/navigation", nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestETag_conditionalGet(t *testing.T) {
+	h := ETag(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]any{"b": 2, "a": 1})
+	}))
+
+	first := serveETag(h, "")
+	tag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || tag == "" || first.Body.Len() == 0 {
+		t.Fatalf("first response: status %d, ETag %q, body %q", first.Code, tag, first.Body.String())
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "matching tag", ifNoneMatch: tag, wantStatus: http.StatusNotModified},
+		{name: "strong form of tag", ifNoneMatch: tag[2:], wantStatus: http.StatusNotModified},
+		{name: "tag in list", ifNoneMatch: `"other", ` + tag, wantStatus: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "stale tag", ifNoneMatch: `W/"stale"`, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveETag(h, tt.ifNoneMatch)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("ETag"); got != tag {
+				t.Errorf("ETag = %q, want %q", got, tag)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 body = %q, want empty", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestETag_errorsNotTagged(t *testing.T) {
+	h := ETag(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteNotFound(w, "page not found")
+	}))
+	w := serveETag(h, "*")
+	if w.Code != http.StatusNotFound || w.Header().Get("ETag") != "" {
+		t.Errorf("status = %d, ETag = %q, want 404 without ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestETag_stableAcrossRegistries(t *testing.T) {
+	// Domains share a navigation order, so their order in the descriptor
+	// depends only on how the registry lists them.
+	var defs []model.DomainDefinition
+	for i := range 8 {
+		defs = append(defs, model.DomainDefinition{
+			Domain:     fmt.Sprintf("domain-%d", i),
+			Navigation: model.NavigationDefinition{Label: fmt.Sprintf("Domain %d", i), Order: 1},
+		})
+	}
+	rctx := &model.RequestContext{SubjectID: "user-1", TenantID: "acme"}
+
+	var want string
+	for range 20 {
+		menu := metadata.NewMenuProvider(newRegistry(defs...), nil)
+		h := ETag(true)(contextMiddleware(rctx, testCaps())(handleNavigation(menu)))
+		tag := serveETag(h, "").Header().Get("ETag")
+		if want == "" {
+			want = tag
+		}
+		if tag != want {
+			t.Fatalf("ETag = %q, want %q: navigation serialized differently across registries", tag, want)
+		}
+	}
+}
+
+func TestETag_relaxesNoStore(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]any{"a": 1})
+	})
+
+	w := serveETag(SecurityHeaders(ETag(true)(ok)), "")
+	if got := w.Header().Get("Cache-Control"); got != "no-cache, private" {
+		t.Errorf("Cache-Control = %q, want no-cache, private", got)
+	}
+
+	configured := ResponseHeaders(map[string]string{"Cache-Control": "private, max-age=60"})
+	w = serveETag(SecurityHeaders(configured(ETag(true)(ok))), "")
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=60" {
+		t.Errorf("Cache-Control = %q, want the configured value kept", got)
+	}
+
+	w = serveETag(SecurityHeaders(ETag(false)(ok)), "")
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store without ETags", got)
+	}
+}
+
+func TestETag_capabilitiesStable(t *testing.T) {
+	caps := model.CapabilitySet{}
+	for i := range 32 {
+		caps[fmt.Sprintf("cap:%02d", i)] = true
+	}
+	resolver := &mockResolver{caps: caps}
+	h := ETag(true)(contextMiddleware(testRequestContext(), caps)(handleCapabilities(resolver, "1.0")))
+
+	want := serveETag(h, "").Header().Get("ETag")
+	for range 20 {
+		if tag := serveETag(h, "").Header().Get("ETag"); tag != want {
+			t.Fatalf("ETag = %q, want %q: permissions serialized in map order", tag, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+)
+
+type explainKey struct{}
+
+// Explain returns middleware that enables the explain debug block on
+// descriptor responses when the request asks for it with ?explain=true and
+// the caller holds capability. Other callers get the plain descriptor, so
+// the request succeeds either way. With no capability configured, explain
+// is disabled. It must run after capabilities are resolved.
+func Explain(capability string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if capability == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("explain") == "true" && CapabilitiesFrom(r.Context()).Has(capability) {
+				r = r.WithContext(context.WithValue(r.Context(), explainKey{}, true))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// explainRequested reports whether the explain block should be attached.
+func explainRequested(ctx context.Context) bool {
+	v, _ := ctx.Value(explainKey{}).(bool)
+	return v
+}
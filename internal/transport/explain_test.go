@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pitabwire/thesa/internal/metadata"
+	"github.com/pitabwire/thesa/model"
+)
+
+func TestExplain_page(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{{
+			ID: "orders.list", Title: "Orders", Layout: "list",
+			Table: &model.TableDefinition{
+				RowActions: []model.ActionDefinition{
+					{ID: "view", Label: "View", Type: "navigate", NavigateTo: "/orders/{id}"},
+					{ID: "refund", Label: "Refund", Type: "command", CommandID: "orders.refund",
+						Capabilities: []string{"payments:refund"}},
+				},
+			},
+		}},
+	})
+	pages := metadata.NewPageProvider(reg, nil, metadata.NewActionProvider())
+	handler := Explain("thesa:explain")(handleGetPage(pages))
+
+	tests := []struct {
+		name        string
+		path        string
+		caps        model.CapabilitySet
+		wantExplain bool
+	}{
+		{name: "with capability", path: "/ui/pages/orders.list?explain=true", caps: model.CapabilitySet{"orders:*": true, "thesa:explain": true}, wantExplain: true},
+		{name: "without capability", path: "/ui/pages/orders.list?explain=true", caps: testCaps()},
+		{name: "not requested", path: "/ui/pages/orders.list", caps: model.CapabilitySet{"orders:*": true, "thesa:explain": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makeRouterRequest("GET", "/ui/pages/{pageId}", tt.path, nil, handler.ServeHTTP, testRequestContext(), tt.caps)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			var desc model.PageDescriptor
+			if err := json.NewDecoder(w.Body).Decode(&desc); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !tt.wantExplain {
+				if desc.Explain != nil {
+					t.Errorf("explain = %+v, want none", desc.Explain)
+				}
+				return
+			}
+			if desc.Explain == nil || len(desc.Explain.Filtered) != 1 {
+				t.Fatalf("explain = %+v, want one filtered element", desc.Explain)
+			}
+			got := desc.Explain.Filtered[0]
+			if got.Kind != "row_action" || got.ID != "refund" || len(got.Missing) != 1 || got.Missing[0] != "payments:refund" {
+				t.Errorf("filtered = %+v, want row_action refund missing payments:refund", got)
+			}
+		})
+	}
+}
+
+func TestExplain_disabledWithoutCapability(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain:     "orders",
+		Navigation: model.NavigationDefinition{Label: "Orders", Capabilities: []string{"orders:nav"}},
+	})
+	handler := Explain("")(handleNavigation(metadata.NewMenuProvider(reg, nil)))
+
+	w := makeRouterRequest("GET", "/ui/navigation", "/ui/navigation?explain=true", nil, handler.ServeHTTP, testRequestContext(), model.CapabilitySet{"*": true})
+	var tree model.NavigationTree
+	if err := json.NewDecoder(w.Body).Decode(&tree); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if tree.Explain != nil {
+		t.Errorf("explain = %+v, want none when no explain capability is configured", tree.Explain)
+	}
+}
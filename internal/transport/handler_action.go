@@ -53,7 +53,7 @@ func handleAction(
 				WriteError(w, err)
 				return
 			}
-			WriteJSON(w, http.StatusOK, resp)
+			writeCommandResponse(w, resp)
 
 		default:
 			// For navigate, form, and custom actions, return the action metadata
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/pitabwire/thesa/internal/metadata"
+	"github.com/pitabwire/thesa/model"
+)
+
+// bootstrapResponse bundles what the frontend needs on initial load, so it
+// can render the shell and landing page in a single round-trip.
+type bootstrapResponse struct {
+	Navigation   model.NavigationTree  `json:"navigation"`
+	Page         *model.PageDescriptor `json:"page,omitempty"`
+	Capabilities capabilitiesResponse  `json:"capabilities"`
+}
+
+func handleBootstrap(menu *metadata.MenuProvider, pages *metadata.PageProvider, appVersion string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rctx := model.RequestContextFrom(r.Context())
+		if rctx == nil {
+			WriteError(w, model.NewUnauthorizedError("missing request context"))
+			return
+		}
+		caps := CapabilitiesFrom(r.Context())
+
+		tree, err := menu.GetMenu(r.Context(), rctx, caps)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		page, err := landingPage(r.Context(), pages, rctx, caps, tree.Items)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, bootstrapResponse{
+			Navigation:   tree,
+			Page:         page,
+			Capabilities: newCapabilitiesResponse(caps, rctx.TenantID, appVersion),
+		})
+	}
+}
+
+// landingPage returns the descriptor of the first page in navigation order
+// the caller can view, or nil if there is none. Pages that are missing or
+// forbidden are skipped.
+func landingPage(
+	ctx context.Context,
+	pages *metadata.PageProvider,
+	rctx *model.RequestContext,
+	caps model.CapabilitySet,
+	nodes []model.NavigationNode,
+) (*model.PageDescriptor, error) {
+	for _, node := range nodes {
+		if node.PageID != "" {
+			desc, err := pages.GetPage(ctx, rctx, caps, node.PageID)
+			if err == nil {
+				return &desc, nil
+			}
+			var env *model.ErrorEnvelope
+			if !errors.As(err, &env) || (env.Code != model.ErrForbidden && env.Code != model.ErrNotFound) {
+				return nil, err
+			}
+		}
+		desc, err := landingPage(ctx, pages, rctx, caps, node.Children)
+		if desc != nil || err != nil {
+			return desc, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/pitabwire/thesa/internal/search"
+	"github.com/pitabwire/thesa/model"
+)
+
+// warmLookupsRequest optionally names the lookups to warm; when empty the
+// configured warm list is used.
+type warmLookupsRequest struct {
+	Lookups []string `json:"lookups"`
+}
+
+type warmLookupsResponse struct {
+	Results []model.LookupWarmResult `json:"results"`
+}
+
+// handleWarmLookups re-populates the lookup cache on demand. It requires the
+// configured cache admin capability; with none configured it always refuses.
+func handleWarmLookups(provider *search.LookupProvider, required string, defaults []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if required == "" {
+			WriteForbidden(w, "cache management is disabled")
+			return
+		}
+		if !CapabilitiesFrom(r.Context()).Has(required) {
+			WriteError(w, model.NewInsufficientCapabilitiesError(
+				"insufficient capabilities for cache management", []string{required},
+			))
+			return
+		}
+
+		var req warmLookupsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			WriteError(w, model.NewBadRequestError("invalid JSON body"))
+			return
+		}
+		lookups := req.Lookups
+		if len(lookups) == 0 {
+			lookups = defaults
+		}
+
+		WriteJSON(w, http.StatusOK, warmLookupsResponse{Results: provider.Warm(r.Context(), lookups)})
+	}
+}
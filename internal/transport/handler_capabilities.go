@@ -2,6 +2,7 @@ package transport
 
 import (
 	"net/http"
+	"slices"
 
 	"github.com/pitabwire/thesa/model"
 )
@@ -48,32 +49,40 @@ func handleCapabilities(capResolver model.CapabilityResolver, appVersion string)
 			return
 		}
 
-		// Build the capabilities map.
-		capMap := make(map[string]capabilityValue, len(caps))
-		permissions := make([]string, 0, len(caps))
-		for k, v := range caps {
-			capMap[k] = capabilityValue{Enabled: v}
-			if v {
-				permissions = append(permissions, k)
-			}
-		}
+		WriteJSON(w, http.StatusOK, newCapabilitiesResponse(caps, rctx.TenantID, appVersion))
+	}
+}
 
-		resp := capabilitiesResponse{
-			Capabilities: capMap,
-			User: &userCapabilities{
-				Roles:       []string{},
-				Permissions: permissions,
-				Features:    []string{},
-			},
-			Tenant: &tenantCapabilities{
-				TenantID: rctx.TenantID,
-				Features: []string{},
-			},
-			App: &appCapabilities{
-				Version: appVersion,
-			},
+// newCapabilitiesResponse builds the capability summary for the given
+// capability set.
+func newCapabilitiesResponse(caps model.CapabilitySet, tenantID, appVersion string) capabilitiesResponse {
+	// Build the capabilities map.
+	capMap := make(map[string]capabilityValue, len(caps))
+	permissions := make([]string, 0, len(caps))
+	for k, v := range caps {
+		capMap[k] = capabilityValue{Enabled: v}
+		if v {
+			permissions = append(permissions, k)
 		}
+	}
+
+	// Sort so equal capability sets serialize to equal bytes and ETags.
+	slices.Sort(permissions)
 
-		WriteJSON(w, http.StatusOK, resp)
+	resp := capabilitiesResponse{
+		Capabilities: capMap,
+		User: &userCapabilities{
+			Roles:       []string{},
+			Permissions: permissions,
+			Features:    []string{},
+		},
+		Tenant: &tenantCapabilities{
+			TenantID: tenantID,
+			Features: []string{},
+		},
+		App: &appCapabilities{
+			Version: appVersion,
+		},
 	}
+	return resp
 }
@@ -2,7 +2,11 @@ package transport
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/pitabwire/thesa/internal/command"
 	"github.com/pitabwire/thesa/model"
@@ -18,17 +22,205 @@ func handleCommand(executor *command.CommandExecutor) http.HandlerFunc {
 		caps := CapabilitiesFrom(r.Context())
 		commandID := r.PathValue("commandId")
 
+		// Multipart bodies carry the command input as JSON in an "input"
+		// part alongside the files to forward.
+		if ct := r.Header.Get("Content-Type"); isMultipartContentType(ct) {
+			input, err := multipartCommandInput(r)
+			if r.MultipartForm != nil {
+				// The files are read while the command runs.
+				defer func() { _ = r.MultipartForm.RemoveAll() }()
+			}
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+			input = withIdempotencyKey(r, input)
+			resp, err := executor.Execute(r.Context(), rctx, caps, commandID, input)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+			writeCommandResponse(w, resp)
+			return
+		}
+
+		// Other non-JSON bodies are streamed to upload commands. Route params are
+		// passed as "route.<name>" query parameters and remaining query
+		// parameters become the command input. Bodies of other commands are
+		// decoded as JSON whatever their content type.
+		if ct := r.Header.Get("Content-Type"); ct != "" && !isJSONContentType(ct) && executor.AcceptsUpload(commandID) {
+			input := withIdempotencyKey(r, uploadInputFromQuery(r))
+			resp, err := executor.ExecuteUpload(r.Context(), rctx, caps, commandID, input, r.Body, ct)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+			writeCommandResponse(w, resp)
+			return
+		}
+
 		var input model.CommandInput
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 			WriteError(w, model.NewBadRequestError("invalid JSON body"))
 			return
 		}
+		input = withIdempotencyKey(r, input)
 
 		resp, err := executor.Execute(r.Context(), rctx, caps, commandID, input)
 		if err != nil {
 			WriteError(w, err)
 			return
 		}
+		writeCommandResponse(w, resp)
+	}
+}
+
+// withIdempotencyKey takes the input's idempotency key from the
+// Idempotency-Key header when the body does not carry one.
+func withIdempotencyKey(r *http.Request, input model.CommandInput) model.CommandInput {
+	if input.IdempotencyKey == "" {
+		input.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+	return input
+}
+
+// writeCommandResponse writes a successful command response. A streamed
+// response is copied to the client as is. A queued async command is sent
+// as 202 with a Location header pointing at its job, and a response
+// locating a created resource as 201 with a Location header.
+func writeCommandResponse(w http.ResponseWriter, resp model.CommandResponse) {
+	if resp.Stream != nil {
+		writeStream(w, resp)
+		return
+	}
+	if resp.JobID != "" {
+		w.Header().Set("Location", "/ui/jobs/"+resp.JobID)
+		WriteJSON(w, http.StatusAccepted, resp)
+		return
+	}
+	if resp.Location == "" {
+		WriteJSON(w, http.StatusOK, resp)
+		return
+	}
+	w.Header().Set("Location", resp.Location)
+	WriteJSON(w, http.StatusCreated, resp)
+}
+
+// writeStream copies a streamed command response to the client without
+// buffering it, with the backend's content headers.
+func writeStream(w http.ResponseWriter, resp model.CommandResponse) {
+	defer func() { _ = resp.Stream.Close() }()
+	for _, key := range []string{"Content-Type", "Content-Disposition", "Content-Length"} {
+		if v := resp.StreamHeaders[key]; v != "" {
+			w.Header().Set(key, v)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, resp.Stream)
+}
+
+// handleConfirmCommand mints a confirmation token for a command that requires
+// one. The request body is the command input the token will be bound to.
+func handleConfirmCommand(executor *command.CommandExecutor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rctx := model.RequestContextFrom(r.Context())
+		if rctx == nil {
+			WriteError(w, model.NewUnauthorizedError("missing request context"))
+			return
+		}
+		caps := CapabilitiesFrom(r.Context())
+
+		var input model.CommandInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			WriteError(w, model.NewBadRequestError("invalid JSON body"))
+			return
+		}
+
+		resp, err := executor.Confirm(rctx, caps, r.PathValue("commandId"), input)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
 		WriteJSON(w, http.StatusOK, resp)
 	}
 }
+
+// isJSONContentType reports whether the media type is JSON.
+func isJSONContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// maxMultipartMemory is how much of a multipart command request is held in
+// memory while parsing; larger files are buffered on disk.
+const maxMultipartMemory = 32 << 20
+
+// isMultipartContentType reports whether the media type is
+// multipart/form-data.
+func isMultipartContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// multipartCommandInput builds the command input for a multipart request.
+// The optional "input" field holds the JSON command envelope; each file
+// part becomes a file under its field name, opened from the parsed form
+// when it is forwarded. The caller removes the form's files afterwards.
+func multipartCommandInput(r *http.Request) (model.CommandInput, error) {
+	var input model.CommandInput
+	if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+		return input, model.NewBadRequestError("invalid multipart body")
+	}
+
+	if values := r.MultipartForm.Value["input"]; len(values) > 0 {
+		if err := json.Unmarshal([]byte(values[0]), &input); err != nil {
+			return input, model.NewBadRequestError("invalid JSON in multipart input field")
+		}
+	}
+
+	input.Files = make(map[string]model.FilePart, len(r.MultipartForm.File))
+	for field, headers := range r.MultipartForm.File {
+		if len(headers) != 1 {
+			return input, model.NewBadRequestError(fmt.Sprintf("multipart field %q must hold exactly one file", field))
+		}
+		fh := headers[0]
+		input.Files[field] = model.FilePart{
+			Filename:    fh.Filename,
+			ContentType: fh.Header.Get("Content-Type"),
+			Open: func() (io.ReadCloser, error) {
+				return fh.Open()
+			},
+		}
+	}
+	if len(input.Files) == 0 {
+		return input, model.NewBadRequestError("multipart body has no files")
+	}
+	return input, nil
+}
+
+// uploadInputFromQuery builds the command input for an upload request from
+// its query string.
+func uploadInputFromQuery(r *http.Request) model.CommandInput {
+	input := model.CommandInput{
+		Input:       make(map[string]any),
+		RouteParams: make(map[string]string),
+	}
+	for key, values := range r.URL.Query() {
+		if len(values) == 0 {
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, "route."); ok {
+			input.RouteParams[name] = values[0]
+			continue
+		}
+		if key == "confirmation_token" {
+			input.ConfirmationToken = values[0]
+			continue
+		}
+		input.Input[key] = values[0]
+	}
+	return input
+}
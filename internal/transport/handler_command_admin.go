@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pitabwire/thesa/internal/definition"
+	"github.com/pitabwire/thesa/model"
+)
+
+type disabledCommandsResponse struct {
+	Disabled []string `json:"disabled"`
+}
+
+// handleListDisabledCommands lists the commands disabled at runtime.
+func handleListDisabledCommands(registry *definition.Registry, required string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeCommandAdmin(w, r, required) {
+			return
+		}
+		WriteJSON(w, http.StatusOK, disabledCommandsResponse{Disabled: registry.DisabledCommands()})
+	}
+}
+
+// handleSetCommandDisabled disables (PUT) or re-enables (DELETE) a command
+// and responds with the resulting disabled list.
+func handleSetCommandDisabled(registry *definition.Registry, required string, disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeCommandAdmin(w, r, required) {
+			return
+		}
+		commandID := r.PathValue("commandId")
+		if _, ok := registry.GetCommand(commandID); !ok {
+			WriteError(w, model.NewNotFoundError(fmt.Sprintf("command %q not found", commandID)))
+			return
+		}
+		registry.SetCommandDisabled(commandID, disabled)
+		WriteJSON(w, http.StatusOK, disabledCommandsResponse{Disabled: registry.DisabledCommands()})
+	}
+}
+
+// authorizeCommandAdmin writes an error and returns false unless the caller
+// holds the command admin capability. With none configured it always
+// refuses.
+func authorizeCommandAdmin(w http.ResponseWriter, r *http.Request, required string) bool {
+	if required == "" {
+		WriteForbidden(w, "command management is disabled")
+		return false
+	}
+	if !CapabilitiesFrom(r.Context()).Has(required) {
+		WriteError(w, model.NewInsufficientCapabilitiesError(
+			"insufficient capabilities for command management", []string{required},
+		))
+		return false
+	}
+	return true
+}
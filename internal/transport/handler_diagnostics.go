@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/pitabwire/thesa/internal/definition"
+	"github.com/pitabwire/thesa/model"
+)
+
+// CacheStatsProvider is implemented by components with an in-memory cache
+// whose effectiveness is reported on the diagnostics endpoint.
+type CacheStatsProvider interface {
+	CacheStats() model.CacheStats
+}
+
+// diagnosticsResponse is the runtime snapshot returned to operators.
+type diagnosticsResponse struct {
+	Version     string                      `json:"version,omitempty"`
+	Definitions definitionsDiagnostics      `json:"definitions"`
+	Caches      map[string]model.CacheStats `json:"caches"`
+}
+
+type definitionsDiagnostics struct {
+	Checksum string              `json:"checksum"`
+	Domains  int                 `json:"domains"`
+	Warnings []definition.VError `json:"warnings,omitempty"`
+}
+
+// diagnosticsCaches collects the cache stats providers available in deps,
+// keyed by cache name.
+func diagnosticsCaches(deps Dependencies) map[string]CacheStatsProvider {
+	caches := make(map[string]CacheStatsProvider)
+	if p, ok := deps.CapabilityResolver.(CacheStatsProvider); ok {
+		caches["capability"] = p
+	}
+	if deps.LookupProvider != nil {
+		caches["lookup"] = deps.LookupProvider
+	}
+	return caches
+}
+
+// handleDiagnostics serves a runtime snapshot for triage. It requires the
+// configured diagnostics capability; with none configured it always
+// refuses.
+func handleDiagnostics(deps Dependencies) http.HandlerFunc {
+	required := deps.Config.Capability.DiagnosticsCapability
+	caches := diagnosticsCaches(deps)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if required == "" {
+			WriteForbidden(w, "diagnostics are disabled")
+			return
+		}
+		if !CapabilitiesFrom(r.Context()).Has(required) {
+			WriteError(w, model.NewInsufficientCapabilitiesError(
+				"insufficient capabilities for diagnostics", []string{required},
+			))
+			return
+		}
+
+		resp := diagnosticsResponse{
+			Version: deps.AppVersion,
+			Caches:  make(map[string]model.CacheStats, len(caches)),
+		}
+		if deps.Registry != nil {
+			resp.Definitions = definitionsDiagnostics{
+				Checksum: deps.Registry.Checksum(),
+				Domains:  len(deps.Registry.AllDomains()),
+				Warnings: deps.Registry.Warnings(),
+			}
+		}
+		for name, p := range caches {
+			resp.Caches[name] = p.CacheStats()
+		}
+
+		WriteJSON(w, http.StatusOK, resp)
+	}
+}
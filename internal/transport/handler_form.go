@@ -22,6 +22,9 @@ func handleGetForm(forms *metadata.FormProvider) http.HandlerFunc {
 			WriteError(w, err)
 			return
 		}
+		if explainRequested(r.Context()) {
+			desc.Explain = forms.ExplainForm(caps, formID)
+		}
 		WriteJSON(w, http.StatusOK, desc)
 	}
 }
@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/pitabwire/thesa/internal/command"
+	"github.com/pitabwire/thesa/model"
+)
+
+// handleGetJob returns the status, and once finished the result, of an
+// async command job. Callers see only the jobs they created.
+func handleGetJob(executor *command.CommandExecutor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rctx := model.RequestContextFrom(r.Context())
+		if rctx == nil {
+			WriteError(w, model.NewUnauthorizedError("missing request context"))
+			return
+		}
+		job, err := executor.Job(r.Context(), rctx, r.PathValue("jobId"))
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, job)
+	}
+}
@@ -16,8 +16,12 @@ func handleLookup(provider *search.LookupProvider) http.HandlerFunc {
 		}
 		lookupID := r.PathValue("lookupId")
 		query := r.URL.Query().Get("q")
+		pagination := model.Pagination{
+			Page:     queryInt(r, "page", 1),
+			PageSize: queryInt(r, "page_size", 0),
+		}
 
-		resp, err := provider.GetLookup(r.Context(), rctx, lookupID, query)
+		resp, err := provider.GetLookup(r.Context(), rctx, lookupID, query, pagination)
 		if err != nil {
 			WriteError(w, err)
 			return
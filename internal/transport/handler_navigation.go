@@ -21,6 +21,9 @@ func handleNavigation(menu *metadata.MenuProvider) http.HandlerFunc {
 			WriteError(w, err)
 			return
 		}
+		if explainRequested(r.Context()) {
+			tree.Explain = menu.ExplainMenu(rctx, caps)
+		}
 		WriteJSON(w, http.StatusOK, tree)
 	}
 }
@@ -23,6 +23,9 @@ func handleGetPage(pages *metadata.PageProvider) http.HandlerFunc {
 			WriteError(w, err)
 			return
 		}
+		if explainRequested(r.Context()) {
+			desc.Explain = pages.ExplainPage(caps, pageID)
+		}
 		WriteJSON(w, http.StatusOK, desc)
 	}
 }
@@ -55,6 +58,27 @@ func handleGetPageData(pages *metadata.PageProvider) http.HandlerFunc {
 	}
 }
 
+// handleGetFilterOptions serves the options of a lookup-backed list filter,
+// narrowed by the current filter values passed as filter[field]=value.
+func handleGetFilterOptions(pages *metadata.PageProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rctx := model.RequestContextFrom(r.Context())
+		if rctx == nil {
+			WriteError(w, model.NewUnauthorizedError("missing request context"))
+			return
+		}
+		caps := CapabilitiesFrom(r.Context())
+
+		options, err := pages.FilterOptions(r.Context(), rctx, caps,
+			r.PathValue("pageId"), r.PathValue("field"), queryMap(r, "filter"))
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, model.LookupResponse{Data: model.LookupPayload{Options: options}})
+	}
+}
+
 // queryInt extracts an integer query param with a default.
 func queryInt(r *http.Request, key string, def int) int {
 	s := r.URL.Query().Get(key)
@@ -66,6 +66,48 @@ func handleGetResourceItem(provider *metadata.ResourceProvider) http.HandlerFunc
 	}
 }
 
+// handleGetResourceActions returns the actions available on a single resource,
+// filtered by capability and evaluated against the resource's current state.
+func handleGetResourceActions(provider *metadata.ResourceProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rctx := model.RequestContextFrom(r.Context())
+		if rctx == nil {
+			WriteError(w, model.NewUnauthorizedError("missing request context"))
+			return
+		}
+		caps := CapabilitiesFrom(r.Context())
+		resourceType := r.PathValue("resourceType")
+		id := r.PathValue("id")
+
+		actions, err := provider.GetResourceActions(r.Context(), rctx, caps, resourceType, id)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, actions)
+	}
+}
+
+// handleResolveRoute maps a resource reference (type and ID) to the route of
+// the detail page that displays it, for deep links and notifications.
+func handleResolveRoute(provider *metadata.ResourceProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resourceType := r.URL.Query().Get("type")
+		id := r.URL.Query().Get("id")
+		if resourceType == "" || id == "" {
+			WriteError(w, model.NewBadRequestError("type and id query parameters are required"))
+			return
+		}
+
+		resolution, err := provider.ResolveRoute(model.RequestContextFrom(r.Context()), resourceType, id)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, resolution)
+	}
+}
+
 // handleResourceSearch searches resources of a specific type. Delegates to the
 // search provider with a domain filter matching the resource type.
 func handleResourceSearch(provider *search.SearchProvider) http.HandlerFunc {
@@ -103,7 +145,7 @@ func handleGetSchema(provider *metadata.SchemaProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		schemaID := r.PathValue("schemaId")
 
-		schema, err := provider.GetSchema(schemaID)
+		schema, err := provider.GetSchema(model.RequestContextFrom(r.Context()), schemaID)
 		if err != nil {
 			WriteError(w, err)
 			return
@@ -1,7 +1,10 @@
 package transport
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/pitabwire/thesa/internal/search"
 	"github.com/pitabwire/thesa/model"
@@ -31,3 +34,75 @@ func handleSearch(provider *search.SearchProvider) http.HandlerFunc {
 		WriteJSON(w, http.StatusOK, resp)
 	}
 }
+
+// searchDoneEvent is the final event of a streamed search.
+type searchDoneEvent struct {
+	Query       string            `json:"query"`
+	Providers   map[string]string `json:"providers"`
+	QueryTimeMs int64             `json:"query_time_ms"`
+}
+
+// handleSearchStream is the server-sent events variant of handleSearch. It
+// sends a "results" event per provider as soon as that provider responds,
+// then a "done" event with every provider's status, and closes the stream.
+// Validation errors are returned as JSON before the stream starts.
+func handleSearchStream(provider *search.SearchProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rctx := model.RequestContextFrom(r.Context())
+		if rctx == nil {
+			WriteError(w, model.NewUnauthorizedError("missing request context"))
+			return
+		}
+		caps := CapabilitiesFrom(r.Context())
+		query := r.URL.Query().Get("q")
+
+		start := time.Now()
+		stream := newEventStream(w)
+		providers := make(map[string]string)
+		err := provider.StreamSearch(r.Context(), rctx, caps, query, r.URL.Query().Get("domain"), func(res search.ProviderResults) {
+			providers[res.Provider] = res.Status
+			stream.send("results", res)
+		})
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		stream.send("done", searchDoneEvent{
+			Query:       query,
+			Providers:   providers,
+			QueryTimeMs: time.Since(start).Milliseconds(),
+		})
+	}
+}
+
+// eventStream writes server-sent events, sending the response headers with
+// the first event so that a handler can still answer with an error before
+// then.
+type eventStream struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+func newEventStream(w http.ResponseWriter) *eventStream {
+	return &eventStream{w: w, rc: http.NewResponseController(w)}
+}
+
+// send writes one event with data encoded as JSON and flushes it to the
+// client. Write errors, such as a disconnected client, are ignored.
+func (s *eventStream) send(event string, data any) {
+	if !s.started {
+		s.started = true
+		h := s.w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		s.w.WriteHeader(http.StatusOK)
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload)
+	_ = s.rc.Flush()
+}
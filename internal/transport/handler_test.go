@@ -4,16 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/pitabwire/thesa/internal/capability"
 	"github.com/pitabwire/thesa/internal/command"
 	"github.com/pitabwire/thesa/internal/definition"
 	"github.com/pitabwire/thesa/internal/invoker"
 	"github.com/pitabwire/thesa/internal/metadata"
+	"github.com/pitabwire/thesa/internal/openapi"
 	"github.com/pitabwire/thesa/internal/search"
 	"github.com/pitabwire/thesa/model"
 )
@@ -83,6 +93,18 @@ func (f *fakeInvoker) Invoke(_ context.Context, _ *model.RequestContext, _ model
 
 func (f *fakeInvoker) Supports(_ model.OperationBinding) bool { return true }
 
+// captureInvoker records the invocation input and returns a 200 response.
+type captureInvoker struct {
+	fn func(input model.InvocationInput)
+}
+
+func (c *captureInvoker) Invoke(_ context.Context, _ *model.RequestContext, _ model.OperationBinding, input model.InvocationInput) (model.InvocationResult, error) {
+	c.fn(input)
+	return model.InvocationResult{StatusCode: 200, Body: map[string]any{}}, nil
+}
+
+func (c *captureInvoker) Supports(_ model.OperationBinding) bool { return true }
+
 func newTestInvokerRegistry(inv model.OperationInvoker) *invoker.Registry {
 	reg := invoker.NewRegistry()
 	reg.Register(inv)
@@ -136,6 +158,76 @@ func TestHandleNavigation_noRequestContext(t *testing.T) {
 	}
 }
 
+func TestHandleBootstrap(t *testing.T) {
+	reg := newRegistry(
+		model.DomainDefinition{
+			Domain: "admin",
+			Navigation: model.NavigationDefinition{
+				Label: "Admin", Order: 1, Capabilities: []string{"admin:view"},
+				Children: []model.NavigationChildDefinition{{PageID: "admin.home", Label: "Home", Route: "/admin"}},
+			},
+			Pages: []model.PageDefinition{
+				{ID: "admin.home", Title: "Admin", Route: "/admin", Layout: "table", Capabilities: []string{"admin:view"}},
+			},
+		},
+		model.DomainDefinition{
+			Domain: "orders",
+			Navigation: model.NavigationDefinition{
+				Label: "Orders", Order: 2,
+				Children: []model.NavigationChildDefinition{
+					{PageID: "orders.reports", Label: "Reports", Route: "/orders/reports", Order: 1},
+					{PageID: "orders.list", Label: "Order List", Route: "/orders", Order: 2},
+				},
+			},
+			Pages: []model.PageDefinition{
+				{ID: "orders.reports", Title: "Reports", Route: "/orders/reports", Layout: "table", Capabilities: []string{"reports:view"}},
+				{ID: "orders.list", Title: "Orders", Route: "/orders", Layout: "table"},
+			},
+		},
+	)
+	handler := handleBootstrap(metadata.NewMenuProvider(reg, nil), metadata.NewPageProvider(reg, nil, metadata.NewActionProvider()), "1.2.3")
+
+	tests := []struct {
+		name      string
+		caps      model.CapabilitySet
+		wantNav   []string
+		wantPage  string
+		wantAdmin bool
+	}{
+		{name: "admin", caps: model.CapabilitySet{"admin:view": true}, wantNav: []string{"admin", "orders"}, wantPage: "admin.home", wantAdmin: true},
+		{name: "skips forbidden pages", caps: testCaps(), wantNav: []string{"orders"}, wantPage: "orders.list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makeRouterRequest("GET", "/ui/bootstrap", "/ui/bootstrap", nil, handler, testRequestContext(), tt.caps)
+			if w.Code != 200 {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+
+			var resp bootstrapResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			var nav []string
+			for _, item := range resp.Navigation.Items {
+				nav = append(nav, item.ID)
+			}
+			if !reflect.DeepEqual(nav, tt.wantNav) {
+				t.Errorf("navigation = %v, want %v", nav, tt.wantNav)
+			}
+			if resp.Page == nil || resp.Page.ID != tt.wantPage {
+				t.Errorf("page = %+v, want %s", resp.Page, tt.wantPage)
+			}
+			if got := resp.Capabilities.Capabilities["admin:view"].Enabled; got != tt.wantAdmin {
+				t.Errorf("capabilities[admin:view] = %v, want %v", got, tt.wantAdmin)
+			}
+			if resp.Capabilities.App == nil || resp.Capabilities.App.Version != "1.2.3" {
+				t.Errorf("app = %+v, want version 1.2.3", resp.Capabilities.App)
+			}
+		})
+	}
+}
+
 // --- Page handler tests ---
 
 func TestHandleGetPage_success(t *testing.T) {
@@ -174,6 +266,51 @@ func TestHandleGetPage_notFound(t *testing.T) {
 	}
 }
 
+// paramOptionSource returns one option per lookup parameter value.
+type paramOptionSource struct{}
+
+func (paramOptionSource) OptionsWithParams(_ context.Context, _ *model.RequestContext, lookupID string, params map[string]string) ([]model.OptionDescriptor, error) {
+	return []model.OptionDescriptor{{Label: lookupID, Value: params["region"]}}, nil
+}
+
+func TestHandleGetFilterOptions(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{{
+			ID: "orders.list", Title: "Orders", Layout: "list",
+			Table: &model.TableDefinition{Filters: []model.FilterDefinition{
+				{Field: "region", Type: "select"},
+				{Field: "status", Type: "select", Options: &model.FilterOptionsDefinition{
+					LookupID: "orders.statuses",
+					Params:   map[string]string{"region": "region"},
+				}},
+			}},
+		}},
+	})
+	pages := metadata.NewPageProvider(reg, nil, metadata.NewActionProvider())
+	pages.SetOptionSource(paramOptionSource{})
+	handler := handleGetFilterOptions(pages)
+
+	w := makeRouterRequest("GET", "/ui/pages/{pageId}/filters/{field}/options",
+		"/ui/pages/orders.list/filters/status/options?filter[region]=eu", nil, handler, testRequestContext(), testCaps())
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp model.LookupResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Data.Options) != 1 || resp.Data.Options[0].Value != "eu" {
+		t.Errorf("options = %+v, want one narrowed by region eu", resp.Data.Options)
+	}
+
+	w = makeRouterRequest("GET", "/ui/pages/{pageId}/filters/{field}/options",
+		"/ui/pages/orders.list/filters/region/options", nil, handler, testRequestContext(), testCaps())
+	if w.Code != 404 {
+		t.Errorf("status for a filter without a lookup = %d, want 404", w.Code)
+	}
+}
+
 func TestHandleGetPageData_success(t *testing.T) {
 	inv := &fakeInvoker{
 		result: model.InvocationResult{
@@ -394,6 +531,429 @@ func TestHandleCommand_success(t *testing.T) {
 	}
 }
 
+func TestHandleCommand_auditLog(t *testing.T) {
+	inv := &fakeInvoker{
+		result: model.InvocationResult{
+			StatusCode: 200,
+			Body: map[string]any{
+				"previous": map[string]any{"status": "draft", "total": 10.0},
+				"data":     map[string]any{"status": "submitted", "total": 10.0},
+			},
+		},
+	}
+
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Commands: []model.CommandDefinition{
+			{
+				ID: "orders.update",
+				Operation: model.OperationBinding{
+					Type:        "openapi",
+					ServiceID:   "orders-svc",
+					OperationID: "updateOrder",
+				},
+				Output: model.OutputMapping{
+					Audit: &model.AuditDiff{
+						Before: "previous",
+						After:  "data",
+						Fields: map[string]string{"status": "status", "total": "total"},
+					},
+				},
+			},
+		},
+	})
+
+	var buf bytes.Buffer
+	executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+	executor.AddObserver(command.NewAuditObserver(slog.New(slog.NewJSONHandler(&buf, nil))))
+	handler := handleCommand(executor)
+
+	body, _ := json.Marshal(model.CommandInput{Input: map[string]any{"status": "submitted"}})
+	w := makeRouterRequest("POST", "/ui/commands/{commandId}", "/ui/commands/orders.update", body, handler, testRequestContext(), testCaps())
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+
+	var entry struct {
+		Type      string `json:"type"`
+		Event     string `json:"event"`
+		SubjectID string `json:"subject_id"`
+		Data      struct {
+			CommandID string                         `json:"command_id"`
+			Success   bool                           `json:"success"`
+			Changes   map[string]command.FieldChange `json:"changes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("audit entry %q: %v", buf.String(), err)
+	}
+	if entry.Type != "audit" || entry.Event != command.AuditEventCommandExecuted {
+		t.Errorf("entry type/event = %q/%q", entry.Type, entry.Event)
+	}
+	if entry.SubjectID != testRequestContext().SubjectID || entry.Data.CommandID != "orders.update" || !entry.Data.Success {
+		t.Errorf("entry = %+v", entry)
+	}
+	want := map[string]command.FieldChange{"status": {Before: "draft", After: "submitted"}}
+	if !reflect.DeepEqual(entry.Data.Changes, want) {
+		t.Errorf("changes = %v, want %v", entry.Data.Changes, want)
+	}
+}
+
+func TestHandleCommand_createdLocation(t *testing.T) {
+	inv := &fakeInvoker{
+		result: model.InvocationResult{StatusCode: 201, Body: map[string]any{"order_id": "ord 9"}},
+	}
+	binding := model.OperationBinding{Type: "openapi", ServiceID: "orders-svc", OperationID: "createOrder"}
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{
+			{ID: "orders.detail", Route: "/orders/{id}", Layout: "detail", ResourceType: "order"},
+		},
+		Commands: []model.CommandDefinition{
+			{
+				ID: "orders.create", Operation: binding,
+				Output: model.OutputMapping{
+					Created: &model.CreatedResource{ResourceType: "order", IDField: "order_id"},
+				},
+			},
+			{ID: "orders.touch", Operation: binding},
+		},
+	})
+	executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+	executor.SetRouteResolver(metadata.NewResourceProvider(reg, nil, nil, metadata.NewActionProvider()))
+	handler := handleCommand(executor)
+
+	tests := []struct {
+		commandID    string
+		wantStatus   int
+		wantLocation string
+	}{
+		{commandID: "orders.create", wantStatus: http.StatusCreated, wantLocation: "/orders/ord%209"},
+		{commandID: "orders.touch", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.commandID, func(t *testing.T) {
+			body, _ := json.Marshal(model.CommandInput{Input: map[string]any{}})
+			w := makeRouterRequest("POST", "/ui/commands/{commandId}", "/ui/commands/"+tt.commandID, body, handler, testRequestContext(), testCaps())
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			var resp model.CommandResponse
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			if !resp.Success || resp.Location != tt.wantLocation {
+				t.Errorf("response = %+v, want success with location %q", resp, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestHandleCommand_uploadStreamsBody(t *testing.T) {
+	var captured model.InvocationInput
+	var streamed []byte
+	inv := &captureInvoker{fn: func(input model.InvocationInput) {
+		captured = input
+		streamed, _ = io.ReadAll(input.BodyStream)
+	}}
+
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "files",
+		Commands: []model.CommandDefinition{
+			{
+				ID:     "files.upload",
+				Upload: true,
+				Operation: model.OperationBinding{
+					Type:        "openapi",
+					ServiceID:   "files-svc",
+					OperationID: "uploadFile",
+				},
+				Input: model.InputMapping{
+					PathParams: map[string]string{"folderId": "route.folder"},
+				},
+			},
+		},
+	})
+
+	executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+	handler := handleCommand(executor)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /ui/commands/{commandId}", contextMiddleware(testRequestContext(), testCaps())(handler))
+	req := httptest.NewRequest("POST", "/ui/commands/files.upload?route.folder=f-1", bytes.NewReader([]byte("raw-bytes")))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	if string(streamed) != "raw-bytes" {
+		t.Errorf("streamed body = %q, want raw-bytes", streamed)
+	}
+	if captured.Body != nil {
+		t.Errorf("Body = %v, want nil for upload", captured.Body)
+	}
+	if captured.PathParams["folderId"] != "f-1" {
+		t.Errorf("PathParams[folderId] = %q, want f-1", captured.PathParams["folderId"])
+	}
+	if captured.Headers["Content-Type"] != "application/octet-stream" {
+		t.Errorf("Content-Type header = %q", captured.Headers["Content-Type"])
+	}
+}
+
+func TestHandleCommand_uploadChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		maxSize    int64
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "within limits", query: "?name=a.txt", maxSize: 9, wantStatus: 200, wantCalled: true},
+		{name: "body too large", query: "?name=a.txt", maxSize: 4, wantStatus: 413, wantCalled: true},
+		{name: "input limits", query: "?name=a.txt&note=x", maxSize: 9, wantStatus: 422},
+		{name: "invalid option", query: "?kind=video", maxSize: 9, wantStatus: 422},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			inv := &captureInvoker{fn: func(input model.InvocationInput) {
+				called = true
+				_, _ = io.ReadAll(input.BodyStream)
+			}}
+			reg := newRegistry(model.DomainDefinition{
+				Domain: "files",
+				Commands: []model.CommandDefinition{
+					{
+						ID:        "files.upload",
+						Upload:    true,
+						Operation: model.OperationBinding{Type: "openapi", ServiceID: "files-svc", OperationID: "uploadFile"},
+						Limits:    &model.InputLimits{MaxFields: 1},
+					},
+				},
+				Forms: []model.FormDefinition{
+					{
+						ID:            "files.upload-form",
+						SubmitCommand: "files.upload",
+						Sections: []model.SectionDefinition{{
+							ID: "main",
+							Fields: []model.FieldDefinition{{
+								Field:  "kind",
+								Type:   "select",
+								Lookup: &model.LookupRefDefinition{Static: []model.StaticOption{{Value: "image"}, {Value: "document"}}},
+							}},
+						}},
+					},
+				},
+			})
+
+			executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+			executor.SetMaxUploadSize(tt.maxSize)
+			mux := http.NewServeMux()
+			mux.Handle("POST /ui/commands/{commandId}", contextMiddleware(testRequestContext(), testCaps())(handleCommand(executor)))
+			req := httptest.NewRequest("POST", "/ui/commands/files.upload"+tt.query, bytes.NewReader([]byte("raw-bytes")))
+			req.Header.Set("Content-Type", "application/octet-stream")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if called != tt.wantCalled {
+				t.Errorf("backend called = %v, want %v", called, tt.wantCalled)
+			}
+		})
+	}
+}
+
+func TestHandleCommand_multipartForwardsFiles(t *testing.T) {
+	var captured model.InvocationInput
+	var content []byte
+	inv := &captureInvoker{fn: func(input model.InvocationInput) {
+		captured = input
+		// Files are opened while the command runs.
+		if f, err := input.Files["invoice"].Open(); err == nil {
+			content, _ = io.ReadAll(f)
+			_ = f.Close()
+		}
+	}}
+
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Commands: []model.CommandDefinition{
+			{
+				ID:     "orders.upload_invoice",
+				Upload: true,
+				Operation: model.OperationBinding{
+					Type:        "openapi",
+					ServiceID:   "orders-svc",
+					OperationID: "uploadInvoice",
+				},
+				Input: model.InputMapping{
+					PathParams:      map[string]string{"orderId": "route.id"},
+					BodyMapping:     "projection",
+					FieldProjection: map[string]string{"note": "input.note"},
+				},
+			},
+			{ID: "orders.create", Operation: model.OperationBinding{Type: "openapi", OperationID: "createOrder"}},
+		},
+	})
+	executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+	mux := http.NewServeMux()
+	mux.Handle("POST /ui/commands/{commandId}", contextMiddleware(testRequestContext(), testCaps())(handleCommand(executor)))
+
+	post := func(commandID string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		_ = mw.WriteField("input", `{"input":{"note":"Q3"},"route_params":{"id":"ord-1"}}`)
+		part, _ := mw.CreateFormFile("invoice", "inv.pdf")
+		_, _ = part.Write([]byte("%PDF-1.4"))
+		_ = mw.Close()
+		req := httptest.NewRequest("POST", "/ui/commands/"+commandID, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("orders.upload_invoice")
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	if captured.PathParams["orderId"] != "ord-1" {
+		t.Errorf("PathParams[orderId] = %q, want ord-1", captured.PathParams["orderId"])
+	}
+	if !reflect.DeepEqual(captured.Body, map[string]any{"note": "Q3"}) {
+		t.Errorf("Body = %v, want the mapped input", captured.Body)
+	}
+	file := captured.Files["invoice"]
+	if file.Filename != "inv.pdf" || string(content) != "%PDF-1.4" {
+		t.Errorf("Files[invoice] = %q %q, want inv.pdf with its content", file.Filename, content)
+	}
+
+	if w := post("orders.create"); w.Code != 400 {
+		t.Errorf("status = %d, want 400 for a command that does not accept uploads", w.Code)
+	}
+}
+
+func TestHandleCommand_streamsResponse(t *testing.T) {
+	body := io.NopCloser(strings.NewReader("id,name\n1,Alice\n"))
+	inv := &fakeInvoker{result: model.InvocationResult{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":        "text/csv",
+			"Content-Disposition": `attachment; filename="orders.csv"`,
+		},
+		BodyReader: body,
+	}}
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Commands: []model.CommandDefinition{{
+			ID:        "orders.export",
+			Operation: model.OperationBinding{Type: "openapi", OperationID: "exportOrders", StreamResponse: true},
+		}},
+	})
+	executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+
+	w := makeRouterRequest("POST", "/ui/commands/{commandId}", "/ui/commands/orders.export", []byte(`{"input":{}}`), handleCommand(executor), testRequestContext(), testCaps())
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	if got := w.Body.String(); got != "id,name\n1,Alice\n" {
+		t.Errorf("body = %q, want the backend CSV", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="orders.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestHandleCommand_asyncJob(t *testing.T) {
+	inv := &fakeInvoker{result: model.InvocationResult{StatusCode: 200, Body: map[string]any{}}}
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Commands: []model.CommandDefinition{{
+			ID:        "orders.reindex",
+			Async:     true,
+			Operation: model.OperationBinding{Type: "openapi", OperationID: "reindexOrders"},
+		}},
+	})
+	executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+	executor.SetJobStore(command.NewMemoryJobStore(time.Minute), 1, 0)
+
+	w := makeRouterRequest("POST", "/ui/commands/{commandId}", "/ui/commands/orders.reindex", []byte(`{"input":{}}`), handleCommand(executor), testRequestContext(), testCaps())
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202; body = %s", w.Code, w.Body.String())
+	}
+	var resp model.CommandResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.JobID == "" || w.Header().Get("Location") != "/ui/jobs/"+resp.JobID {
+		t.Fatalf("JobID = %q, Location = %q, want the job's status URL", resp.JobID, w.Header().Get("Location"))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var job command.Job
+	for !job.Done() && time.Now().Before(deadline) {
+		w = makeRouterRequest("GET", "/ui/jobs/{jobId}", "/ui/jobs/"+resp.JobID, nil, handleGetJob(executor), testRequestContext(), testCaps())
+		if w.Code != http.StatusOK {
+			t.Fatalf("job status = %d, want 200; body = %s", w.Code, w.Body.String())
+		}
+		_ = json.NewDecoder(w.Body).Decode(&job)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job.Status != command.JobSucceeded || job.Response == nil {
+		t.Errorf("job = %+v, want succeeded with a response", job)
+	}
+
+	other := testRequestContext()
+	other.SubjectID = "user-2"
+	w = makeRouterRequest("GET", "/ui/jobs/{jobId}", "/ui/jobs/"+resp.JobID, nil, handleGetJob(executor), other, testCaps())
+	if w.Code != http.StatusNotFound {
+		t.Errorf("other subject status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandleCommand_nonUploadDecodesJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "raw bytes", contentType: "application/octet-stream", body: "raw", wantStatus: 400},
+		{name: "JSON as text", contentType: "text/plain", body: `{"input":{}}`, wantStatus: 200},
+		{name: "JSON as form", contentType: "application/x-www-form-urlencoded", body: `{"input":{}}`, wantStatus: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := newRegistry(model.DomainDefinition{
+				Domain: "orders",
+				Commands: []model.CommandDefinition{
+					{ID: "orders.create", Operation: model.OperationBinding{Type: "openapi", OperationID: "createOrder"}},
+				},
+			})
+			inv := &fakeInvoker{result: model.InvocationResult{StatusCode: 200}}
+			executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(inv), nil)
+			handler := handleCommand(executor)
+
+			mux := http.NewServeMux()
+			mux.Handle("POST /ui/commands/{commandId}", contextMiddleware(testRequestContext(), testCaps())(handler))
+			req := httptest.NewRequest("POST", "/ui/commands/orders.create", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestHandleCommand_invalidJSON(t *testing.T) {
 	reg := newRegistry()
 	executor := command.NewCommandExecutor(reg, newTestInvokerRegistry(&fakeInvoker{}), nil)
@@ -434,6 +994,284 @@ func TestHandleCommand_noRequestContext(t *testing.T) {
 	}
 }
 
+// --- Resolve route handler tests ---
+
+func TestHandleResolveRoute_success(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{
+			{ID: "orders.list", Title: "Orders", Route: "/orders", Layout: "table"},
+			{ID: "orders.detail", Title: "Order", Route: "/orders/{id}", Layout: "detail", ResourceType: "order", Capabilities: []string{"orders:view"}},
+		},
+	})
+	resources := metadata.NewResourceProvider(reg, nil, nil, metadata.NewActionProvider())
+	handler := handleResolveRoute(resources)
+
+	w := makeRouterRequest("GET", "/ui/resolve", "/ui/resolve?type=order&id=ord-1", nil, handler, testRequestContext(), testCaps())
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	var res model.RouteResolution
+	_ = json.NewDecoder(w.Body).Decode(&res)
+	if res.Route != "/orders/ord-1" {
+		t.Errorf("route = %q, want /orders/ord-1", res.Route)
+	}
+	if res.PageID != "orders.detail" {
+		t.Errorf("page_id = %q, want orders.detail", res.PageID)
+	}
+	if len(res.Capabilities) != 1 || res.Capabilities[0] != "orders:view" {
+		t.Errorf("capabilities = %v, want [orders:view]", res.Capabilities)
+	}
+}
+
+func TestHandleResolveRoute_severalDetailPages(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{
+			{ID: "orders.shipment_detail", Title: "Shipment", Route: "/shipments/{id}", Layout: "detail", ResourceType: "shipment"},
+			{ID: "orders.detail", Title: "Order", Route: "/orders/{id}", Layout: "detail", ResourceType: "order"},
+			{ID: "returns.detail", Title: "Return", Route: "/returns/{id}", Layout: "detail"},
+		},
+	})
+	resources := metadata.NewResourceProvider(reg, nil, nil, metadata.NewActionProvider())
+	handler := handleResolveRoute(resources)
+
+	tests := []struct {
+		resourceType string
+		wantStatus   int
+		wantRoute    string
+	}{
+		{resourceType: "order", wantStatus: 200, wantRoute: "/orders/x-1"},
+		{resourceType: "shipment", wantStatus: 200, wantRoute: "/shipments/x-1"},
+		{resourceType: "returns", wantStatus: 200, wantRoute: "/returns/x-1"},
+		// A page with a resource type does not also match its ID prefix.
+		{resourceType: "orders", wantStatus: 404},
+		{resourceType: "return", wantStatus: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			w := makeRouterRequest("GET", "/ui/resolve", "/ui/resolve?type="+tt.resourceType+"&id=x-1", nil, handler, testRequestContext(), testCaps())
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var res model.RouteResolution
+			_ = json.NewDecoder(w.Body).Decode(&res)
+			if res.Route != tt.wantRoute {
+				t.Errorf("route = %q, want %q", res.Route, tt.wantRoute)
+			}
+		})
+	}
+}
+
+func TestHandleResolveRoute_unknownType(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{
+			{ID: "orders.detail", Title: "Order", Route: "/orders/{id}", Layout: "detail"},
+		},
+	})
+	resources := metadata.NewResourceProvider(reg, nil, nil, metadata.NewActionProvider())
+	handler := handleResolveRoute(resources)
+
+	w := makeRouterRequest("GET", "/ui/resolve", "/ui/resolve?type=invoice&id=inv-1", nil, handler, testRequestContext(), testCaps())
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestResourceRoutes_unentitledDomain(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{
+			{
+				ID: "orders.detail", Title: "Order", Route: "/orders/{id}", Layout: "detail",
+				Actions: []model.ActionDefinition{{ID: "track", Label: "Track", Type: "navigate", NavigateTo: "/tracking/{id}"}},
+			},
+		},
+		Forms: []model.FormDefinition{{ID: "orders.edit", Title: "Edit order"}},
+	})
+	resources := metadata.NewResourceProvider(reg, nil, nil, metadata.NewActionProvider())
+	schemas := metadata.NewSchemaProvider(reg)
+	rctx := testRequestContext()
+	rctx.UnentitledDomains = []string{"orders"}
+
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{name: "resolve route", pattern: "/ui/resolve", path: "/ui/resolve?type=orders&id=ord-1", handler: handleResolveRoute(resources)},
+		{name: "resource actions", pattern: "/ui/resources/{resourceType}/{id}/actions", path: "/ui/resources/orders/ord-1/actions", handler: handleGetResourceActions(resources)},
+		{name: "form schema", pattern: "/ui/schemas/{schemaId}", path: "/ui/schemas/orders.edit", handler: handleGetSchema(schemas)},
+		{name: "page schema", pattern: "/ui/schemas/{schemaId}", path: "/ui/schemas/orders.detail", handler: handleGetSchema(schemas)},
+		{name: "schema by prefix", pattern: "/ui/schemas/{schemaId}", path: "/ui/schemas/orders", handler: handleGetSchema(schemas)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makeRouterRequest("GET", tt.pattern, tt.path, nil, tt.handler, rctx, testCaps())
+			if w.Code != 404 {
+				t.Errorf("status = %d, want 404 for an unentitled domain", w.Code)
+			}
+			w = makeRouterRequest("GET", tt.pattern, tt.path, nil, tt.handler, testRequestContext(), testCaps())
+			if w.Code != 200 {
+				t.Errorf("entitled status = %d, want 200; body = %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// --- Resource actions handler tests ---
+
+const resourceActionsSpec = `openapi: "3.0.3"
+info:
+  title: Orders
+  version: "1.0"
+paths:
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+`
+
+func newResourceActionsProvider(t *testing.T, item map[string]any, disabled ...string) *metadata.ResourceProvider {
+	t.Helper()
+	specPath := filepath.Join(t.TempDir(), "orders.yaml")
+	if err := os.WriteFile(specPath, []byte(resourceActionsSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx := openapi.NewIndex()
+	if err := idx.Load([]openapi.SpecSource{{ServiceID: "orders-svc", SpecPath: specPath}}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	shippedOnly := []model.ConditionDefinition{{Field: "status", Operator: "eq", Value: "shipped", Effect: "show"}}
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Pages: []model.PageDefinition{
+			{
+				ID: "orders.list", Title: "Orders", Route: "/orders", Layout: "table",
+				Table: &model.TableDefinition{
+					DataSource: model.DataSourceDefinition{OperationID: "listOrders", ServiceID: "orders-svc"},
+					RowActions: []model.ActionDefinition{
+						{ID: "view", Label: "View", Type: "navigate", NavigateTo: "/orders/{id}"},
+						{ID: "cancel", Label: "Cancel", Type: "command", CommandID: "orders.cancel", Capabilities: []string{"orders:cancel"}},
+					},
+				},
+			},
+			{
+				ID: "orders.detail", Title: "Order", Route: "/orders/{id}", Layout: "detail", ResourceType: "order",
+				Actions: []model.ActionDefinition{
+					{ID: "cancel", Label: "Cancel", Type: "command", CommandID: "orders.cancel", Capabilities: []string{"orders:cancel"}},
+					{ID: "refund", Label: "Refund", Type: "command", CommandID: "orders.refund", Capabilities: []string{"billing:refund"}},
+					{ID: "track", Label: "Track", Type: "navigate", NavigateTo: "/tracking/{id}", Conditions: shippedOnly},
+				},
+			},
+		},
+	})
+	for _, id := range disabled {
+		reg.SetCommandDisabled(id, true)
+	}
+	actions := metadata.NewActionProvider()
+	actions.SetCommandStatus(reg)
+	inv := &fakeInvoker{result: model.InvocationResult{StatusCode: 200, Body: item}}
+	return metadata.NewResourceProvider(reg, newTestInvokerRegistry(inv), idx, actions)
+}
+
+func resourceActionIDs(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	var res model.ResourceActions
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(res.Actions))
+	for i, a := range res.Actions {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func TestHandleGetResourceActions_capabilityFiltered(t *testing.T) {
+	handler := handleGetResourceActions(newResourceActionsProvider(t, map[string]any{"id": "ord-1", "status": "shipped"}))
+	pattern := "/ui/resources/{resourceType}/{id}/actions"
+
+	// Detail actions come first; the duplicate "cancel" row action is merged.
+	w := makeRouterRequest("GET", pattern, "/ui/resources/orders/ord-1/actions", nil, handler, testRequestContext(), testCaps())
+	got := resourceActionIDs(t, w)
+	want := []string{"cancel", "track", "view"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("actions = %v, want %v", got, want)
+	}
+
+	viewer := model.CapabilitySet{"orders:view": true}
+	w = makeRouterRequest("GET", pattern, "/ui/resources/orders/ord-1/actions", nil, handler, testRequestContext(), viewer)
+	got = resourceActionIDs(t, w)
+	want = []string{"track", "view"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("viewer actions = %v, want %v", got, want)
+	}
+}
+
+func TestHandleGetResourceActions_excludesFailingPreconditions(t *testing.T) {
+	handler := handleGetResourceActions(newResourceActionsProvider(t, map[string]any{"id": "ord-1", "status": "pending"}))
+
+	// The detail page's resource_type and the list page's prefix name the
+	// same resource, so both resolve the detail and row actions and fetch
+	// the item to evaluate conditions.
+	for _, resourceType := range []string{"orders", "order"} {
+		t.Run(resourceType, func(t *testing.T) {
+			w := makeRouterRequest("GET", "/ui/resources/{resourceType}/{id}/actions", "/ui/resources/"+resourceType+"/ord-1/actions", nil, handler, testRequestContext(), testCaps())
+			got := resourceActionIDs(t, w)
+			want := []string{"cancel", "view"}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("actions = %v, want %v (track requires shipped status)", got, want)
+			}
+		})
+	}
+}
+
+func TestHandleGetResourceActions_hidesDisabledCommands(t *testing.T) {
+	handler := handleGetResourceActions(newResourceActionsProvider(t, map[string]any{"id": "ord-1", "status": "shipped"}, "orders.cancel"))
+
+	w := makeRouterRequest("GET", "/ui/resources/{resourceType}/{id}/actions", "/ui/resources/orders/ord-1/actions", nil, handler, testRequestContext(), testCaps())
+	got := resourceActionIDs(t, w)
+	want := []string{"track", "view"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("actions = %v, want %v (orders.cancel is disabled)", got, want)
+	}
+}
+
+func TestHandleGetResourceActions_unknownType(t *testing.T) {
+	handler := handleGetResourceActions(newResourceActionsProvider(t, nil))
+
+	w := makeRouterRequest("GET", "/ui/resources/{resourceType}/{id}/actions", "/ui/resources/invoices/inv-1/actions", nil, handler, testRequestContext(), testCaps())
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandleResolveRoute_missingParams(t *testing.T) {
+	resources := metadata.NewResourceProvider(newRegistry(), nil, nil, metadata.NewActionProvider())
+	handler := handleResolveRoute(resources)
+
+	w := makeRouterRequest("GET", "/ui/resolve", "/ui/resolve?type=order", nil, handler, testRequestContext(), testCaps())
+	if w.Code != 400 {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
 // --- Search handler tests ---
 
 func TestHandleSearch_success(t *testing.T) {
@@ -482,6 +1320,57 @@ func TestHandleSearch_success(t *testing.T) {
 	}
 }
 
+func TestHandleSearchStream(t *testing.T) {
+	inv := &fakeInvoker{result: model.InvocationResult{
+		StatusCode: 200,
+		Body:       []any{map[string]any{"id": "1", "title": "Order 1"}},
+	}}
+	mapping := model.SearchResultMapping{IDField: "id", TitleField: "title", Route: "/orders/{id}"}
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "orders",
+		Searches: []model.SearchDefinition{
+			{ID: "orders", Domain: "orders", Operation: model.OperationBinding{Type: "openapi", OperationID: "searchOrders"}, ResultMapping: mapping},
+			{ID: "archive", Domain: "orders", Operation: model.OperationBinding{Type: "openapi", OperationID: "searchArchive"}, ResultMapping: mapping},
+		},
+	})
+	provider := search.NewSearchProvider(reg, newTestInvokerRegistry(inv), 3*time.Second, 50)
+
+	w := makeRouterRequest("GET", "/ui/search/stream", "/ui/search/stream?q=order", nil, handleSearchStream(provider), testRequestContext(), testCaps())
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	if !w.Flushed {
+		t.Error("events were not flushed")
+	}
+
+	var names []string
+	var done struct {
+		Providers map[string]string `json:"providers"`
+	}
+	for _, event := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		lines := strings.SplitN(event, "\n", 2)
+		name := strings.TrimPrefix(lines[0], "event: ")
+		names = append(names, name)
+		if name == "done" {
+			_ = json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &done)
+		}
+	}
+	if !slices.Equal(names, []string{"results", "results", "done"}) {
+		t.Errorf("events = %v, want a results event per provider, then done", names)
+	}
+	if done.Providers["orders"] != "ok" || done.Providers["archive"] != "ok" {
+		t.Errorf("done providers = %v, want both ok", done.Providers)
+	}
+
+	w = makeRouterRequest("GET", "/ui/search/stream", "/ui/search/stream?q=a", nil, handleSearchStream(provider), testRequestContext(), testCaps())
+	if w.Code != 400 || strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		t.Errorf("short query: status = %d, Content-Type = %q, want a 400 JSON error", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
 func TestHandleSearch_queryTooShort(t *testing.T) {
 	reg := newRegistry()
 	provider := search.NewSearchProvider(reg, newTestInvokerRegistry(&fakeInvoker{}), 3*time.Second, 50)
@@ -533,7 +1422,7 @@ func TestHandleLookup_success(t *testing.T) {
 		},
 	})
 
-	provider := search.NewLookupProvider(reg, newTestInvokerRegistry(inv), 5*time.Minute, 100)
+	provider := search.NewLookupProvider(reg, newTestInvokerRegistry(inv), 5*time.Minute, 100, 100)
 	handler := handleLookup(provider)
 
 	w := makeRouterRequest("GET", "/ui/lookups/{lookupId}", "/ui/lookups/currencies?q=usd", nil, handler, testRequestContext(), testCaps())
@@ -550,7 +1439,7 @@ func TestHandleLookup_success(t *testing.T) {
 
 func TestHandleLookup_notFound(t *testing.T) {
 	reg := newRegistry()
-	provider := search.NewLookupProvider(reg, newTestInvokerRegistry(&fakeInvoker{}), 5*time.Minute, 100)
+	provider := search.NewLookupProvider(reg, newTestInvokerRegistry(&fakeInvoker{}), 5*time.Minute, 100, 100)
 	handler := handleLookup(provider)
 
 	w := makeRouterRequest("GET", "/ui/lookups/{lookupId}", "/ui/lookups/nonexistent", nil, handler, testRequestContext(), testCaps())
@@ -560,7 +1449,7 @@ func TestHandleLookup_notFound(t *testing.T) {
 }
 
 func TestHandleLookup_noRequestContext(t *testing.T) {
-	provider := search.NewLookupProvider(newRegistry(), newTestInvokerRegistry(&fakeInvoker{}), 5*time.Minute, 100)
+	provider := search.NewLookupProvider(newRegistry(), newTestInvokerRegistry(&fakeInvoker{}), 5*time.Minute, 100, 100)
 	handler := handleLookup(provider)
 
 	mux := http.NewServeMux()
@@ -614,3 +1503,198 @@ func TestQueryMap_empty(t *testing.T) {
 		t.Errorf("expected empty map, got %v", result)
 	}
 }
+
+// --- Diagnostics ---
+
+type staticPolicyEvaluator model.CapabilitySet
+
+func (s staticPolicyEvaluator) ResolveCapabilities(_ context.Context, _ *model.RequestContext) (model.CapabilitySet, error) {
+	return model.CapabilitySet(s), nil
+}
+
+func newDiagnosticsDeps(t *testing.T) Dependencies {
+	t.Helper()
+	inv := &fakeInvoker{
+		result: model.InvocationResult{
+			StatusCode: 200,
+			Body:       []any{map[string]any{"name": "USD", "code": "USD"}},
+		},
+	}
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "reference",
+		Lookups: []model.LookupDefinition{{
+			ID:         "currencies",
+			Operation:  model.OperationBinding{Type: "openapi", ServiceID: "ref-svc", OperationID: "getCurrencies"},
+			LabelField: "name",
+			ValueField: "code",
+		}},
+	})
+
+	deps := testDeps()
+	deps.Registry = reg
+	deps.LookupProvider = search.NewLookupProvider(reg, newTestInvokerRegistry(inv), 5*time.Minute, 100, 100)
+	deps.CapabilityResolver = capability.NewResolver(staticPolicyEvaluator{"orders:*": true}, time.Minute)
+
+	// One miss then one hit on each cache.
+	ctx := context.Background()
+	for range 2 {
+		if _, err := deps.LookupProvider.GetLookup(ctx, testRequestContext(), "currencies", "", model.Pagination{}); err != nil {
+			t.Fatalf("GetLookup: %v", err)
+		}
+		if _, err := deps.CapabilityResolver.Resolve(ctx, testRequestContext()); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+	}
+	return deps
+}
+
+func TestHandleDiagnostics_reportsCacheStats(t *testing.T) {
+	deps := newDiagnosticsDeps(t)
+	deps.Registry.SetWarnings([]definition.VError{{
+		Path: "definitions[0].pages[0].actions[0].conditions[0].operator", Code: "DEPRECATED", Message: "deprecated", Warning: true,
+	}})
+	caps := model.CapabilitySet{"thesa:diagnostics:view": true}
+
+	w := makeRouterRequest("GET", "/ui/admin/diagnostics", "/ui/admin/diagnostics", nil, handleDiagnostics(deps), testRequestContext(), caps)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+
+	var resp diagnosticsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := model.CacheStats{Entries: 1, Hits: 1, Misses: 1, HitRate: 0.5}
+	for _, name := range []string{"capability", "lookup"} {
+		if got := resp.Caches[name]; got != want {
+			t.Errorf("caches[%s] = %+v, want %+v", name, got, want)
+		}
+	}
+	if resp.Definitions.Domains != 1 || resp.Definitions.Checksum == "" {
+		t.Errorf("definitions = %+v, want 1 domain with a checksum", resp.Definitions)
+	}
+	if len(resp.Definitions.Warnings) != 1 || resp.Definitions.Warnings[0].Code != "DEPRECATED" {
+		t.Errorf("definitions.warnings = %+v, want the DEPRECATED warning", resp.Definitions.Warnings)
+	}
+}
+
+func TestHandleDiagnostics_requiresCapability(t *testing.T) {
+	deps := newDiagnosticsDeps(t)
+
+	w := makeRouterRequest("GET", "/ui/admin/diagnostics", "/ui/admin/diagnostics", nil, handleDiagnostics(deps), testRequestContext(), testCaps())
+	if w.Code != 403 {
+		t.Errorf("status = %d, want 403 without the diagnostics capability", w.Code)
+	}
+
+	deps.Config.Capability.DiagnosticsCapability = ""
+	caps := model.CapabilitySet{"*": true}
+	w = makeRouterRequest("GET", "/ui/admin/diagnostics", "/ui/admin/diagnostics", nil, handleDiagnostics(deps), testRequestContext(), caps)
+	if w.Code != 403 {
+		t.Errorf("status = %d, want 403 when no diagnostics capability is configured", w.Code)
+	}
+}
+
+func TestHandleWarmLookups(t *testing.T) {
+	deps := testDeps()
+	inv := &fakeInvoker{result: model.InvocationResult{
+		StatusCode: 200,
+		Body:       []any{map[string]any{"name": "USD", "code": "USD"}},
+	}}
+	reg := newRegistry(model.DomainDefinition{
+		Domain: "reference",
+		Lookups: []model.LookupDefinition{{
+			ID:         "currencies",
+			Operation:  model.OperationBinding{Type: "openapi", ServiceID: "ref-svc", OperationID: "getCurrencies"},
+			LabelField: "name",
+			ValueField: "code",
+		}},
+	})
+	provider := search.NewLookupProvider(reg, newTestInvokerRegistry(inv), 5*time.Minute, 100, 100)
+	handler := handleWarmLookups(provider, deps.Config.Capability.CacheAdminCapability, []string{"currencies"})
+
+	w := makeRouterRequest("POST", "/ui/admin/lookups/warm", "/ui/admin/lookups/warm", nil, handler, testRequestContext(), testCaps())
+	if w.Code != 403 {
+		t.Fatalf("status = %d, want 403 without the cache admin capability", w.Code)
+	}
+	if provider.CacheLen() != 0 {
+		t.Fatal("cache warmed without the capability")
+	}
+
+	caps := model.CapabilitySet{"thesa:cache:manage": true}
+	w = makeRouterRequest("POST", "/ui/admin/lookups/warm", "/ui/admin/lookups/warm", nil, handler, testRequestContext(), caps)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	var resp warmLookupsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Status != model.WarmStatusWarmed {
+		t.Errorf("results = %+v, want currencies warmed", resp.Results)
+	}
+	if provider.CacheLen() != 1 {
+		t.Errorf("CacheLen = %d, want 1", provider.CacheLen())
+	}
+
+	// An explicit list overrides the configured one.
+	w = makeRouterRequest("POST", "/ui/admin/lookups/warm", "/ui/admin/lookups/warm", []byte(`{"lookups":["countries"]}`), handler, testRequestContext(), caps)
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].LookupID != "countries" || resp.Results[0].Status != model.WarmStatusFailed {
+		t.Errorf("results = %+v, want countries failed", resp.Results)
+	}
+}
+
+func TestHandleSetCommandDisabled(t *testing.T) {
+	reg := newRegistry(model.DomainDefinition{
+		Domain:   "orders",
+		Commands: []model.CommandDefinition{{ID: "orders.cancel"}, {ID: "orders.create"}},
+	})
+	required := testDeps().Config.Capability.CommandAdminCapability
+	pattern := "/ui/admin/commands/{commandId}/disabled"
+	caps := model.CapabilitySet{"thesa:commands:manage": true}
+
+	w := makeRouterRequest("PUT", pattern, "/ui/admin/commands/orders.cancel/disabled", nil, handleSetCommandDisabled(reg, required, true), testRequestContext(), testCaps())
+	if w.Code != 403 {
+		t.Fatalf("status = %d, want 403 without the command admin capability", w.Code)
+	}
+	if reg.CommandDisabled("orders.cancel") {
+		t.Fatal("command disabled without the capability")
+	}
+
+	w = makeRouterRequest("PUT", pattern, "/ui/admin/commands/orders.cancel/disabled", nil, handleSetCommandDisabled(reg, required, true), testRequestContext(), caps)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	if !reg.CommandDisabled("orders.cancel") || reg.CommandDisabled("orders.create") {
+		t.Errorf("disabled = %v, want only orders.cancel", reg.DisabledCommands())
+	}
+
+	w = makeRouterRequest("GET", "/ui/admin/commands/disabled", "/ui/admin/commands/disabled", nil, handleListDisabledCommands(reg, required), testRequestContext(), caps)
+	var resp disabledCommandsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(resp.Disabled, []string{"orders.cancel"}) {
+		t.Errorf("disabled = %v, want [orders.cancel]", resp.Disabled)
+	}
+
+	w = makeRouterRequest("PUT", pattern, "/ui/admin/commands/orders.unknown/disabled", nil, handleSetCommandDisabled(reg, required, true), testRequestContext(), caps)
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404 for an unknown command", w.Code)
+	}
+
+	w = makeRouterRequest("DELETE", pattern, "/ui/admin/commands/orders.cancel/disabled", nil, handleSetCommandDisabled(reg, required, false), testRequestContext(), caps)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	if reg.CommandDisabled("orders.cancel") {
+		t.Error("command still disabled after DELETE")
+	}
+
+	w = makeRouterRequest("GET", "/ui/admin/commands/disabled", "/ui/admin/commands/disabled", nil, handleListDisabledCommands(reg, ""), testRequestContext(), model.CapabilitySet{"*": true})
+	if w.Code != 403 {
+		t.Errorf("status = %d, want 403 when no command admin capability is configured", w.Code)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path"
+	"runtime"
+	"runtime/debug"
 	"strings"
 	"time"
 
@@ -30,22 +33,62 @@ func CapabilitiesFrom(ctx context.Context) model.CapabilitySet {
 	return caps
 }
 
-// Recovery catches panics in downstream handlers, logs them, and returns
-// a 500 JSON error response.
-func Recovery(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				util.Log(r.Context()).Error("panic recovered",
-					"error", rec,
-					"method", r.Method,
-					"path", r.URL.Path,
-				)
-				WriteError(w, model.NewInternalError())
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+// Recovery returns middleware that catches panics in downstream handlers,
+// logs them with their stack, and returns a 500 JSON error response. With
+// exposeDetails, the response also carries the panic message and a
+// sanitized stack.
+func Recovery(exposeDetails bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					util.Log(r.Context()).Error("panic recovered",
+						"error", rec,
+						"method", r.Method,
+						"path", r.URL.Path,
+						"stack", string(debug.Stack()),
+					)
+					env := model.NewInternalError()
+					if exposeDetails {
+						env.Panic = panicDetail(rec)
+					}
+					WriteError(w, env)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxPanicFrames and maxPanicMessage bound the panic detail in a response.
+const (
+	maxPanicFrames  = 32
+	maxPanicMessage = 1024
+)
+
+// panicDetail describes the panic rec for a response. It must be called
+// from the deferred function that recovered it. Frames are reduced to
+// function, file name and line, so argument values and absolute paths are
+// not exposed, and runtime frames are skipped.
+func panicDetail(rec any) *model.PanicDetail {
+	msg := fmt.Sprint(rec)
+	if len(msg) > maxPanicMessage {
+		msg = msg[:maxPanicMessage] + "..."
+	}
+
+	pcs := make([]uintptr, maxPanicFrames+16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	stack := make([]string, 0, maxPanicFrames)
+	for len(stack) < maxPanicFrames {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, fmt.Sprintf("%s (%s:%d)", frame.Function, path.Base(frame.File), frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return &model.PanicDetail{Message: msg, Stack: stack}
 }
 
 // CORS returns middleware that handles Cross-Origin Resource Sharing based
@@ -108,6 +151,47 @@ func SecurityHeaders(next http.Handler) http.Handler {
 	})
 }
 
+// ResponseHeaders returns middleware that sets the given headers on every
+// response, overriding global defaults such as Cache-Control. Vary values are
+// merged with those added by other middleware (e.g. compression) rather than
+// replacing them.
+func ResponseHeaders(headers map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(headers) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for name, value := range headers {
+				if http.CanonicalHeaderKey(name) == "Vary" {
+					addVary(h, value)
+					continue
+				}
+				h.Set(name, value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// addVary adds each comma-separated field in value to the Vary header unless
+// it is already listed.
+func addVary(h http.Header, value string) {
+	existing := make(map[string]bool)
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			existing[strings.ToLower(strings.TrimSpace(field))] = true
+		}
+	}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" && !existing[strings.ToLower(field)] {
+			h.Add("Vary", field)
+			existing[strings.ToLower(field)] = true
+		}
+	}
+}
+
 // BuildRequestContextMiddleware returns middleware that constructs a
 // model.RequestContext from Frame's security.AuthenticationClaims (set by
 // Frame's AuthenticationMiddleware) and standard request headers.
@@ -132,6 +216,10 @@ func BuildRequestContextMiddleware() func(http.Handler) http.Handler {
 				rctx.SessionID = authClaims.GetSessionID()
 				rctx.Email, _ = authClaims.Ext["email"].(string)
 				rctx.Claims = authClaims.Ext
+				if rctx.Timezone == "" {
+					// OIDC standard claim for the user's time zone.
+					rctx.Timezone, _ = authClaims.Ext["zoneinfo"].(string)
+				}
 			}
 
 			ctx := model.WithRequestContext(r.Context(), rctx)
@@ -140,6 +228,80 @@ func BuildRequestContextMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
+// RequireTenant returns middleware that rejects requests whose
+// RequestContext has no tenant with 401 Unauthorized, so that a token without
+// a tenant claim cannot act outside any tenant's scope. It must run after
+// BuildRequestContextMiddleware. When required is false it is a no-op.
+func RequireTenant(required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !required {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := model.RequestContextFrom(r.Context())
+			if rctx == nil || rctx.TenantID == "" {
+				WriteError(w, model.NewUnauthorizedError("a tenant is required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ApplyTenantLocale returns middleware that fills in the tenant's default
+// currency, and its locale when the request sent no Accept-Language, from
+// cfg. It must run after BuildRequestContextMiddleware.
+func ApplyTenantLocale(cfg config.LocaleConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := model.RequestContextFrom(r.Context())
+			if rctx == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			defaults := cfg.For(rctx.TenantID)
+			if defaults.Currency == "" && (defaults.Locale == "" || rctx.Locale != "") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The request context is immutable once stored; replace it.
+			updated := *rctx
+			updated.Currency = defaults.Currency
+			if updated.Locale == "" {
+				updated.Locale = defaults.Locale
+			}
+			next.ServeHTTP(w, r.WithContext(model.WithRequestContext(r.Context(), &updated)))
+		})
+	}
+}
+
+// ApplyDomainEntitlements returns middleware that records, from cfg, the
+// gated domains the tenant is not entitled to, so providers and the
+// command executor hide and deny them. It must run after
+// BuildRequestContextMiddleware.
+func ApplyDomainEntitlements(cfg config.EntitlementsConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := model.RequestContextFrom(r.Context())
+			if rctx == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			denied := cfg.Unentitled(rctx.TenantID)
+			if len(denied) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The request context is immutable once stored; replace it.
+			updated := *rctx
+			updated.UnentitledDomains = denied
+			next.ServeHTTP(w, r.WithContext(model.WithRequestContext(r.Context(), &updated)))
+		})
+	}
+}
+
 // ResolveCapabilities returns middleware that eagerly resolves capabilities
 // for the current user and stores them in the context. If the authorization
 // service is unavailable the request fails with 502 so the frontend can
@@ -215,6 +377,11 @@ func (w *statusWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *statusWriter) Write(b []byte) (int, error) {
 	if !w.written {
 		w.written = true
@@ -14,16 +14,20 @@ import (
 
 // statusForCode maps ErrorEnvelope codes to HTTP status codes.
 var statusForCode = map[string]int{
-	model.ErrBadRequest:         http.StatusBadRequest,
-	model.ErrUnauthorized:       http.StatusUnauthorized,
-	model.ErrForbidden:          http.StatusForbidden,
-	model.ErrNotFound:           http.StatusNotFound,
-	model.ErrConflict:           http.StatusConflict,
-	model.ErrValidationError:    http.StatusUnprocessableEntity,
-	model.ErrRateLimited:        http.StatusTooManyRequests,
-	model.ErrInternalError:      http.StatusInternalServerError,
-	model.ErrBackendUnavailable: http.StatusBadGateway,
-	model.ErrBackendTimeout:     http.StatusGatewayTimeout,
+	model.ErrBadRequest:           http.StatusBadRequest,
+	model.ErrUnauthorized:         http.StatusUnauthorized,
+	model.ErrForbidden:            http.StatusForbidden,
+	model.ErrNotFound:             http.StatusNotFound,
+	model.ErrConflict:             http.StatusConflict,
+	model.ErrValidationError:      http.StatusUnprocessableEntity,
+	model.ErrConfirmationRequired: http.StatusPreconditionRequired,
+	model.ErrPayloadTooLarge:      http.StatusRequestEntityTooLarge,
+	model.ErrRateLimited:          http.StatusTooManyRequests,
+	model.ErrCommandUnavailable:   http.StatusServiceUnavailable,
+	model.ErrInternalError:        http.StatusInternalServerError,
+	model.ErrBackendUnavailable:   http.StatusBadGateway,
+	model.ErrBackendError:         http.StatusBadGateway,
+	model.ErrBackendTimeout:       http.StatusGatewayTimeout,
 }
 
 // WriteJSON writes a JSON response with the given status code.
@@ -51,6 +55,14 @@ func WriteError(w http.ResponseWriter, err error) {
 		}
 	}
 
+	auditDenial(w, ee)
+
+	if len(ee.RequiredCapabilities) > 0 && hidesCapabilityDetails(w) {
+		redacted := *ee
+		redacted.RequiredCapabilities = nil
+		ee = &redacted
+	}
+
 	status := statusForCode[ee.Code]
 	if status == 0 {
 		status = http.StatusInternalServerError
@@ -76,6 +88,37 @@ func InjectTraceContext(next http.Handler) http.Handler {
 	})
 }
 
+// capabilityRedactWriter marks a response whose error envelopes must not
+// list the caller's missing capabilities.
+type capabilityRedactWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *capabilityRedactWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// CapabilityDetails is middleware controlling whether 403 responses list the
+// missing capabilities. When disabled, WriteError strips them.
+func CapabilityDetails(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&capabilityRedactWriter{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// hidesCapabilityDetails reports whether w, or any writer it wraps, was
+// installed by CapabilityDetails(false).
+func hidesCapabilityDetails(w http.ResponseWriter) bool {
+	_, ok := findWriter[*capabilityRedactWriter](w)
+	return ok
+}
+
 // WriteNotFound writes a 404 error response.
 func WriteNotFound(w http.ResponseWriter, msg string) {
 	WriteError(w, model.NewNotFoundError(msg))
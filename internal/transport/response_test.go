@@ -73,6 +73,48 @@ func TestWriteForbidden(t *testing.T) {
 	}
 }
 
+func TestWriteError_capabilityDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    []string
+	}{
+		{"enabled", true, []string{"orders:list:view"}},
+		{"disabled", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CapabilityDetails(tt.enabled)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				WriteError(w, model.NewInsufficientCapabilitiesError(
+					"insufficient capabilities for page \"orders-list\"",
+					[]string{"orders:list:view"},
+				))
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/ui/pages/orders-list", nil))
+
+			if w.Code != 403 {
+				t.Errorf("status = %d, want 403", w.Code)
+			}
+			var resp struct {
+				Error map[string]any `json:"error"`
+			}
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			got, present := resp.Error["required_capabilities"]
+			if tt.want == nil {
+				if present {
+					t.Errorf("required_capabilities = %v, want absent", got)
+				}
+				return
+			}
+			list, _ := got.([]any)
+			if len(list) != 1 || list[0] != tt.want[0] {
+				t.Errorf("required_capabilities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestWriteValidationError(t *testing.T) {
 	w := httptest.NewRecorder()
 	WriteValidationError(w, []model.FieldError{
@@ -93,10 +135,12 @@ func TestStatusForCode_coverage(t *testing.T) {
 		{model.ErrForbidden, 403},
 		{model.ErrNotFound, 404},
 		{model.ErrConflict, 409},
+		{model.ErrPayloadTooLarge, 413},
 		{model.ErrValidationError, 422},
 		{model.ErrRateLimited, 429},
 		{model.ErrInternalError, 500},
 		{model.ErrBackendUnavailable, 502},
+		{model.ErrBackendError, 502},
 		{model.ErrBackendTimeout, 504},
 	}
 	for _, tc := range codes {
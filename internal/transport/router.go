@@ -25,6 +25,7 @@ type Dependencies struct {
 	CommandExecutor    *command.CommandExecutor
 	SearchProvider     *search.SearchProvider
 	LookupProvider     *search.LookupProvider
+	DenialRecorder     DenialRecorder
 	AppVersion         string
 }
 
@@ -53,51 +54,89 @@ func NewRouter(deps Dependencies) http.Handler {
 	authChain := chainMiddleware(
 		auth,
 		BuildRequestContextMiddleware(),
+		RequireTenant(deps.Config.Capability.RequireTenant),
+		ApplyTenantLocale(deps.Config.Locale),
+		ApplyDomainEntitlements(deps.Config.Entitlements),
 		ResolveCapabilities(deps.CapabilityResolver),
 		HandlerTimeout(deps.Config.Server.HandlerTimeout),
 		RequestLogging,
+		AuditDenials(deps.DenialRecorder),
+		CapabilityDetails(deps.Config.Capability.ForbiddenDetails),
 	)
 
+	// Each route group sets its configured response headers ahead of auth,
+	// so error responses carry them too.
+	group := func(name string) func(http.Handler) http.Handler {
+		return chainMiddleware(ResponseHeaders(deps.Config.Server.ResponseHeaders[name]), authChain)
+	}
+	metadataRoutes := chainMiddleware(
+		group(config.RouteGroupMetadata),
+		ETag(deps.Config.Server.ETags),
+		Explain(deps.Config.Capability.ExplainCapability),
+	)
+	dataRoutes := group(config.RouteGroupData)
+	commandRoutes := group(config.RouteGroupCommands)
+	fileRoutes := group(config.RouteGroupFiles)
+	adminRoutes := group(config.RouteGroupAdmin)
+
 	// Capabilities
-	mux.Handle("GET /ui/capabilities", authChain(handleCapabilities(deps.CapabilityResolver, deps.AppVersion)))
+	mux.Handle("GET /ui/capabilities", metadataRoutes(handleCapabilities(deps.CapabilityResolver, deps.AppVersion)))
 
 	// Navigation & Pages
-	mux.Handle("GET /ui/navigation", authChain(handleNavigation(deps.MenuProvider)))
-	mux.Handle("GET /ui/pages/{pageId}", authChain(handleGetPage(deps.PageProvider)))
-	mux.Handle("GET /ui/pages/{pageId}/data", authChain(handleGetPageData(deps.PageProvider)))
+	mux.Handle("GET /ui/bootstrap", metadataRoutes(handleBootstrap(deps.MenuProvider, deps.PageProvider, deps.AppVersion)))
+	mux.Handle("GET /ui/navigation", metadataRoutes(handleNavigation(deps.MenuProvider)))
+	mux.Handle("GET /ui/pages/{pageId}", metadataRoutes(handleGetPage(deps.PageProvider)))
+	mux.Handle("GET /ui/pages/{pageId}/data", dataRoutes(handleGetPageData(deps.PageProvider)))
+	mux.Handle("GET /ui/pages/{pageId}/filters/{field}/options", dataRoutes(handleGetFilterOptions(deps.PageProvider)))
 
 	// Forms
-	mux.Handle("GET /ui/forms/{formId}", authChain(handleGetForm(deps.FormProvider)))
-	mux.Handle("GET /ui/forms/{formId}/data", authChain(handleGetFormData(deps.FormProvider)))
+	mux.Handle("GET /ui/forms/{formId}", metadataRoutes(handleGetForm(deps.FormProvider)))
+	mux.Handle("GET /ui/forms/{formId}/data", dataRoutes(handleGetFormData(deps.FormProvider)))
 
 	// Schemas
-	mux.Handle("GET /ui/schemas/{schemaId}", authChain(handleGetSchema(deps.SchemaProvider)))
+	mux.Handle("GET /ui/schemas/{schemaId}", metadataRoutes(handleGetSchema(deps.SchemaProvider)))
 
 	// Commands & Actions
-	mux.Handle("POST /ui/commands/{commandId}", authChain(handleCommand(deps.CommandExecutor)))
-	mux.Handle("POST /ui/actions/{actionId}", authChain(handleAction(deps.Registry, deps.CommandExecutor)))
+	mux.Handle("POST /ui/commands/{commandId}", commandRoutes(handleCommand(deps.CommandExecutor)))
+	mux.Handle("POST /ui/commands/{commandId}/confirm", commandRoutes(handleConfirmCommand(deps.CommandExecutor)))
+	mux.Handle("POST /ui/actions/{actionId}", commandRoutes(handleAction(deps.Registry, deps.CommandExecutor)))
+	mux.Handle("GET /ui/jobs/{jobId}", commandRoutes(handleGetJob(deps.CommandExecutor)))
 
 	// Resources
-	mux.Handle("GET /ui/resources/{resourceType}/search", authChain(handleResourceSearch(deps.SearchProvider)))
-	mux.Handle("GET /ui/resources/{resourceType}/{id}", authChain(handleGetResourceItem(deps.ResourceProvider)))
-	mux.Handle("GET /ui/resources/{resourceType}", authChain(handleGetResource(deps.ResourceProvider)))
+	mux.Handle("GET /ui/resources/{resourceType}/search", dataRoutes(handleResourceSearch(deps.SearchProvider)))
+	mux.Handle("GET /ui/resources/{resourceType}/{id}/actions", dataRoutes(handleGetResourceActions(deps.ResourceProvider)))
+	mux.Handle("GET /ui/resources/{resourceType}/{id}", dataRoutes(handleGetResourceItem(deps.ResourceProvider)))
+	mux.Handle("GET /ui/resources/{resourceType}", dataRoutes(handleGetResource(deps.ResourceProvider)))
+	mux.Handle("GET /ui/resolve", dataRoutes(handleResolveRoute(deps.ResourceProvider)))
 
 	// Search & Lookups
-	mux.Handle("GET /ui/search", authChain(handleSearch(deps.SearchProvider)))
-	mux.Handle("GET /ui/lookups/{lookupId}", authChain(handleLookup(deps.LookupProvider)))
+	mux.Handle("GET /ui/search", dataRoutes(handleSearch(deps.SearchProvider)))
+	mux.Handle("GET /ui/search/stream", dataRoutes(handleSearchStream(deps.SearchProvider)))
+	mux.Handle("GET /ui/lookups/{lookupId}", dataRoutes(handleLookup(deps.LookupProvider)))
+
+	// Operator diagnostics
+	mux.Handle("GET /ui/admin/diagnostics", adminRoutes(handleDiagnostics(deps)))
+	mux.Handle("POST /ui/admin/lookups/warm", adminRoutes(handleWarmLookups(
+		deps.LookupProvider, deps.Config.Capability.CacheAdminCapability, deps.Config.Lookup.Warm,
+	)))
+	commandAdmin := deps.Config.Capability.CommandAdminCapability
+	mux.Handle("GET /ui/admin/commands/disabled", adminRoutes(handleListDisabledCommands(deps.Registry, commandAdmin)))
+	mux.Handle("PUT /ui/admin/commands/{commandId}/disabled", adminRoutes(handleSetCommandDisabled(deps.Registry, commandAdmin, true)))
+	mux.Handle("DELETE /ui/admin/commands/{commandId}/disabled", adminRoutes(handleSetCommandDisabled(deps.Registry, commandAdmin, false)))
 
 	// File operations (proxied to files-svc)
 	filesSvc := deps.Config.Services["files-svc"]
-	mux.Handle("POST /ui/upload", authChain(handleUpload(filesSvc)))
-	mux.Handle("GET /ui/download/{fileId}", authChain(handleDownload(filesSvc)))
+	mux.Handle("POST /ui/upload", fileRoutes(handleUpload(filesSvc)))
+	mux.Handle("GET /ui/download/{fileId}", fileRoutes(handleDownload(filesSvc)))
 
 	// Global middleware: applied to all routes.
 	// CORS is handled by the API gateway — not duplicated here.
 	var handler http.Handler = mux
+	handler = Compression(deps.Config.Server.Compression)(handler)
 	handler = InjectTraceContext(handler)
 	handler = SecurityHeaders(handler)
 	handler = RequestID(handler)
-	handler = Recovery(handler)
+	handler = Recovery(deps.Config.Server.PanicDetails)(handler)
 
 	return handler
 }
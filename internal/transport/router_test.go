@@ -2,9 +2,11 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -52,14 +54,25 @@ func TestNewRouter_authenticatedRoutes_areRegistered(t *testing.T) {
 		method string
 		path   string
 	}{
+		{"GET", "/ui/bootstrap"},
 		{"GET", "/ui/navigation"},
 		{"GET", "/ui/pages/orders.list"},
 		{"GET", "/ui/pages/orders.list/data"},
 		{"GET", "/ui/forms/orders.create"},
 		{"GET", "/ui/forms/orders.create/data"},
 		{"POST", "/ui/commands/orders.cancel"},
+		{"POST", "/ui/commands/orders.cancel/confirm"},
+		{"GET", "/ui/jobs/job-1"},
 		{"GET", "/ui/search"},
+		{"GET", "/ui/search/stream"},
 		{"GET", "/ui/lookups/currencies"},
+		{"GET", "/ui/resolve?type=order&id=ord-1"},
+		{"GET", "/ui/resources/orders/ord-1/actions"},
+		{"GET", "/ui/admin/diagnostics"},
+		{"POST", "/ui/admin/lookups/warm"},
+		{"GET", "/ui/admin/commands/disabled"},
+		{"PUT", "/ui/admin/commands/cmd1/disabled"},
+		{"DELETE", "/ui/admin/commands/cmd1/disabled"},
 	}
 
 	for _, tc := range routes {
@@ -76,7 +89,7 @@ func TestNewRouter_authenticatedRoutes_areRegistered(t *testing.T) {
 // --- Middleware tests ---
 
 func TestRecovery_catchesPanic(t *testing.T) {
-	handler := Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Recovery(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		panic("test panic")
 	}))
 
@@ -86,10 +99,44 @@ func TestRecovery_catchesPanic(t *testing.T) {
 	if w.Code != 500 {
 		t.Errorf("status = %d, want 500 after panic", w.Code)
 	}
+	if strings.Contains(w.Body.String(), "test panic") || strings.Contains(w.Body.String(), `"panic"`) {
+		t.Errorf("body = %s, want no panic detail", w.Body.String())
+	}
+}
+
+func TestRecovery_exposesPanicDetails(t *testing.T) {
+	handler := Recovery(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("test panic")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != 500 {
+		t.Fatalf("status = %d, want 500 after panic", w.Code)
+	}
+	var resp struct {
+		Error model.ErrorEnvelope `json:"error"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	detail := resp.Error.Panic
+	if resp.Error.Code != model.ErrInternalError || detail == nil || detail.Message != "test panic" {
+		t.Fatalf("error = %+v, want INTERNAL_ERROR with the panic message", resp.Error)
+	}
+	if len(detail.Stack) == 0 || !strings.Contains(detail.Stack[0], "TestRecovery_exposesPanicDetails") {
+		t.Errorf("stack = %v, want the panicking handler first", detail.Stack)
+	}
+	for _, frame := range detail.Stack {
+		if strings.Contains(frame, "(/") {
+			t.Errorf("frame %q exposes an absolute path", frame)
+		}
+	}
 }
 
 func TestRecovery_passesThrough(t *testing.T) {
-	handler := Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Recovery(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -188,6 +235,66 @@ func TestRequestID_propagated(t *testing.T) {
 	}
 }
 
+func TestNewRouter_routeGroupResponseHeaders(t *testing.T) {
+	deps := testDeps()
+	deps.Authenticate = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			WriteError(w, model.NewUnauthorizedError("rejected"))
+		})
+	}
+	deps.Config.Server.ResponseHeaders = map[string]map[string]string{
+		config.RouteGroupData: {
+			"Cache-Control":      "private, no-cache",
+			"vary":               "Accept-Language, accept-encoding",
+			"X-Content-Language": "negotiated",
+		},
+	}
+	r := NewRouter(deps)
+
+	serve := func(method, path string) http.Header {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	for _, path := range []string{"/ui/pages/orders.list/data", "/ui/search?q=acme", "/ui/lookups/currencies"} {
+		t.Run("data "+path, func(t *testing.T) {
+			h := serve("GET", path)
+			if got := h.Get("Cache-Control"); got != "private, no-cache" {
+				t.Errorf("Cache-Control = %q, want private, no-cache", got)
+			}
+			if got := h.Get("X-Content-Language"); got != "negotiated" {
+				t.Errorf("X-Content-Language = %q, want negotiated", got)
+			}
+			// Composes with compression: Accept-Encoding is listed once.
+			if got := h.Values("Vary"); len(got) != 2 || got[0] != "Accept-Encoding" || got[1] != "Accept-Language" {
+				t.Errorf("Vary = %v, want [Accept-Encoding Accept-Language]", got)
+			}
+		})
+	}
+
+	for _, tc := range []struct{ method, path string }{
+		{"GET", "/ui/pages/orders.list"},
+		{"GET", "/ui/navigation"},
+		{"POST", "/ui/commands/orders.cancel"},
+	} {
+		t.Run("other "+tc.path, func(t *testing.T) {
+			h := serve(tc.method, tc.path)
+			if got := h.Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want the global no-store", got)
+			}
+			if got := h.Get("X-Content-Language"); got != "" {
+				t.Errorf("X-Content-Language = %q, want unset", got)
+			}
+			if got := h.Values("Vary"); len(got) != 1 || got[0] != "Accept-Encoding" {
+				t.Errorf("Vary = %v, want [Accept-Encoding]", got)
+			}
+		})
+	}
+}
+
 func TestSecurityHeaders(t *testing.T) {
 	handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -273,6 +380,109 @@ func TestBuildRequestContextMiddleware_emailFromExt(t *testing.T) {
 	handler.ServeHTTP(w, req)
 }
 
+func TestBuildRequestContextMiddleware_timezone(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		claim  any
+		want   string
+	}{
+		{"header", "Africa/Nairobi", "Europe/Berlin", "Africa/Nairobi"},
+		{"claim fallback", "", "Europe/Berlin", "Europe/Berlin"},
+		{"none", "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authClaims := &security.AuthenticationClaims{TenantID: "tenant-1", Ext: map[string]any{}}
+			if tt.claim != nil {
+				authClaims.Ext["zoneinfo"] = tt.claim
+			}
+
+			var got string
+			handler := BuildRequestContextMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = model.RequestContextFrom(r.Context()).Timezone
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(authClaims.ClaimsToContext(req.Context()))
+			if tt.header != "" {
+				req.Header.Set("X-Timezone", tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("Timezone = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireTenant(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		tenantID string
+		want     int
+	}{
+		{name: "tenant present", required: true, tenantID: "t-1", want: http.StatusOK},
+		{name: "tenant absent", required: true, tenantID: "", want: http.StatusUnauthorized},
+		{name: "not required", required: false, tenantID: "", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := BuildRequestContextMiddleware()(RequireTenant(tt.required)(inner))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(testAuthContext(req.Context(), "user-1", tt.tenantID, nil))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTenantLocale(t *testing.T) {
+	cfg := config.LocaleConfig{
+		Default: config.TenantLocale{Locale: "en-US", Currency: "USD"},
+		Tenants: map[string]config.TenantLocale{"t-eu": {Locale: "de-DE", Currency: "EUR"}},
+	}
+	tests := []struct {
+		name           string
+		tenantID       string
+		acceptLanguage string
+		wantLocale     string
+		wantCurrency   string
+	}{
+		{name: "tenant defaults", tenantID: "t-eu", wantLocale: "de-DE", wantCurrency: "EUR"},
+		{name: "global defaults", tenantID: "t-us", wantLocale: "en-US", wantCurrency: "USD"},
+		{name: "request locale wins", tenantID: "t-eu", acceptLanguage: "fr-FR", wantLocale: "fr-FR", wantCurrency: "EUR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *model.RequestContext
+			inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = model.RequestContextFrom(r.Context())
+			})
+			handler := BuildRequestContextMiddleware()(ApplyTenantLocale(cfg)(inner))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			req = req.WithContext(testAuthContext(req.Context(), "user-1", tt.tenantID, nil))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got.Locale != tt.wantLocale || got.Currency != tt.wantCurrency {
+				t.Errorf("locale, currency = %q, %q; want %q, %q", got.Locale, got.Currency, tt.wantLocale, tt.wantCurrency)
+			}
+		})
+	}
+}
+
 func TestResolveCapabilities(t *testing.T) {
 	resolver := &mockResolver{
 		caps: model.CapabilitySet{"orders:list:view": true},
@@ -2,6 +2,8 @@ package model
 
 import (
 	"context"
+	"maps"
+	"slices"
 	"strings"
 )
 
@@ -12,20 +14,36 @@ type CapabilitySet map[string]bool
 
 // Has returns true if the set contains the exact capability or a wildcard
 // that matches it.
+//
+// Rather than scanning every granted pattern, it looks up the few wildcards
+// that could match: "*" and one "<prefix>:*" per ":" in cap. A check costs
+// one map lookup per capability segment, independent of the set's size, and
+// does not allocate for capabilities up to wildcardBufSize bytes.
 func (cs CapabilitySet) Has(cap string) bool {
-	if cs[cap] {
+	if len(cs) == 0 {
+		return false
+	}
+	if cs[cap] || cs["*"] {
 		return true
 	}
-	// Check wildcard matches: "orders:*" matches "orders:list:view",
-	// "*" matches everything.
-	for pattern := range cs {
-		if matchWildcard(pattern, cap) {
+
+	var buf [wildcardBufSize]byte
+	for i := 0; i < len(cap); i++ {
+		if cap[i] != ':' {
+			continue
+		}
+		// "orders:list:view" → "orders:*", then "orders:list:*".
+		pattern := append(append(buf[:0], cap[:i+1]...), '*')
+		if cs[string(pattern)] {
 			return true
 		}
 	}
 	return false
 }
 
+// wildcardBufSize bounds the stack buffer Has builds wildcard keys in.
+const wildcardBufSize = 128
+
 // HasAll returns true if the set matches all given capabilities (including
 // via wildcards).
 func (cs CapabilitySet) HasAll(caps ...string) bool {
@@ -48,7 +66,62 @@ func (cs CapabilitySet) HasAny(caps ...string) bool {
 	return false
 }
 
+// Missing returns the given capabilities that the set does not match, in
+// the order given.
+func (cs CapabilitySet) Missing(caps ...string) []string {
+	var missing []string
+	for _, cap := range caps {
+		if !cs.Has(cap) {
+			missing = append(missing, cap)
+		}
+	}
+	return missing
+}
+
+// WithAliases returns the set extended with capability aliases, which map
+// an alias to its canonical capability. A canonical capability and all of
+// its aliases are equivalent: if the set matches any one of them, the result
+// contains all of them. The receiver is not modified.
+func (cs CapabilitySet) WithAliases(aliases map[string]string) CapabilitySet {
+	if len(aliases) == 0 || len(cs) == 0 {
+		return cs
+	}
+	out, cloned := cs, false
+	for _, class := range AliasClasses(aliases) {
+		if !cs.HasAny(class...) {
+			continue
+		}
+		for _, c := range class {
+			if out[c] {
+				continue
+			}
+			if !cloned {
+				out, cloned = maps.Clone(cs), true
+			}
+			out[c] = true
+		}
+	}
+	return out
+}
+
+// AliasClasses groups capability aliases by canonical capability. Each class
+// lists the canonical capability first, followed by its aliases.
+func AliasClasses(aliases map[string]string) map[string][]string {
+	classes := make(map[string][]string, len(aliases))
+	for alias, canonical := range aliases {
+		if _, ok := classes[canonical]; !ok {
+			classes[canonical] = []string{canonical}
+		}
+		classes[canonical] = append(classes[canonical], alias)
+	}
+	for _, class := range classes {
+		slices.Sort(class[1:])
+	}
+	return classes
+}
+
 // matchWildcard returns true if pattern (which may end in "*") matches cap.
+// It defines the wildcard semantics that Has implements with direct lookups.
 // Examples:
 //
 //	"*"             matches anything
@@ -1,6 +1,9 @@
 package model
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestCapabilitySet_Has_exact(t *testing.T) {
 	cs := CapabilitySet{
@@ -92,6 +95,17 @@ func TestCapabilitySet_HasAll_wildcard(t *testing.T) {
 	}
 }
 
+func TestCapabilitySet_Missing(t *testing.T) {
+	cs := CapabilitySet{"orders:list:*": true}
+	missing := cs.Missing("orders:list:view", "orders:cancel:execute", "orders:detail:view")
+	if len(missing) != 2 || missing[0] != "orders:cancel:execute" || missing[1] != "orders:detail:view" {
+		t.Errorf("Missing = %v, want [orders:cancel:execute orders:detail:view]", missing)
+	}
+	if got := cs.Missing("orders:list:view"); got != nil {
+		t.Errorf("Missing = %v, want nil when all present", got)
+	}
+}
+
 func TestCapabilitySet_HasAny(t *testing.T) {
 	cs := CapabilitySet{
 		"orders:list:view": true,
@@ -136,3 +150,124 @@ func TestMatchWildcard(t *testing.T) {
 		})
 	}
 }
+
+// hasByScan checks cap by scanning every granted pattern, as Has did before
+// it switched to direct wildcard lookups. It is the reference for
+// TestCapabilitySet_Has_matchesScan and the baseline for the benchmarks.
+func hasByScan(cs CapabilitySet, cap string) bool {
+	if cs[cap] {
+		return true
+	}
+	for pattern, granted := range cs {
+		if granted && matchWildcard(pattern, cap) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCapabilitySet_Has_matchesScan(t *testing.T) {
+	sets := []CapabilitySet{
+		{},
+		{"*": true},
+		{"orders:*": true},
+		{"orders:list:*": true, "inventory:detail:view": true},
+		{"orders:*": false, "orders:list:view": true},
+		{":*": true},
+		{"orders": true, "orders:list": true},
+	}
+	caps := []string{
+		"orders", "orders:", "orders:list", "orders:list:view", "orders:list:export:csv",
+		"orders:detail:view", "inventory:detail:view", "inventory:list:view", ":odd", "",
+	}
+	for i, cs := range sets {
+		for _, cap := range caps {
+			if got, want := cs.Has(cap), hasByScan(cs, cap); got != want {
+				t.Errorf("set %d: Has(%q) = %v, want %v", i, cap, got, want)
+			}
+		}
+	}
+}
+
+func TestCapabilitySet_Has_longCapability(t *testing.T) {
+	long := "orders:" + string(make([]byte, wildcardBufSize)) + ":view"
+	cs := CapabilitySet{"orders:*": true}
+	if !cs.Has(long) {
+		t.Error("orders:* should match a capability longer than the stack buffer")
+	}
+}
+
+// benchmarkSet returns a realistic capability set: n exact grants across
+// several domains plus a resource-level wildcard.
+func benchmarkSet(n int) CapabilitySet {
+	cs := make(CapabilitySet, n+1)
+	for i := range n {
+		cs[fmt.Sprintf("domain%d:resource%d:view", i%20, i)] = true
+	}
+	cs["orders:list:*"] = true
+	return cs
+}
+
+func BenchmarkCapabilitySet_Has(b *testing.B) {
+	cs := benchmarkSet(500)
+	checks := []string{"orders:list:view", "orders:cancel:execute", "domain3:resource3:view"}
+
+	b.Run("lookup", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for _, c := range checks {
+				cs.Has(c)
+			}
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for _, c := range checks {
+				hasByScan(cs, c)
+			}
+		}
+	})
+}
+
+func BenchmarkCapabilitySet_HasAll(b *testing.B) {
+	cs := benchmarkSet(500)
+	b.ReportAllocs()
+	for b.Loop() {
+		cs.HasAll("orders:list:view", "orders:list:export", "domain1:resource1:view")
+	}
+}
+
+func TestCapabilitySet_WithAliases(t *testing.T) {
+	aliases := map[string]string{"orders:view": "orders:read", "orders:list": "orders:read"}
+	tests := []struct {
+		name    string
+		granted CapabilitySet
+		want    []string
+		notWant []string
+	}{
+		{name: "alias grants canonical", granted: CapabilitySet{"orders:view": true}, want: []string{"orders:read", "orders:list"}},
+		{name: "canonical grants alias", granted: CapabilitySet{"orders:read": true}, want: []string{"orders:view", "orders:list"}},
+		{name: "wildcard grants class", granted: CapabilitySet{"orders:*": true}, want: []string{"orders:read", "orders:view"}},
+		{name: "unrelated", granted: CapabilitySet{"customers:read": true}, notWant: []string{"orders:read", "orders:view"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := tt.granted.WithAliases(aliases)
+			if missing := cs.Missing(tt.want...); len(missing) > 0 {
+				t.Errorf("WithAliases() missing %v", missing)
+			}
+			for _, c := range tt.notWant {
+				if cs.Has(c) {
+					t.Errorf("WithAliases() has %q", c)
+				}
+			}
+		})
+	}
+
+	granted := CapabilitySet{"orders:view": true}
+	granted.WithAliases(aliases)
+	if len(granted) != 1 {
+		t.Errorf("WithAliases() modified the receiver: %v", granted)
+	}
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Holds evaluates the condition against data server-side, ignoring its
+// effect. It is shared by everything that has data to evaluate against:
+// action conditions on resources, column format rules on rows, navigation
+// conditions on the caller's claims, and command operation and message
+// selection.
+//
+// Supported operators: eq, ne, in, not_in, gt, gte, lt, lte, exists and
+// not_exists (plus the aliases equals, neq, not_equals, == and !=). A
+// missing field satisfies only not_exists.
+func (c ConditionDefinition) Holds(data map[string]any) bool {
+	fieldVal, exists := data[c.Field]
+
+	switch c.Operator {
+	case "exists":
+		return exists
+	case "not_exists":
+		return !exists
+	}
+	if !exists {
+		return false
+	}
+
+	condValue := c.Value
+
+	switch c.Operator {
+	case "eq", "equals", "==":
+		return fmt.Sprint(fieldVal) == fmt.Sprint(condValue)
+	case "ne", "neq", "not_equals", "!=":
+		return fmt.Sprint(fieldVal) != fmt.Sprint(condValue)
+	case "in":
+		return valueInSlice(fieldVal, condValue)
+	case "not_in":
+		return !valueInSlice(fieldVal, condValue)
+	case "gt":
+		cmp, ok := compareNumeric(fieldVal, condValue)
+		return ok && cmp > 0
+	case "gte":
+		cmp, ok := compareNumeric(fieldVal, condValue)
+		return ok && cmp >= 0
+	case "lt":
+		cmp, ok := compareNumeric(fieldVal, condValue)
+		return ok && cmp < 0
+	case "lte":
+		cmp, ok := compareNumeric(fieldVal, condValue)
+		return ok && cmp <= 0
+	default:
+		return false
+	}
+}
+
+// ConditionsHold reports whether every condition holds for data, ignoring
+// condition effects.
+func ConditionsHold(conds []ConditionDefinition, data map[string]any) bool {
+	for _, cond := range conds {
+		if !cond.Holds(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNumeric compares two values numerically, returning -1, 0 or 1 and
+// false if either value is not a number.
+func compareNumeric(a, b any) (int, bool) {
+	af, ok := toFloat(a)
+	if !ok {
+		return 0, false
+	}
+	bf, ok := toFloat(b)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case af < bf:
+		return -1, true
+	case af > bf:
+		return 1, true
+	}
+	return 0, true
+}
+
+// toFloat converts numeric values (and numeric strings) to float64.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// valueInSlice checks if fieldVal matches any value in condValue (expected to be a slice-like value).
+func valueInSlice(fieldVal, condValue any) bool {
+	// condValue could be a comma-separated string or a slice.
+	fieldStr := fmt.Sprint(fieldVal)
+
+	switch cv := condValue.(type) {
+	case []any:
+		for _, v := range cv {
+			if fmt.Sprint(v) == fieldStr {
+				return true
+			}
+		}
+	case string:
+		// Treat as comma-separated list.
+		for _, v := range splitComma(cv) {
+			if v == fieldStr {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitComma splits a string by commas and trims whitespace.
+func splitComma(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var parts []string
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == ',' {
+			part := trimSpace(s[start:i])
+			if part != "" {
+				parts = append(parts, part)
+			}
+			start = i + 1
+		}
+	}
+	return parts
+}
+
+// trimSpace trims leading and trailing spaces.
+func trimSpace(s string) string {
+	i := 0
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	j := len(s)
+	for j > i && s[j-1] == ' ' {
+		j--
+	}
+	return s[i:j]
+}
@@ -0,0 +1,96 @@
+package model
+
+import "testing"
+
+func TestConditionDefinition_Holds_operators(t *testing.T) {
+	data := map[string]any{
+		"status": "pending",
+		"amount": float64(250),
+		"count":  "12",
+	}
+	tests := []struct {
+		operator string
+		field    string
+		value    any
+		want     bool
+	}{
+		{"eq", "status", "pending", true},
+		{"eq", "status", "shipped", false},
+		{"ne", "status", "shipped", true},
+		{"ne", "status", "pending", false},
+		{"neq", "status", "shipped", true},
+		{"in", "status", []any{"pending", "draft"}, true},
+		{"in", "status", "draft, pending", true},
+		{"in", "status", []any{"shipped"}, false},
+		{"not_in", "status", []any{"shipped"}, true},
+		{"gt", "amount", 100, true},
+		{"gt", "amount", 250, false},
+		{"gte", "amount", 250, true},
+		{"lt", "amount", 300, true},
+		{"lt", "count", 10, false},
+		{"lte", "count", 12, true},
+		{"gt", "status", 1, false},
+		{"exists", "status", nil, true},
+		{"exists", "missing", nil, false},
+		{"not_exists", "missing", nil, true},
+		{"eq", "missing", "", false},
+		{">", "amount", 100, false},
+		{"unknown", "status", "pending", false},
+	}
+	for _, tt := range tests {
+		cond := ConditionDefinition{Field: tt.field, Operator: tt.operator, Value: tt.value}
+		if got := cond.Holds(data); got != tt.want {
+			t.Errorf("Holds(%s %s %v) = %v, want %v", tt.field, tt.operator, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestConditionDefinition_Holds_nilData(t *testing.T) {
+	if (ConditionDefinition{Field: "x", Operator: "exists"}).Holds(nil) {
+		t.Error("exists on nil data = true, want false")
+	}
+	if !(ConditionDefinition{Field: "x", Operator: "not_exists"}).Holds(nil) {
+		t.Error("not_exists on nil data = false, want true")
+	}
+}
+
+func TestConditionsHold(t *testing.T) {
+	data := map[string]any{"plan": "pro", "seats": 5.0}
+	conds := []ConditionDefinition{
+		{Field: "plan", Operator: "eq", Value: "pro", Effect: "hide"},
+		{Field: "seats", Operator: "gte", Value: 5},
+	}
+	if !ConditionsHold(conds, data) {
+		t.Error("ConditionsHold = false, want true regardless of effects")
+	}
+	if ConditionsHold(append(conds, ConditionDefinition{Field: "plan", Operator: "eq", Value: "free"}), data) {
+		t.Error("ConditionsHold = true, want false when one condition fails")
+	}
+	if !ConditionsHold(nil, data) {
+		t.Error("ConditionsHold(nil) = false, want true")
+	}
+}
+
+func TestSplitComma(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"a,b,c", []string{"a", "b", "c"}},
+		{"a, b , c", []string{"a", "b", "c"}},
+		{"single", []string{"single"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := splitComma(tt.input)
+		if len(got) != len(tt.want) {
+			t.Errorf("splitComma(%q) = %v, want %v", tt.input, got, tt.want)
+			continue
+		}
+		for i, v := range got {
+			if v != tt.want[i] {
+				t.Errorf("splitComma(%q)[%d] = %q, want %q", tt.input, i, v, tt.want[i])
+			}
+		}
+	}
+}
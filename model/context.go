@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 // RequestContext carries all identity, tenancy, and tracing information for the
@@ -23,7 +27,11 @@ type RequestContext struct {
 	SpanID        string
 	Locale        string
 	Timezone      string
+	Currency      string // Tenant's default ISO 4217 currency code.
 	Token         string // Original Bearer token, for forwarding to backends.
+	// UnentitledDomains lists the domains the tenant has not been granted.
+	// They are hidden and denied regardless of capabilities.
+	UnentitledDomains []string
 }
 
 // Validate checks that all mandatory fields are present.
@@ -60,6 +68,72 @@ func (rc *RequestContext) Claim(key string) any {
 	return rc.Claims[key]
 }
 
+// Attribute returns a request context attribute by name: subject_id,
+// tenant_id, partition_id, email, session_id, device_id, locale, timezone,
+// or claims.<key> for a string or numeric claim. It reports false for an
+// unknown name or an absent claim.
+func (rc *RequestContext) Attribute(name string) (string, bool) {
+	if rc == nil {
+		return "", false
+	}
+	switch name {
+	case "subject_id":
+		return rc.SubjectID, true
+	case "tenant_id":
+		return rc.TenantID, true
+	case "partition_id":
+		return rc.PartitionID, true
+	case "email":
+		return rc.Email, true
+	case "session_id":
+		return rc.SessionID, true
+	case "device_id":
+		return rc.DeviceID, true
+	case "locale":
+		return rc.Locale, true
+	case "timezone":
+		return rc.Timezone, true
+	}
+	key, ok := strings.CutPrefix(name, "claims.")
+	if !ok {
+		return "", false
+	}
+	switch v := rc.Claim(key).(type) {
+	case string:
+		return v, true
+	case float64, int, int64, bool:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// EntitledTo reports whether the tenant may use the given domain.
+func (rc *RequestContext) EntitledTo(domain string) bool {
+	return rc == nil || !slices.Contains(rc.UnentitledDomains, domain)
+}
+
+// Location returns the user's time zone for server-side date computations,
+// falling back to UTC when none was supplied or it is not a known IANA zone.
+func (rc *RequestContext) Location() *time.Location {
+	if rc == nil || rc.Timezone == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(rc.Timezone); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(rc.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(rc.Timezone, loc)
+	return loc
+}
+
+// locations caches loaded time zones by name, so the zoneinfo database is
+// read once per zone rather than once per request. Unknown names are not
+// cached; the set of valid zones bounds its size.
+var locations sync.Map
+
 type contextKey struct{}
 
 // WithRequestContext attaches a RequestContext to the given context.
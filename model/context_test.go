@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 func TestRequestContext_Validate(t *testing.T) {
@@ -96,6 +97,60 @@ func TestRequestContext_Claim_nil_map(t *testing.T) {
 	}
 }
 
+func TestRequestContext_Attribute(t *testing.T) {
+	rc := &RequestContext{
+		TenantID: "acme",
+		Locale:   "fr-FR",
+		Claims:   map[string]any{"region": "eu", "tier": float64(2), "groups": []any{"a"}},
+	}
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "tenant_id", want: "acme", wantOK: true},
+		{name: "locale", want: "fr-FR", wantOK: true},
+		{name: "subject_id", want: "", wantOK: true},
+		{name: "claims.region", want: "eu", wantOK: true},
+		{name: "claims.tier", want: "2", wantOK: true},
+		{name: "claims.groups", wantOK: false},
+		{name: "claims.missing", wantOK: false},
+		{name: "roles", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := rc.Attribute(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Attribute(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	var nilRC *RequestContext
+	if _, ok := nilRC.Attribute("tenant_id"); ok {
+		t.Error("Attribute on nil RequestContext reported ok")
+	}
+}
+
+func TestRequestContext_Location(t *testing.T) {
+	rc := &RequestContext{Timezone: "Europe/Paris"}
+	first := rc.Location()
+	if first.String() != "Europe/Paris" {
+		t.Fatalf("Location() = %q, want Europe/Paris", first)
+	}
+	if second := rc.Location(); second != first {
+		t.Error("Location() loaded the zone again, want the cached *time.Location")
+	}
+
+	for _, tz := range []string{"", "Not/AZone"} {
+		rc := &RequestContext{Timezone: tz}
+		if got := rc.Location(); got != time.UTC {
+			t.Errorf("Location() for %q = %q, want UTC", tz, got)
+		}
+	}
+	if _, ok := locations.Load("Not/AZone"); ok {
+		t.Error("unknown zone was cached")
+	}
+}
+
 func TestWithRequestContext_and_RequestContextFrom(t *testing.T) {
 	rctx := &RequestContext{
 		SubjectID: "user-1",
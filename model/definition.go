@@ -1,5 +1,11 @@
 package model
 
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
 // DomainDefinition is the root structure of a definition file. Each file
 // declares one domain's pages, forms, commands, workflows, searches, and lookups.
 type DomainDefinition struct {
@@ -25,6 +31,8 @@ type NavigationDefinition struct {
 	Order        int                         `yaml:"order"        json:"order"`
 	Capabilities []string                    `yaml:"capabilities" json:"capabilities"`
 	Children     []NavigationChildDefinition `yaml:"children"     json:"children"`
+	// OrderOverrides replace Order for matching callers; see OrderOverride.
+	OrderOverrides []OrderOverride `yaml:"order_overrides" json:"order_overrides,omitempty"`
 }
 
 // NavigationChildDefinition describes a child navigation item in the menu.
@@ -36,6 +44,21 @@ type NavigationChildDefinition struct {
 	Capabilities []string         `yaml:"capabilities" json:"capabilities"`
 	Order        int              `yaml:"order"        json:"order"`
 	Badge        *BadgeDefinition `yaml:"badge"        json:"badge,omitempty"`
+	// Conditions with effect show/hide are evaluated against the caller's
+	// token claims; the item is omitted when they are not met.
+	Conditions []ConditionDefinition `yaml:"conditions" json:"conditions,omitempty"`
+	// OrderOverrides replace Order for matching callers; see OrderOverride.
+	OrderOverrides []OrderOverride `yaml:"order_overrides" json:"order_overrides,omitempty"`
+}
+
+// OrderOverride gives a navigation item a different sort order for some
+// callers, e.g. to put an approver's most-used items first. It matches a
+// caller holding any of Roles and all of Capabilities; an empty list does
+// not restrict. The first matching override applies.
+type OrderOverride struct {
+	Roles        []string `yaml:"roles"        json:"roles,omitempty"`
+	Capabilities []string `yaml:"capabilities" json:"capabilities,omitempty"`
+	Order        int      `yaml:"order"        json:"order"`
 }
 
 // BadgeDefinition describes a count badge on a navigation item.
@@ -47,10 +70,13 @@ type BadgeDefinition struct {
 
 // PageDefinition describes a page visible in the UI.
 type PageDefinition struct {
-	ID              string              `yaml:"id"               json:"id"`
-	Title           string              `yaml:"title"            json:"title"`
-	Route           string              `yaml:"route"            json:"route"`
-	Layout          string              `yaml:"layout"           json:"layout"`
+	ID     string `yaml:"id"               json:"id"`
+	Title  string `yaml:"title"            json:"title"`
+	Route  string `yaml:"route"            json:"route"`
+	Layout string `yaml:"layout"           json:"layout"`
+	// ResourceType is the resource type a detail page shows, for route
+	// resolution. Without it the page ID prefix is the resource type.
+	ResourceType    string              `yaml:"resource_type"    json:"-"`
 	Capabilities    []string            `yaml:"capabilities"     json:"capabilities"`
 	RefreshInterval int                 `yaml:"refresh_interval" json:"refresh_interval,omitempty"`
 	Breadcrumb      []BreadcrumbItem    `yaml:"breadcrumb"       json:"breadcrumb,omitempty"`
@@ -76,6 +102,9 @@ type TableDefinition struct {
 	SortDir     string               `yaml:"sort_dir"     json:"sort_dir,omitempty"`
 	PageSize    int                  `yaml:"page_size"    json:"page_size,omitempty"`
 	Selectable  bool                 `yaml:"selectable"   json:"selectable,omitempty"`
+	// EvaluateFormatRules pre-evaluates column format rules for each row in
+	// the data response, in addition to sending them in the descriptor.
+	EvaluateFormatRules bool `yaml:"evaluate_format_rules" json:"evaluate_format_rules,omitempty"`
 }
 
 // DataSourceDefinition describes how to fetch data from a backend service.
@@ -84,13 +113,44 @@ type DataSourceDefinition struct {
 	ServiceID   string                    `yaml:"service_id"   json:"service_id,omitempty"`
 	Handler     string                    `yaml:"handler"      json:"handler,omitempty"`
 	Mapping     ResponseMappingDefinition `yaml:"mapping"      json:"mapping"`
+	// Optional marks a section data source whose failure degrades the page
+	// (the section is reported as unavailable) instead of failing it.
+	Optional bool `yaml:"optional" json:"optional,omitempty"`
 }
 
 // ResponseMappingDefinition describes how to transform a backend response.
+// ItemsPaths and TotalPaths list fallback candidates tried in order after
+// ItemsPath and TotalPath, so one mapping can serve services that report
+// the same data under different keys.
 type ResponseMappingDefinition struct {
-	ItemsPath string            `yaml:"items_path" json:"items_path"`
-	TotalPath string            `yaml:"total_path" json:"total_path,omitempty"`
-	FieldMap  map[string]string `yaml:"field_map"  json:"field_map,omitempty"`
+	ItemsPath  string            `yaml:"items_path"  json:"items_path"`
+	ItemsPaths []string          `yaml:"items_paths" json:"items_paths,omitempty"`
+	TotalPath  string            `yaml:"total_path"  json:"total_path,omitempty"`
+	TotalPaths []string          `yaml:"total_paths" json:"total_paths,omitempty"`
+	FieldMap   map[string]string `yaml:"field_map"   json:"field_map,omitempty"`
+}
+
+// ItemsCandidates returns the items paths to try, in priority order.
+func (m ResponseMappingDefinition) ItemsCandidates() []string {
+	return pathCandidates(m.ItemsPath, m.ItemsPaths)
+}
+
+// TotalCandidates returns the total count paths to try, in priority order.
+func (m ResponseMappingDefinition) TotalCandidates() []string {
+	return pathCandidates(m.TotalPath, m.TotalPaths)
+}
+
+func pathCandidates(primary string, fallbacks []string) []string {
+	var out []string
+	if primary != "" {
+		out = append(out, primary)
+	}
+	for _, p := range fallbacks {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
 }
 
 // ColumnDefinition describes a table column.
@@ -105,6 +165,23 @@ type ColumnDefinition struct {
 	Width      string            `yaml:"width"      json:"width,omitempty"`
 	Link       *LinkDefinition   `yaml:"link"       json:"link,omitempty"`
 	StatusMap  map[string]string `yaml:"status_map" json:"status_map,omitempty"`
+	// FormatRules are evaluated in order against the column value; the first
+	// matching rule's style applies.
+	FormatRules []FormatRuleDefinition `yaml:"format_rules" json:"format_rules,omitempty"`
+	// Compute derives the column value server-side from the RFC3339
+	// timestamp in Source: "days_since" or "days_until", counted in calendar
+	// days in the user's time zone.
+	Compute string `yaml:"compute" json:"compute,omitempty"`
+	Source  string `yaml:"source"  json:"source,omitempty"`
+}
+
+// FormatRuleDefinition maps a condition on a column value to a display style.
+// Operators are those supported by action conditions, including the numeric
+// comparisons gt, gte, lt and lte.
+type FormatRuleDefinition struct {
+	Operator string `yaml:"operator" json:"operator"`
+	Value    any    `yaml:"value"    json:"value"`
+	Style    string `yaml:"style"    json:"style"`
 }
 
 // LinkDefinition describes a clickable link within a table cell.
@@ -127,6 +204,10 @@ type FilterDefinition struct {
 type FilterOptionsDefinition struct {
 	LookupID string         `yaml:"lookup_id" json:"lookup_id,omitempty"`
 	Static   []StaticOption `yaml:"static"    json:"static,omitempty"`
+	// Params maps lookup parameters to the filters in the same table whose
+	// current values supply them, making the options depend on those
+	// filters.
+	Params map[string]string `yaml:"params" json:"params,omitempty"`
 }
 
 // StaticOption is a label/value pair for dropdowns and filters.
@@ -145,6 +226,9 @@ type SectionDefinition struct {
 	Collapsible  bool              `yaml:"collapsible"  json:"collapsible,omitempty"`
 	Collapsed    bool              `yaml:"collapsed"    json:"collapsed,omitempty"`
 	Fields       []FieldDefinition `yaml:"fields"       json:"fields"`
+	// DataSource optionally loads the section's data independently of the
+	// page table.
+	DataSource *DataSourceDefinition `yaml:"data_source" json:"data_source,omitempty"`
 }
 
 // FieldDefinition describes a single field in a section or form.
@@ -162,6 +246,50 @@ type FieldDefinition struct {
 	HelpText    string                `yaml:"help_text"   json:"help_text,omitempty"`
 	Span        int                   `yaml:"span"        json:"span,omitempty"`
 	DependsOn   []FieldDependency     `yaml:"depends_on"  json:"depends_on,omitempty"`
+	// Mask hides a sensitive value in form load data. A masked value
+	// submitted back unchanged is dropped from the command input, so the
+	// backend keeps the stored value.
+	Mask *MaskDefinition `yaml:"mask" json:"mask,omitempty"`
+}
+
+// MaskDefinition masks all but the trailing characters of a field value,
+// e.g. "*****1234" for a card number.
+type MaskDefinition struct {
+	// ShowLast is the number of trailing characters left visible.
+	ShowLast int `yaml:"show_last" json:"show_last,omitempty"`
+	// Char replaces the hidden characters. Defaults to "*".
+	Char string `yaml:"char" json:"char,omitempty"`
+}
+
+// DefaultMaskChar is the mask character used when MaskDefinition.Char is
+// empty.
+const DefaultMaskChar = "*"
+
+// Apply masks value, keeping its last ShowLast characters. A value no longer
+// than ShowLast is masked entirely.
+func (m MaskDefinition) Apply(value string) string {
+	runes := []rune(value)
+	hidden := len(runes) - m.ShowLast
+	if hidden <= 0 {
+		hidden = len(runes)
+	}
+	return strings.Repeat(m.char(), hidden) + string(runes[hidden:])
+}
+
+// IsMasked reports whether value is shaped like a masked value produced by
+// Apply, i.e. at least one mask character followed by at most ShowLast
+// others. Whether it is the rendering of a particular value can only be
+// told by comparing it with Apply of that value.
+func (m MaskDefinition) IsMasked(value string) bool {
+	rest := strings.TrimLeft(value, m.char())
+	return rest != value && len([]rune(rest)) <= m.ShowLast
+}
+
+func (m MaskDefinition) char() string {
+	if m.Char == "" {
+		return DefaultMaskChar
+	}
+	return m.Char
 }
 
 // ValidationDefinition describes validation rules for a field.
@@ -178,6 +306,10 @@ type ValidationDefinition struct {
 type LookupRefDefinition struct {
 	LookupID string         `yaml:"lookup_id" json:"lookup_id,omitempty"`
 	Static   []StaticOption `yaml:"static"    json:"static,omitempty"`
+	// Params maps lookup parameters to the input fields whose submitted
+	// values supply them when a submitted value is checked against the
+	// lookup's options.
+	Params map[string]string `yaml:"params" json:"params,omitempty"`
 }
 
 // FieldDependency describes a dependency between fields.
@@ -187,16 +319,29 @@ type FieldDependency struct {
 	Value     string `yaml:"value"     json:"value,omitempty"`
 }
 
-// FormDefinition describes an input form.
+// FormDefinition describes an input form. A form submits either to
+// SubmitCommand or, when SubmitActions is set, through one button per
+// submit action.
 type FormDefinition struct {
-	ID             string                `yaml:"id"              json:"id"`
-	Title          string                `yaml:"title"           json:"title"`
-	Capabilities   []string              `yaml:"capabilities"    json:"capabilities"`
-	SubmitCommand  string                `yaml:"submit_command"  json:"submit_command"`
-	LoadSource     *DataSourceDefinition `yaml:"load_source"     json:"load_source,omitempty"`
-	SuccessRoute   string                `yaml:"success_route"   json:"success_route,omitempty"`
-	SuccessMessage string                `yaml:"success_message" json:"success_message,omitempty"`
-	Sections       []SectionDefinition   `yaml:"sections"        json:"sections"`
+	ID             string                   `yaml:"id"              json:"id"`
+	Title          string                   `yaml:"title"           json:"title"`
+	Capabilities   []string                 `yaml:"capabilities"    json:"capabilities"`
+	SubmitCommand  string                   `yaml:"submit_command"  json:"submit_command"`
+	SubmitActions  []SubmitActionDefinition `yaml:"submit_actions"  json:"submit_actions,omitempty"`
+	LoadSource     *DataSourceDefinition    `yaml:"load_source"     json:"load_source,omitempty"`
+	SuccessRoute   string                   `yaml:"success_route"   json:"success_route,omitempty"`
+	SuccessMessage string                   `yaml:"success_message" json:"success_message,omitempty"`
+	Sections       []SectionDefinition      `yaml:"sections"        json:"sections"`
+}
+
+// SubmitActionDefinition describes a form submit button bound to its own
+// command (e.g. "Save" and "Save and Submit").
+type SubmitActionDefinition struct {
+	ID           string   `yaml:"id"           json:"id"`
+	Label        string   `yaml:"label"        json:"label"`
+	Style        string   `yaml:"style"        json:"style,omitempty"`
+	CommandID    string   `yaml:"command_id"   json:"command_id"`
+	Capabilities []string `yaml:"capabilities" json:"capabilities,omitempty"`
 }
 
 // ActionDefinition describes a UI action (button, menu item).
@@ -240,15 +385,87 @@ type CommandDefinition struct {
 	Input        InputMapping       `yaml:"input"        json:"input"`
 	Output       OutputMapping      `yaml:"output"       json:"output"`
 	Idempotency  *IdempotencyConfig `yaml:"idempotency"  json:"idempotency,omitempty"`
-}
-
-// OperationBinding describes the backend operation to invoke.
+	Limits       *InputLimits       `yaml:"limits"       json:"limits,omitempty"`
+	// Upload marks the command as accepting a raw (non-JSON) request body that
+	// is streamed to the backend instead of being decoded into Input, or a
+	// multipart request whose files are forwarded alongside the mapped body.
+	Upload bool `yaml:"upload" json:"upload,omitempty"`
+	// Operations selects the backend operation by input: the first rule
+	// whose conditions all hold on the command input replaces Operation,
+	// which applies when none match.
+	Operations []ConditionalOperation `yaml:"operations" json:"operations,omitempty"`
+	// RequireConfirmation rejects the command unless the input carries a
+	// confirmation token minted for this command, caller and input.
+	RequireConfirmation bool `yaml:"require_confirmation" json:"require_confirmation,omitempty"`
+	// StrictInput rejects a request body with fields the operation's OpenAPI
+	// request schema does not declare, instead of forwarding them.
+	StrictInput bool `yaml:"strict_input" json:"strict_input,omitempty"`
+	// Async queues the backend call as a job and answers at once with the
+	// job ID, for commands that run longer than a request should wait.
+	Async bool `yaml:"async" json:"async,omitempty"`
+}
+
+// ConditionalOperation binds a command to an operation when its conditions
+// hold on the command input, e.g. a separate endpoint per order type.
+type ConditionalOperation struct {
+	When      []ConditionDefinition `yaml:"when"      json:"when"`
+	Operation OperationBinding      `yaml:"operation" json:"operation"`
+}
+
+// InputLimits bounds the size of a command's input payload. Zero means
+// unlimited.
+type InputLimits struct {
+	// MaxArrayLength caps the number of elements in any array in the input.
+	MaxArrayLength int `yaml:"max_array_length" json:"max_array_length,omitempty"`
+	// MaxFields caps the total number of object fields in the input,
+	// counted across all nesting levels.
+	MaxFields int `yaml:"max_fields" json:"max_fields,omitempty"`
+}
+
+// OperationBinding describes the backend operation to invoke. Type is
+// "openapi", "sdk", or "noop" for commands that call no backend.
 type OperationBinding struct {
 	Type        string `yaml:"type"         json:"type"`
 	OperationID string `yaml:"operation_id" json:"operation_id,omitempty"`
 	ServiceID   string `yaml:"service_id"   json:"service_id,omitempty"`
 	Handler     string `yaml:"handler"      json:"handler,omitempty"`
-}
+	// ContentType selects the request body encoding for openapi bindings,
+	// overriding the service default. See ContentTypeJSON and
+	// ContentTypeForm.
+	ContentType string `yaml:"content_type" json:"content_type,omitempty"`
+	// RequestEnvelope wraps the mapped request body for backends that expect
+	// a fixed envelope, e.g. {"method": "orders.cancel", "params": "{{body}}"}.
+	// String values may contain {{body}} or {{body.<path>}} placeholders; a
+	// value that is exactly one placeholder keeps the referenced value's type.
+	RequestEnvelope map[string]any `yaml:"request_envelope" json:"request_envelope,omitempty"`
+	// Timeout overrides the service timeout for this operation, for slow
+	// endpoints on otherwise fast services (Go duration format, e.g. "60s").
+	// It bounds each attempt of openapi bindings.
+	Timeout string `yaml:"timeout" json:"timeout,omitempty"`
+	// StreamResponse passes successful non-JSON responses of openapi
+	// bindings, such as CSV or PDF exports, through to the client without
+	// buffering them. Commands honour it; other consumers ignore it.
+	StreamResponse bool `yaml:"stream_response" json:"stream_response,omitempty"`
+}
+
+// TimeoutDuration parses Timeout. It returns zero when Timeout is empty,
+// meaning the service timeout applies.
+func (b OperationBinding) TimeoutDuration() (time.Duration, error) {
+	if b.Timeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(b.Timeout)
+}
+
+// EnvelopePlaceholder matches a {{...}} placeholder in a request envelope
+// string and captures the reference inside it.
+var EnvelopePlaceholder = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// Request body encodings for OperationBinding.ContentType.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
 
 // InputMapping describes how to map frontend input to a backend request.
 type InputMapping struct {
@@ -266,7 +483,81 @@ type OutputMapping struct {
 	Fields         map[string]string `yaml:"fields"          json:"fields,omitempty"`
 	ErrorMap       map[string]string `yaml:"error_map"       json:"error_map,omitempty"`
 	SuccessMessage string            `yaml:"success_message" json:"success_message,omitempty"`
-}
+	// SuccessMessages are checked in order against the mapped result; the
+	// first whose conditions all hold supplies the message. SuccessMessage
+	// is used when none match.
+	SuccessMessages []SuccessMessageRule `yaml:"success_messages" json:"success_messages,omitempty"`
+	// ExpectedStatus is the 2xx status the backend is expected to return.
+	// Zero accepts any 2xx status.
+	ExpectedStatus int `yaml:"expected_status" json:"expected_status,omitempty"`
+	// OnUnexpectedStatus controls how a 2xx status other than ExpectedStatus
+	// is handled: "warn" (default) logs and treats it as success, "fail"
+	// fails the command with BACKEND_ERROR (502).
+	OnUnexpectedStatus string `yaml:"on_unexpected_status" json:"on_unexpected_status,omitempty"`
+	// Echo maps result fields to input expressions (input.*, route.*,
+	// context.*) that are merged into the backend output.
+	Echo map[string]string `yaml:"echo" json:"echo,omitempty"`
+	// Merge controls how a key present in both the backend output and Echo
+	// is resolved: "backend-wins" (default), "input-wins" or
+	// "error-on-conflict". A conflict is detected after the backend has
+	// applied the command, so it fails with BACKEND_ERROR (502) and a message
+	// saying the change was applied.
+	Merge string `yaml:"merge" json:"merge,omitempty"`
+	// Resource opts in to returning the updated resource from the backend
+	// response so the UI can refresh without re-fetching it.
+	Resource *ResourceOutput `yaml:"resource" json:"resource,omitempty"`
+	// Audit opts in to a field-level diff of the before and after resource
+	// snapshots in the backend response, reported to command observers.
+	Audit *AuditDiff `yaml:"audit" json:"audit,omitempty"`
+	// Created marks the command as creating a resource. A successful
+	// response is returned with status 201 and a Location header pointing
+	// at the created resource's detail route.
+	Created *CreatedResource `yaml:"created" json:"created,omitempty"`
+}
+
+// CreatedResource identifies the resource a command creates.
+type CreatedResource struct {
+	// ResourceType selects the detail page, as for GET /ui/resolve.
+	ResourceType string `yaml:"resource_type" json:"resource_type"`
+	// IDField names the mapped result field holding the created resource's
+	// ID. Defaults to "id".
+	IDField string `yaml:"id_field" json:"id_field,omitempty"`
+}
+
+// AuditDiff locates before and after snapshots of an updated resource in a
+// command's backend response. Only the listed fields are compared.
+type AuditDiff struct {
+	// Before and After locate the snapshots in the response body.
+	Before string `yaml:"before" json:"before"`
+	After  string `yaml:"after"  json:"after"`
+	// Fields maps audited field names to paths within each snapshot.
+	Fields map[string]string `yaml:"fields" json:"fields"`
+}
+
+// SuccessMessageRule selects a command success message when all of its
+// conditions hold for the mapped result. Condition effects are ignored.
+type SuccessMessageRule struct {
+	When    []ConditionDefinition `yaml:"when"    json:"when"`
+	Message string                `yaml:"message" json:"message"`
+}
+
+// ResourceOutput selects the updated resource from a command's backend
+// response. Only the listed fields are returned, so backend-internal fields
+// never reach the client.
+type ResourceOutput struct {
+	// Path locates the resource object in the response body. Empty means
+	// the body itself.
+	Path string `yaml:"path" json:"path,omitempty"`
+	// Fields maps UI field names to paths within the resource object.
+	Fields map[string]string `yaml:"fields" json:"fields"`
+}
+
+// Result merge strategies for OutputMapping.Merge.
+const (
+	MergeBackendWins     = "backend-wins"
+	MergeInputWins       = "input-wins"
+	MergeErrorOnConflict = "error-on-conflict"
+)
 
 // IdempotencyConfig describes idempotency settings for a command.
 type IdempotencyConfig struct {
@@ -304,6 +595,11 @@ type LookupDefinition struct {
 	ValueField  string           `yaml:"value_field"  json:"value_field"`
 	SearchField string           `yaml:"search_field" json:"search_field,omitempty"`
 	Cache       *CacheConfig     `yaml:"cache"        json:"cache,omitempty"`
+	MaxResults  int              `yaml:"max_results"  json:"max_results,omitempty"`
+	// Params names the parameters the lookup accepts. Each is forwarded to
+	// the backend as a query parameter of the same name, and options are
+	// cached per parameter value.
+	Params []string `yaml:"params" json:"params,omitempty"`
 }
 
 // CacheConfig describes caching settings for a lookup.
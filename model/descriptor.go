@@ -1,8 +1,31 @@
 package model
 
+import (
+	"io"
+	"time"
+)
+
 // NavigationTree is the top-level navigation structure returned to the frontend.
 type NavigationTree struct {
-	Items []NavigationNode `json:"items"`
+	Items   []NavigationNode `json:"items"`
+	Explain *ExplainBlock    `json:"explain,omitempty"`
+}
+
+// ExplainBlock is a debug block describing the elements a descriptor omits
+// because the caller lacks capabilities. It is only attached on request, to
+// callers holding the configured explain capability.
+type ExplainBlock struct {
+	Filtered []FilteredElement `json:"filtered"`
+}
+
+// FilteredElement is a definition element omitted from a descriptor.
+type FilteredElement struct {
+	// Kind is "domain", "navigation_item", "section", "action",
+	// "row_action", "bulk_action" or "submit_action".
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+	// Missing lists the element's required capabilities the caller lacks.
+	Missing []string `json:"missing_capabilities"`
 }
 
 // NavigationNode is a single node in the navigation tree.
@@ -33,6 +56,7 @@ type PageDescriptor struct {
 	Table           *TableDescriptor       `json:"table,omitempty"`
 	Sections        []SectionDescriptor    `json:"sections,omitempty"`
 	Actions         []ActionDescriptor     `json:"actions,omitempty"`
+	Explain         *ExplainBlock          `json:"explain,omitempty"`
 }
 
 // BreadcrumbDescriptor is a single breadcrumb entry.
@@ -64,6 +88,15 @@ type ColumnDescriptor struct {
 	Width     string            `json:"width,omitempty"`
 	Link      *LinkDescriptor   `json:"link,omitempty"`
 	StatusMap map[string]string `json:"status_map,omitempty"`
+	// FormatRules are applied client-side in order; the first match wins.
+	FormatRules []FormatRuleDescriptor `json:"format_rules,omitempty"`
+}
+
+// FormatRuleDescriptor is a conditional formatting rule for a column value.
+type FormatRuleDescriptor struct {
+	Operator string `json:"operator"`
+	Value    any    `json:"value"`
+	Style    string `json:"style"`
 }
 
 // LinkDescriptor describes a clickable link.
@@ -80,6 +113,11 @@ type FilterDescriptor struct {
 	Operator string             `json:"operator"`
 	Options  []OptionDescriptor `json:"options,omitempty"`
 	Default  any                `json:"default,omitempty"`
+	// DependsOn lists the filters whose values narrow this filter's
+	// options. When one changes, the frontend refetches the options from
+	// OptionsEndpoint.
+	DependsOn       []string `json:"depends_on,omitempty"`
+	OptionsEndpoint string   `json:"options_endpoint,omitempty"`
 }
 
 // OptionDescriptor is a resolved option for dropdowns and filters.
@@ -91,13 +129,23 @@ type OptionDescriptor struct {
 
 // FormDescriptor is the resolved form sent to the frontend.
 type FormDescriptor struct {
-	ID             string              `json:"id"`
-	Title          string              `json:"title"`
-	Sections       []SectionDescriptor `json:"sections"`
-	Actions        []ActionDescriptor  `json:"actions,omitempty"`
-	SubmitEndpoint string              `json:"submit_endpoint"`
-	SuccessRoute   string              `json:"success_route,omitempty"`
-	SuccessMessage string              `json:"success_message,omitempty"`
+	ID             string                   `json:"id"`
+	Title          string                   `json:"title"`
+	Sections       []SectionDescriptor      `json:"sections"`
+	Actions        []ActionDescriptor       `json:"actions,omitempty"`
+	SubmitEndpoint string                   `json:"submit_endpoint"`
+	SubmitActions  []SubmitActionDescriptor `json:"submit_actions,omitempty"`
+	SuccessRoute   string                   `json:"success_route,omitempty"`
+	SuccessMessage string                   `json:"success_message,omitempty"`
+	Explain        *ExplainBlock            `json:"explain,omitempty"`
+}
+
+// SubmitActionDescriptor is a resolved form submit button.
+type SubmitActionDescriptor struct {
+	ID             string `json:"id"`
+	Label          string `json:"label"`
+	Style          string `json:"style,omitempty"`
+	SubmitEndpoint string `json:"submit_endpoint"`
 }
 
 // SectionDescriptor is a resolved section.
@@ -187,10 +235,22 @@ type DataResponse struct {
 
 // DataPayload contains the items and pagination for a data response.
 type DataPayload struct {
-	Items      []map[string]any `json:"items"`
-	TotalCount int              `json:"total_count"`
-	Page       int              `json:"page"`
-	PageSize   int              `json:"page_size"`
+	Items      []map[string]any       `json:"items"`
+	TotalCount int                    `json:"total_count"`
+	Page       int                    `json:"page"`
+	PageSize   int                    `json:"page_size"`
+	Sections   map[string]SectionData `json:"sections,omitempty"`
+	// RowStyles holds pre-evaluated format rule styles per item (field → style),
+	// parallel to Items. Only set when the table enables evaluate_format_rules.
+	RowStyles []map[string]string `json:"row_styles,omitempty"`
+}
+
+// SectionData is the data loaded for a page section with its own data source.
+// Unavailable is set when an optional data source failed.
+type SectionData struct {
+	Items       []map[string]any `json:"items"`
+	TotalCount  int              `json:"total_count"`
+	Unavailable bool             `json:"unavailable,omitempty"`
 }
 
 // CommandResponse is the response from executing a command.
@@ -198,7 +258,31 @@ type CommandResponse struct {
 	Success bool           `json:"success"`
 	Message string         `json:"message,omitempty"`
 	Result  map[string]any `json:"result,omitempty"`
-	Errors  []FieldError   `json:"errors,omitempty"`
+	// Resource is the mapped updated resource, present only for commands
+	// whose output mapping opts in.
+	Resource map[string]any `json:"resource,omitempty"`
+	// Location is the detail route of the resource a creating command
+	// created. The transport layer also returns it as a Location header.
+	Location string       `json:"location,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// ErrorCode is the error code of a failed response that is not the
+	// client's fault, such as a backend contract violation. It is not
+	// serialized; the transport layer reports it in the error envelope.
+	ErrorCode string `json:"-"`
+	// JobID identifies the job an async command was queued as. The
+	// transport layer answers 202 Accepted when it is set.
+	JobID string `json:"job_id,omitempty"`
+	// Stream is a streamed backend body, sent to the client in place of the
+	// JSON response with StreamHeaders. The transport layer closes it.
+	Stream        io.ReadCloser     `json:"-"`
+	StreamHeaders map[string]string `json:"-"`
+}
+
+// ConfirmationResponse carries a confirmation token for a command that
+// requires one.
+type ConfirmationResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // SearchResponse is the response from a global search query.
@@ -225,13 +309,35 @@ type SearchResult struct {
 	Score    float64 `json:"score"`
 }
 
+// RouteResolution maps a resource reference to the frontend page route that
+// displays it.
+type RouteResolution struct {
+	Type         string   `json:"type"`
+	ID           string   `json:"id"`
+	PageID       string   `json:"page_id"`
+	Route        string   `json:"route"`
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// ResourceActions lists the actions the caller may perform on a resource.
+type ResourceActions struct {
+	Type    string             `json:"type"`
+	ID      string             `json:"id"`
+	Actions []ActionDescriptor `json:"actions"`
+}
+
 // LookupResponse is the response from a lookup endpoint.
 type LookupResponse struct {
 	Data LookupPayload  `json:"data"`
 	Meta map[string]any `json:"meta,omitempty"`
 }
 
-// LookupPayload contains the lookup options.
+// LookupPayload contains a page of the lookup options. TotalCount counts
+// the options matching the query across all pages.
 type LookupPayload struct {
-	Options []OptionDescriptor `json:"options"`
+	Options    []OptionDescriptor `json:"options"`
+	HasMore    bool               `json:"has_more"`
+	TotalCount int                `json:"total_count"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
 }
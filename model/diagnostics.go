@@ -0,0 +1,34 @@
+package model
+
+// CacheStats summarises the effectiveness of an in-memory cache.
+type CacheStats struct {
+	Entries int     `json:"entries"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// NewCacheStats builds CacheStats, deriving the hit rate from hits and
+// misses. The hit rate is 0 before the first lookup.
+func NewCacheStats(entries int, hits, misses int64) CacheStats {
+	stats := CacheStats{Entries: entries, Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+// Lookup warm-up statuses.
+const (
+	WarmStatusWarmed  = "warmed"
+	WarmStatusSkipped = "skipped"
+	WarmStatusFailed  = "failed"
+)
+
+// LookupWarmResult reports the outcome of pre-populating one lookup's cache
+// entry.
+type LookupWarmResult struct {
+	LookupID string `json:"lookup_id"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+}
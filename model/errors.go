@@ -4,16 +4,20 @@ import "fmt"
 
 // Standard error codes.
 const (
-	ErrBadRequest         = "BAD_REQUEST"
-	ErrUnauthorized       = "UNAUTHORIZED"
-	ErrForbidden          = "FORBIDDEN"
-	ErrNotFound           = "NOT_FOUND"
-	ErrConflict           = "CONFLICT"
-	ErrValidationError    = "VALIDATION_ERROR"
-	ErrRateLimited        = "RATE_LIMITED"
-	ErrInternalError      = "INTERNAL_ERROR"
-	ErrBackendUnavailable = "BACKEND_UNAVAILABLE"
-	ErrBackendTimeout     = "BACKEND_TIMEOUT"
+	ErrBadRequest           = "BAD_REQUEST"
+	ErrUnauthorized         = "UNAUTHORIZED"
+	ErrForbidden            = "FORBIDDEN"
+	ErrNotFound             = "NOT_FOUND"
+	ErrConflict             = "CONFLICT"
+	ErrValidationError      = "VALIDATION_ERROR"
+	ErrConfirmationRequired = "CONFIRMATION_REQUIRED"
+	ErrPayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	ErrRateLimited          = "RATE_LIMITED"
+	ErrCommandUnavailable   = "COMMAND_UNAVAILABLE"
+	ErrInternalError        = "INTERNAL_ERROR"
+	ErrBackendUnavailable   = "BACKEND_UNAVAILABLE"
+	ErrBackendError         = "BACKEND_ERROR"
+	ErrBackendTimeout       = "BACKEND_TIMEOUT"
 )
 
 // ErrorEnvelope is the standard error response envelope returned by the BFF.
@@ -22,9 +26,23 @@ type ErrorEnvelope struct {
 	Code    string       `json:"code"`
 	Message string       `json:"message"`
 	Details []FieldError `json:"details,omitempty"`
+	// RequiredCapabilities lists the capabilities the caller is missing for a
+	// FORBIDDEN response. It is stripped by the transport layer when
+	// capability details are disabled.
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"`
+	// Panic describes the panic behind an INTERNAL_ERROR. It is only set
+	// when the server is configured to expose panic details.
+	Panic   *PanicDetail `json:"panic,omitempty"`
 	TraceID string       `json:"trace_id"`
 }
 
+// PanicDetail is the panic value and call stack of a recovered handler
+// panic. Stack frames name the function, file and line only.
+type PanicDetail struct {
+	Message string   `json:"message"`
+	Stack   []string `json:"stack"`
+}
+
 // Error implements the error interface.
 func (e *ErrorEnvelope) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
@@ -52,6 +70,12 @@ func NewForbiddenError(msg string) *ErrorEnvelope {
 	return &ErrorEnvelope{Code: ErrForbidden, Message: msg}
 }
 
+// NewInsufficientCapabilitiesError returns a FORBIDDEN error listing the
+// capabilities the caller lacks.
+func NewInsufficientCapabilitiesError(msg string, required []string) *ErrorEnvelope {
+	return &ErrorEnvelope{Code: ErrForbidden, Message: msg, RequiredCapabilities: required}
+}
+
 // NewNotFoundError returns a NOT_FOUND error.
 func NewNotFoundError(msg string) *ErrorEnvelope {
 	return &ErrorEnvelope{Code: ErrNotFound, Message: msg}
@@ -62,6 +86,21 @@ func NewConflictError(msg string) *ErrorEnvelope {
 	return &ErrorEnvelope{Code: ErrConflict, Message: msg}
 }
 
+// NewConfirmationRequiredError returns a CONFIRMATION_REQUIRED error for a
+// command invoked without a valid confirmation token.
+func NewConfirmationRequiredError(msg string) *ErrorEnvelope {
+	return &ErrorEnvelope{Code: ErrConfirmationRequired, Message: msg}
+}
+
+// NewPayloadTooLargeError returns a PAYLOAD_TOO_LARGE error for a request
+// body over the limit of limit bytes.
+func NewPayloadTooLargeError(limit int64) *ErrorEnvelope {
+	return &ErrorEnvelope{
+		Code:    ErrPayloadTooLarge,
+		Message: fmt.Sprintf("The request body exceeds the limit of %d bytes", limit),
+	}
+}
+
 // NewValidationError returns a VALIDATION_ERROR with field-level details.
 func NewValidationError(details []FieldError) *ErrorEnvelope {
 	return &ErrorEnvelope{
@@ -87,6 +126,12 @@ func NewBackendUnavailableError() *ErrorEnvelope {
 	}
 }
 
+// NewBackendError returns a BACKEND_ERROR for a backend response that
+// violates the operation's contract, as opposed to a client mistake.
+func NewBackendError(msg string) *ErrorEnvelope {
+	return &ErrorEnvelope{Code: ErrBackendError, Message: msg}
+}
+
 // NewBackendTimeoutError returns a BACKEND_TIMEOUT error.
 func NewBackendTimeoutError() *ErrorEnvelope {
 	return &ErrorEnvelope{
@@ -95,6 +140,15 @@ func NewBackendTimeoutError() *ErrorEnvelope {
 	}
 }
 
+// NewCommandUnavailableError returns a COMMAND_UNAVAILABLE error for a
+// command that operators have disabled.
+func NewCommandUnavailableError(commandID string) *ErrorEnvelope {
+	return &ErrorEnvelope{
+		Code:    ErrCommandUnavailable,
+		Message: fmt.Sprintf("Command %q is temporarily unavailable", commandID),
+	}
+}
+
 // NewRateLimitedError returns a RATE_LIMITED error.
 func NewRateLimitedError() *ErrorEnvelope {
 	return &ErrorEnvelope{
@@ -1,6 +1,9 @@
 package model
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestErrorEnvelope_Error(t *testing.T) {
 	e := &ErrorEnvelope{Code: ErrNotFound, Message: "Page not found"}
@@ -75,6 +78,16 @@ func TestNewRateLimitedError(t *testing.T) {
 	}
 }
 
+func TestNewPayloadTooLargeError(t *testing.T) {
+	e := NewPayloadTooLargeError(1024)
+	if e.Code != ErrPayloadTooLarge {
+		t.Errorf("Code = %q, want %q", e.Code, ErrPayloadTooLarge)
+	}
+	if !strings.Contains(e.Message, "1024") {
+		t.Errorf("Message = %q, want the limit", e.Message)
+	}
+}
+
 func TestNewBadRequestError(t *testing.T) {
 	e := NewBadRequestError("bad json")
 	if e.Code != ErrBadRequest {
@@ -1,6 +1,9 @@
 package model
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 // OperationInvoker is the unified interface for backend invocation.
 type OperationInvoker interface {
@@ -17,6 +20,23 @@ type InvocationInput struct {
 	QueryParams map[string]string `json:"query_params,omitempty"`
 	Headers     map[string]string `json:"headers,omitempty"`
 	Body        any               `json:"body,omitempty"`
+	// BodyStream, when set, is streamed to the backend as the raw request
+	// body instead of JSON-encoding Body. Streamed requests are not retried.
+	BodyStream io.Reader `json:"-"`
+	// Files, when set, sends the request as multipart/form-data: each entry
+	// becomes a file part under its field name and the fields of Body, which
+	// must then be an object, become form fields. Like BodyStream, the body
+	// is streamed and the request is not retried.
+	Files map[string]FilePart `json:"-"`
+}
+
+// FilePart is a file sent as one part of a multipart/form-data request.
+type FilePart struct {
+	Filename    string
+	ContentType string
+	// Open returns a reader of the file content. It is called when the part
+	// is written, so the content is not held in memory as a whole.
+	Open func() (io.ReadCloser, error)
 }
 
 // InvocationResult is the backend response.
@@ -24,12 +44,30 @@ type InvocationResult struct {
 	StatusCode int               `json:"status_code"`
 	Body       any               `json:"body,omitempty"`
 	Headers    map[string]string `json:"headers,omitempty"`
+	// ErrorCode is set when the service's error mapping overrides the error
+	// code for this response, e.g. a 409 treated as VALIDATION_ERROR or a
+	// 200 whose body carries an error. Consumers fail the response with it.
+	ErrorCode string `json:"error_code,omitempty"`
+	// BodyReader, when set, is the unread body of a streamed response (see
+	// OperationBinding.StreamResponse) and Body is nil. The consumer must
+	// close it.
+	BodyReader io.ReadCloser `json:"-"`
 }
 
 // CommandInput is the frontend command request payload.
 type CommandInput struct {
 	Input       map[string]any    `json:"input"`
 	RouteParams map[string]string `json:"route_params,omitempty"`
+	// ConfirmationToken is required for commands that set
+	// RequireConfirmation. It is not part of the bound input.
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+	// IdempotencyKey deduplicates repeated submissions of an async command:
+	// a key already used for the command returns the earlier job. The
+	// transport layer fills it from the Idempotency-Key header if unset.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Files holds the file parts of a multipart command request, keyed by
+	// field name. They are forwarded to the backend unchanged.
+	Files map[string]FilePart `json:"-"`
 }
 
 // DataParams describes parameters for data-fetching endpoints.
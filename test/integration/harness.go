@@ -246,7 +246,11 @@ func NewTestHarness(t *testing.T, opts ...HarnessOption) *TestHarness {
 	}
 
 	h.InvokerRegistry = invoker.NewRegistry()
-	h.InvokerRegistry.Register(invoker.NewOpenAPIOperationInvoker(h.OAIndex, serviceConfigs, testHTTPClient))
+	openapiInvoker, err := invoker.NewOpenAPIOperationInvoker(h.OAIndex, serviceConfigs, testHTTPClient, nil)
+	if err != nil {
+		t.Fatalf("create OpenAPI invoker: %v", err)
+	}
+	h.InvokerRegistry.Register(openapiInvoker)
 	h.InvokerRegistry.Register(invoker.NewSDKOperationInvoker(sdkHandlers))
 
 	// Step 8: Build providers.
@@ -257,7 +261,7 @@ func NewTestHarness(t *testing.T, opts ...HarnessOption) *TestHarness {
 	pageProvider := metadata.NewPageProvider(h.Registry, h.InvokerRegistry, actionProvider)
 	formProvider := metadata.NewFormProvider(h.Registry, h.InvokerRegistry, actionProvider)
 	searchProvider := search.NewSearchProvider(h.Registry, h.InvokerRegistry, 3*time.Second, 50)
-	lookupProvider := search.NewLookupProvider(h.Registry, h.InvokerRegistry, 5*time.Minute, 1000)
+	lookupProvider := search.NewLookupProvider(h.Registry, h.InvokerRegistry, 5*time.Minute, 1000, 100)
 
 	// Step 9: Create JWT issuer.
 	h.issuer = newTokenIssuer(t)